@@ -1,16 +1,19 @@
 package main
 
 import (
+	"cmd/pkg/config"
 	"cmd/pkg/handler"
 	"cmd/pkg/handler/websocket"
 	"cmd/pkg/repository"
+	"cmd/pkg/repository/migrations"
 	"cmd/pkg/service"
+	"cmd/pkg/storage"
+	"context"
+	"flag"
 	"fmt"
-	"github.com/jinzhu/gorm"
-	_ "github.com/jinzhu/gorm/dialects/mysql"
-	"github.com/joho/godotenv"
 	"log"
 	"os"
+	"time"
 )
 
 // @title          Server API
@@ -31,55 +34,190 @@ import (
 // @host     localhost:8000
 // @BasePath /api/
 
-func GetConnectionString() string {
-	host := os.Getenv("DB_HOST")
-	if host == "" {
-		host = "localhost"
+// migrateUploads переносить файли з локальної директорії до налаштованого сховища
+func migrateUploads(args []string, cnf config.Storage, to storage.Storage) {
+	fs := flag.NewFlagSet("migrate-uploads", flag.ExitOnError)
+	from := fs.String("from", "uploads", "local directory with existing uploads")
+	fs.Parse(args)
+
+	if cnf.Driver != config.StorageS3 {
+		log.Fatal("set STORAGE_DRIVER=s3 to migrate local uploads")
+	}
+	src, err := storage.NewLocalStorage(*from)
+	if err != nil {
+		log.Fatal(err)
 	}
+	moved, err := storage.Migrate(context.Background(), src, to)
+	log.Printf("moved %d files", moved)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// reportOrphans виводить зображення, які ніхто не використовує, та за
+// прапорцем -delete видаляє їх
+func reportOrphans(args []string, upload service.Upload) {
+	fs := flag.NewFlagSet("orphans", flag.ExitOnError)
+	remove := fs.Bool("delete", false, "delete orphaned files instead of dry-run report")
+	fs.Parse(args)
+	ctx := context.Background()
 
-	port := os.Getenv("DB_PORT")
-	if port == "" {
-		port = "3307"
+	orphans, err := upload.Sweep(ctx, !*remove)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, o := range orphans {
+		fmt.Printf("orphan\t%s\tcreated %s\tunused since %s\n", o.Name, o.CreatedAt.Format(time.RFC3339), o.UpdatedAt.Format(time.RFC3339))
 	}
 
-	user := os.Getenv("DB_USER")
-	if user == "" {
-		user = "root"
+	untracked, err := upload.Untracked(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range untracked {
+		fmt.Printf("untracked\t%s\n", name)
+		if *remove {
+			if err := upload.DeleteImage(ctx, name); err != nil {
+				log.Fatal(err)
+			}
+		}
 	}
 
-	password := os.Getenv("DB_PASS")
-	if password == "" {
-		password = "@root"
+	if *remove {
+		log.Printf("deleted %d orphans and %d untracked files", len(orphans), len(untracked))
+	} else {
+		log.Printf("dry run: %d orphans and %d untracked files", len(orphans), len(untracked))
 	}
+}
 
-	dbName := os.Getenv("DB_NAME")
-	if dbName == "" {
-		dbName = "chatDB"
+// migrateSchema застосовує (up), відкочує (down -steps N) міграції схеми
+// БД, виводить їх стан (status) або знімає позначку незавершеної міграції
+// після ручного виправлення схеми (force -version N -applied=true|false)
+func migrateSchema(args []string, migrator *migrations.Migrator) {
+	command := "up"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
 	}
 
-	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		user, password, host, port, dbName)
+	switch command {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			log.Printf("applied %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "down":
+		fs := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := fs.Int("steps", 1, "number of migrations to revert")
+		fs.Parse(args)
+		reverted, err := migrator.Down(*steps)
+		for _, m := range reverted {
+			log.Printf("reverted %04d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, s := range status {
+			applied := "pending"
+			if !s.AppliedAt.IsZero() {
+				applied = s.AppliedAt.Format(time.RFC3339)
+			}
+			if s.Dirty {
+				applied += " dirty"
+			}
+			fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, applied)
+		}
+	case "force":
+		fs := flag.NewFlagSet("migrate force", flag.ExitOnError)
+		version := fs.Int("version", 0, "dirty migration version")
+		applied := fs.Bool("applied", true, "keep the migration applied")
+		fs.Parse(args)
+		if err := migrator.Force(*version, *applied); err != nil {
+			log.Fatal(err)
+		}
+	default:
+		log.Fatalf("unknown migrate command %q, expected up, down, status or force", command)
+	}
 }
 
 func main() {
 
-	go websocket.Hub.Run()
-	errEnv := godotenv.Load()
-	if errEnv != nil {
-		log.Fatal("Error loading .env file")
+	// Налаштування з файлу, змінних оточення та прапорців. Аргументи після
+	// прапорців - підкоманда та її прапорці
+	cnf, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	command := ""
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	store, err := storage.New(cnf.Storage)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Перенесення завантажених файлів до нового сховища
+	if command == "migrate-uploads" {
+		migrateUploads(args, cnf.Storage, store)
+		return
+	}
+
+	db, err := repository.NewRepositoryDB(cnf.DB)
+	if err != nil {
+		log.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatal(err)
 	}
-	var db, err = gorm.Open("mysql", GetConnectionString())
+
+	files, err := migrations.Files(cnf.DB.Driver)
+	if err != nil {
+		log.Fatal(err)
+	}
+	migrator, err := migrations.NewMigrator(sqlDB, cnf.DB.Driver, files)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Міграції схеми БД
+	if command == "migrate" {
+		migrateSchema(args, migrator)
+		return
+	}
+	if cnf.DB.AutoMigrate {
+		migrateSchema(nil, migrator)
+	}
+
 	repos := repository.NewRepository(db)
-	services := service.NewService(repos)
-	handlers := handler.NewHandler(services)
+	services := service.NewService(repos, store, cnf)
+
+	// Звіт про зображення, які ніхто не використовує
+	if command == "orphans" {
+		reportOrphans(args, services.Upload)
+		return
+	}
+	if command != "" {
+		log.Fatalf("unknown command %q, expected migrate, migrate-uploads or orphans", command)
+	}
+
+	hub := websocket.NewHub(cnf.Websocket, services.Status)
+	go hub.Run()
+	go service.RunSweeper(services.Upload, cnf.Uploads.SweepInterval)
+	handlers := handler.NewHandler(services, hub, cnf.Server)
 
 	server := new(service.Server)
 
-	if err := server.Run(os.Getenv("PORT"), handlers.InitRoutes()); err != nil {
+	if err := server.Run(cnf.Server.Port, handlers.InitRoutes()); err != nil {
 		log.Fatalf("error %s", err.Error())
 	}
 
@@ -1,4 +1,5 @@
-// Code generated by swaggo/swag. DO NOT EDIT
+// Package docs GENERATED BY SWAG; DO NOT EDIT
+// This file was generated by swaggo/swag
 package docs
 
 import "github.com/swaggo/swag"
@@ -30,7 +31,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Користувач надсилає новий файл зображення. Замінює зображення на нове.",
+                "description": "Користувач надсилає новий файл зображення. Замінює зображення на нове.\nСпіврозмовники приватних чатів отримують подію user_updated у будь-якій кімнаті.",
                 "produces": [
                     "application/json"
                 ],
@@ -45,14 +46,32 @@ const docTemplate = `{
                             "$ref": "#/definitions/auth.MessageResponse"
                         }
                     },
+                    "400": {
+                        "description": "corrupt_image: incorrect image error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "incorrect user data",
+                        "description": "user_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "image_too_large: image is too large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported_type: incorrect file type error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "delete icon error",
+                        "description": "update icon references error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -97,13 +116,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "incorrect password",
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "wrong_password: incorrect password (202 in compatibility mode)",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "incorrect user data",
+                        "description": "user_not_found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -124,7 +149,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Користувач надсилає новий нікнейм.\nПісля перевірки нового нікнейму на унікальність, змінює нікнейм на новий.",
+                "description": "Користувач надсилає новий нікнейм.\nПісля перевірки нового нікнейму на унікальність, змінює нікнейм на новий.\nСпіврозмовники приватних чатів отримують подію user_updated у будь-якій кімнаті.",
                 "consumes": [
                     "application/json"
                 ],
@@ -154,13 +179,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "incorrect request data",
+                        "description": "validation_failed: list of invalid fields",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "incorrect user data",
+                        "description": "user_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "username_taken (202 in compatibility mode)",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -196,10 +227,96 @@ const docTemplate = `{
                             "$ref": "#/definitions/auth.TokenResponse"
                         }
                     },
+                    "401": {
+                        "description": "unauthenticated: user not found (204 in compatibility mode)",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/settings": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Повертає налаштування приватності активного користувача:\nхто може писати йому в особисті чати (direct_messages), додавати до\nпублічних чатів (chat_invites), бачити час активності (last_seen) та\nчи знаходять його у пошуку (searchable).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get privacy settings",
+                "responses": {
+                    "200": {
+                        "description": "privacy settings",
+                        "schema": {
+                            "$ref": "#/definitions/models.UserSettings"
+                        }
+                    },
+                    "500": {
+                        "description": "get settings error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Користувач надсилає нові налаштування приватності. direct_messages та\nlast_seen приймають everyone, friends або nobody, chat_invites - everyone\nабо friends.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Update privacy settings",
+                "parameters": [
+                    {
+                        "description": "New settings",
+                        "name": "settings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/auth.SettingsInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "settings changed",
+                        "schema": {
+                            "$ref": "#/definitions/auth.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "404": {
-                        "description": "user not found",
+                        "description": "user_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "update settings error",
                         "schema": {
-                            "$ref": "#/definitions/auth.IdResponse"
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
@@ -237,25 +354,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "incorrect request data",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "user not found",
+                        "description": "validation_failed: list of invalid fields",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
-                    "409": {
-                        "description": "incorrect password",
+                    "401": {
+                        "description": "wrong_password: incorrect password (202 in compatibility mode)",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "check user error",
+                        "description": "get user error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -284,7 +395,7 @@ const docTemplate = `{
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/auth.SignInInput"
+                            "$ref": "#/definitions/auth.SignUpInput"
                         }
                     }
                 ],
@@ -296,13 +407,13 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "Password must be at least 6 symbols",
+                        "description": "validation_failed: list of invalid fields",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "409": {
-                        "description": "username is already used",
+                        "description": "username_taken (202 in compatibility mode)",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -323,7 +434,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ім'я чату. Створює новий чат. Повертає ID чату.",
+                "description": "Отримує ім'я чату та необов'язкову видимість (open, request або hidden).\nСтворює новий чат, творець стає його адміністратором. Повертає ID чату.",
                 "consumes": [
                     "application/json"
                 ],
@@ -353,13 +464,71 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "name is empty",
+                        "description": "validation_failed: list of invalid fields",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "add user to chat error",
+                        "description": "create chat error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/join/{token}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Приєднує активного користувача до чату за токеном посилання-запрошення.\nЯкщо посилання потребує схвалення, повертає заявку зі статусом pending;\nвикористання такого посилання зараховується лише після схвалення.\nУчасники чату отримують подію member_joined у кімнаті чату,\nа адміністратори про заявку - подію join_requested.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Join chat by invite link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Invite token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "join record (joined or pending)",
+                        "schema": {
+                            "$ref": "#/definitions/invites.JoinResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "blocked: a chat admin has blocked the user",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "invite_invalid: revoked, expired or used up",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "join_pending",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "join chat error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -417,7 +586,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID користувача.\nПовертає список приватних чатів користувача.",
+                "description": "Отримує ID користувача.\nПовертає список приватних чатів користувача зі співрозмовником.\nКожен чат називається ім'ям співрозмовника та має його зображення.",
                 "consumes": [
                     "application/json"
                 ],
@@ -445,7 +614,7 @@ const docTemplate = `{
                         }
                     },
                     "500": {
-                        "description": "get users error",
+                        "description": "get chats error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -540,7 +709,19 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "body is empty",
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "privacy_restricted: user's privacy settings do not allow this",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "chat_not_found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -645,6 +826,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/messages.MessageResponse"
                         }
                     },
+                    "404": {
+                        "description": "message_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "get message error",
                         "schema": {
@@ -661,7 +848,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID чату. Повертає дані чату.",
+                "description": "Отримує ID чату. Повертає дані чату.\nПриватний чат називається ім'ям співрозмовника та має його зображення.",
                 "consumes": [
                     "application/json"
                 ],
@@ -688,6 +875,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/chat.ChatResponse"
                         }
                     },
+                    "404": {
+                        "description": "chat_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "get chat error",
                         "schema": {
@@ -696,13 +889,81 @@ const docTemplate = `{
                     }
                 }
             },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отримує ID чату, нову назву, опис та тему.\nЗмінює дані публічного чату, якщо активний користувач - його адміністратор. Повертає оновлені дані чату.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Update chat details",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Chat details",
+                        "name": "chat",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/chat.ChatInfoInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "result is updated chat data",
+                        "schema": {
+                            "$ref": "#/definitions/chat.ChatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin or not a public chat",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "chat_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "update chat error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID чату. Видаляє чат.",
+                "description": "Отримує ID чату. Видаляє чат. Видалити чат може лише його адміністратор.",
                 "consumes": [
                     "application/json"
                 ],
@@ -729,8 +990,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/chat.MessageResponse"
                         }
                     },
+                    "403": {
+                        "description": "forbidden: only chat admins can do this",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "messages delete error",
+                        "description": "chat delete error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -745,7 +1012,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID чату та користувача.\nДодає користувача до чату.\nПовертає ID зв'язку між чатами та користувачами.",
+                "description": "Отримує ID чату та користувача.\nУчасник публічного чату додає до нього іншого користувача; до чату з заявками\nчи прихованого чату - лише адміністратор. До приватного чату додати не можна.\nПовертає ID зв'язку між чатами та користувачами.\nВласний ID активного користувача означає вступ до чату, як POST /chats/{id}/join:\nтоді повертається запис вступу (join).",
                 "consumes": [
                     "application/json"
                 ],
@@ -782,7 +1049,25 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "incorrect request data",
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "privacy_restricted: user's privacy settings do not allow this",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not_found: chat or user not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "already_in_chat: user is already in chat",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -803,7 +1088,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID чату та користувача.\nВидаляє користувача з чату.",
+                "description": "Отримує ID чату та користувача.\nВидаляє користувача з чату. Користувач може вийти з чату сам, а видалити\nіншого учасника може лише адміністратор чату.",
                 "consumes": [
                     "application/json"
                 ],
@@ -839,14 +1124,26 @@ const docTemplate = `{
                             "$ref": "#/definitions/chat.MessageResponse"
                         }
                     },
+                    "202": {
+                        "description": "delete last user from chat and chat",
+                        "schema": {
+                            "$ref": "#/definitions/chat.MessageResponse"
+                        }
+                    },
                     "400": {
-                        "description": "incorrect request data",
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: only chat admins can remove other members",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "messages delete error",
+                        "description": "delete user error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -861,7 +1158,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID чату та файл зображення.\nОновлює зображення користувача.",
+                "description": "Отримує ID чату та файл зображення.\nОновлює зображення публічного чату. Змінити зображення може лише адміністратор чату.",
                 "consumes": [
                     "application/json"
                 ],
@@ -889,20 +1186,463 @@ const docTemplate = `{
                         }
                     },
                     "400": {
-                        "description": "incorrect chat data",
+                        "description": "corrupt_image: incorrect image error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "delete icon error",
+                    "403": {
+                        "description": "forbidden: not an admin or not a public chat",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
+                    },
+                    "404": {
+                        "description": "chat_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "image_too_large: image is too large",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported_type: incorrect file type error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "update icon references error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{id}/invites": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Повертає посилання-запрошення чату, включно з відкликаними. Лише для адміністраторів.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Get chat invite links",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "list of invites",
+                        "schema": {
+                            "$ref": "#/definitions/invites.InviteListResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "get invites error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Учасник публічного чату створює посилання-запрошення з необов'язковими\nчасом дії (expires_in, секунди), кількістю використань (max_uses) та\nсхваленням вступу адміністратором (approval). 0 - без обмеження.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Create chat invite link",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Invite options",
+                        "name": "invite",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/invites.InviteInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "created invite with token",
+                        "schema": {
+                            "$ref": "#/definitions/invites.InviteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not a member or not a public chat",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "chat_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "create invite error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{id}/invites/{inviteId}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Відкликає посилання-запрошення. Доступно адміністраторам чату та автору посилання.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Revoke chat invite link",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Invite ID",
+                        "name": "inviteId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "invite revoked",
+                        "schema": {
+                            "$ref": "#/definitions/invites.MessageResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "invite_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "revoke invite error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{id}/join": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Приєднує активного користувача до відкритого чату (visibility open) або\nстворює заявку на вступ до чату з заявками (visibility request).\nУчасники чату отримують подію member_joined у кімнаті чату,\nа адміністратори про заявку - подію join_requested.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Join public chat",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "join record (joined or pending)",
+                        "schema": {
+                            "$ref": "#/definitions/invites.JoinResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "blocked: a chat admin has blocked the user",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "chat_not_found: no such chat, private or hidden chat",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "join_pending",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "join chat error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{id}/joins": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Повертає вступи до чату зі статусом status (за замовчуванням pending). Лише для адміністраторів.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Get chat join requests",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, joined or rejected",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "list of joins",
+                        "schema": {
+                            "$ref": "#/definitions/invites.JoinListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "get joins error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{id}/joins/{joinId}/approve": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Адміністратор схвалює заявку на вступ, і її автор стає учасником чату.\nСхвалення зараховує використання посилання, за яким подано заявку.\nУчасники чату отримують подію member_joined у кімнаті чату,\nа автор заявки - подію join_approved.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Approve join request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Join ID",
+                        "name": "joinId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "approved join",
+                        "schema": {
+                            "$ref": "#/definitions/invites.JoinResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "invite_invalid: invite revoked or used up",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "join_resolved",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "approve join error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/chats/{id}/joins/{joinId}/reject": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Адміністратор відхиляє заявку на вступ до чату.\nАвтор заявки отримує подію join_rejected.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "invites"
+                ],
+                "summary": "Reject join request",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Join ID",
+                        "name": "joinId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "rejected join",
+                        "schema": {
+                            "$ref": "#/definitions/invites.JoinResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "join_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "join_resolved",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "reject join error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/chats/{id}/link": {
             "get": {
                 "security": [
@@ -910,7 +1650,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID чату.\nПовертає дані чату та, якщо чат приватний, користувача.",
+                "description": "Отримує ID чату.\nПовертає дані чату та, якщо чат приватний, користувача.\nПриватний чат називається ім'ям співрозмовника та має його зображення.",
                 "consumes": [
                     "application/json"
                 ],
@@ -937,8 +1677,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/chat.ChatAndUserResponse"
                         }
                     },
+                    "404": {
+                        "description": "chat_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "get users error",
+                        "description": "no chat error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -995,6 +1741,76 @@ const docTemplate = `{
                 }
             }
         },
+        "/chats/{id}/visibility": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Адміністратор змінює видимість публічного чату: open - будь-хто вступає\nсам, request - вступ після схвалення адміністратора, hidden - чат не\nзнаходить пошук, вступ лише за посиланням-запрошенням.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "chat"
+                ],
+                "summary": "Change chat visibility",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Chat ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Chat visibility",
+                        "name": "visibility",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/chat.VisibilityInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "visibility changed",
+                        "schema": {
+                            "$ref": "#/definitions/chat.MessageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: not an admin or not a public chat",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "chat_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "change visibility error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/chats/{userId}/private": {
             "get": {
                 "security": [
@@ -1029,8 +1845,67 @@ const docTemplate = `{
                             "$ref": "#/definitions/chat.ChatIdResponse"
                         }
                     },
+                    "403": {
+                        "description": "privacy_restricted: user's privacy settings do not allow this",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "create chat error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/image/{name}": {
+            "get": {
+                "description": "Отримує ім'я файлу зображення.\nПовертає зображення або перенаправляє на тимчасове посилання сховища.",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "images"
+                ],
+                "summary": "Get uploaded image",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "File name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "image",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "302": {
+                        "description": "redirect to signed url",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "304": {
+                        "description": "not modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "image_not_found: image not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "add second user to chat error",
+                        "description": "get image error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -1072,6 +1947,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.ListResponse"
                         }
                     },
+                    "401": {
+                        "description": "unauthenticated",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "search users error",
                         "schema": {
@@ -1115,6 +1996,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.UserResponse"
                         }
                     },
+                    "404": {
+                        "description": "user_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "get user error",
                         "schema": {
@@ -1158,6 +2045,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.MessageResponse"
                         }
                     },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "update status error",
                         "schema": {
@@ -1201,8 +2094,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.IdResponse"
                         }
                     },
+                    "404": {
+                        "description": "user_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "add status error",
+                        "description": "update status error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -1217,7 +2122,7 @@ const docTemplate = `{
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Отримує ID користувача.\nПовертає списки відносин між користувачем та іншими користувачами.",
+                "description": "Отримує ID користувача.\nПовертає списки відносин між користувачем та іншими користувачами.\nСписки діляться на сторінки по limit записів відносин; next_offset\nвказує на наступну сторінку і відсутній на останній.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1235,6 +2140,18 @@ const docTemplate = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Relations to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Relations per page (1-500, default 200)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1244,8 +2161,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.StatusesListResponse"
                         }
                     },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
-                        "description": "friend requires list error",
+                        "description": "relationship lists error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -1287,6 +2210,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.MessageResponse"
                         }
                     },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "delete status error",
                         "schema": {
@@ -1330,6 +2259,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.MessageResponse"
                         }
                     },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "delete status error",
                         "schema": {
@@ -1373,6 +2308,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.MessageResponse"
                         }
                     },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "delete status error",
                         "schema": {
@@ -1416,6 +2357,24 @@ const docTemplate = `{
                             "$ref": "#/definitions/users.IdResponse"
                         }
                     },
+                    "403": {
+                        "description": "blocked: user has blocked you",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "user_not_found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "add status error",
                         "schema": {
@@ -1425,6 +2384,46 @@ const docTemplate = `{
                 }
             }
         },
+        "/users/{id}/presence": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Отримує ID користувача.\nПовертає час його останньої активності. Якщо налаштування приватності\nкористувача не дозволяють його показувати або користувач вас заблокував,\nlast_seen_at дорівнює null.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user` + "`" + `s last seen time",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "last seen time",
+                        "schema": {
+                            "$ref": "#/definitions/models.Presence"
+                        }
+                    },
+                    "500": {
+                        "description": "get presence error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/users/{id}/refuse": {
             "put": {
                 "security": [
@@ -1456,11 +2455,75 @@ const docTemplate = `{
                     "200": {
                         "description": "invitation refused",
                         "schema": {
-                            "$ref": "#/definitions/users.MessageResponse"
+                            "$ref": "#/definitions/users.MessageResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "invalid_transition: relationship change is not allowed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "delete status error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{id}/suggestions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Повертає користувачів, яких ви можете знати, за спаданням кількості\nспільних друзів (mutual_friends) та спільних публічних чатів (shared_chats).\nДрузі, запрошені та заблоковані користувачі не пропонуються.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get friend suggestions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Active user ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Suggestions count (1-50, default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "list of suggested users",
+                        "schema": {
+                            "$ref": "#/definitions/users.SuggestionsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "validation_failed: list of invalid fields",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "forbidden: suggestions of another user",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "delete status error",
+                        "description": "suggestions error",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -1478,26 +2541,57 @@ const docTemplate = `{
             ],
             "properties": {
                 "new_password": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 6
                 },
                 "old_password": {
                     "type": "string"
                 }
             }
         },
-        "auth.IdResponse": {
+        "auth.MessageResponse": {
             "type": "object",
             "properties": {
-                "id": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "auth.MessageResponse": {
+        "auth.SettingsInput": {
             "type": "object",
+            "required": [
+                "chat_invites",
+                "direct_messages",
+                "last_seen",
+                "searchable"
+            ],
             "properties": {
-                "message": {
-                    "type": "string"
+                "chat_invites": {
+                    "type": "string",
+                    "enum": [
+                        "everyone",
+                        "friends"
+                    ]
+                },
+                "direct_messages": {
+                    "type": "string",
+                    "enum": [
+                        "everyone",
+                        "friends",
+                        "nobody"
+                    ]
+                },
+                "last_seen": {
+                    "type": "string",
+                    "enum": [
+                        "everyone",
+                        "friends",
+                        "nobody"
+                    ]
+                },
+                "searchable": {
+                    "type": "boolean"
                 }
             }
         },
@@ -1516,6 +2610,25 @@ const docTemplate = `{
                 }
             }
         },
+        "auth.SignUpInput": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 6
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3
+                }
+            }
+        },
         "auth.TokenResponse": {
             "type": "object",
             "properties": {
@@ -1526,9 +2639,14 @@ const docTemplate = `{
         },
         "auth.UsernameInput": {
             "type": "object",
+            "required": [
+                "username"
+            ],
             "properties": {
                 "username": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 32,
+                    "minLength": 3
                 }
             }
         },
@@ -1551,6 +2669,27 @@ const docTemplate = `{
                 }
             }
         },
+        "chat.ChatInfoInput": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "description": "Description - опис чату, Topic - поточна тема обговорення",
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "topic": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
         "chat.ChatListResponse": {
             "type": "object",
             "properties": {
@@ -1588,20 +2727,117 @@ const docTemplate = `{
         },
         "chat.NameInput": {
             "type": "object",
+            "required": [
+                "name"
+            ],
             "properties": {
                 "name": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 50
+                },
+                "visibility": {
+                    "description": "Visibility - видимість нового чату, за замовчуванням open",
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "request",
+                        "hidden"
+                    ]
                 }
             }
         },
         "chat.UserIdInput": {
             "type": "object",
+            "required": [
+                "user_id"
+            ],
             "properties": {
                 "user_id": {
                     "type": "integer"
                 }
             }
         },
+        "chat.VisibilityInput": {
+            "type": "object",
+            "required": [
+                "visibility"
+            ],
+            "properties": {
+                "visibility": {
+                    "type": "string",
+                    "enum": [
+                        "open",
+                        "request",
+                        "hidden"
+                    ]
+                }
+            }
+        },
+        "invites.InviteInput": {
+            "type": "object",
+            "properties": {
+                "approval": {
+                    "type": "boolean"
+                },
+                "expires_in": {
+                    "description": "ExpiresIn - час дії посилання у секундах, 0 - без обмеження",
+                    "type": "integer",
+                    "maximum": 31536000,
+                    "minimum": 0
+                },
+                "max_uses": {
+                    "description": "MaxUses - найбільша кількість використань, 0 - без обмеження",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "invites.InviteListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChatInvite"
+                    }
+                }
+            }
+        },
+        "invites.InviteResponse": {
+            "type": "object",
+            "properties": {
+                "invite": {
+                    "$ref": "#/definitions/models.ChatInvite"
+                }
+            }
+        },
+        "invites.JoinListResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ChatJoin"
+                    }
+                }
+            }
+        },
+        "invites.JoinResponse": {
+            "type": "object",
+            "properties": {
+                "join": {
+                    "$ref": "#/definitions/models.ChatJoin"
+                }
+            }
+        },
+        "invites.MessageResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
         "messages.IdResponse": {
             "type": "object",
             "properties": {
@@ -1631,38 +2867,133 @@ const docTemplate = `{
         },
         "messages.TextInput": {
             "type": "object",
+            "required": [
+                "text"
+            ],
             "properties": {
                 "text": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 4096
                 }
             }
         },
         "models.Chat": {
             "type": "object",
-            "required": [
-                "icon",
-                "name"
-            ],
             "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "description": "CreatedBy та CreatedAt - автор та час створення чату. Для чатів,\nстворених до їх появи, вони можуть бути невідомі",
+                    "type": "integer"
+                },
+                "description": {
+                    "description": "Description - опис чату, Topic - поточна тема обговорення",
+                    "type": "string"
+                },
                 "icon": {
                     "type": "string"
                 },
+                "icons": {
+                    "description": "Icons містить посилання на квадратні копії зображення за їх розміром",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
+                "last_message": {
+                    "description": "LastMessage - останнє повідомлення чату для попереднього перегляду",
+                    "$ref": "#/definitions/models.Message"
+                },
+                "last_message_at": {
+                    "type": "string"
+                },
+                "member_count": {
+                    "description": "MemberCount та LastMessageAt обчислюються запитом і не зберігаються",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
+                "partner_id": {
+                    "description": "PartnerId - ID співрозмовника у списку приватних чатів",
+                    "type": "integer"
+                },
+                "topic": {
+                    "type": "string"
+                },
                 "types": {
                     "type": "string"
+                },
+                "visibility": {
+                    "description": "Visibility - хто може знайти публічний чат та приєднатися до нього",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ChatInvite": {
+            "type": "object",
+            "properties": {
+                "approval": {
+                    "type": "boolean"
+                },
+                "chat_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "max_uses": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "token": {
+                    "type": "string"
+                },
+                "uses": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ChatJoin": {
+            "type": "object",
+            "properties": {
+                "chat_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "invite_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
                 }
             }
         },
         "models.Message": {
             "type": "object",
-            "required": [
-                "text"
-            ],
             "properties": {
                 "author": {
                     "type": "integer"
@@ -1681,17 +3012,60 @@ const docTemplate = `{
                 }
             }
         },
+        "models.Presence": {
+            "type": "object",
+            "properties": {
+                "last_seen_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Suggestion": {
+            "type": "object",
+            "properties": {
+                "icon": {
+                    "type": "string"
+                },
+                "icons": {
+                    "description": "Icons містить посилання на квадратні копії зображення за їх розміром",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mutual_friends": {
+                    "type": "integer"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "shared_chats": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
         "models.User": {
             "type": "object",
-            "required": [
-                "icon",
-                "password",
-                "username"
-            ],
             "properties": {
                 "icon": {
                     "type": "string"
                 },
+                "icons": {
+                    "description": "Icons містить посилання на квадратні копії зображення за їх розміром",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -1703,9 +3077,41 @@ const docTemplate = `{
                 }
             }
         },
+        "models.UserSettings": {
+            "type": "object",
+            "properties": {
+                "chat_invites": {
+                    "description": "ChatInvites - хто може додавати користувача до публічних чатів",
+                    "type": "string"
+                },
+                "direct_messages": {
+                    "description": "DirectMessages - хто може писати користувачу в приватний чат",
+                    "type": "string"
+                },
+                "last_seen": {
+                    "description": "LastSeen - кому показується час останньої активності",
+                    "type": "string"
+                },
+                "searchable": {
+                    "description": "Searchable - чи знаходить користувача пошук за іменем",
+                    "type": "boolean"
+                }
+            }
+        },
         "responses.ErrorResponse": {
             "type": "object",
             "properties": {
+                "code": {
+                    "description": "Code - машинозчитуваний код помилки, наприклад \"username_taken\"",
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Fields - поля запиту, що не пройшли перевірку",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/validation.FieldError"
+                    }
+                },
                 "message": {
                     "type": "string"
                 }
@@ -1759,6 +3165,9 @@ const docTemplate = `{
                         "$ref": "#/definitions/models.User"
                     }
                 },
+                "next_offset": {
+                    "type": "integer"
+                },
                 "onBlacklist": {
                     "type": "array",
                     "items": {
@@ -1773,6 +3182,17 @@ const docTemplate = `{
                 }
             }
         },
+        "users.SuggestionsResponse": {
+            "type": "object",
+            "properties": {
+                "list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Suggestion"
+                    }
+                }
+            }
+        },
         "users.UserResponse": {
             "type": "object",
             "properties": {
@@ -1780,6 +3200,20 @@ const docTemplate = `{
                     "$ref": "#/definitions/models.User"
                 }
             }
+        },
+        "validation.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
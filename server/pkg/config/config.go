@@ -0,0 +1,263 @@
+// Package config містить налаштування сервера. Налаштування читаються з
+// файлу, змінних оточення та прапорців командного рядка і перевіряються
+// перед запуском
+package config
+
+import (
+	"cmd/pkg/imaging"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Режими роботи сервера
+const (
+	ModeDevelopment = "development"
+	ModeProduction  = "production"
+)
+
+// Драйвери БД
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Драйвери сховища завантажених файлів
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+const (
+	// minKeyLength - найменша довжина ключа підпису токенів у режимі production
+	minKeyLength = 32
+	// minSaltLength - найменша довжина солі паролів у режимі production
+	minSaltLength = 16
+)
+
+// weakSecrets - відомі значення секретів з прикладів та документації, які
+// не можна використовувати у режимі production
+var weakSecrets = []string{"code", "secret", "salt", "password", "changeme", "239tjeaWFYh2rofjw"}
+
+type Config struct {
+	// Mode - режим роботи: development (за замовчуванням) або production
+	Mode      string
+	Server    Server
+	DB        DB
+	Storage   Storage
+	Auth      Auth
+	Uploads   Uploads
+	Websocket Websocket
+}
+
+type Server struct {
+	// Port - адреса, яку слухає сервер, наприклад ":8080"
+	Port string
+	// RequestTimeout - час, протягом якого запит до API може звертатися до
+	// БД та сховища (0 - без обмежень)
+	RequestTimeout time.Duration
+	// LegacyErrors - режим сумісності: частина помилок повертається з
+	// кодами 2xx, на які розраховує попередня версія веб-клієнта
+	LegacyErrors bool
+}
+
+type DB struct {
+	// Driver - драйвер БД: mysql (за замовчуванням), postgres або sqlite
+	Driver string
+	// DSN - рядок підключення до БД. Якщо задано, Host, Port, User,
+	// Password, Name та SSLMode не використовуються
+	DSN      string
+	Host     string
+	Port     string
+	User     string
+	Password string
+	// Name - назва БД. Для SQLite - шлях до файлу БД
+	Name    string
+	SSLMode string
+	// AutoMigrate - чи застосовувати міграції схеми під час запуску
+	AutoMigrate bool
+	// MaxOpenConns - найбільша кількість відкритих з'єднань (0 - без обмежень)
+	MaxOpenConns int
+	// MaxIdleConns - найбільша кількість з'єднань, що очікують
+	MaxIdleConns int
+	// ConnMaxLifetime - найбільший час використання з'єднання (0 - без обмежень)
+	ConnMaxLifetime time.Duration
+	// ConnMaxIdleTime - найбільший час очікування з'єднання (0 - без обмежень)
+	ConnMaxIdleTime time.Duration
+}
+
+type Storage struct {
+	// Driver - сховище файлів: local (за замовчуванням) або s3
+	Driver   string
+	Dir      string
+	Endpoint string
+	Region   string
+	Bucket   string
+	KeyId    string
+	Secret   string
+	UseSSL   bool
+}
+
+type Auth struct {
+	// SignInKey - ключ підпису токенів
+	SignInKey string
+	// Salt - сіль, що додається до хешу паролів
+	Salt string
+	// TokenTTL - час дії токена
+	TokenTTL time.Duration
+}
+
+type Uploads struct {
+	// Sizes - розміри квадратних копій завантажених зображень
+	Sizes []int
+	// Grace - час, протягом якого не видаляються зображення без посилань
+	Grace time.Duration
+	// SweepInterval - період запуску прибиральника зображень
+	SweepInterval time.Duration
+}
+
+type Websocket struct {
+	// MaxMessageSize - найбільший розмір повідомлення від клієнта в байтах
+	MaxMessageSize int64
+	// WriteWait - час на запис повідомлення клієнту
+	WriteWait time.Duration
+	// PongWait - час очікування відповіді на ping від клієнта
+	PongWait time.Duration
+}
+
+// Default повертає налаштування за замовчуванням
+func Default() Config {
+	return Config{
+		Mode: ModeDevelopment,
+		Server: Server{
+			Port:           ":8080",
+			RequestTimeout: 10 * time.Second,
+		},
+		DB: DB{
+			Driver:          DriverMySQL,
+			Host:            "localhost",
+			User:            "root",
+			Password:        "@root",
+			Name:            "chatDB",
+			SSLMode:         "disable",
+			AutoMigrate:     true,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnMaxIdleTime: time.Minute,
+		},
+		Storage: Storage{
+			Driver: StorageLocal,
+			Dir:    "uploads",
+		},
+		Auth: Auth{
+			TokenTTL: 48 * time.Hour,
+		},
+		Uploads: Uploads{
+			Sizes:         imaging.DefaultSizes,
+			Grace:         24 * time.Hour,
+			SweepInterval: time.Hour,
+		},
+		Websocket: Websocket{
+			MaxMessageSize: 512,
+			WriteWait:      10 * time.Second,
+			PongWait:       60 * time.Second,
+		},
+	}
+}
+
+// ConnectionString повертає рядок підключення до БД для налаштованого драйвера
+func (d DB) ConnectionString() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	if d.Driver == DriverSQLite {
+		return d.Name
+	}
+
+	port := d.Port
+	if port == "" {
+		port = "3307"
+		if d.Driver == DriverPostgres {
+			port = "5432"
+		}
+	}
+
+	if d.Driver == DriverPostgres {
+		return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			d.Host, port, d.User, d.Password, d.Name, d.SSLMode)
+	}
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
+		d.User, d.Password, d.Host, port, d.Name)
+}
+
+// Validate перевіряє налаштування та повертає помилку з переліком усіх
+// некоректних значень. У режимі production також відхиляються слабкі секрети
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Mode == ModeDevelopment || c.Mode == ModeProduction, "unknown mode %q", c.Mode)
+	check(c.Server.Port != "", "port is required")
+	check(c.Server.RequestTimeout >= 0, "request timeout must not be negative")
+
+	check(c.DB.Driver == DriverMySQL || c.DB.Driver == DriverPostgres || c.DB.Driver == DriverSQLite,
+		"unknown database driver %q", c.DB.Driver)
+	check(c.DB.ConnectionString() != "", "database name is required")
+	check(c.DB.MaxOpenConns >= 0 && c.DB.MaxIdleConns >= 0, "database pool sizes must not be negative")
+	check(c.DB.ConnMaxLifetime >= 0 && c.DB.ConnMaxIdleTime >= 0, "database connection lifetimes must not be negative")
+
+	switch c.Storage.Driver {
+	case StorageLocal:
+		check(c.Storage.Dir != "", "uploads directory is required")
+	case StorageS3:
+		check(c.Storage.Endpoint != "" && c.Storage.Bucket != "", "s3 endpoint and bucket are required")
+	default:
+		check(false, "unknown storage driver %q", c.Storage.Driver)
+	}
+
+	check(c.Auth.SignInKey != "", "sign in key is required")
+	check(c.Auth.Salt != "", "salt is required")
+	check(c.Auth.TokenTTL > 0, "token ttl must be positive")
+	if c.Mode == ModeProduction {
+		check(len(c.Auth.SignInKey) >= minKeyLength && !weak(c.Auth.SignInKey),
+			"sign in key is too weak for production: use at least %d random characters", minKeyLength)
+		check(len(c.Auth.Salt) >= minSaltLength && !weak(c.Auth.Salt),
+			"salt is too weak for production: use at least %d random characters", minSaltLength)
+		check(c.Auth.SignInKey != c.Auth.Salt, "sign in key and salt must differ")
+	}
+
+	check(len(c.Uploads.Sizes) > 0, "at least one image size is required")
+	for _, size := range c.Uploads.Sizes {
+		check(size > 0, "incorrect image size %d", size)
+	}
+	check(c.Uploads.Grace >= 0, "uploads grace must not be negative")
+	check(c.Uploads.SweepInterval > 0, "uploads sweep interval must be positive")
+
+	check(c.Websocket.MaxMessageSize > 0, "websocket message size must be positive")
+	check(c.Websocket.WriteWait > 0 && c.Websocket.PongWait > 0, "websocket timeouts must be positive")
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// weak перевіряє, чи секрет є відомим значенням або складається з
+// одного повтореного символу
+func weak(secret string) bool {
+	if secret == "" {
+		return true
+	}
+	for _, known := range weakSecrets {
+		if strings.EqualFold(secret, known) {
+			return true
+		}
+	}
+	return strings.Count(secret, secret[:1]) == len(secret)
+}
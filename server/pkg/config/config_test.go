@@ -0,0 +1,146 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// valid повертає коректні налаштування режиму production
+func valid() Config {
+	cnf := Default()
+	cnf.Mode = ModeProduction
+	cnf.Auth.SignInKey = "Xq3vT9pLm2Wz8RkF5nYc1HbJ7sDg4AeU"
+	cnf.Auth.Salt = "k8Fz2QwLp5VnR9tY"
+	return cnf
+}
+
+func TestLoad(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "server.env")
+	require.NoError(t, os.WriteFile(file, []byte(`
+PORT = ":7000"
+DB_DRIVER = "sqlite"
+DB_NAME = "from-file.db"
+signInKey = "file key"
+salt = "file salt"
+IMAGE_SIZES = "16, 48"
+UPLOADS_GRACE = "1h"
+`), 0o600))
+	t.Setenv("DB_NAME", "from-env.db")
+	t.Setenv("UPLOADS_GRACE", "2h")
+	t.Setenv("S3_REGION", "")
+
+	cnf, args, err := Load([]string{"-config", file, "-uploads-grace", "3h", "migrate", "down", "-steps", "2"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"migrate", "down", "-steps", "2"}, args)
+
+	assert.Equal(t, ":7000", cnf.Server.Port, "file overrides default")
+	assert.Equal(t, "from-env.db", cnf.DB.ConnectionString(), "env overrides file")
+	assert.Equal(t, 3*time.Hour, cnf.Uploads.Grace, "flag overrides env")
+	assert.Equal(t, []int{16, 48}, cnf.Uploads.Sizes)
+	assert.Equal(t, "file key", cnf.Auth.SignInKey)
+	assert.Equal(t, 48*time.Hour, cnf.Auth.TokenTTL)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	t.Setenv("signInKey", "key")
+	t.Setenv("salt", "salt")
+
+	// Файл за замовчуванням може бути відсутнім, а явно вказаний - ні
+	_, _, err := Load(nil)
+	assert.NoError(t, err)
+	_, _, err = Load([]string{"-config", filepath.Join(t.TempDir(), "missing.env")})
+	assert.Error(t, err)
+
+	_, _, err = Load([]string{"-db-max-open-conns", "many"})
+	assert.ErrorContains(t, err, "DB_MAX_OPEN_CONNS")
+
+	t.Setenv("APP_MODE", ModeProduction)
+	_, _, err = Load(nil)
+	assert.ErrorContains(t, err, "too weak")
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cnf *Config)
+		err    string
+	}{
+		{
+			name:   "Ok",
+			modify: func(cnf *Config) {},
+		},
+		{
+			name:   "Weak secrets are allowed in development",
+			modify: func(cnf *Config) { cnf.Mode, cnf.Auth.SignInKey, cnf.Auth.Salt = ModeDevelopment, "code", "code" },
+		},
+		{
+			name:   "Short key",
+			modify: func(cnf *Config) { cnf.Auth.SignInKey = "code" },
+			err:    "sign in key is too weak",
+		},
+		{
+			name:   "Known salt",
+			modify: func(cnf *Config) { cnf.Auth.Salt = "239tjeaWFYh2rofjw" },
+			err:    "salt is too weak",
+		},
+		{
+			name:   "Repeated characters",
+			modify: func(cnf *Config) { cnf.Auth.SignInKey = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa" },
+			err:    "sign in key is too weak",
+		},
+		{
+			name:   "Same key and salt",
+			modify: func(cnf *Config) { cnf.Auth.Salt = cnf.Auth.SignInKey },
+			err:    "must differ",
+		},
+		{
+			name:   "Missing secrets",
+			modify: func(cnf *Config) { cnf.Mode, cnf.Auth.SignInKey = ModeDevelopment, "" },
+			err:    "sign in key is required",
+		},
+		{
+			name:   "Unknown drivers",
+			modify: func(cnf *Config) { cnf.DB.Driver, cnf.Storage.Driver = "oracle", "ftp" },
+			err:    `unknown database driver "oracle"; unknown storage driver "ftp"`,
+		},
+		{
+			name:   "S3 without bucket",
+			modify: func(cnf *Config) { cnf.Storage.Driver = StorageS3 },
+			err:    "s3 endpoint and bucket are required",
+		},
+		{
+			name:   "Image size",
+			modify: func(cnf *Config) { cnf.Uploads.Sizes = []int{32, 0} },
+			err:    "incorrect image size 0",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cnf := valid()
+			test.modify(&cnf)
+			err := cnf.Validate()
+			if test.err == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+func TestDB_ConnectionString(t *testing.T) {
+	db := Default().DB
+	assert.Equal(t, "root:@root@tcp(localhost:3307)/chatDB?charset=utf8mb4&parseTime=True&loc=Local", db.ConnectionString())
+
+	db.Driver = DriverPostgres
+	assert.Equal(t, "host=localhost port=5432 user=root password=@root dbname=chatDB sslmode=disable", db.ConnectionString())
+
+	db.DSN = "postgres://localhost/chat"
+	assert.Equal(t, db.DSN, db.ConnectionString())
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// defaultFile - файл налаштувань, що читається, якщо інший не вказано.
+// На відміну від явно вказаного файлу, він може бути відсутнім
+const defaultFile = ".env"
+
+// option пов'язує поле налаштувань з ключем у файлі та змінних оточення
+// і з прапорцем командного рядка
+type option struct {
+	key   string
+	flag  string
+	usage string
+	value interface{}
+}
+
+func (c *Config) options() []option {
+	return []option{
+		{"APP_MODE", "mode", "development or production", &c.Mode},
+		{"PORT", "port", "address to listen on", &c.Server.Port},
+		{"DB_REQUEST_TIMEOUT", "db-request-timeout", "database time limit of an API request (0 - unlimited)", &c.Server.RequestTimeout},
+		{"API_LEGACY_ERRORS", "api-legacy-errors", "answer auth errors with the 2xx codes of the old web client", &c.Server.LegacyErrors},
+
+		{"DB_DRIVER", "db-driver", "mysql, postgres or sqlite", &c.DB.Driver},
+		{"DB_DSN", "db-dsn", "database connection string, overrides DB_HOST, DB_PORT and others", &c.DB.DSN},
+		{"DB_HOST", "db-host", "database host", &c.DB.Host},
+		{"DB_PORT", "db-port", "database port (3307 for mysql, 5432 for postgres by default)", &c.DB.Port},
+		{"DB_USER", "db-user", "database user", &c.DB.User},
+		{"DB_PASS", "db-pass", "database password", &c.DB.Password},
+		{"DB_NAME", "db-name", "database name or sqlite file", &c.DB.Name},
+		{"DB_SSLMODE", "db-sslmode", "postgres sslmode", &c.DB.SSLMode},
+		{"DB_AUTO_MIGRATE", "db-auto-migrate", "apply schema migrations on start", &c.DB.AutoMigrate},
+		{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "max open connections (0 - unlimited)", &c.DB.MaxOpenConns},
+		{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "max idle connections", &c.DB.MaxIdleConns},
+		{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "max connection lifetime (0 - unlimited)", &c.DB.ConnMaxLifetime},
+		{"DB_CONN_MAX_IDLE_TIME", "db-conn-max-idle-time", "max connection idle time (0 - unlimited)", &c.DB.ConnMaxIdleTime},
+
+		{"STORAGE_DRIVER", "storage-driver", "local or s3", &c.Storage.Driver},
+		{"UPLOADS_DIR", "uploads-dir", "directory of the local storage", &c.Storage.Dir},
+		{"S3_ENDPOINT", "s3-endpoint", "s3 endpoint", &c.Storage.Endpoint},
+		{"S3_REGION", "s3-region", "s3 region", &c.Storage.Region},
+		{"S3_BUCKET", "s3-bucket", "s3 bucket", &c.Storage.Bucket},
+		{"S3_ACCESS_KEY", "s3-access-key", "s3 access key", &c.Storage.KeyId},
+		{"S3_SECRET_KEY", "s3-secret-key", "s3 secret key", &c.Storage.Secret},
+		{"S3_USE_SSL", "s3-use-ssl", "connect to s3 over https", &c.Storage.UseSSL},
+
+		{"signInKey", "sign-in-key", "token signing key", &c.Auth.SignInKey},
+		{"salt", "salt", "password hash salt", &c.Auth.Salt},
+		{"TOKEN_TTL", "token-ttl", "token lifetime", &c.Auth.TokenTTL},
+
+		{"IMAGE_SIZES", "image-sizes", "comma separated sizes of image copies", &c.Uploads.Sizes},
+		{"UPLOADS_GRACE", "uploads-grace", "time before unused images are deleted", &c.Uploads.Grace},
+		{"UPLOADS_SWEEP_INTERVAL", "uploads-sweep-interval", "period of unused images cleanup", &c.Uploads.SweepInterval},
+
+		{"WS_MAX_MESSAGE_SIZE", "ws-max-message-size", "max websocket message size in bytes", &c.Websocket.MaxMessageSize},
+		{"WS_WRITE_WAIT", "ws-write-wait", "websocket write time limit", &c.Websocket.WriteWait},
+		{"WS_PONG_WAIT", "ws-pong-wait", "websocket pong time limit", &c.Websocket.PongWait},
+	}
+}
+
+// Load повертає перевірені налаштування та аргументи, що залишилися після
+// прапорців (підкоманду). Значення за замовчуванням перекриваються файлом
+// налаштувань (прапорець -config, змінна CONFIG_FILE або .env), далі
+// змінними оточення і, нарешті, прапорцями
+func Load(args []string) (Config, []string, error) {
+	cnf := Default()
+	options := cnf.options()
+
+	flags := make(map[string]string)
+	fset := flag.NewFlagSet("server", flag.ContinueOnError)
+	file := fset.String("config", os.Getenv("CONFIG_FILE"), "settings file in .env format")
+	for _, o := range options {
+		key := o.key
+		fset.Func(o.flag, fmt.Sprintf("%s (%s)", o.usage, key), func(value string) error {
+			flags[key] = value
+			return nil
+		})
+	}
+	if err := fset.Parse(args); err != nil {
+		return cnf, nil, err
+	}
+
+	values, err := readFile(*file)
+	if err != nil {
+		return cnf, nil, err
+	}
+	for _, o := range options {
+		if value, ok := os.LookupEnv(o.key); ok {
+			values[o.key] = value
+		}
+		if value, ok := flags[o.key]; ok {
+			values[o.key] = value
+		}
+	}
+
+	for _, o := range options {
+		// Порожнє значення, як і відсутнє, залишає значення за замовчуванням
+		value := strings.TrimSpace(values[o.key])
+		if value == "" {
+			continue
+		}
+		if err := set(o.value, value); err != nil {
+			return cnf, nil, fmt.Errorf("incorrect %s: %w", o.key, err)
+		}
+	}
+	return cnf, fset.Args(), cnf.Validate()
+}
+
+// readFile читає файл налаштувань. Відсутність файлу за замовчуванням не
+// є помилкою
+func readFile(name string) (map[string]string, error) {
+	if name == "" {
+		values, err := godotenv.Read(defaultFile)
+		if errors.Is(err, fs.ErrNotExist) {
+			return make(map[string]string), nil
+		}
+		return values, err
+	}
+	return godotenv.Read(name)
+}
+
+// set розбирає значення відповідно до типу поля
+func set(field interface{}, value string) error {
+	switch field := field.(type) {
+	case *string:
+		*field = value
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field = b
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field = n
+	case *int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*field = n
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field = d
+	case *[]int:
+		var sizes []int
+		for _, item := range strings.Split(value, ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(item))
+			if err != nil {
+				return err
+			}
+			sizes = append(sizes, n)
+		}
+		*field = sizes
+	default:
+		return fmt.Errorf("unsupported type %T", field)
+	}
+	return nil
+}
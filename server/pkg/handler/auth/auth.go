@@ -2,22 +2,43 @@ package auth
 
 import (
 	"cmd/pkg/handler/middlewares"
-	"cmd/pkg/handler/responses"
+	"cmd/pkg/handler/websocket"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
-	"fmt"
+	"context"
+	"errors"
 	"github.com/labstack/echo/v4"
+	"log"
 	"net/http"
-	"os"
-	"strings"
 )
 
 type AuthHandler struct {
 	services *service.Service
+	events   websocket.Publisher
 }
 
-func NewAuthHandler(services *service.Service) *AuthHandler {
-	return &AuthHandler{services: services}
+func NewAuthHandler(services *service.Service, events websocket.Publisher) *AuthHandler {
+	return &AuthHandler{services: services, events: events}
+}
+
+// publishProfile повідомляє співрозмовників приватних чатів користувача про
+// зміну його імені чи зображення однією подією після збереження профілю.
+// Помилка не скасовує зміну профілю
+func (h *AuthHandler) publishProfile(ctx context.Context, user models.User) {
+	chats, err := h.services.Chat.GetPrivateChats(ctx, user.Id)
+	if err != nil {
+		log.Printf("error : %v", err)
+		return
+	}
+	if len(chats) == 0 {
+		return
+	}
+	partners := make([]int, 0, len(chats))
+	for _, chat := range chats {
+		partners = append(partners, chat.PartnerId)
+	}
+	profile := models.User{Id: user.Id, Username: user.Username, Icon: user.Icon}
+	h.events.Notify(user.Id, partners, websocket.Event{Type: websocket.EventUserUpdated, Data: profile})
 }
 
 // SignUp godoc
@@ -29,51 +50,34 @@ func NewAuthHandler(services *service.Service) *AuthHandler {
 // @Tags         auth
 // @Accept       json
 // @Produce      json
-// @Param        user	body     SignInInput   true  "User data"
+// @Param        user	body     SignUpInput   true  "User data"
 // @Success      200 	{object} TokenResponse	 "result is user token"
-// @Success 	 202 	{object} responses.ErrorResponse	 "username is already used"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "You must enter a username"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "Password must be at least 6 symbols"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "username_taken (202 in compatibility mode)"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "create user error"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "generate token error"
 // @Router       /auth/sign-up [post]
 func (h *AuthHandler) SignUp(c echo.Context) error {
 
-	// Отримуємо дані з сайту (ім'я та пароль)
-	var input models.User
-	if errReq := c.Bind(&input); errReq != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
-	}
-
-	// Перевіряємо отримані дані
-	{
-		//username is not empty
-		if len(input.Username) == 0 {
-			responses.NewErrorResponse(c, http.StatusBadRequest, "You must enter a username")
-			return nil
-		}
-
-		// password length
-		if len(input.Password) < 6 {
-			responses.NewErrorResponse(c, http.StatusBadRequest, "Password must be at least 6 symbols")
-			return nil
-		}
-	}
-
-	// Створюємо нового користувача
-	_, errUser := h.services.Authorization.CreateUser(input)
-	// При спробі створення користувача з однаковим ім'ям викличеться помилка
+	// Отримуємо дані з сайту (ім'я та пароль) та перевіряємо їх
+	var input SignUpInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
+	}
+
+	// Створюємо нового користувача. При спробі створення користувача з
+	// однаковим ім'ям повернеться service.ErrUsernameTaken
+	user := models.User{Username: input.Username, Password: input.Password}
+	_, errUser := h.services.Authorization.CreateUser(c.Request().Context(), user)
 	if errUser != nil {
-		responses.NewErrorResponse(c, http.StatusAccepted, "username is already used")
-		return nil
+		return service.Internal(errUser, "create user error")
 	}
 
 	// Генеруємо токен та шифруємо в ньому ID користувача
-	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
+	token, err := h.services.Authorization.GenerateToken(c.Request().Context(), input.Username, input.Password)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "generate token error")
-		return nil
+		return service.Internal(err, "generate token error")
 	}
 
 	// Відгук сервера
@@ -87,8 +91,8 @@ func (h *AuthHandler) SignUp(c echo.Context) error {
 }
 
 type SignInInput struct {
-	Username string `json:"username" form:"username"  binding:"required"`
-	Password string `json:"password" form:"password"  binding:"required"`
+	Username string `json:"username" form:"username" validate:"required"`
+	Password string `json:"password" form:"password" validate:"required"`
 }
 
 // SignIn godoc
@@ -100,31 +104,33 @@ type SignInInput struct {
 // @Produce      json
 // @Param        user	body     SignInInput   true  "User data"
 // @Success      200 	{object} TokenResponse  "result is user token"
-// @Success 	 202 	{object} responses.ErrorResponse	 "user not found"
-// @Success 	 202 	{object} responses.ErrorResponse	 "incorrect password"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 401 	{object} responses.ErrorResponse	 "unknown_user: user not found (202 in compatibility mode)"
+// @Failure 	 401 	{object} responses.ErrorResponse	 "wrong_password: incorrect password (202 in compatibility mode)"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get user error"
 // @Router       /auth/sign-in [post]
 func (h *AuthHandler) SignIn(c echo.Context) error {
 
 	// Отримуємо дані з сайту (ім'я та пароль)
 	var input SignInInput
-	if err := c.Bind(&input); err != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
 	}
 
 	//Перевіряємо чи існує користувач за його іменем
-	_, errCheck := h.services.Authorization.GetByName(input.Username)
+	_, errCheck := h.services.Authorization.GetByName(c.Request().Context(), input.Username)
+	if errors.Is(errCheck, service.ErrUserNotFound) {
+		return service.ErrUnknownUser.Wrap(errCheck)
+	}
 	if errCheck != nil {
-		responses.NewErrorResponse(c, http.StatusAccepted, "user not found")
-		return nil
+		return service.Internal(errCheck, "get user error")
 	}
 
 	// Генеруємо токен (якщо ім'я та пароль правильні)
-	token, err := h.services.Authorization.GenerateToken(input.Username, input.Password)
+	token, err := h.services.Authorization.GenerateToken(c.Request().Context(), input.Username, input.Password)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusAccepted, "incorrect password")
-		return nil
+		return service.Internal(err, "generate token error")
 	}
 
 	// Відгук сервера
@@ -144,7 +150,7 @@ func (h *AuthHandler) SignIn(c echo.Context) error {
 // @Tags         auth
 // @Produce      json
 // @Success      200 	{object} TokenResponse   "result is user ID"
-// @Success 	 204 	{object} IdResponse	 "user not found"
+// @Failure 	 401 	{object} responses.ErrorResponse	 "unauthenticated: user not found (204 in compatibility mode)"
 // @Router       /auth/get-me [get]
 func (h *AuthHandler) GetMe(c echo.Context) error {
 
@@ -152,8 +158,7 @@ func (h *AuthHandler) GetMe(c echo.Context) error {
 	userId := c.Get(middlewares.UserCtx)
 
 	if userId == 0 {
-		responses.NewErrorResponse(c, http.StatusNoContent, "user not found")
-		return nil
+		return service.ErrUnauthenticated.WithMessage("user not found")
 	}
 
 	// Відгук сервера
@@ -167,8 +172,8 @@ func (h *AuthHandler) GetMe(c echo.Context) error {
 }
 
 type ChangePassword struct {
-	OldPassword string `json:"old_password" form:"old_password"  binding:"required"`
-	NewPassword string `json:"new_password" form:"new_password"  binding:"required"`
+	OldPassword string `json:"old_password" form:"old_password" validate:"required"`
+	NewPassword string `json:"new_password" form:"new_password" validate:"required,min=6,max=128"`
 }
 
 // ChangePassword godoc
@@ -181,10 +186,10 @@ type ChangePassword struct {
 // @Produce      json
 // @Param        passwords	body     ChangePassword  	 true  	 "actual and new password"
 // @Success      200 	{object} MessageResponse   			 "password changed"
-// @Success 	 202 	{object} responses.ErrorResponse	 "incorrect password"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "password must be at least 6 symbols"
-// @Failure 	 404 	{object} responses.ErrorResponse	 "incorrect user data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 401 	{object} responses.ErrorResponse	 "wrong_password: incorrect password (202 in compatibility mode)"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "update password error"
 // @Router       /auth/change/password [put]
 func (h *AuthHandler) ChangePassword(c echo.Context) error {
@@ -194,35 +199,27 @@ func (h *AuthHandler) ChangePassword(c echo.Context) error {
 
 	//Отримуємо актуальний та новий паролі
 	var passwords ChangePassword
-	if errReq := c.Bind(&passwords); errReq != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
-	}
-	if len(passwords.NewPassword) < 6 {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "password must be at least 6 symbols")
-		return nil
+	if err := middlewares.Bind(c, &passwords); err != nil {
+		return err
 	}
 
 	//Отримуємо дані активного користувача
-	user, errU := h.services.Authorization.GetUserById(userId)
+	user, errU := h.services.Authorization.GetUserById(c.Request().Context(), userId)
 	if errU != nil {
-		responses.NewErrorResponse(c, http.StatusNotFound, "incorrect user data")
-		return nil
+		return service.Internal(errU, "incorrect user data")
 	}
 
 	//Перевіряємо вірність введеного паролю
-	_, errCheck := h.services.Authorization.GenerateToken(user.Username, passwords.OldPassword)
+	_, errCheck := h.services.Authorization.GenerateToken(c.Request().Context(), user.Username, passwords.OldPassword)
 	if errCheck != nil {
-		responses.NewErrorResponse(c, http.StatusAccepted, "incorrect password")
-		return nil
+		return service.Internal(errCheck, "check password error")
 	}
 
 	//Оновлюємо пароль у БД
 	user.Password = passwords.NewPassword
-	err := h.services.Authorization.UpdatePassword(user)
+	err := h.services.Authorization.UpdatePassword(c.Request().Context(), user)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "update password error")
-		return nil
+		return service.Internal(err, "update password error")
 	}
 
 	//Відгук сервера
@@ -239,15 +236,17 @@ func (h *AuthHandler) ChangePassword(c echo.Context) error {
 // @Summary      Change username
 // @Description  Користувач надсилає новий нікнейм.
 // @Description	 Після перевірки нового нікнейму на унікальність, змінює нікнейм на новий.
+// @Description	 Співрозмовники приватних чатів отримують подію user_updated у будь-якій кімнаті.
 // @Security ApiKeyAuth
 // @Tags         auth
 // @Accept       json
 // @Produce      json
 // @Param        username	body     UsernameInput  	 true 	 "New username"
 // @Success      200 	{object} MessageResponse  			 "username changed"
-// @Success 	 202 	{object} responses.ErrorResponse	 "username is used"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
-// @Failure 	 404 	{object} responses.ErrorResponse	 "incorrect user data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "username_taken (202 in compatibility mode)"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "update username error"
 // @Router       /auth/change/username [put]
 func (h *AuthHandler) ChangeUsername(c echo.Context) error {
@@ -256,34 +255,31 @@ func (h *AuthHandler) ChangeUsername(c echo.Context) error {
 	userId := c.Get(middlewares.UserCtx).(int)
 
 	//Отримуємо новий нікнейм
-	var username models.User
-	if errReq := c.Bind(&username); errReq != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
+	var username UsernameInput
+	if err := middlewares.Bind(c, &username); err != nil {
+		return err
 	}
 
 	//Отримуємо дані активного користувача
-	user, errU := h.services.Authorization.GetUserById(userId)
+	user, errU := h.services.Authorization.GetUserById(c.Request().Context(), userId)
 	if errU != nil {
-		responses.NewErrorResponse(c, http.StatusNotFound, "incorrect user data")
-		return nil
+		return service.Internal(errU, "incorrect user data")
 	}
 
 	//Перевіряємо чи існує користувач за його іменем.
 	//Якщо ім'я не зайняте, повернеться помилка
-	_, errCheck := h.services.Authorization.GetByName(username.Username)
+	_, errCheck := h.services.Authorization.GetByName(c.Request().Context(), username.Username)
 	if errCheck == nil {
-		responses.NewErrorResponse(c, http.StatusAccepted, "username is used")
-		return nil
+		return service.ErrUsernameTaken
 	}
 
 	//Оновлюємо нікнейм у БД
 	user.Username = username.Username
-	errPut := h.services.Authorization.UpdateData(user)
+	errPut := h.services.Authorization.UpdateData(c.Request().Context(), user)
 	if errPut != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "update username error")
-		return nil
+		return service.Internal(errPut, "update username error")
 	}
+	h.publishProfile(c.Request().Context(), user)
 
 	//Відгук сервера
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
@@ -298,13 +294,17 @@ func (h *AuthHandler) ChangeUsername(c echo.Context) error {
 // ChangeIcon godoc
 // @Summary      Change username
 // @Description  Користувач надсилає новий файл зображення. Замінює зображення на нове.
+// @Description  Співрозмовники приватних чатів отримують подію user_updated у будь-якій кімнаті.
 // @Security ApiKeyAuth
 // @Tags         auth
 // @Produce      json
 // @Success      200 	{object} MessageResponse  			 "icon changed"
-// @Failure 	 404 	{object} responses.ErrorResponse	 "incorrect user data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "corrupt_image: incorrect image error"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
+// @Failure 	 413 	{object} responses.ErrorResponse	 "image_too_large: image is too large"
+// @Failure 	 415 	{object} responses.ErrorResponse	 "unsupported_type: incorrect file type error"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "update icon error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "delete icon error"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "update icon references error"
 // @Router       /auth/change/icon [put]
 func (h *AuthHandler) ChangeIcon(c echo.Context) error {
 
@@ -312,38 +312,31 @@ func (h *AuthHandler) ChangeIcon(c echo.Context) error {
 	userId := c.Get(middlewares.UserCtx).(int)
 
 	//Отримуємо ім'я файлу зображення
-	fileName, err := middlewares.UploadImage(c)
+	fileName, err := middlewares.UploadImage(c, h.services.Upload)
 	if err != nil {
 		return err
 	}
 
 	//Отримуємо дані активного користувача
-	user, errU := h.services.Authorization.GetUserById(userId)
+	user, errU := h.services.Authorization.GetUserById(c.Request().Context(), userId)
 	if errU != nil {
-		responses.NewErrorResponse(c, http.StatusNotFound, "incorrect user data")
-		return nil
+		return service.Internal(errU, "incorrect user data")
 	}
 
 	//Замінюємо дані у БД
 	var oldIcon = user.Icon
-	user.Icon = strings.TrimPrefix(fileName, "uploads/")
-	errPut := h.services.Authorization.UpdateData(user)
+	user.Icon = fileName
+	errPut := h.services.Authorization.UpdateData(c.Request().Context(), user)
 	if errPut != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "update icon error")
-		return nil
+		return service.Internal(errPut, "update icon error")
 	}
 
-	//Видалення застарілих файлів
-	if len(oldIcon) != 0 {
-		if err := os.Remove(fmt.Sprintf("uploads/%s", oldIcon)); err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete icon error")
-			return nil
-		}
-		if err := os.Remove(fmt.Sprintf("uploads/resize-%s", oldIcon)); err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete icon error")
-			return nil
-		}
+	//Позначаємо нове зображення як використане, а старе - як непотрібне.
+	//Старі файли видалить прибиральник після пільгового періоду
+	if err := h.services.Upload.Replace(c.Request().Context(), oldIcon, fileName); err != nil {
+		return service.Internal(err, "update icon references error")
 	}
+	h.publishProfile(c.Request().Context(), user)
 
 	//Відгук сервера
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
@@ -354,3 +347,79 @@ func (h *AuthHandler) ChangeIcon(c echo.Context) error {
 	}
 	return nil
 }
+
+// GetSettings godoc
+// @Summary      Get privacy settings
+// @Description  Повертає налаштування приватності активного користувача:
+// @Description  хто може писати йому в особисті чати (direct_messages), додавати до
+// @Description  публічних чатів (chat_invites), бачити час активності (last_seen) та
+// @Description  чи знаходять його у пошуку (searchable).
+// @Security ApiKeyAuth
+// @Tags         auth
+// @Produce      json
+// @Success      200 	{object} models.UserSettings   		 "privacy settings"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get settings error"
+// @Router       /auth/settings [get]
+func (h *AuthHandler) GetSettings(c echo.Context) error {
+
+	//Отримуємо власний ID з контексту
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	//Отримуємо налаштування з БД
+	settings, err := h.services.Settings.GetSettings(c.Request().Context(), userId)
+	if err != nil {
+		return service.Internal(err, "get settings error")
+	}
+
+	//Відгук сервера
+	return c.JSON(http.StatusOK, settings)
+}
+
+// UpdateSettings godoc
+// @Summary      Update privacy settings
+// @Description  Користувач надсилає нові налаштування приватності. direct_messages та
+// @Description  last_seen приймають everyone, friends або nobody, chat_invites - everyone
+// @Description  або friends.
+// @Security ApiKeyAuth
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        settings	body     SettingsInput  	 true 	 "New settings"
+// @Success      200 	{object} MessageResponse  			 "settings changed"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "update settings error"
+// @Router       /auth/settings [put]
+func (h *AuthHandler) UpdateSettings(c echo.Context) error {
+
+	//Отримуємо власний ID з контексту
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	//Отримуємо нові налаштування
+	var input SettingsInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
+	}
+
+	//Зберігаємо налаштування у БД
+	settings := models.UserSettings{
+		UserId:         userId,
+		DirectMessages: input.DirectMessages,
+		ChatInvites:    input.ChatInvites,
+		Searchable:     *input.Searchable,
+		LastSeen:       input.LastSeen,
+	}
+	if err := h.services.Settings.UpdateSettings(c.Request().Context(), settings); err != nil {
+		return service.Internal(err, "update settings error")
+	}
+
+	//Відгук сервера
+	errRes := c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "settings changed",
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
@@ -1,14 +1,21 @@
 package auth
 
 import (
+	"bytes"
 	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/responses"
+	"cmd/pkg/handler/websocket"
+	"cmd/pkg/imaging"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
 	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/validation"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
@@ -16,6 +23,21 @@ import (
 	"testing"
 )
 
+// events запам'ятовує надіслані події та отримувачів Notify
+type events struct {
+	published  []websocket.Event
+	recipients []int
+}
+
+func (e *events) Publish(userId int, event websocket.Event) {
+	e.published = append(e.published, event)
+}
+
+func (e *events) Notify(userId int, recipients []int, event websocket.Event) {
+	e.published = append(e.published, event)
+	e.recipients = append(e.recipients, recipients...)
+}
+
 func TestAuthHandler_SignUp(t *testing.T) {
 	type mockBehavior func(s *mockService.MockAuthorization, user models.User)
 
@@ -29,15 +51,15 @@ func TestAuthHandler_SignUp(t *testing.T) {
 	}{
 		{
 			name:      "ok",
-			inputBody: `{"username":"test username","password":"password"}`,
+			inputBody: `{"username":"test_username","password":"password"}`,
 			inputUser: models.User{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
 				token := "token"
-				s.EXPECT().CreateUser(user).Return(1, nil)
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return(token, nil)
+				s.EXPECT().CreateUser(gomock.Any(), user).Return(1, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return(token, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"token":"token"}` + "\n",
@@ -46,13 +68,13 @@ func TestAuthHandler_SignUp(t *testing.T) {
 			name:      "Error request data",
 			inputBody: "error",
 			inputUser: models.User{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:      "Wrong Input UserName",
@@ -60,7 +82,17 @@ func TestAuthHandler_SignUp(t *testing.T) {
 			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"You must enter a username"}` + "\n",
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"username","rule":"required","message":"is required"}]}` + "\n",
+		},
+		{
+			name:      "Every invalid field is reported",
+			inputBody: `{"username": "a b", "password": "fifth"}`,
+			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
+			},
+			expectedStatusCode: 400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[` +
+				`{"field":"username","rule":"username","message":"may contain only letters, digits, '_', '.' and '-'"},` +
+				`{"field":"password","rule":"min","message":"must be at least 6 characters"}]}` + "\n",
 		},
 		{
 			name:      "Wrong Input Password",
@@ -68,34 +100,47 @@ func TestAuthHandler_SignUp(t *testing.T) {
 			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"Password must be at least 6 symbols"}` + "\n",
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"password","rule":"min","message":"must be at least 6 characters"}]}` + "\n",
 		},
 		{
 			name:      "Create User Error",
-			inputBody: `{"username":"test username","password":"password"}`,
+			inputBody: `{"username":"test_username","password":"password"}`,
 			inputUser: models.User{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
-				r.EXPECT().CreateUser(user).Return(0, errors.New("username is already used"))
+				r.EXPECT().CreateUser(gomock.Any(), user).Return(0, service.ErrUsernameTaken)
 			},
-			expectedStatusCode:   202,
-			expectedResponseBody: `{"message":"username is already used"}` + "\n",
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"username_taken","message":"username is already used"}` + "\n",
+		},
+		{
+			name:      "Create User Internal Error",
+			inputBody: `{"username":"test_username","password":"password"}`,
+			inputUser: models.User{
+				Username: "test_username",
+				Password: "password",
+			},
+			mockBehavior: func(r *mockService.MockAuthorization, user models.User) {
+				r.EXPECT().CreateUser(gomock.Any(), user).Return(0, errors.New("some error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"create user error"}` + "\n",
 		},
 		{
 			name:      "Generate Token Error",
-			inputBody: `{"username":"test username","password":"password"}`,
+			inputBody: `{"username":"test_username","password":"password"}`,
 			inputUser: models.User{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user models.User) {
-				s.EXPECT().CreateUser(user).Return(1, nil)
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("", errors.New("generate token error"))
+				s.EXPECT().CreateUser(gomock.Any(), user).Return(1, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return("", errors.New("generate token error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"generate token error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"generate token error"}` + "\n",
 		},
 	}
 
@@ -109,9 +154,11 @@ func TestAuthHandler_SignUp(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.inputUser)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewAuthHandler(services)
+			handler := NewAuthHandler(services, &events{})
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			req := httptest.NewRequest(http.MethodPost, "/auth/sign-up",
 				strings.NewReader(testCase.inputBody))
@@ -119,10 +166,11 @@ func TestAuthHandler_SignUp(t *testing.T) {
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 
-			if assert.NoError(t, handler.SignUp(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.SignUp(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -141,20 +189,20 @@ func TestAuthHandler_SignIn(t *testing.T) {
 	}{
 		{
 			name:      "ok",
-			inputBody: `{"username":"test username","password":"password"}`,
+			inputBody: `{"username":"test_username","password":"password"}`,
 			inputUser: SignInInput{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
 				res := models.User{
 					Id:       2,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetByName(user.Username).Return(res, nil)
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("token", nil)
+				s.EXPECT().GetByName(gomock.Any(), user.Username).Return(res, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return("token", nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"token":"token"}` + "\n",
@@ -163,19 +211,19 @@ func TestAuthHandler_SignIn(t *testing.T) {
 			name:      "Error request data",
 			inputBody: "error",
 			inputUser: SignInInput{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:      "User not found",
-			inputBody: `{"username":"test username","password":"password"}`,
+			inputBody: `{"username":"test_username","password":"password"}`,
 			inputUser: SignInInput{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
@@ -185,30 +233,30 @@ func TestAuthHandler_SignIn(t *testing.T) {
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetByName(user.Username).Return(res, errors.New("user not found"))
+				s.EXPECT().GetByName(gomock.Any(), user.Username).Return(res, service.ErrUserNotFound)
 			},
-			expectedStatusCode:   202,
-			expectedResponseBody: `{"message":"user not found"}` + "\n",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"code":"unknown_user","message":"user not found"}` + "\n",
 		},
 		{
 			name:      "Incorrect password",
-			inputBody: `{"username":"test username","password":"password"}`,
+			inputBody: `{"username":"test_username","password":"password"}`,
 			inputUser: SignInInput{
-				Username: "test username",
+				Username: "test_username",
 				Password: "password",
 			},
 			mockBehavior: func(s *mockService.MockAuthorization, user SignInInput) {
 				res := models.User{
 					Id:       2,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetByName(user.Username).Return(res, nil)
-				s.EXPECT().GenerateToken(user.Username, user.Password).Return("", errors.New("incorrect password"))
+				s.EXPECT().GetByName(gomock.Any(), user.Username).Return(res, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), user.Username, user.Password).Return("", service.ErrWrongPassword)
 			},
-			expectedStatusCode:   202,
-			expectedResponseBody: `{"message":"incorrect password"}` + "\n",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"code":"wrong_password","message":"incorrect password"}` + "\n",
 		},
 	}
 
@@ -222,9 +270,11 @@ func TestAuthHandler_SignIn(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.inputUser)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewAuthHandler(services)
+			handler := NewAuthHandler(services, &events{})
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			req := httptest.NewRequest(http.MethodPost, "/auth/sign-in",
 				strings.NewReader(testCase.inputBody))
@@ -232,10 +282,11 @@ func TestAuthHandler_SignIn(t *testing.T) {
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 
-			if assert.NoError(t, handler.SignIn(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.SignIn(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -264,8 +315,8 @@ func TestAuthHandler_GetMe(t *testing.T) {
 			inputUserId: 0,
 			mockBehavior: func(s *mockService.MockAuthorization, userId int) {
 			},
-			expectedStatusCode:   204,
-			expectedResponseBody: `{"message":"user not found"}` + "\n",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"code":"unauthenticated","message":"user not found"}` + "\n",
 		},
 	}
 
@@ -279,9 +330,11 @@ func TestAuthHandler_GetMe(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.inputUserId)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewAuthHandler(services)
+			handler := NewAuthHandler(services, &events{})
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			req := httptest.NewRequest(http.MethodPost, "/auth/get-me", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -289,10 +342,11 @@ func TestAuthHandler_GetMe(t *testing.T) {
 			ctx := e.NewContext(req, rec)
 			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
 
-			if assert.NoError(t, handler.GetMe(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetMe(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -321,14 +375,14 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			mockBehavior: func(s *mockService.MockAuthorization, userId int, passwords ChangePassword) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
-				s.EXPECT().GenerateToken(res.Username, passwords.OldPassword).Return("token", nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), res.Username, passwords.OldPassword).Return("token", nil)
 				res.Password = passwords.NewPassword
-				s.EXPECT().UpdatePassword(res).Return(nil)
+				s.EXPECT().UpdatePassword(gomock.Any(), res).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"password changed"}` + "\n",
@@ -341,7 +395,7 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			mockBehavior: func(s *mockService.MockAuthorization, userId int, passwords ChangePassword) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:        "Password length error",
@@ -354,7 +408,7 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			mockBehavior: func(s *mockService.MockAuthorization, userId int, passwords ChangePassword) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"password must be at least 6 symbols"}` + "\n",
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"new_password","rule":"min","message":"must be at least 6 characters"}]}` + "\n",
 		},
 		{
 			name:        "Incorrect user data",
@@ -371,10 +425,10 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetUserById(userId).Return(res, errors.New("incorrect user data"))
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, service.ErrUserNotFound)
 			},
 			expectedStatusCode:   404,
-			expectedResponseBody: `{"message":"incorrect user data"}` + "\n",
+			expectedResponseBody: `{"code":"user_not_found","message":"user not found"}` + "\n",
 		},
 		{
 			name:        "Incorrect old password",
@@ -387,15 +441,15 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			mockBehavior: func(s *mockService.MockAuthorization, userId int, passwords ChangePassword) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
-				s.EXPECT().GenerateToken(res.Username, passwords.OldPassword).Return("", errors.New("incorrect password"))
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), res.Username, passwords.OldPassword).Return("", service.ErrWrongPassword)
 			},
-			expectedStatusCode:   202,
-			expectedResponseBody: `{"message":"incorrect password"}` + "\n",
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"code":"wrong_password","message":"incorrect password"}` + "\n",
 		},
 		{
 			name:        "Update password error",
@@ -408,17 +462,17 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			mockBehavior: func(s *mockService.MockAuthorization, userId int, passwords ChangePassword) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
-				s.EXPECT().GenerateToken(res.Username, passwords.OldPassword).Return("token", nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				s.EXPECT().GenerateToken(gomock.Any(), res.Username, passwords.OldPassword).Return("token", nil)
 				res.Password = passwords.NewPassword
-				s.EXPECT().UpdatePassword(res).Return(errors.New("update password error"))
+				s.EXPECT().UpdatePassword(gomock.Any(), res).Return(errors.New("update password error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"update password error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"update password error"}` + "\n",
 		},
 	}
 
@@ -432,9 +486,11 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			testCase.mockBehavior(auth, testCase.inputUserId, testCase.inputPasswords)
 
 			services := &service.Service{Authorization: auth}
-			handler := NewAuthHandler(services)
+			handler := NewAuthHandler(services, &events{})
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			req := httptest.NewRequest(http.MethodPost, "/auth/change/password",
 				strings.NewReader(testCase.inputBody))
@@ -443,17 +499,18 @@ func TestAuthHandler_ChangePassword(t *testing.T) {
 			ctx := e.NewContext(req, rec)
 			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
 
-			if assert.NoError(t, handler.ChangePassword(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.ChangePassword(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
 }
 
 func TestAuthHandler_ChangeUsername(t *testing.T) {
-	type mockBehavior func(s *mockService.MockAuthorization, userId int, user models.User)
+	type mockBehavior func(s *mockService.MockAuthorization, ch *mockService.MockChat, userId int, user models.User)
 
 	testTable := []struct {
 		name                 string
@@ -463,100 +520,107 @@ func TestAuthHandler_ChangeUsername(t *testing.T) {
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
+		expectedEvents       []websocket.Event
+		expectedRecipients   []int
 	}{
 		{
 			name:        "ok",
 			inputUserId: 4,
-			inputBody:   `{"username":"new username"}`,
+			inputBody:   `{"username":"new_username"}`,
 			inputUserName: models.User{
-				Username: "new username",
+				Username: "new_username",
 			},
-			mockBehavior: func(s *mockService.MockAuthorization, userId int, user models.User) {
+			mockBehavior: func(s *mockService.MockAuthorization, ch *mockService.MockChat, userId int, user models.User) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
 				check := models.User{}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
-				s.EXPECT().GetByName(user.Username).Return(check, errors.New("record not found"))
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				s.EXPECT().GetByName(gomock.Any(), user.Username).Return(check, errors.New("record not found"))
 				res.Username = user.Username
-				s.EXPECT().UpdateData(res).Return(nil)
+				s.EXPECT().UpdateData(gomock.Any(), res).Return(nil)
+				ch.EXPECT().GetPrivateChats(gomock.Any(), userId).Return([]models.Chat{{Id: 7, PartnerId: 5}, {Id: 9, PartnerId: 6}}, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"username changed"}` + "\n",
+			expectedEvents: []websocket.Event{
+				{Type: websocket.EventUserUpdated, Data: models.User{Id: 4, Username: "new_username"}},
+			},
+			expectedRecipients: []int{5, 6},
 		},
 		{
 			name:        "Incorrect request data",
 			inputUserId: 4,
 			inputBody:   `{"error"}`,
-			mockBehavior: func(s *mockService.MockAuthorization, userId int, user models.User) {
+			mockBehavior: func(s *mockService.MockAuthorization, ch *mockService.MockChat, userId int, user models.User) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:        "Incorrect user data",
 			inputUserId: 4,
-			inputBody:   `{"username":"new username"}`,
+			inputBody:   `{"username":"new_username"}`,
 			inputUserName: models.User{
-				Username: "new username",
+				Username: "new_username",
 			},
-			mockBehavior: func(s *mockService.MockAuthorization, userId int, user models.User) {
+			mockBehavior: func(s *mockService.MockAuthorization, ch *mockService.MockChat, userId int, user models.User) {
 				res := models.User{}
-				s.EXPECT().GetUserById(userId).Return(res, errors.New("incorrect user data"))
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, service.ErrUserNotFound)
 
 			},
 			expectedStatusCode:   404,
-			expectedResponseBody: `{"message":"incorrect user data"}` + "\n",
+			expectedResponseBody: `{"code":"user_not_found","message":"user not found"}` + "\n",
 		},
 		{
 			name:        "Username is used",
 			inputUserId: 4,
-			inputBody:   `{"username":"new username"}`,
+			inputBody:   `{"username":"new_username"}`,
 			inputUserName: models.User{
-				Username: "new username",
+				Username: "new_username",
 			},
-			mockBehavior: func(s *mockService.MockAuthorization, userId int, user models.User) {
+			mockBehavior: func(s *mockService.MockAuthorization, ch *mockService.MockChat, userId int, user models.User) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
 				check := models.User{
 					Id:       2,
-					Username: "new username",
+					Username: "new_username",
 				}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
-				s.EXPECT().GetByName(user.Username).Return(check, nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				s.EXPECT().GetByName(gomock.Any(), user.Username).Return(check, nil)
 			},
-			expectedStatusCode:   202,
-			expectedResponseBody: `{"message":"username is used"}` + "\n",
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"username_taken","message":"username is already used"}` + "\n",
 		},
 		{
 			name:        "Update username error",
 			inputUserId: 4,
-			inputBody:   `{"username":"new username"}`,
+			inputBody:   `{"username":"new_username"}`,
 			inputUserName: models.User{
-				Username: "new username",
+				Username: "new_username",
 			},
-			mockBehavior: func(s *mockService.MockAuthorization, userId int, user models.User) {
+			mockBehavior: func(s *mockService.MockAuthorization, ch *mockService.MockChat, userId int, user models.User) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
 				check := models.User{}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
-				s.EXPECT().GetByName(user.Username).Return(check, errors.New("record is not found"))
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				s.EXPECT().GetByName(gomock.Any(), user.Username).Return(check, errors.New("record is not found"))
 				res.Username = user.Username
-				s.EXPECT().UpdateData(res).Return(errors.New("update username error"))
+				s.EXPECT().UpdateData(gomock.Any(), res).Return(errors.New("update username error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"update username error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"update username error"}` + "\n",
 		},
 	}
 
@@ -567,12 +631,16 @@ func TestAuthHandler_ChangeUsername(t *testing.T) {
 			defer c.Finish()
 
 			auth := mockService.NewMockAuthorization(c)
-			testCase.mockBehavior(auth, testCase.inputUserId, testCase.inputUserName)
+			chat := mockService.NewMockChat(c)
+			testCase.mockBehavior(auth, chat, testCase.inputUserId, testCase.inputUserName)
 
-			services := &service.Service{Authorization: auth}
-			handler := NewAuthHandler(services)
+			services := &service.Service{Authorization: auth, Chat: chat}
+			published := &events{}
+			handler := NewAuthHandler(services, published)
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			req := httptest.NewRequest(http.MethodPost, "/auth/change/username",
 				strings.NewReader(testCase.inputBody))
@@ -581,45 +649,113 @@ func TestAuthHandler_ChangeUsername(t *testing.T) {
 			ctx := e.NewContext(req, rec)
 			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
 
-			if assert.NoError(t, handler.ChangeUsername(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.ChangeUsername(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedEvents, published.published)
+			assert.Equal(t, testCase.expectedRecipients, published.recipients)
 		})
 	}
 
 }
 
 func TestAuthHandler_ChangeIcon(t *testing.T) {
-	type mockBehavior func(s *mockService.MockAuthorization, userId int, filename string)
+	type mockBehavior func(s *mockService.MockAuthorization, u *mockService.MockUpload, ch *mockService.MockChat, userId int, filename string)
 
 	testTable := []struct {
 		name                 string
 		inputUserId          int
 		inputFilename        string
-		inputFile            multipart.File
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
+		expectedEvents       []websocket.Event
+		expectedRecipients   []int
 	}{
 		{
 			name:          "ok",
 			inputUserId:   4,
 			inputFilename: "filename",
-			mockBehavior: func(s *mockService.MockAuthorization, userId int, filename string) {
+			mockBehavior: func(s *mockService.MockAuthorization, u *mockService.MockUpload, ch *mockService.MockChat, userId int, filename string) {
 				res := models.User{
 					Id:       4,
-					Username: "test username",
+					Username: "test_username",
 					Icon:     "",
 					Password: "",
 				}
-				s.EXPECT().GetUserById(userId).Return(res, nil)
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return(filename, nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				res.Icon = filename
+				s.EXPECT().UpdateData(gomock.Any(), res).Return(nil)
+				u.EXPECT().Replace(gomock.Any(), "", filename).Return(nil)
+				ch.EXPECT().GetPrivateChats(gomock.Any(), userId).Return([]models.Chat{{Id: 7, PartnerId: 5}}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"icon changed"}` + "\n",
+			expectedEvents: []websocket.Event{
+				{Type: websocket.EventUserUpdated, Data: models.User{Id: 4, Username: "test_username", Icon: "filename"}},
+			},
+			expectedRecipients: []int{5},
+		},
+		{
+			name:          "Old icon released",
+			inputUserId:   4,
+			inputFilename: "filename",
+			mockBehavior: func(s *mockService.MockAuthorization, u *mockService.MockUpload, ch *mockService.MockChat, userId int, filename string) {
+				res := models.User{
+					Id:       4,
+					Username: "test_username",
+					Icon:     "old",
+				}
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return(filename, nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
 				res.Icon = filename
-				s.EXPECT().UpdateData(res).Return(nil)
+				s.EXPECT().UpdateData(gomock.Any(), res).Return(nil)
+				u.EXPECT().Replace(gomock.Any(), "old", filename).Return(nil)
+				ch.EXPECT().GetPrivateChats(gomock.Any(), userId).Return(nil, errors.New("get chats error"))
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"icon changed"}` + "\n",
 		},
+		{
+			name:          "Incorrect file type",
+			inputUserId:   4,
+			inputFilename: "filename",
+			mockBehavior: func(s *mockService.MockAuthorization, u *mockService.MockUpload, ch *mockService.MockChat, userId int, filename string) {
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return("", service.ErrUnsupportedType.Wrap(imaging.ErrUnsupportedFormat))
+			},
+			expectedStatusCode:   415,
+			expectedResponseBody: `{"code":"unsupported_type","message":"incorrect file type error"}` + "\n",
+		},
+		{
+			name:          "Image is too large",
+			inputUserId:   4,
+			inputFilename: "filename",
+			mockBehavior: func(s *mockService.MockAuthorization, u *mockService.MockUpload, ch *mockService.MockChat, userId int, filename string) {
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return("", service.ErrImageTooLarge.Wrap(imaging.ErrTooLarge))
+			},
+			expectedStatusCode:   413,
+			expectedResponseBody: `{"code":"image_too_large","message":"image is too large"}` + "\n",
+		},
+		{
+			name:          "Update icon error",
+			inputUserId:   4,
+			inputFilename: "filename",
+			mockBehavior: func(s *mockService.MockAuthorization, u *mockService.MockUpload, ch *mockService.MockChat, userId int, filename string) {
+				res := models.User{
+					Id:       4,
+					Username: "test_username",
+				}
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return(filename, nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(res, nil)
+				res.Icon = filename
+				s.EXPECT().UpdateData(gomock.Any(), res).Return(errors.New("update icon error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"update icon error"}` + "\n",
+		},
 	}
 
 	for _, testCase := range testTable {
@@ -629,24 +765,214 @@ func TestAuthHandler_ChangeIcon(t *testing.T) {
 			defer c.Finish()
 
 			auth := mockService.NewMockAuthorization(c)
-			testCase.mockBehavior(auth, testCase.inputUserId, testCase.inputFilename)
+			upload := mockService.NewMockUpload(c)
+			chat := mockService.NewMockChat(c)
+			testCase.mockBehavior(auth, upload, chat, testCase.inputUserId, testCase.inputFilename)
 
-			services := &service.Service{Authorization: auth}
-			handler := NewAuthHandler(services)
+			services := &service.Service{Authorization: auth, Upload: upload, Chat: chat}
+			published := &events{}
+			handler := NewAuthHandler(services, published)
+
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			body, contentType := imageForm(t)
+			req := httptest.NewRequest(http.MethodPut, "/auth/change/icon", body)
+			req.Header.Set(echo.HeaderContentType, contentType)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+
+			if err := handler.ChangeIcon(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedEvents, published.published)
+			assert.Equal(t, testCase.expectedRecipients, published.recipients)
+		})
+	}
+
+}
+
+// imageForm створює multipart-форму з невеликим PNG зображенням
+func imageForm(t *testing.T) (*bytes.Buffer, string) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("image", "icon.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(part, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, w.FormDataContentType()
+}
+
+func TestAuthHandler_GetSettings(t *testing.T) {
+	type mockBehavior func(s *mockService.MockSettings, userId int)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "ok",
+			inputUserId: 4,
+			mockBehavior: func(s *mockService.MockSettings, userId int) {
+				s.EXPECT().GetSettings(gomock.Any(), userId).Return(models.UserSettings{
+					UserId:         userId,
+					DirectMessages: "friends",
+					ChatInvites:    "everyone",
+					Searchable:     false,
+					LastSeen:       "nobody",
+				}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"direct_messages":"friends","chat_invites":"everyone","searchable":false,"last_seen":"nobody"}` + "\n",
+		},
+		{
+			name:        "Get settings error",
+			inputUserId: 4,
+			mockBehavior: func(s *mockService.MockSettings, userId int) {
+				s.EXPECT().GetSettings(gomock.Any(), userId).Return(models.UserSettings{}, errors.New("some error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"get settings error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			settings := mockService.NewMockSettings(c)
+			testCase.mockBehavior(settings, testCase.inputUserId)
+
+			services := &service.Service{Settings: settings}
+			handler := NewAuthHandler(services, &events{})
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
 
-			req := httptest.NewRequest(http.MethodPut, "/auth/change/username", testCase.inputFile)
-			req.Header.Set(echo.HeaderContentType, echo.MIMEMultipartForm)
+			req := httptest.NewRequest(http.MethodGet, "/auth/settings", nil)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
 
-			if assert.NoError(t, handler.ChangeIcon(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetSettings(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
+}
+
+func TestAuthHandler_UpdateSettings(t *testing.T) {
+	type mockBehavior func(s *mockService.MockSettings, settings models.UserSettings)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputBody            string
+		inputSettings        models.UserSettings
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "ok",
+			inputUserId: 4,
+			inputBody:   `{"direct_messages":"friends","chat_invites":"everyone","searchable":false,"last_seen":"nobody"}`,
+			inputSettings: models.UserSettings{
+				UserId:         4,
+				DirectMessages: "friends",
+				ChatInvites:    "everyone",
+				Searchable:     false,
+				LastSeen:       "nobody",
+			},
+			mockBehavior: func(s *mockService.MockSettings, settings models.UserSettings) {
+				s.EXPECT().UpdateSettings(gomock.Any(), settings).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"settings changed"}` + "\n",
+		},
+		{
+			name:                 "Incorrect request data",
+			inputUserId:          4,
+			inputBody:            `{"error"}`,
+			mockBehavior:         func(s *mockService.MockSettings, settings models.UserSettings) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
+		},
+		{
+			name:               "Invalid settings",
+			inputUserId:        4,
+			inputBody:          `{"direct_messages":"friends","chat_invites":"nobody","last_seen":"nobody"}`,
+			mockBehavior:       func(s *mockService.MockSettings, settings models.UserSettings) {},
+			expectedStatusCode: 400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[` +
+				`{"field":"chat_invites","rule":"oneof","message":"must be one of everyone, friends"},` +
+				`{"field":"searchable","rule":"required","message":"is required"}]}` + "\n",
+		},
+		{
+			name:        "Update settings error",
+			inputUserId: 4,
+			inputBody:   `{"direct_messages":"everyone","chat_invites":"everyone","searchable":true,"last_seen":"everyone"}`,
+			inputSettings: models.UserSettings{
+				UserId:         4,
+				DirectMessages: "everyone",
+				ChatInvites:    "everyone",
+				Searchable:     true,
+				LastSeen:       "everyone",
+			},
+			mockBehavior: func(s *mockService.MockSettings, settings models.UserSettings) {
+				s.EXPECT().UpdateSettings(gomock.Any(), settings).Return(errors.New("some error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"update settings error"}` + "\n",
+		},
+	}
 
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			settings := mockService.NewMockSettings(c)
+			testCase.mockBehavior(settings, testCase.inputSettings)
+
+			services := &service.Service{Settings: settings}
+			handler := NewAuthHandler(services, &events{})
+
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			req := httptest.NewRequest(http.MethodPut, "/auth/settings",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+
+			if err := handler.UpdateSettings(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
 }
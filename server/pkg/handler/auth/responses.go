@@ -12,6 +12,18 @@ type MessageResponse struct {
 	Message string `json:"message"`
 }
 
+type SignUpInput struct {
+	Username string `json:"username" validate:"required,min=3,max=32,username"`
+	Password string `json:"password" validate:"required,min=6,max=128"`
+}
+
 type UsernameInput struct {
-	Username string `json:"username"`
+	Username string `json:"username" validate:"required,min=3,max=32,username"`
+}
+
+type SettingsInput struct {
+	DirectMessages string `json:"direct_messages" validate:"required,oneof=everyone friends nobody"`
+	ChatInvites    string `json:"chat_invites" validate:"required,oneof=everyone friends"`
+	Searchable     *bool  `json:"searchable" validate:"required"`
+	LastSeen       string `json:"last_seen" validate:"required,oneof=everyone friends nobody"`
 }
@@ -2,74 +2,56 @@ package chat
 
 import (
 	"cmd/pkg/handler/middlewares"
-	"cmd/pkg/handler/responses"
+	"cmd/pkg/handler/websocket"
 	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
 	"fmt"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"os"
-	"strings"
 )
 
 type ChatHandler struct {
 	services *service.Service
+	events   websocket.Publisher
 }
 
-func NewChatHandler(services *service.Service) *ChatHandler {
-	return &ChatHandler{services: services}
+func NewChatHandler(services *service.Service, events websocket.Publisher) *ChatHandler {
+	return &ChatHandler{services: services, events: events}
 }
 
 // CreatePublicChat godoc
 // @Summary      Create a new public chat
-// @Description  Отримує ім'я чату. Створює новий чат. Повертає ID чату.
+// @Description  Отримує ім'я чату та необов'язкову видимість (open, request або hidden).
+// @Description  Створює новий чат, творець стає його адміністратором. Повертає ID чату.
 // @Security ApiKeyAuth
 // @Tags         chat
 // @Accept       json
 // @Produce      json
 // @Param        chat_name	body     NameInput   true  "Chat name"
 // @Success      200 	{object} IdResponse   "result is chat ID"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "name is empty"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "create message error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "add user to chat error"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "create chat error"
 // @Router       /chats/create [post]
 func (h *ChatHandler) CreatePublicChat(c echo.Context) error {
 
 	// Отримуємо дані з сайту (ім'я) та перевіряємо їх
-	var chat models.Chat
-	if err := c.Bind(&chat); err != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
-	}
-	if chat.Name == "" {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "name is empty")
-		return nil
+	var input NameInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
 	}
 
 	// Призначаємо публічний тип чату
-	chat.Types = repository.ChatPublic
+	chat := models.Chat{Name: input.Name, Types: repository.ChatPublic, Visibility: input.Visibility}
 
 	// Отримуємо ID активного користувача
 	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Створюємо чат
-	chatId, err := h.services.Chat.Create(chat)
+	// Створюємо чат та додаємо до нього активного користувача
+	chatId, err := h.services.Chat.Create(c.Request().Context(), chat, userId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "create message error")
-		return nil
-	}
-
-	// Додаємо активного користувача до новоствореного чату
-	newUser := models.ChatUsers{
-		ChatId: chatId,
-		UserId: userId,
-	}
-	_, errAdd := h.services.Chat.AddUser(newUser)
-	if errAdd != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "add user to chat error")
-		return nil
+		return service.Internal(err, "create chat error")
 	}
 
 	// Відгук сервера
@@ -85,27 +67,31 @@ func (h *ChatHandler) CreatePublicChat(c echo.Context) error {
 // GetChat godoc
 // @Summary      Get chat info
 // @Description  Отримує ID чату. Повертає дані чату.
+// @Description  Приватний чат називається ім'ям співрозмовника та має його зображення.
 // @Security ApiKeyAuth
 // @Tags         chat
 // @Accept       json
 // @Produce      json
 // @Param        id		path     int   true  "Chat ID"
 // @Success      200 	{object} ChatResponse   "result is chat data"
-// @Success 	 204 	{object} responses.ErrorResponse	 "get chat error"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get chat error"
 // @Router       /chats/{id} [get]
 func (h *ChatHandler) GetChat(c echo.Context) error {
 
+	// Отримання власного ID
+	userId := c.Get(middlewares.UserCtx).(int)
+
 	// Отримуємо ID чату
 	id, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Отримує дані чату за його ID
-	chat, err := h.services.Chat.Get(id)
+	// Отримує дані чату за його ID так, як їх бачить активний користувач
+	chat, _, err := h.services.Chat.GetForUser(c.Request().Context(), userId, id)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusNoContent, "get chat error")
-		return nil
+		return service.Internal(err, "get chat error")
 	}
 
 	//Відгук сервера
@@ -122,14 +108,15 @@ func (h *ChatHandler) GetChat(c echo.Context) error {
 // @Summary      Get chat (and if chat is private - user) info
 // @Description  Отримує ID чату.
 // @Description  Повертає дані чату та, якщо чат приватний, користувача.
+// @Description  Приватний чат називається ім'ям співрозмовника та має його зображення.
 // @Security ApiKeyAuth
 // @Accept       json
 // @Tags         chat
 // @Produce      json
 // @Param        id		path     int   true  "Chat ID"
 // @Success      200 	{object} ChatAndUserResponse   "result is chat data (and user data)"
-// @Success 	 204 	{object} responses.ErrorResponse	 "no chat error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "get users error"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "no chat error"
 // @Router       /chats/{id}/link [get]
 func (h *ChatHandler) GetById(c echo.Context) error {
 
@@ -142,33 +129,10 @@ func (h *ChatHandler) GetById(c echo.Context) error {
 		return errParamC
 	}
 
-	// Отримання даних чату
-	chat, err := h.services.Chat.Get(chatId)
+	// Отримання даних чату та співрозмовника (для приватного чату)
+	chat, user, err := h.services.Chat.GetForUser(c.Request().Context(), creatorId, chatId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusNoContent, "no chat error")
-		return nil
-	}
-
-	//Отримання даних користувача (для приватного чату)
-	var user models.User
-	if chat.Types == repository.ChatPrivate {
-		// Отримання користувачів приватного чату
-		users, err := h.services.Chat.GetUsers(chatId)
-		if err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "get users error")
-			return nil
-		}
-
-		// Фільтрація користувачів
-		if len(users) == 1 {
-			user = users[0]
-		} else {
-			for _, u := range users {
-				if u.Id != creatorId {
-					user = u
-				}
-			}
-		}
+		return service.Internal(err, "no chat error")
 	}
 
 	// Відгук чату
@@ -202,10 +166,9 @@ func (h *ChatHandler) GetUsers(c echo.Context) error {
 	}
 
 	// Отримуємо усіх користувачів чату
-	users, err := h.services.Chat.GetUsers(chatId)
+	users, err := h.services.Chat.GetUsers(c.Request().Context(), chatId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get users error")
-		return nil
+		return service.Internal(err, "get users error")
 	}
 
 	// Відгук сервера
@@ -237,10 +200,9 @@ func (h *ChatHandler) GetUserPublicChats(c echo.Context) error {
 	}
 
 	// Отримує список публічних чатів, в яких присутній користувач
-	chats, err := h.services.Chat.GetPublicChats(userId)
+	chats, err := h.services.Chat.GetPublicChats(c.Request().Context(), userId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get chats error")
-		return nil
+		return service.Internal(err, "get chats error")
 	}
 
 	// Відгук сервера
@@ -256,7 +218,8 @@ func (h *ChatHandler) GetUserPublicChats(c echo.Context) error {
 // GetUserPrivateChats godoc
 // @Summary      Get user`s private chats
 // @Description  Отримує ID користувача.
-// @Description  Повертає список приватних чатів користувача.
+// @Description  Повертає список приватних чатів користувача зі співрозмовником.
+// @Description  Кожен чат називається ім'ям співрозмовника та має його зображення.
 // @Security ApiKeyAuth
 // @Accept       json
 // @Tags         chat
@@ -264,50 +227,25 @@ func (h *ChatHandler) GetUserPublicChats(c echo.Context) error {
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} ChatListResponse  "result is list of chats"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "get chats error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "get users error"
 // @Router       /chats/users/{id}/private [get]
 func (h *ChatHandler) GetUserPrivateChats(c echo.Context) error {
 
-	// Отримання власного ID
-	creatorId := c.Get(middlewares.UserCtx).(int)
-
 	// Отримуємо ID користувача
 	userId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Отримуємо список приватних чатів
-	chats, err := h.services.Chat.GetPrivateChats(userId)
+	// Отримуємо список приватних чатів зі співрозмовником, названих його
+	// ім'ям. Особисті чати та чати, які залишив співрозмовник, не виводяться
+	chats, err := h.services.Chat.GetPrivateChats(c.Request().Context(), userId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get chats error")
-		return nil
-	}
-
-	// Перевіряємо кількість користувачів у чаті
-	// якщо їх не двоє, то не виводимо
-	var result []models.Chat
-
-	for _, chat := range chats {
-		users, err := h.services.Chat.GetUsers(chat.Id)
-		if err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "get users error")
-			return nil
-		}
-		if len(users) == 2 {
-			for _, u := range users {
-				if u.Id != creatorId {
-					chat.Name = u.Username
-					chat.Icon = u.Icon
-					result = append(result, chat)
-				}
-			}
-		}
+		return service.Internal(err, "get chats error")
 	}
 
 	// Відгук сервера
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
-		"list": result,
+		"list": chats,
 	})
 	if errRes != nil {
 		return errRes
@@ -318,8 +256,11 @@ func (h *ChatHandler) GetUserPrivateChats(c echo.Context) error {
 // AddUserToChat godoc
 // @Summary      Add user to chat
 // @Description  Отримує ID чату та користувача.
-// @Description  Додає користувача до чату.
+// @Description  Учасник публічного чату додає до нього іншого користувача; до чату з заявками
+// @Description  чи прихованого чату - лише адміністратор. До приватного чату додати не можна.
 // @Description  Повертає ID зв'язку між чатами та користувачами.
+// @Description  Власний ID активного користувача означає вступ до чату, як POST /chats/{id}/join:
+// @Description  тоді повертається запис вступу (join).
 // @Security ApiKeyAuth
 // @Tags         chat
 // @Accept       json
@@ -327,7 +268,13 @@ func (h *ChatHandler) GetUserPrivateChats(c echo.Context) error {
 // @Param        id		path     int   true  "Chat ID"
 // @Param        user_id	body     UserIdInput   true  "User ID"
 // @Success      200 	{object} IdResponse   "result is ID of chats and users relations"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not a member, not an admin or a private chat"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "blocked: user has blocked you"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "privacy_restricted: user's privacy settings do not allow this"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "not_found: chat or user not found"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "already_in_chat: user is already in chat"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "add user to chat error"
 // @Router       /chats/{id}/add [post]
 func (h *ChatHandler) AddUserToChat(c echo.Context) error {
@@ -338,19 +285,40 @@ func (h *ChatHandler) AddUserToChat(c echo.Context) error {
 		return errParamC
 	}
 
+	// Отримуємо ID активного користувача
+	userId, errId := middlewares.GetUserId(c)
+	if errId != nil {
+		return errId
+	}
+
 	// Отримуємо від сайту ID користувача
-	var list models.ChatUsers
-	if errReq := c.Bind(&list); errReq != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
+	var input UserIdInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
+	}
+	list := models.ChatUsers{ChatId: chatId, UserId: input.UserId}
+
+	// Сам користувач вступає до чату з тими ж перевірками видимості,
+	// блокувань та заявок, що й POST /chats/:id/join
+	if input.UserId == userId {
+		join, err := h.services.Invite.JoinChat(c.Request().Context(), userId, chatId)
+		if err != nil {
+			return service.Internal(err, "join chat error")
+		}
+		websocket.PublishJoin(c.Request().Context(), h.events, h.services.Invite.GetAdmins, join)
+		return c.JSON(http.StatusOK, map[string]interface{}{
+			"join": join,
+		})
 	}
-	list.ChatId = chatId
 
 	// Додаємо користувача до чату
-	id, err := h.services.Chat.AddUser(list)
+	// Повторне додавання повертає service.ErrAlreadyInChat, відсутній
+	// чат чи користувач - service.ErrNotFound, користувач, що заблокував
+	// активного, - service.ErrBlocked, а недостатні права -
+	// service.ErrForbidden
+	id, err := h.services.Chat.AddUser(c.Request().Context(), userId, list)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "add user to chat error")
-		return nil
+		return service.Internal(err, "add user to chat error")
 	}
 
 	// Відгук сайту
@@ -366,7 +334,8 @@ func (h *ChatHandler) AddUserToChat(c echo.Context) error {
 // DeleteUserFromChat godoc
 // @Summary      Delete user from chat
 // @Description  Отримує ID чату та користувача.
-// @Description  Видаляє користувача з чату.
+// @Description  Видаляє користувача з чату. Користувач може вийти з чату сам, а видалити
+// @Description  іншого учасника може лише адміністратор чату.
 // @Security ApiKeyAuth
 // @Tags         chat
 // @Accept       json
@@ -375,19 +344,17 @@ func (h *ChatHandler) AddUserToChat(c echo.Context) error {
 // @Param        user_id	body     UserIdInput   true  "User ID"
 // @Success      200 	{object} MessageResponse			"user deleted from chat"
 // @Success      202 	{object} MessageResponse			"delete last user from chat and chat"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: only chat admins can remove other members"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "delete user error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "get chat users error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "chat delete error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "messages delete error"
 // @Router       /chats/{id}/delete [put]
 func (h *ChatHandler) DeleteUserFromChat(c echo.Context) error {
 
 	// Отримуємо ID користувача від сайту
-	var list models.ChatUsers
-	if errReq := c.Bind(&list); errReq != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect request data")
-		return nil
+	var list UserIdInput
+	if err := middlewares.Bind(c, &list); err != nil {
+		return err
 	}
 
 	// Отримуємо ID чату
@@ -396,36 +363,17 @@ func (h *ChatHandler) DeleteUserFromChat(c echo.Context) error {
 		return errParamC
 	}
 
-	// Видаляємо користувача з чату
-	err := h.services.Chat.DeleteUser(list.UserId, chatId)
-	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "delete user error")
-		return nil
-	}
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо усіх користувачів чату
-	users, errUser := h.services.Chat.GetUsers(chatId)
-	if errUser != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get chat users error")
-		return nil
+	// Видаляємо користувача з чату. Якщо в чаті не залишилося
+	// користувачів, чат видаляється
+	deleted, err := h.services.Chat.DeleteUser(c.Request().Context(), userId, list.UserId, chatId)
+	if err != nil {
+		return service.Internal(err, "delete user error")
 	}
 
-	// Якщо в чаті не залишилося користувачів - видаляємо чат
-	if len(users) == 0 {
-
-		// Видаляємо чат
-		err := h.services.Chat.Delete(chatId)
-		if err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "chat delete error")
-			return nil
-		}
-
-		// Видаляємо усі повідомлення чату
-		errMsg := h.services.Chat.DeleteAllMessages(chatId)
-		if errMsg != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "messages delete error")
-			return nil
-		}
+	if deleted {
 		// Відгук сервера
 		errRes := c.JSON(http.StatusAccepted, map[string]interface{}{
 			"message": fmt.Sprintf("user with id %d deleted from chat with id %d", list.UserId, chatId),
@@ -448,16 +396,20 @@ func (h *ChatHandler) DeleteUserFromChat(c echo.Context) error {
 // ChangeChatIcon godoc
 // @Summary      Change chat icon
 // @Description  Отримує ID чату та файл зображення.
-// @Description  Оновлює зображення користувача.
+// @Description  Оновлює зображення публічного чату. Змінити зображення може лише адміністратор чату.
 // @Security ApiKeyAuth
 // @Tags         chat
 // @Accept       json
 // @Produce      json
 // @Param        id		path     int   true  "Chat ID"
 // @Success      200 	{object} MessageResponse			"icon changed"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "incorrect chat data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "corrupt_image: incorrect image error"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin or not a public chat"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
+// @Failure 	 413 	{object} responses.ErrorResponse	 "image_too_large: image is too large"
+// @Failure 	 415 	{object} responses.ErrorResponse	 "unsupported_type: incorrect file type error"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "update icon error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "delete icon error"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "update icon references error"
 // @Router       /chats/{id}/icon [put]
 func (h *ChatHandler) ChangeChatIcon(c echo.Context) error {
 	// Отримуємо ID чату
@@ -466,38 +418,37 @@ func (h *ChatHandler) ChangeChatIcon(c echo.Context) error {
 		return errParamC
 	}
 
-	fileName, err := middlewares.UploadImage(c)
-	if err != nil {
-		return err
+	// Отримуємо ID активного користувача
+	userId, errId := middlewares.GetUserId(c)
+	if errId != nil {
+		return errId
 	}
 
-	//Отримуємо дані чату
-	chat, errCh := h.services.Chat.Get(chatId)
+	//Отримуємо дані чату до завантаження зображення, щоб не зберігати
+	//файл для чату, який користувач не може змінити
+	chat, errCh := h.services.Chat.GetForAdmin(c.Request().Context(), userId, chatId)
 	if errCh != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect chat data")
-		return nil
+		return service.Internal(errCh, "incorrect chat data")
 	}
+
+	fileName, err := middlewares.UploadImage(c, h.services.Upload)
+	if err != nil {
+		return err
+	}
+
 	//Замінюємо дані у БД
 	var oldIcon = chat.Icon
-	chat.Icon = strings.TrimPrefix(fileName, "uploads/")
+	chat.Icon = fileName
 
-	errPut := h.services.Chat.Update(chat)
+	errPut := h.services.Chat.Update(c.Request().Context(), chat)
 	if errPut != nil {
-
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "update icon error")
-		return nil
+		return service.Internal(errPut, "update icon error")
 	}
 
-	//Видалення застарілих файлів
-	if len(oldIcon) != 0 {
-		if err := os.Remove(fmt.Sprintf("uploads/%s", oldIcon)); err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete icon error")
-			return nil
-		}
-		if err := os.Remove(fmt.Sprintf("uploads/resize-%s", oldIcon)); err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete icon error")
-			return nil
-		}
+	//Позначаємо нове зображення як використане, а старе - як непотрібне.
+	//Старі файли видалить прибиральник після пільгового періоду
+	if err := h.services.Upload.Replace(c.Request().Context(), oldIcon, fileName); err != nil {
+		return service.Internal(err, "update icon references error")
 	}
 
 	//Відгук сервера
@@ -512,17 +463,15 @@ func (h *ChatHandler) ChangeChatIcon(c echo.Context) error {
 
 // DeleteChat godoc
 // @Summary      Delete chat
-// @Description  Отримує ID чату. Видаляє чат.
+// @Description  Отримує ID чату. Видаляє чат. Видалити чат може лише його адміністратор.
 // @Security ApiKeyAuth
 // @Tags         chat
 // @Accept       json
 // @Produce      json
 // @Param        id		path     int   true  "Chat ID"
 // @Success      200 	{object} MessageResponse			"chat  deleted"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "get chat users error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "delete user from chat error"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: only chat admins can do this"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "chat delete error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "messages delete error"
 // @Router       /chats/{id} [delete]
 func (h *ChatHandler) DeleteChat(c echo.Context) error {
 
@@ -532,33 +481,13 @@ func (h *ChatHandler) DeleteChat(c echo.Context) error {
 		return errParam
 	}
 
-	// Отримуємо усіх користувачів чату
-	users, errUser := h.services.Chat.GetUsers(chatId)
-	if errUser != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get chat users error")
-		return nil
-	}
-	// Видаляємо усіх користувачів з чату
-	for _, user := range users {
-		errDelUser := h.services.Chat.DeleteUser(user.Id, chatId)
-		if errDelUser != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete user from chat error")
-			return nil
-		}
-	}
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Видаляємо чат
-	err := h.services.Chat.Delete(chatId)
+	// Видаляємо чат разом з учасниками, повідомленнями та зображенням
+	err := h.services.Chat.Delete(c.Request().Context(), userId, chatId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "chat delete error")
-		return nil
-	}
-
-	// Видаляємо усі повідомлення чату
-	errMsg := h.services.Chat.DeleteAllMessages(chatId)
-	if errMsg != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "messages delete error")
-		return nil
+		return service.Internal(err, "chat delete error")
 	}
 
 	// Відгук сервера
@@ -584,11 +513,9 @@ func (h *ChatHandler) DeleteChat(c echo.Context) error {
 // @Produce      json
 // @Param        userId		path     int   true  "User ID"
 // @Success      200 	{object} ChatIdResponse			"return chat ID"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "wrong users"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "wrong user"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "blocked: user has blocked you"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "privacy_restricted: user's privacy settings do not allow this"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "create chat error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "add active user to chat error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "add second user to chat error"
 // @Router       /chats/{userId}/private [get]
 func (h *ChatHandler) PrivateChat(c echo.Context) error {
 
@@ -601,55 +528,11 @@ func (h *ChatHandler) PrivateChat(c echo.Context) error {
 		return errParamC
 	}
 
-	// Отримуємо ID чату або 0
-	code, errP := h.services.Chat.GetPrivates(creatorId, userId)
-	if errP != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "wrong users")
-		return nil
-	}
-
-	// Якщо не існує чату, створюємо новий
-	if code == 0 {
-		// Отримуємо дані переданого користувача
-		user, errUser := h.services.Chat.GetUserById(userId)
-		if errUser != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "wrong user")
-			return nil
-		}
-
-		// Створюємо новий чат
-		chat := models.Chat{
-			Name:  user.Username,
-			Types: repository.ChatPrivate,
-		}
-		chatId, err := h.services.Chat.Create(chat)
-		if err != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "create chat error")
-			return nil
-		}
-		code = chatId
-
-		// Додаємо активного користувача до чату
-		_, errAdd := h.services.Chat.AddUser(models.ChatUsers{
-			ChatId: chatId,
-			UserId: creatorId,
-		})
-		if errAdd != nil {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "add active user to chat error")
-			return nil
-		}
-
-		// Додаємо отриманого користувача до чату (якщо це не особистий чат)
-		if creatorId != userId {
-			_, errNewAdd := h.services.Chat.AddUser(models.ChatUsers{
-				ChatId: chatId,
-				UserId: userId,
-			})
-			if errNewAdd != nil {
-				responses.NewErrorResponse(c, http.StatusInternalServerError, "add second user to chat error")
-				return nil
-			}
-		}
+	// Отримуємо ID чату, створюючи його за необхідністю. Користувач, що
+	// заблокував активного, повертає service.ErrBlocked
+	code, err := h.services.Chat.PrivateChat(c.Request().Context(), creatorId, userId)
+	if err != nil {
+		return service.Internal(err, "create chat error")
 	}
 
 	// Відгук чату
@@ -682,10 +565,9 @@ func (h *ChatHandler) SearchChat(c echo.Context) error {
 		return nil
 	}
 	// Отримуємо список чатів
-	chats, err := h.services.Chat.SearchChat(name)
+	chats, err := h.services.Chat.SearchChat(c.Request().Context(), name)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "found chats error")
-		return nil
+		return service.Internal(err, "found chats error")
 	}
 	// Відгук сервера
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
@@ -696,3 +578,94 @@ func (h *ChatHandler) SearchChat(c echo.Context) error {
 	}
 	return nil
 }
+
+// ChangeVisibility godoc
+// @Summary      Change chat visibility
+// @Description  Адміністратор змінює видимість публічного чату: open - будь-хто вступає
+// @Description  сам, request - вступ після схвалення адміністратора, hidden - чат не
+// @Description  знаходить пошук, вступ лише за посиланням-запрошенням.
+// @Security ApiKeyAuth
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Param        visibility	body     VisibilityInput   true  "Chat visibility"
+// @Success      200 	{object} MessageResponse   "visibility changed"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin or not a public chat"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "change visibility error"
+// @Router       /chats/{id}/visibility [put]
+func (h *ChatHandler) ChangeVisibility(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Отримуємо нову видимість
+	var input VisibilityInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
+	}
+
+	// Змінюємо видимість
+	if err := h.services.Chat.SetVisibility(c.Request().Context(), userId, chatId, input.Visibility); err != nil {
+		return service.Internal(err, "change visibility error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "visibility changed",
+	})
+}
+
+// UpdateChat godoc
+// @Summary      Update chat details
+// @Description  Отримує ID чату, нову назву, опис та тему.
+// @Description  Змінює дані публічного чату, якщо активний користувач - його адміністратор. Повертає оновлені дані чату.
+// @Security ApiKeyAuth
+// @Tags         chat
+// @Accept       json
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Param        chat	body     ChatInfoInput   true  "Chat details"
+// @Success      200 	{object} ChatResponse   "result is updated chat data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin or not a public chat"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "update chat error"
+// @Router       /chats/{id} [put]
+func (h *ChatHandler) UpdateChat(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Отримуємо нові дані чату
+	var input ChatInfoInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
+	}
+
+	// Змінюємо дані чату
+	info := models.Chat{Id: chatId, Name: input.Name, Description: input.Description, Topic: input.Topic}
+	chat, err := h.services.Chat.UpdateInfo(c.Request().Context(), userId, info)
+	if err != nil {
+		return service.Internal(err, "update chat error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"chat": chat,
+	})
+}
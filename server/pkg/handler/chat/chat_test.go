@@ -1,21 +1,50 @@
 package chat
 
 import (
+	"bytes"
 	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/responses"
+	"cmd/pkg/handler/websocket"
+	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
 	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/validation"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
+// events запам'ятовує надіслані події: події кімнат та події окремим
+// користувачам за їх ID
+type events struct {
+	published []websocket.Event
+	notified  map[int][]websocket.Event
+}
+
+func (e *events) Publish(userId int, event websocket.Event) {
+	e.published = append(e.published, event)
+}
+
+func (e *events) Notify(userId int, recipients []int, event websocket.Event) {
+	if e.notified == nil {
+		e.notified = map[int][]websocket.Event{}
+	}
+	for _, id := range recipients {
+		e.notified[id] = append(e.notified[id], event)
+	}
+}
+
 func TestChatHandler_CreatePublicChat(t *testing.T) {
 	type mockBehavior func(s *mockService.MockChat, userId int, chat models.Chat)
 
@@ -37,13 +66,7 @@ func TestChatHandler_CreatePublicChat(t *testing.T) {
 				Types: "public",
 			},
 			mockBehavior: func(s *mockService.MockChat, userId int, chat models.Chat) {
-				res := 5
-				s.EXPECT().Create(chat).Return(res, nil)
-				addUser := models.ChatUsers{
-					ChatId: res,
-					UserId: userId,
-				}
-				s.EXPECT().AddUser(addUser).Return(3, nil)
+				s.EXPECT().Create(gomock.Any(), chat, userId).Return(5, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":5}` + "\n",
@@ -55,7 +78,7 @@ func TestChatHandler_CreatePublicChat(t *testing.T) {
 			mockBehavior: func(s *mockService.MockChat, userId int, chat models.Chat) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:        "No name",
@@ -64,24 +87,19 @@ func TestChatHandler_CreatePublicChat(t *testing.T) {
 			mockBehavior: func(s *mockService.MockChat, userId int, chat models.Chat) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"name is empty"}` + "\n",
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"name","rule":"required","message":"is required"}]}` + "\n",
 		},
 		{
-			name:        "Create message error",
+			name:        "Name too long",
 			inputUserId: 4,
-			inputBody:   `{"name":"test"}`,
-			inputChat: models.Chat{
-				Name:  "test",
-				Types: "public",
-			},
+			inputBody:   `{"name":"` + strings.Repeat("n", 51) + `"}`,
 			mockBehavior: func(s *mockService.MockChat, userId int, chat models.Chat) {
-				s.EXPECT().Create(chat).Return(0, errors.New("create message error"))
 			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"create message error"}` + "\n",
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"name","rule":"max","message":"must be at most 50 characters"}]}` + "\n",
 		},
 		{
-			name:        "Add user to chat error",
+			name:        "Create chat error",
 			inputUserId: 4,
 			inputBody:   `{"name":"test"}`,
 			inputChat: models.Chat{
@@ -89,16 +107,10 @@ func TestChatHandler_CreatePublicChat(t *testing.T) {
 				Types: "public",
 			},
 			mockBehavior: func(s *mockService.MockChat, userId int, chat models.Chat) {
-				res := 5
-				s.EXPECT().Create(chat).Return(res, nil)
-				addUser := models.ChatUsers{
-					ChatId: res,
-					UserId: userId,
-				}
-				s.EXPECT().AddUser(addUser).Return(0, errors.New("add user to chat error"))
+				s.EXPECT().Create(gomock.Any(), chat, userId).Return(0, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"add user to chat error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"create chat error"}` + "\n",
 		},
 	}
 
@@ -114,10 +126,12 @@ func TestChatHandler_CreatePublicChat(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputChat)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodPost, "/api/chats/create",
@@ -128,20 +142,22 @@ func TestChatHandler_CreatePublicChat(t *testing.T) {
 			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.CreatePublicChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.CreatePublicChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
 }
 
 func TestChatHandler_GetChat(t *testing.T) {
-	type mockBehavior func(s *mockService.MockChat, chatId int)
+	type mockBehavior func(s *mockService.MockChat, userId, chatId int)
 
 	testTable := []struct {
 		name                 string
+		inputUserId          int
 		inputChatId          int
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
@@ -149,28 +165,41 @@ func TestChatHandler_GetChat(t *testing.T) {
 	}{
 		{
 			name:        "ok",
+			inputUserId: 2,
 			inputChatId: 4,
-			mockBehavior: func(s *mockService.MockChat, chatId int) {
+			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
 				res := models.Chat{
 					Id:    4,
 					Name:  "name",
 					Types: "public",
 					Icon:  "",
 				}
-				s.EXPECT().Get(chatId).Return(res, nil)
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(res, models.User{}, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"chat":{"id":4,"name":"name","types":"public","icon":""}}` + "\n",
 		},
+		{
+			name:        "Private chat is named after the partner",
+			inputUserId: 2,
+			inputChatId: 4,
+			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
+				partner := models.User{Id: 3, Username: "partner", Icon: "partner.png"}
+				res := models.Chat{Id: 4, Name: partner.Username, Types: "private", Icon: partner.Icon}
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(res, partner, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"chat":{"id":4,"name":"partner","types":"private","icon":"partner.png"}}` + "\n",
+		},
 		{
 			name:        "Get chat error",
+			inputUserId: 2,
 			inputChatId: 4,
-			mockBehavior: func(s *mockService.MockChat, chatId int) {
-				res := models.Chat{}
-				s.EXPECT().Get(chatId).Return(res, errors.New("get chat error"))
+			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(models.Chat{}, models.User{}, service.ErrChatNotFound)
 			},
-			expectedStatusCode:   204,
-			expectedResponseBody: `{"message":"get chat error"}` + "\n",
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
 		},
 	}
 
@@ -183,28 +212,32 @@ func TestChatHandler_GetChat(t *testing.T) {
 			defer c.Finish()
 
 			chat := mockService.NewMockChat(c)
-			testCase.mockBehavior(chat, testCase.inputChatId)
+			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputChatId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:id", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
 			ctx.SetPath("/api/chats/:id")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -226,50 +259,12 @@ func TestChatHandler_GetById(t *testing.T) {
 			inputUserId: 4,
 			inputChatId: 6,
 			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
-				res := models.Chat{
-					Id:    6,
-					Name:  "name",
-					Types: "private",
-					Icon:  "",
-				}
-				s.EXPECT().Get(chatId).Return(res, nil)
-				users := []models.User{
-					{
-						Id:       4,
-						Username: "first",
-					},
-					{
-						Id:       6,
-						Username: "second",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-			},
-			expectedStatusCode:   200,
-			expectedResponseBody: `{"chat":{"id":6,"name":"name","types":"private","icon":""},"user":{"id":6,"username":"second","password":"","icon":""}}` + "\n",
-		},
-		{
-			name:        "Ok for personal chats",
-			inputUserId: 4,
-			inputChatId: 6,
-			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
-				res := models.Chat{
-					Id:    6,
-					Name:  "name",
-					Types: "private",
-					Icon:  "",
-				}
-				s.EXPECT().Get(chatId).Return(res, nil)
-				users := []models.User{
-					{
-						Id:       6,
-						Username: "second",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
+				partner := models.User{Id: 6, Username: "second"}
+				res := models.Chat{Id: 6, Name: partner.Username, Types: "private"}
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(res, partner, nil)
 			},
 			expectedStatusCode:   200,
-			expectedResponseBody: `{"chat":{"id":6,"name":"name","types":"private","icon":""},"user":{"id":6,"username":"second","password":"","icon":""}}` + "\n",
+			expectedResponseBody: `{"chat":{"id":6,"name":"second","types":"private","icon":""},"user":{"id":6,"username":"second","password":"","icon":""}}` + "\n",
 		},
 		{
 			name:        "Ok for public chats",
@@ -282,39 +277,30 @@ func TestChatHandler_GetById(t *testing.T) {
 					Types: "public",
 					Icon:  "",
 				}
-				s.EXPECT().Get(chatId).Return(res, nil)
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(res, models.User{}, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"chat":{"id":6,"name":"name","types":"public","icon":""},"user":{"id":0,"username":"","password":"","icon":""}}` + "\n",
 		},
 		{
-			name:        "Get chat info error",
+			name:        "Chat not found",
 			inputUserId: 4,
 			inputChatId: 6,
 			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
-				res := models.Chat{}
-				s.EXPECT().Get(chatId).Return(res, errors.New("no chat error"))
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(models.Chat{}, models.User{}, service.ErrChatNotFound)
 			},
-			expectedStatusCode:   204,
-			expectedResponseBody: `{"message":"no chat error"}` + "\n",
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
 		},
 		{
-			name:        "Get users info error",
+			name:        "Get chat info error",
 			inputUserId: 4,
 			inputChatId: 6,
 			mockBehavior: func(s *mockService.MockChat, userId, chatId int) {
-				res := models.Chat{
-					Id:    6,
-					Name:  "name",
-					Types: "private",
-					Icon:  "",
-				}
-				s.EXPECT().Get(chatId).Return(res, nil)
-				users := []models.User{{}}
-				s.EXPECT().GetUsers(chatId).Return(users, errors.New("get users error"))
+				s.EXPECT().GetForUser(gomock.Any(), userId, chatId).Return(models.Chat{}, models.User{}, errors.New("no chat error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get users error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"no chat error"}` + "\n",
 		},
 	}
 
@@ -330,10 +316,12 @@ func TestChatHandler_GetById(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputChatId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:id/link", nil)
@@ -346,10 +334,11 @@ func TestChatHandler_GetById(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetById(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetById(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -379,7 +368,7 @@ func TestChatHandler_GetUsers(t *testing.T) {
 						Username: "second",
 					},
 				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
+				s.EXPECT().GetUsers(gomock.Any(), chatId).Return(users, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `[{"id":4,"username":"first","password":"","icon":""},{"id":6,"username":"second","password":"","icon":""}]` + "\n",
@@ -389,10 +378,10 @@ func TestChatHandler_GetUsers(t *testing.T) {
 			inputChatId: 6,
 			mockBehavior: func(s *mockService.MockChat, chatId int) {
 				users := []models.User{{}}
-				s.EXPECT().GetUsers(chatId).Return(users, errors.New("some error"))
+				s.EXPECT().GetUsers(gomock.Any(), chatId).Return(users, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get users error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"get users error"}` + "\n",
 		},
 	}
 
@@ -408,10 +397,12 @@ func TestChatHandler_GetUsers(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputChatId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:id/users", nil)
@@ -423,10 +414,11 @@ func TestChatHandler_GetUsers(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetUsers(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetUsers(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -459,7 +451,7 @@ func TestChatHandler_GetUserPublicChats(t *testing.T) {
 						Types: "public",
 					},
 				}
-				s.EXPECT().GetPublicChats(userId).Return(chats, nil)
+				s.EXPECT().GetPublicChats(gomock.Any(), userId).Return(chats, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"list":[{"id":4,"name":"first","types":"public","icon":"some image name"},{"id":6,"name":"second","types":"public","icon":""}]}` + "\n",
@@ -469,10 +461,10 @@ func TestChatHandler_GetUserPublicChats(t *testing.T) {
 			inputUserId: 6,
 			mockBehavior: func(s *mockService.MockChat, userId int) {
 				chats := []models.Chat{{}}
-				s.EXPECT().GetPublicChats(userId).Return(chats, errors.New("some error"))
+				s.EXPECT().GetPublicChats(gomock.Any(), userId).Return(chats, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get chats error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"get chats error"}` + "\n",
 		},
 	}
 
@@ -488,10 +480,12 @@ func TestChatHandler_GetUserPublicChats(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputUserId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:id/public", nil)
@@ -503,10 +497,11 @@ func TestChatHandler_GetUserPublicChats(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputUserId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetUserPublicChats(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetUserPublicChats(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -535,35 +530,8 @@ func TestChatHandler_GetUserPrivateChats(t *testing.T) {
 						Types: "private",
 						Icon:  "some image name",
 					},
-					{
-						Id:    6,
-						Name:  "second",
-						Types: "private",
-					},
-				}
-				s.EXPECT().GetPrivateChats(userId).Return(chats, nil)
-				users := [][]models.User{
-					{
-						{
-							Id:       3,
-							Username: "first",
-							Icon:     "some image name",
-						},
-						{
-							Id:       6,
-							Username: "second",
-						},
-					},
-					{
-						{
-							Id:       3,
-							Username: "first",
-						},
-					},
-				}
-				for i, v := range chats {
-					s.EXPECT().GetUsers(v.Id).Return(users[i], nil)
 				}
+				s.EXPECT().GetPrivateChats(gomock.Any(), userId).Return(chats, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"list":[{"id":3,"name":"first","types":"private","icon":"some image name"}]}` + "\n",
@@ -574,58 +542,10 @@ func TestChatHandler_GetUserPrivateChats(t *testing.T) {
 			inputUserId:     6,
 			mockBehavior: func(s *mockService.MockChat, userId, personalId int) {
 				chats := []models.Chat{{}}
-				s.EXPECT().GetPrivateChats(userId).Return(chats, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get chats error"}` + "\n",
-		},
-		{
-			name:            "Get users error",
-			inputPersonalId: 3,
-			inputUserId:     6,
-			mockBehavior: func(s *mockService.MockChat, userId, personalId int) {
-				chats := []models.Chat{
-					{
-						Id:    3,
-						Name:  "first",
-						Types: "private",
-						Icon:  "some image name",
-					},
-					{
-						Id:    6,
-						Name:  "second",
-						Types: "private",
-					},
-				}
-				s.EXPECT().GetPrivateChats(userId).Return(chats, nil)
-				users := [][]models.User{
-					{
-						{
-							Id:       3,
-							Username: "first",
-						},
-						{
-							Id:       6,
-							Username: "second",
-						},
-					},
-					{
-						{
-							Id:       0,
-							Username: "",
-						},
-					},
-				}
-				errors := []interface{}{
-					nil,
-					errors.New("some error"),
-				}
-				for i, v := range chats {
-					s.EXPECT().GetUsers(v.Id).Return(users[i], errors[i])
-				}
+				s.EXPECT().GetPrivateChats(gomock.Any(), userId).Return(chats, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get users error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"get chats error"}` + "\n",
 		},
 	}
 
@@ -641,10 +561,12 @@ func TestChatHandler_GetUserPrivateChats(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputPersonalId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:id/private", nil)
@@ -657,10 +579,11 @@ func TestChatHandler_GetUserPrivateChats(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputUserId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetUserPrivateChats(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetUserPrivateChats(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -688,11 +611,67 @@ func TestChatHandler_AddUserToChat(t *testing.T) {
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
 				res := 5
 				list.ChatId = chatId
-				s.EXPECT().AddUser(list).Return(res, nil)
+				s.EXPECT().AddUser(gomock.Any(), 1, list).Return(res, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":5}` + "\n",
 		},
+		{
+			name:        "User is already in chat",
+			inputChatId: 4,
+			inputBody:   `{"user_id":8}`,
+			inputChatUsers: models.ChatUsers{
+				UserId: 8,
+			},
+			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
+				list.ChatId = chatId
+				s.EXPECT().AddUser(gomock.Any(), 1, list).Return(0, service.ErrAlreadyInChat)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"already_in_chat","message":"user is already in chat"}` + "\n",
+		},
+		{
+			name:        "Chat or user not found",
+			inputChatId: 4,
+			inputBody:   `{"user_id":8}`,
+			inputChatUsers: models.ChatUsers{
+				UserId: 8,
+			},
+			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
+				list.ChatId = chatId
+				s.EXPECT().AddUser(gomock.Any(), 1, list).Return(0, service.ErrNotFound.WithMessage("chat or user not found"))
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"not_found","message":"chat or user not found"}` + "\n",
+		},
+		{
+			name:        "Blocked by user",
+			inputChatId: 4,
+			inputBody:   `{"user_id":8}`,
+			inputChatUsers: models.ChatUsers{
+				UserId: 8,
+			},
+			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
+				list.ChatId = chatId
+				s.EXPECT().AddUser(gomock.Any(), 1, list).Return(0, service.ErrBlocked)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"blocked","message":"user has blocked you"}` + "\n",
+		},
+		{
+			name:        "Not a member",
+			inputChatId: 4,
+			inputBody:   `{"user_id":8}`,
+			inputChatUsers: models.ChatUsers{
+				UserId: 8,
+			},
+			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
+				list.ChatId = chatId
+				s.EXPECT().AddUser(gomock.Any(), 1, list).Return(0, service.ErrForbidden.WithMessage("only chat members can do this"))
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"only chat members can do this"}` + "\n",
+		},
 		{
 			name:        "Incorrect request data",
 			inputChatId: 4,
@@ -703,7 +682,7 @@ func TestChatHandler_AddUserToChat(t *testing.T) {
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:        "Add user to chat error",
@@ -714,10 +693,10 @@ func TestChatHandler_AddUserToChat(t *testing.T) {
 			},
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
 				list.ChatId = chatId
-				s.EXPECT().AddUser(list).Return(0, errors.New("some error"))
+				s.EXPECT().AddUser(gomock.Any(), 1, list).Return(0, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"add user to chat error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"add user to chat error"}` + "\n",
 		},
 	}
 
@@ -733,10 +712,12 @@ func TestChatHandler_AddUserToChat(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputChatId, testCase.inputChatUsers)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodPost, "/api/chats/:id/add",
@@ -744,20 +725,107 @@ func TestChatHandler_AddUserToChat(t *testing.T) {
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, 1)
 			ctx.SetPath("/api/chats/:id/add")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.AddUserToChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.AddUserToChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
 }
 
+func TestChatHandler_AddUserToChat_Self(t *testing.T) {
+	type mockBehavior func(s *mockService.MockInvite, userId, chatId int)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedPublished    []websocket.Event
+		expectedNotified     map[int][]websocket.Event
+	}{
+		{
+			name: "Joined open chat",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+				s.EXPECT().JoinChat(gomock.Any(), userId, chatId).Return(models.ChatJoin{Id: 1, ChatId: chatId, UserId: userId, Status: repository.JoinJoined, CreatedAt: created}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"join":{"id":1,"chat_id":4,"user_id":1,"invite_id":null,"status":"joined","created_at":"2024-01-02T03:04:05Z"}}` + "\n",
+			expectedPublished: []websocket.Event{{Type: websocket.EventMemberJoined, ChatId: 4,
+				Data: models.ChatJoin{Id: 1, ChatId: 4, UserId: 1, Status: repository.JoinJoined, CreatedAt: created}}},
+		},
+		{
+			name: "Request to join",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+				s.EXPECT().JoinChat(gomock.Any(), userId, chatId).Return(models.ChatJoin{Id: 1, ChatId: chatId, UserId: userId, Status: repository.JoinPending, CreatedAt: created}, nil)
+				s.EXPECT().GetAdmins(gomock.Any(), chatId).Return([]int{7}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"join":{"id":1,"chat_id":4,"user_id":1,"invite_id":null,"status":"pending","created_at":"2024-01-02T03:04:05Z"}}` + "\n",
+			expectedNotified: map[int][]websocket.Event{7: {{Type: websocket.EventJoinRequested, ChatId: 4,
+				Data: models.ChatJoin{Id: 1, ChatId: 4, UserId: 1, Status: repository.JoinPending, CreatedAt: created}}}},
+		},
+		{
+			name: "Hidden or private chat",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+				s.EXPECT().JoinChat(gomock.Any(), userId, chatId).Return(models.ChatJoin{}, service.ErrChatNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invite := mockService.NewMockInvite(c)
+			testCase.mockBehavior(invite, 1, 4)
+
+			// Вступ самого користувача не доходить до ChatService.AddUser
+			services := &service.Service{Chat: mockService.NewMockChat(c), Invite: invite}
+			published := &events{}
+			handler := NewChatHandler(services, published)
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodPost, "/api/chats/:id/add",
+				strings.NewReader(`{"user_id":1}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, 1)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues("4")
+
+			//Перевірка результатів
+			if err := handler.AddUserToChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedPublished, published.published)
+			assert.Equal(t, testCase.expectedNotified, published.notified)
+		})
+	}
+}
+
 func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 	type mockBehavior func(s *mockService.MockChat, chatId int, list models.ChatUsers)
 
@@ -778,14 +846,7 @@ func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 				UserId: 8,
 			},
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
-				s.EXPECT().DeleteUser(list.UserId, chatId).Return(nil)
-				users := []models.User{
-					{
-						Id:       4,
-						Username: "user",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
+				s.EXPECT().DeleteUser(gomock.Any(), 1, list.UserId, chatId).Return(false, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"user with id 8 deleted from chat with id 4"}` + "\n",
@@ -798,11 +859,7 @@ func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 				UserId: 8,
 			},
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
-				s.EXPECT().DeleteUser(list.UserId, chatId).Return(nil)
-				var users []models.User
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				s.EXPECT().Delete(chatId).Return(nil)
-				s.EXPECT().DeleteAllMessages(chatId).Return(nil)
+				s.EXPECT().DeleteUser(gomock.Any(), 1, list.UserId, chatId).Return(true, nil)
 			},
 			expectedStatusCode:   202,
 			expectedResponseBody: `{"message":"user with id 8 deleted from chat with id 4"}` + "\n",
@@ -814,7 +871,7 @@ func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"incorrect request data"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_request","message":"incorrect request data"}` + "\n",
 		},
 		{
 			name:        "Delete user error",
@@ -824,58 +881,23 @@ func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 				UserId: 8,
 			},
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
-				s.EXPECT().DeleteUser(list.UserId, chatId).Return(errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete user error"}` + "\n",
-		},
-		{
-			name:        "Get chat users error",
-			inputChatId: 4,
-			inputBody:   `{"user_id":8}`,
-			inputChatUsers: models.ChatUsers{
-				UserId: 8,
-			},
-			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
-				s.EXPECT().DeleteUser(list.UserId, chatId).Return(nil)
-				var users []models.User
-				s.EXPECT().GetUsers(chatId).Return(users, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get chat users error"}` + "\n",
-		},
-		{
-			name:        "Chat delete error",
-			inputChatId: 4,
-			inputBody:   `{"user_id":8}`,
-			inputChatUsers: models.ChatUsers{
-				UserId: 8,
-			},
-			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
-				s.EXPECT().DeleteUser(list.UserId, chatId).Return(nil)
-				var users []models.User
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				s.EXPECT().Delete(chatId).Return(errors.New("some error"))
+				s.EXPECT().DeleteUser(gomock.Any(), 1, list.UserId, chatId).Return(false, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"chat delete error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"delete user error"}` + "\n",
 		},
 		{
-			name:        "Messages delete error",
+			name:        "Not an admin",
 			inputChatId: 4,
 			inputBody:   `{"user_id":8}`,
 			inputChatUsers: models.ChatUsers{
 				UserId: 8,
 			},
 			mockBehavior: func(s *mockService.MockChat, chatId int, list models.ChatUsers) {
-				s.EXPECT().DeleteUser(list.UserId, chatId).Return(nil)
-				var users []models.User
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				s.EXPECT().Delete(chatId).Return(nil)
-				s.EXPECT().DeleteAllMessages(chatId).Return(errors.New("some error"))
+				s.EXPECT().DeleteUser(gomock.Any(), 1, list.UserId, chatId).Return(false, service.ErrForbidden.WithMessage("only chat admins can do this"))
 			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"messages delete error"}` + "\n",
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"only chat admins can do this"}` + "\n",
 		},
 	}
 
@@ -891,10 +913,12 @@ func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputChatId, testCase.inputChatUsers)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodDelete, "/api/chats/:id/delete",
@@ -905,12 +929,14 @@ func TestChatHandler_DeleteUserFromChat(t *testing.T) {
 			ctx.SetPath("/api/chats/:id/delete")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+			ctx.Set(middlewares.UserCtx, 1)
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.DeleteUserFromChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.DeleteUserFromChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -930,120 +956,28 @@ func TestChatHandler_DeleteChat(t *testing.T) {
 			name:        "Ok",
 			inputChatId: 4,
 			mockBehavior: func(s *mockService.MockChat, chatId int) {
-				users := []models.User{
-					{
-						Id:       3,
-						Username: "first",
-					},
-					{
-						Id:       5,
-						Username: "second",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				errors := []interface{}{
-					nil,
-					nil,
-				}
-				for i, v := range users {
-					s.EXPECT().DeleteUser(v.Id, chatId).Return(errors[i])
-				}
-				s.EXPECT().Delete(chatId).Return(nil)
-				s.EXPECT().DeleteAllMessages(chatId).Return(nil)
+				s.EXPECT().Delete(gomock.Any(), 1, chatId).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"chat with id 4 deleted"}` + "\n",
 		},
 		{
-			name:        "Get chat users error",
+			name:        "Chat delete error",
 			inputChatId: 4,
 			mockBehavior: func(s *mockService.MockChat, chatId int) {
-				var users []models.User
-				s.EXPECT().GetUsers(chatId).Return(users, errors.New("some error"))
+				s.EXPECT().Delete(gomock.Any(), 1, chatId).Return(errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get chat users error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"chat delete error"}` + "\n",
 		},
 		{
-			name:        "Delete user from chat error",
+			name:        "Not an admin",
 			inputChatId: 4,
 			mockBehavior: func(s *mockService.MockChat, chatId int) {
-				users := []models.User{
-					{
-						Id:       3,
-						Username: "first",
-					},
-					{
-						Id:       5,
-						Username: "second",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				errors := []interface{}{
-					nil,
-					errors.New("some error"),
-				}
-				for i, v := range users {
-					s.EXPECT().DeleteUser(v.Id, chatId).Return(errors[i])
-				}
+				s.EXPECT().Delete(gomock.Any(), 1, chatId).Return(service.ErrForbidden.WithMessage("only chat admins can do this"))
 			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete user from chat error"}` + "\n",
-		},
-		{
-			name:        "Chat delete error",
-			inputChatId: 4,
-			mockBehavior: func(s *mockService.MockChat, chatId int) {
-				users := []models.User{
-					{
-						Id:       3,
-						Username: "first",
-					},
-					{
-						Id:       5,
-						Username: "second",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				error := []interface{}{
-					nil,
-					nil,
-				}
-				for i, v := range users {
-					s.EXPECT().DeleteUser(v.Id, chatId).Return(error[i])
-				}
-				s.EXPECT().Delete(chatId).Return(errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"chat delete error"}` + "\n",
-		},
-		{
-			name:        "Messages delete error",
-			inputChatId: 4,
-			mockBehavior: func(s *mockService.MockChat, chatId int) {
-				users := []models.User{
-					{
-						Id:       3,
-						Username: "first",
-					},
-					{
-						Id:       5,
-						Username: "second",
-					},
-				}
-				s.EXPECT().GetUsers(chatId).Return(users, nil)
-				error := []interface{}{
-					nil,
-					nil,
-				}
-				for i, v := range users {
-					s.EXPECT().DeleteUser(v.Id, chatId).Return(error[i])
-				}
-				s.EXPECT().Delete(chatId).Return(nil)
-				s.EXPECT().DeleteAllMessages(chatId).Return(errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"messages delete error"}` + "\n",
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"only chat admins can do this"}` + "\n",
 		},
 	}
 
@@ -1059,10 +993,12 @@ func TestChatHandler_DeleteChat(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputChatId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodDelete, "/api/chats/:id", nil)
@@ -1072,12 +1008,14 @@ func TestChatHandler_DeleteChat(t *testing.T) {
 			ctx.SetPath("/api/chats/:id")
 			ctx.SetParamNames("id")
 			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+			ctx.Set(middlewares.UserCtx, 1)
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.DeleteChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.DeleteChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -1099,169 +1037,20 @@ func TestChatHandler_PrivateChat(t *testing.T) {
 			inputUserId:       4,
 			inputActiveUserId: 1,
 			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 12
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
+				s.EXPECT().PrivateChat(gomock.Any(), activeUserId, userId).Return(12, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"chatId":12}` + "\n",
 		},
-		{
-			name:              "Ok and create new private chat",
-			inputUserId:       4,
-			inputActiveUserId: 1,
-			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
-				user := models.User{
-					Id:       4,
-					Username: "first",
-				}
-				s.EXPECT().GetUserById(userId).Return(user, nil)
-				chat := models.Chat{
-					Name:  user.Username,
-					Types: "private",
-				}
-				newChatId := 12
-				s.EXPECT().Create(chat).Return(12, nil)
-				s.EXPECT().AddUser(models.ChatUsers{
-					ChatId: newChatId,
-					UserId: activeUserId,
-				}).Return(3, nil)
-				s.EXPECT().AddUser(models.ChatUsers{
-					ChatId: newChatId,
-					UserId: userId,
-				}).Return(4, nil)
-
-			},
-			expectedStatusCode:   200,
-			expectedResponseBody: `{"chatId":12}` + "\n",
-		},
-		{
-			name:              "Ok and create new personal chat",
-			inputUserId:       1,
-			inputActiveUserId: 1,
-			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
-				user := models.User{
-					Id:       1,
-					Username: "first",
-				}
-				s.EXPECT().GetUserById(userId).Return(user, nil)
-				chat := models.Chat{
-					Name:  user.Username,
-					Types: "private",
-				}
-				newChatId := 12
-				s.EXPECT().Create(chat).Return(12, nil)
-				s.EXPECT().AddUser(models.ChatUsers{
-					ChatId: newChatId,
-					UserId: activeUserId,
-				}).Return(3, nil)
-			},
-			expectedStatusCode:   200,
-			expectedResponseBody: `{"chatId":12}` + "\n",
-		},
-		{
-			name:              "Wrong users",
-			inputUserId:       4,
-			inputActiveUserId: 1,
-			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"wrong users"}` + "\n",
-		},
-		{
-			name:              "Wrong user",
-			inputUserId:       4,
-			inputActiveUserId: 1,
-			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
-				user := models.User{}
-				s.EXPECT().GetUserById(userId).Return(user, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"wrong user"}` + "\n",
-		},
 		{
 			name:              "Create chat error",
 			inputUserId:       4,
 			inputActiveUserId: 1,
 			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
-				user := models.User{
-					Id:       4,
-					Username: "first",
-				}
-				s.EXPECT().GetUserById(userId).Return(user, nil)
-				chat := models.Chat{
-					Name:  user.Username,
-					Types: "private",
-				}
-				s.EXPECT().Create(chat).Return(0, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"create chat error"}` + "\n",
-		},
-		{
-			name:              "Add active user to chat error",
-			inputUserId:       4,
-			inputActiveUserId: 1,
-			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
-				user := models.User{
-					Id:       4,
-					Username: "first",
-				}
-				s.EXPECT().GetUserById(userId).Return(user, nil)
-				chat := models.Chat{
-					Name:  user.Username,
-					Types: "private",
-				}
-				newChatId := 12
-				s.EXPECT().Create(chat).Return(12, nil)
-				s.EXPECT().AddUser(models.ChatUsers{
-					ChatId: newChatId,
-					UserId: activeUserId,
-				}).Return(0, errors.New("some error"))
+				s.EXPECT().PrivateChat(gomock.Any(), activeUserId, userId).Return(0, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"add active user to chat error"}` + "\n",
-		},
-		{
-			name:              "Add user to chat error",
-			inputUserId:       4,
-			inputActiveUserId: 1,
-			mockBehavior: func(s *mockService.MockChat, userId, activeUserId int) {
-				code := 0
-				s.EXPECT().GetPrivates(activeUserId, userId).Return(code, nil)
-				user := models.User{
-					Id:       4,
-					Username: "first",
-				}
-				s.EXPECT().GetUserById(userId).Return(user, nil)
-				chat := models.Chat{
-					Name:  user.Username,
-					Types: "private",
-				}
-				newChatId := 12
-				s.EXPECT().Create(chat).Return(12, nil)
-				s.EXPECT().AddUser(models.ChatUsers{
-					ChatId: newChatId,
-					UserId: activeUserId,
-				}).Return(3, nil)
-				s.EXPECT().AddUser(models.ChatUsers{
-					ChatId: newChatId,
-					UserId: userId,
-				}).Return(0, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"add second user to chat error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"create chat error"}` + "\n",
 		},
 	}
 
@@ -1277,10 +1066,12 @@ func TestChatHandler_PrivateChat(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputActiveUserId)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:userId/private", nil)
@@ -1293,10 +1084,11 @@ func TestChatHandler_PrivateChat(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputUserId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.PrivateChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.PrivateChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -1330,7 +1122,7 @@ func TestChatHandler_SearchChat(t *testing.T) {
 						Icon:  "",
 					},
 				}
-				s.EXPECT().SearchChat(name).Return(chats, nil)
+				s.EXPECT().SearchChat(gomock.Any(), name).Return(chats, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"list":[{"id":4,"name":"first","types":"public","icon":""},{"id":6,"name":"stuff","types":"public","icon":""}]}` + "\n",
@@ -1340,10 +1132,10 @@ func TestChatHandler_SearchChat(t *testing.T) {
 			inputName: "f",
 			mockBehavior: func(s *mockService.MockChat, name string) {
 				var chats []models.Chat
-				s.EXPECT().SearchChat(name).Return(chats, errors.New("some error"))
+				s.EXPECT().SearchChat(gomock.Any(), name).Return(chats, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"found chats error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"found chats error"}` + "\n",
 		},
 	}
 
@@ -1359,10 +1151,12 @@ func TestChatHandler_SearchChat(t *testing.T) {
 			testCase.mockBehavior(chat, testCase.inputName)
 
 			services := &service.Service{Chat: chat}
-			handler := NewChatHandler(services)
+			handler := NewChatHandler(services, &events{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/search/:name", nil)
@@ -1374,11 +1168,327 @@ func TestChatHandler_SearchChat(t *testing.T) {
 			ctx.SetParamValues(testCase.inputName)
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.SearchChat(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.SearchChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+
+}
+
+func TestChatHandler_ChangeVisibility(t *testing.T) {
+	type mockBehavior func(s *mockService.MockChat, userId, chatId int, visibility string)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputChatId          int
+		inputBody            string
+		inputVisibility      string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:            "Ok",
+			inputUserId:     2,
+			inputChatId:     4,
+			inputBody:       `{"visibility":"hidden"}`,
+			inputVisibility: "hidden",
+			mockBehavior: func(s *mockService.MockChat, userId, chatId int, visibility string) {
+				s.EXPECT().SetVisibility(gomock.Any(), userId, chatId, visibility).Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"visibility changed"}` + "\n",
+		},
+		{
+			name:        "Unknown visibility",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"visibility":"secret"}`,
+			mockBehavior: func(s *mockService.MockChat, userId, chatId int, visibility string) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"visibility","rule":"oneof","message":"must be one of open, request, hidden"}]}` + "\n",
+		},
+		{
+			name:            "Not an admin",
+			inputUserId:     2,
+			inputChatId:     4,
+			inputBody:       `{"visibility":"open"}`,
+			inputVisibility: "open",
+			mockBehavior: func(s *mockService.MockChat, userId, chatId int, visibility string) {
+				s.EXPECT().SetVisibility(gomock.Any(), userId, chatId, visibility).Return(service.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"access denied"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chat := mockService.NewMockChat(c)
+			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputChatId, testCase.inputVisibility)
+
+			services := &service.Service{Chat: chat}
+			handler := NewChatHandler(services, &events{})
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodPut, "/api/chats/:id/visibility",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+
+			//Перевірка результатів
+			if err := handler.ChangeVisibility(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
+
+func TestChatHandler_UpdateChat(t *testing.T) {
+	type mockBehavior func(s *mockService.MockChat, userId int, info models.Chat)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputChatId          int
+		inputBody            string
+		inputInfo            models.Chat
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"name":"chat","description":"about","topic":"news"}`,
+			inputInfo:   models.Chat{Id: 4, Name: "chat", Description: "about", Topic: "news"},
+			mockBehavior: func(s *mockService.MockChat, userId int, info models.Chat) {
+				chat := info
+				chat.Types, chat.Visibility, chat.MemberCount = "public", "open", 3
+				s.EXPECT().UpdateInfo(gomock.Any(), userId, info).Return(chat, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"chat":{"id":4,"name":"chat","types":"public","icon":"","description":"about","topic":"news","visibility":"open","member_count":3}}` + "\n",
+		},
+		{
+			name:        "Empty name",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"topic":"news"}`,
+			mockBehavior: func(s *mockService.MockChat, userId int, info models.Chat) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"name","rule":"required","message":"is required"}]}` + "\n",
+		},
+		{
+			name:        "Topic too long",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"name":"chat","topic":"` + strings.Repeat("t", 101) + `"}`,
+			mockBehavior: func(s *mockService.MockChat, userId int, info models.Chat) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"topic","rule":"max","message":"must be at most 100 characters"}]}` + "\n",
+		},
+		{
+			name:        "Not an admin",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"name":"chat"}`,
+			inputInfo:   models.Chat{Id: 4, Name: "chat"},
+			mockBehavior: func(s *mockService.MockChat, userId int, info models.Chat) {
+				s.EXPECT().UpdateInfo(gomock.Any(), userId, info).Return(info, service.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"access denied"}` + "\n",
+		},
+		{
+			name:        "Chat not found",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"name":"chat"}`,
+			inputInfo:   models.Chat{Id: 4, Name: "chat"},
+			mockBehavior: func(s *mockService.MockChat, userId int, info models.Chat) {
+				s.EXPECT().UpdateInfo(gomock.Any(), userId, info).Return(info, service.ErrChatNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chat := mockService.NewMockChat(c)
+			testCase.mockBehavior(chat, testCase.inputUserId, testCase.inputInfo)
+
+			services := &service.Service{Chat: chat}
+			handler := NewChatHandler(services, &events{})
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodPut, "/api/chats/:id",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+
+			//Перевірка результатів
+			if err := handler.UpdateChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
+}
 
+// imageForm формує multipart-запит із PNG-зображенням у полі "image"
+func imageForm(t *testing.T) (*bytes.Buffer, string) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("image", "icon.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(part, img); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return &body, w.FormDataContentType()
+}
+
+func TestChatHandler_ChangeChatIcon(t *testing.T) {
+	type mockBehavior func(s *mockService.MockChat, u *mockService.MockUpload, userId, chatId int)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputChatId          int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Ok",
+			inputUserId: 2,
+			inputChatId: 4,
+			mockBehavior: func(s *mockService.MockChat, u *mockService.MockUpload, userId, chatId int) {
+				chat := models.Chat{Id: chatId, Name: "chat", Types: "public", Icon: "old.png"}
+				s.EXPECT().GetForAdmin(gomock.Any(), userId, chatId).Return(chat, nil)
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return("new.png", nil)
+				updated := chat
+				updated.Icon = "new.png"
+				s.EXPECT().Update(gomock.Any(), updated).Return(nil)
+				u.EXPECT().Replace(gomock.Any(), "old.png", "new.png").Return(nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"message":"icon changed"}` + "\n",
+		},
+		{
+			name:        "Not an admin",
+			inputUserId: 2,
+			inputChatId: 4,
+			mockBehavior: func(s *mockService.MockChat, u *mockService.MockUpload, userId, chatId int) {
+				s.EXPECT().GetForAdmin(gomock.Any(), userId, chatId).Return(models.Chat{}, service.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"access denied"}` + "\n",
+		},
+		{
+			name:        "Chat not found",
+			inputUserId: 2,
+			inputChatId: 4,
+			mockBehavior: func(s *mockService.MockChat, u *mockService.MockUpload, userId, chatId int) {
+				s.EXPECT().GetForAdmin(gomock.Any(), userId, chatId).Return(models.Chat{}, service.ErrChatNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+		{
+			name:        "Update error",
+			inputUserId: 2,
+			inputChatId: 4,
+			mockBehavior: func(s *mockService.MockChat, u *mockService.MockUpload, userId, chatId int) {
+				s.EXPECT().GetForAdmin(gomock.Any(), userId, chatId).Return(models.Chat{Id: chatId}, nil)
+				u.EXPECT().SaveImage(gomock.Any(), gomock.Any()).Return("new.png", nil)
+				s.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"update icon error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			chat := mockService.NewMockChat(c)
+			upload := mockService.NewMockUpload(c)
+			testCase.mockBehavior(chat, upload, testCase.inputUserId, testCase.inputChatId)
+
+			services := &service.Service{Chat: chat, Upload: upload}
+			handler := NewChatHandler(services, &events{})
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+
+			//Тестовий запит
+			body, contentType := imageForm(t)
+			req := httptest.NewRequest(http.MethodPut, "/api/chats/:id/icon", body)
+			req.Header.Set(echo.HeaderContentType, contentType)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+
+			//Перевірка результатів
+			if err := handler.ChangeChatIcon(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
 }
@@ -24,7 +24,20 @@ type ChatAndUserResponse struct {
 }
 
 type NameInput struct {
-	Name string `json:"name"`
+	Name string `json:"name" validate:"required,max=50"`
+	// Visibility - видимість нового чату, за замовчуванням open
+	Visibility string `json:"visibility" validate:"omitempty,oneof=open request hidden"`
+}
+
+type ChatInfoInput struct {
+	Name string `json:"name" validate:"required,max=50"`
+	// Description - опис чату, Topic - поточна тема обговорення
+	Description string `json:"description" validate:"max=500"`
+	Topic       string `json:"topic" validate:"max=100"`
+}
+
+type VisibilityInput struct {
+	Visibility string `json:"visibility" validate:"required,oneof=open request hidden"`
 }
 
 type ChatListResponse struct {
@@ -32,5 +45,5 @@ type ChatListResponse struct {
 }
 
 type UserIdInput struct {
-	UserId int `json:"user_id"`
+	UserId int `json:"user_id" validate:"required,gt=0"`
 }
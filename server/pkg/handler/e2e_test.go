@@ -0,0 +1,549 @@
+package handler_test
+
+import (
+	"bytes"
+	"cmd/pkg/config"
+	"cmd/pkg/handler"
+	"cmd/pkg/handler/websocket"
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/memory"
+	"cmd/pkg/repository/models"
+	"cmd/pkg/service"
+	"cmd/pkg/storage"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	gorilla "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// client - користувач, що звертається до тестового сервера
+type client struct {
+	t     *testing.T
+	url   string
+	token string
+	id    int
+}
+
+// newServer запускає API поверх сховища в пам'яті
+func newServer(t *testing.T) *httptest.Server {
+	return newServerWith(t, memory.NewRepository())
+}
+
+// newServerWith запускає сервер поверх repos, щоб тест міг підготувати
+// дані, які не задати через API
+func newServerWith(t *testing.T, repos *repository.Repository) *httptest.Server {
+	cnf := config.Default()
+	cnf.Auth.SignInKey, cnf.Auth.Salt = "e2e sign in key", "e2e salt"
+	cnf.Uploads.Sizes = []int{64}
+	require.NoError(t, cnf.Validate())
+
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	services := service.NewService(repos, store, cnf)
+	hub := websocket.NewHub(cnf.Websocket, services.Status)
+	go hub.Run()
+
+	server := httptest.NewServer(handler.NewHandler(services, hub, cnf.Server).InitRoutes())
+	t.Cleanup(server.Close)
+	return server
+}
+
+// do виконує запит та декодує відповідь в out, повертаючи код відповіді
+func (c *client) do(method, path string, body, out interface{}) int {
+	var reader bytes.Buffer
+	if body != nil {
+		require.NoError(c.t, json.NewEncoder(&reader).Encode(body))
+	}
+	req, err := http.NewRequest(method, c.url+"/api"+path, &reader)
+	require.NoError(c.t, err)
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", c.token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(c.t, err)
+	defer res.Body.Close()
+	if out != nil && res.StatusCode < http.StatusMultipleChoices {
+		require.NoError(c.t, json.NewDecoder(res.Body).Decode(out))
+	}
+	return res.StatusCode
+}
+
+// signUp реєструє користувача та повертає клієнта з його токеном
+func signUp(t *testing.T, server *httptest.Server, username string) *client {
+	c := &client{t: t, url: server.URL}
+	credentials := map[string]string{"username": username, "password": "qwerty"}
+	require.Equal(t, http.StatusOK, c.do(http.MethodPost, "/auth/sign-up", credentials, nil))
+
+	var token struct{ Token string }
+	require.Equal(t, http.StatusOK, c.do(http.MethodPost, "/auth/sign-in", credentials, &token))
+	c.token = token.Token
+
+	var me struct{ Id int }
+	require.Equal(t, http.StatusOK, c.do(http.MethodGet, "/auth/get-me", nil, &me))
+	c.id = me.Id
+	return c
+}
+
+// dial під'єднує клієнта до кімнати та чекає, доки хаб його зареєструє
+func (c *client) dial(room int) *gorilla.Conn {
+	url := fmt.Sprintf("ws%s/ws/%d?token=%s", strings.TrimPrefix(c.url, "http"), room, c.token)
+	conn, _, err := gorilla.DefaultDialer.Dial(url, nil)
+	require.NoError(c.t, err)
+	c.t.Cleanup(func() { conn.Close() })
+
+	// Хаб повертає повідомлення і відправнику, тож власна відповідь
+	// означає, що з'єднання вже зареєстроване в кімнаті
+	hello := fmt.Sprintf("hello from %d", c.id)
+	require.NoError(c.t, conn.WriteMessage(gorilla.TextMessage, []byte(hello)))
+	for {
+		if read(c.t, conn) == hello {
+			return conn
+		}
+	}
+}
+
+// readEvent повертає наступне повідомлення, пропускаючи привітання dial
+func readEvent(t *testing.T, conn *gorilla.Conn) string {
+	for {
+		if text := read(t, conn); !strings.HasPrefix(text, "hello from") {
+			return text
+		}
+	}
+}
+
+func read(t *testing.T, conn *gorilla.Conn) string {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	_, data, err := conn.ReadMessage()
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestEndToEnd_Friends(t *testing.T) {
+	server := newServer(t)
+	alice, bob := signUp(t, server, "alice"), signUp(t, server, "bob")
+
+	guest := &client{t: t, url: server.URL}
+	assert.Equal(t, http.StatusConflict, guest.do(http.MethodPost, "/auth/sign-up",
+		map[string]string{"username": "alice", "password": "qwerty"}, nil), "username is taken")
+	assert.Equal(t, http.StatusUnauthorized, guest.do(http.MethodGet, "/auth/get-me", nil, nil))
+	assert.Equal(t, http.StatusBadRequest, alice.do(http.MethodGet, "/users/bob/all", nil, nil))
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, fmt.Sprintf("/users/%d/invite", bob.id), nil, nil))
+
+	var lists struct {
+		Friends  []struct{ Id int }
+		Invites  []struct{ Id int }
+		Requires []struct{ Id int }
+	}
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/users/%d/all", bob.id), nil, &lists))
+	require.Len(t, append(lists.Invites, lists.Requires...), 1)
+
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPut, fmt.Sprintf("/users/%d/accept", alice.id), nil, nil))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/users/%d/all", alice.id), nil, &lists))
+	require.Len(t, lists.Friends, 1)
+	assert.Equal(t, bob.id, lists.Friends[0].Id)
+}
+
+func TestEndToEnd_PrivateChat(t *testing.T) {
+	server := newServer(t)
+	alice, bob, carol := signUp(t, server, "alice"), signUp(t, server, "bob"), signUp(t, server, "carol")
+
+	var first, second, other struct{ ChatId int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", bob.id), nil, &first))
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", alice.id), nil, &second))
+	assert.Equal(t, first.ChatId, second.ChatId, "private chat is reused from either side")
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", carol.id), nil, &other))
+	assert.NotEqual(t, first.ChatId, other.ChatId)
+}
+
+func TestEndToEnd_PrivateChatTitle(t *testing.T) {
+	server := newServer(t)
+	alice, bob := signUp(t, server, "alice"), signUp(t, server, "bob")
+
+	var private struct{ ChatId int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", bob.id), nil, &private))
+
+	// Кожен учасник бачить ім'я співрозмовника
+	var chat struct{ Chat struct{ Name string } }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d", private.ChatId), nil, &chat))
+	assert.Equal(t, "bob", chat.Chat.Name)
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/chats/%d", private.ChatId), nil, &chat))
+	assert.Equal(t, "alice", chat.Chat.Name)
+
+	// Співрозмовник дізнається про нове ім'я з події в будь-якій кімнаті
+	var own struct{ Id int }
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, "/chats/create", map[string]string{"name": "Notes"}, &own))
+	bobWs := bob.dial(own.Id)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, "/auth/change/username", map[string]string{"username": "alicia"}, nil))
+	var event struct {
+		Type   string
+		ChatId int `json:"chat_id"`
+		Data   struct {
+			Id       int
+			Username string
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(readEvent(t, bobWs)), &event))
+	assert.Equal(t, "user_updated", event.Type)
+	assert.Zero(t, event.ChatId)
+	assert.Equal(t, alice.id, event.Data.Id)
+	assert.Equal(t, "alicia", event.Data.Username)
+
+	var list struct {
+		List []struct {
+			Id        int
+			Name      string
+			PartnerId int `json:"partner_id"`
+		}
+	}
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/users/%d/private", bob.id), nil, &list))
+	require.Len(t, list.List, 1)
+	assert.Equal(t, "alicia", list.List[0].Name)
+	assert.Equal(t, alice.id, list.List[0].PartnerId, "client matches user_updated to the chat by partner")
+}
+
+func TestEndToEnd_Messages(t *testing.T) {
+	server := newServer(t)
+	alice, bob := signUp(t, server, "alice"), signUp(t, server, "bob")
+
+	var chat struct{ Id int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/chats/create", map[string]string{"name": "Room"}, &chat))
+	add := map[string]int{"user_id": bob.id}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id), add, nil))
+	assert.Equal(t, http.StatusConflict, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id), add, nil),
+		"user is already in chat")
+
+	var found struct{ List []struct{ Id int } }
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/chats/search/roo", nil, &found))
+	require.Len(t, found.List, 1)
+	assert.Equal(t, chat.Id, found.List[0].Id)
+
+	aliceWs, bobWs := alice.dial(chat.Id), bob.dial(chat.Id)
+
+	for i, c := range []*client{alice, bob, alice} {
+		text := fmt.Sprintf("message %d", i)
+		require.Equal(t, http.StatusOK, c.do(http.MethodPost, fmt.Sprintf("/chats/%d/messages", chat.Id),
+			map[string]string{"text": text}, nil))
+	}
+	require.NoError(t, aliceWs.WriteMessage(gorilla.TextMessage, []byte("new message")))
+	assert.Equal(t, "new message", read(t, bobWs))
+
+	var messages struct {
+		List []struct {
+			Text   string
+			Author int
+		}
+	}
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/chats/%d/messages/limit/2", chat.Id), nil, &messages))
+	require.Len(t, messages.List, 2)
+	assert.Equal(t, "message 1", messages.List[0].Text, "oldest of the last messages goes first")
+	assert.Equal(t, bob.id, messages.List[0].Author)
+	assert.Equal(t, "message 2", messages.List[1].Text)
+
+	// Учасник, що не є адміністратором, не може видалити чат чи іншого учасника
+	assert.Equal(t, http.StatusForbidden, bob.do(http.MethodDelete, fmt.Sprintf("/chats/%d", chat.Id), nil, nil))
+	assert.Equal(t, http.StatusForbidden, bob.do(http.MethodPut, fmt.Sprintf("/chats/%d/delete", chat.Id),
+		map[string]int{"user_id": alice.id}, nil))
+
+	// Чат видаляється, коли його покидає останній учасник
+	for _, c := range []*client{bob, alice} {
+		c.do(http.MethodPut, fmt.Sprintf("/chats/%d/delete", chat.Id), map[string]int{"user_id": c.id}, nil)
+	}
+	assert.Equal(t, http.StatusNotFound, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/messages", chat.Id),
+		map[string]string{"text": "gone"}, nil))
+}
+
+func TestEndToEnd_Blocks(t *testing.T) {
+	server := newServer(t)
+	alice, bob, carol := signUp(t, server, "alice"), signUp(t, server, "bob"), signUp(t, server, "carol")
+
+	var chat struct{ Id int }
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, "/chats/create", map[string]string{"name": "Room"}, &chat))
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id), map[string]int{"user_id": bob.id}, nil))
+	var private struct{ ChatId int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", bob.id), nil, &private))
+
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, fmt.Sprintf("/users/%d/addToBL", alice.id), nil, nil))
+
+	assert.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, fmt.Sprintf("/users/%d/invite", bob.id), nil, nil))
+	assert.Equal(t, http.StatusForbidden, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", bob.id), nil, nil))
+	assert.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/messages", private.ChatId),
+		map[string]string{"text": "hi"}, nil))
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id), map[string]int{"user_id": alice.id}, nil))
+	assert.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id),
+		map[string]int{"user_id": bob.id}, nil))
+
+	var found struct{ List []struct{ Id int } }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/users/search/bo", nil, &found))
+	assert.Empty(t, found.List, "user who blocked the searcher is hidden")
+	require.Equal(t, http.StatusOK, carol.do(http.MethodGet, "/users/search/bo", nil, &found))
+	assert.Len(t, found.List, 1)
+
+	// Без токена до кімнати не під'єднатися
+	_, res, err := gorilla.DefaultDialer.Dial(fmt.Sprintf("ws%s/ws/%d", strings.TrimPrefix(server.URL, "http"), chat.Id), nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	// Сторонній користувач не може слухати чужу кімнату
+	dave := signUp(t, server, "dave")
+	_, res, err = gorilla.DefaultDialer.Dial(fmt.Sprintf("ws%s/ws/%d?token=%s",
+		strings.TrimPrefix(server.URL, "http"), chat.Id, dave.token), nil)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+	// Той, хто заблокував, не отримує подій від заблокованого
+	aliceWs, bobWs, carolWs := alice.dial(chat.Id), bob.dial(chat.Id), carol.dial(chat.Id)
+	require.NoError(t, aliceWs.WriteMessage(gorilla.TextMessage, []byte("from alice")))
+	assert.Equal(t, "from alice", readEvent(t, carolWs))
+	require.NoError(t, carolWs.WriteMessage(gorilla.TextMessage, []byte("from carol")))
+	assert.Equal(t, "from carol", readEvent(t, bobWs))
+	assert.Equal(t, "from alice", readEvent(t, aliceWs))
+	assert.Equal(t, "from carol", readEvent(t, aliceWs))
+
+	// Після розблокування відкрите з'єднання знову доставляє повідомлення
+	require.Equal(t, http.StatusOK, bob.do(http.MethodDelete, fmt.Sprintf("/users/%d/deleteFromBlacklist", alice.id), nil, nil))
+	// Хаб перечитує блокування асинхронно, тож повідомлення Керол після
+	// Алісиного показує, чи дійшло воно до Боба
+	for i := 0; ; i++ {
+		require.Less(t, i, 100)
+		require.NoError(t, aliceWs.WriteMessage(gorilla.TextMessage, []byte("after unblock")))
+		require.Equal(t, "after unblock", readEvent(t, aliceWs))
+		require.NoError(t, carolWs.WriteMessage(gorilla.TextMessage, []byte("marker")))
+		if readEvent(t, bobWs) == "after unblock" {
+			break
+		}
+	}
+}
+
+func TestEndToEnd_Privacy(t *testing.T) {
+	server := newServer(t)
+	alice, bob, carol := signUp(t, server, "alice"), signUp(t, server, "bob"), signUp(t, server, "carol")
+
+	// Аліса дружить з Бобом і приймає повідомлення та запрошення лише від друзів
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, fmt.Sprintf("/users/%d/invite", bob.id), nil, nil))
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPut, fmt.Sprintf("/users/%d/accept", alice.id), nil, nil))
+	settings := map[string]interface{}{
+		"direct_messages": "friends", "chat_invites": "friends", "searchable": false, "last_seen": "friends",
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, "/auth/settings", settings, nil))
+	var saved map[string]interface{}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, "/auth/settings", nil, &saved))
+	assert.Equal(t, settings, saved)
+
+	assert.Equal(t, http.StatusForbidden, carol.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", alice.id), nil, nil))
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", alice.id), nil, nil))
+
+	var chat struct{ Id int }
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, "/chats/create", map[string]string{"name": "Room"}, &chat))
+	assert.Equal(t, http.StatusForbidden, carol.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id),
+		map[string]int{"user_id": alice.id}, nil))
+
+	var found struct{ List []struct{ Id int } }
+	require.Equal(t, http.StatusOK, carol.do(http.MethodGet, "/users/search/ali", nil, &found))
+	assert.Empty(t, found.List, "user who disabled search is hidden")
+
+	var presence struct {
+		LastSeenAt *time.Time `json:"last_seen_at"`
+	}
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, fmt.Sprintf("/users/%d/presence", alice.id), nil, &presence))
+	assert.NotNil(t, presence.LastSeenAt)
+	require.Equal(t, http.StatusOK, carol.do(http.MethodGet, fmt.Sprintf("/users/%d/presence", alice.id), nil, &presence))
+	assert.Nil(t, presence.LastSeenAt)
+}
+
+func TestEndToEnd_InviteLinks(t *testing.T) {
+	server := newServer(t)
+	alice, bob, carol := signUp(t, server, "alice"), signUp(t, server, "bob"), signUp(t, server, "carol")
+
+	var chat struct{ Id int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/chats/create", map[string]string{"name": "Room"}, &chat))
+	aliceWs := alice.dial(chat.Id)
+
+	// Одноразове посилання приєднує першого користувача та сповіщає чат
+	var created struct {
+		Invite struct {
+			Id    int
+			Token string
+		}
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/invites", chat.Id),
+		map[string]int{"max_uses": 1}, &created))
+	var joined struct{ Join struct{ Status string } }
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, "/chats/join/"+created.Invite.Token, nil, &joined))
+	assert.Equal(t, "joined", joined.Join.Status)
+	var event struct {
+		Type   string
+		ChatId int `json:"chat_id"`
+		Data   struct {
+			UserId int `json:"user_id"`
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(readEvent(t, aliceWs)), &event))
+	assert.Equal(t, "member_joined", event.Type)
+	assert.Equal(t, chat.Id, event.ChatId)
+	assert.Equal(t, bob.id, event.Data.UserId)
+	assert.Equal(t, http.StatusNotFound, carol.do(http.MethodPost, "/chats/join/"+created.Invite.Token, nil, nil))
+
+	// Список посилань бачить лише адміністратор
+	assert.Equal(t, http.StatusForbidden, bob.do(http.MethodGet, fmt.Sprintf("/chats/%d/invites", chat.Id), nil, nil))
+	var invites struct{ List []struct{ Uses int } }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/invites", chat.Id), nil, &invites))
+	require.Len(t, invites.List, 1)
+	assert.Equal(t, 1, invites.List[0].Uses)
+
+	// Вступ за посиланням зі схваленням чекає рішення адміністратора
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, fmt.Sprintf("/chats/%d/invites", chat.Id),
+		map[string]bool{"approval": true}, &created))
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, "/chats/join/"+created.Invite.Token, nil, &joined))
+	assert.Equal(t, "pending", joined.Join.Status)
+	var pending struct{ List []struct{ Id, UserId int } }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/joins", chat.Id), nil, &pending))
+	require.Len(t, pending.List, 1)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut,
+		fmt.Sprintf("/chats/%d/joins/%d/approve", chat.Id, pending.List[0].Id), nil, &joined))
+	assert.Equal(t, "joined", joined.Join.Status)
+	var users []struct{ Id int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/users", chat.Id), nil, &users))
+	assert.Len(t, users, 3)
+
+	// Відкликане посилання більше не працює
+	require.Equal(t, http.StatusOK, bob.do(http.MethodDelete,
+		fmt.Sprintf("/chats/%d/invites/%d", chat.Id, created.Invite.Id), nil, nil))
+	dave := signUp(t, server, "dave")
+	assert.Equal(t, http.StatusNotFound, dave.do(http.MethodPost, "/chats/join/"+created.Invite.Token, nil, nil))
+}
+
+func TestEndToEnd_JoinRequests(t *testing.T) {
+	server := newServer(t)
+	alice, bob, carol := signUp(t, server, "alice"), signUp(t, server, "bob"), signUp(t, server, "carol")
+
+	var chat struct{ Id int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/chats/create",
+		map[string]string{"name": "Book club", "visibility": "request"}, &chat))
+
+	// Адміністратор отримує заявку в будь-якій кімнаті, а автор заявки -
+	// рішення щодо неї
+	var own struct{ Id int }
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, "/chats/create", map[string]string{"name": "Notes"}, &own))
+	aliceWs, carolWs := alice.dial(chat.Id), carol.dial(own.Id)
+	var joined struct {
+		Join struct {
+			Id     int
+			UserId int `json:"user_id"`
+		}
+	}
+	require.Equal(t, http.StatusOK, carol.do(http.MethodPost, fmt.Sprintf("/chats/%d/join", chat.Id), nil, &joined))
+	assert.Equal(t, http.StatusConflict, carol.do(http.MethodPost, fmt.Sprintf("/chats/%d/join", chat.Id), nil, nil))
+	var event struct {
+		Type   string
+		ChatId int `json:"chat_id"`
+		Data   struct {
+			UserId int `json:"user_id"`
+			Status string
+		}
+	}
+	require.NoError(t, json.Unmarshal([]byte(readEvent(t, aliceWs)), &event))
+	assert.Equal(t, "join_requested", event.Type)
+	assert.Equal(t, carol.id, event.Data.UserId)
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut,
+		fmt.Sprintf("/chats/%d/joins/%d/approve", chat.Id, joined.Join.Id), nil, nil))
+	require.NoError(t, json.Unmarshal([]byte(readEvent(t, carolWs)), &event))
+	assert.Equal(t, "join_approved", event.Type)
+	assert.Equal(t, chat.Id, event.ChatId)
+	require.NoError(t, json.Unmarshal([]byte(readEvent(t, aliceWs)), &event))
+	assert.Equal(t, "member_joined", event.Type)
+
+	// Прихований чат не знаходить пошук, а вступити до нього можна лише за посиланням
+	assert.Equal(t, http.StatusForbidden, carol.do(http.MethodPut, fmt.Sprintf("/chats/%d/visibility", chat.Id),
+		map[string]string{"visibility": "open"}, nil))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, fmt.Sprintf("/chats/%d/visibility", chat.Id),
+		map[string]string{"visibility": "hidden"}, nil))
+	var found struct{ List []struct{ Id int } }
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/chats/search/Book", nil, &found))
+	assert.Empty(t, found.List)
+	assert.Equal(t, http.StatusNotFound, bob.do(http.MethodPost, fmt.Sprintf("/chats/%d/join", chat.Id), nil, nil))
+	// Запит додавання не обходить перевірки вступу
+	assert.Equal(t, http.StatusNotFound, bob.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id),
+		map[string]int{"user_id": bob.id}, nil))
+	assert.Equal(t, http.StatusForbidden, carol.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", chat.Id),
+		map[string]int{"user_id": bob.id}, nil), "only admins add to a hidden chat")
+	var private struct{ ChatId int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/chats/%d/private", carol.id), nil, &private))
+	assert.Equal(t, http.StatusNotFound, bob.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", private.ChatId),
+		map[string]int{"user_id": bob.id}, nil))
+	assert.Equal(t, http.StatusForbidden, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", private.ChatId),
+		map[string]int{"user_id": bob.id}, nil))
+
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, fmt.Sprintf("/chats/%d/visibility", chat.Id),
+		map[string]string{"visibility": "open"}, nil))
+	require.Equal(t, http.StatusOK, bob.do(http.MethodGet, "/chats/search/Book", nil, &found))
+	assert.Len(t, found.List, 1)
+	require.Equal(t, http.StatusOK, bob.do(http.MethodPost, fmt.Sprintf("/chats/%d/join", chat.Id), nil, &joined))
+	assert.Equal(t, bob.id, joined.Join.UserId)
+}
+
+func TestEndToEnd_ChatDetails(t *testing.T) {
+	repos := memory.NewRepository()
+	server := newServerWith(t, repos)
+	alice, bob := signUp(t, server, "alice"), signUp(t, server, "bob")
+
+	var first, second struct{ Id int }
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/chats/create", map[string]string{"name": "First"}, &first))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, "/chats/create", map[string]string{"name": "Second"}, &second))
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPost, fmt.Sprintf("/chats/%d/add", first.Id),
+		map[string]int{"user_id": bob.id}, nil))
+
+	// Опис та тему змінює лише адміністратор
+	info := map[string]string{"name": "Renamed", "description": "About the chat", "topic": "Today"}
+	assert.Equal(t, http.StatusForbidden, bob.do(http.MethodPut, fmt.Sprintf("/chats/%d", first.Id), info, nil))
+	var updated struct {
+		Chat struct {
+			Name        string
+			Description string
+			Topic       string
+			CreatedBy   int `json:"created_by"`
+			MemberCount int `json:"member_count"`
+		}
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodPut, fmt.Sprintf("/chats/%d", first.Id), info, &updated))
+	assert.Equal(t, "Renamed", updated.Chat.Name)
+	assert.Equal(t, "About the chat", updated.Chat.Description)
+	assert.Equal(t, "Today", updated.Chat.Topic)
+	assert.Equal(t, alice.id, updated.Chat.CreatedBy)
+	assert.Equal(t, 2, updated.Chat.MemberCount)
+
+	// Чат з новим повідомленням піднімається на початок списку
+	var list struct {
+		List []struct {
+			Id          int
+			LastMessage *struct{ Text string } `json:"last_message"`
+		}
+	}
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/users/%d/public", alice.id), nil, &list))
+	require.Len(t, list.List, 2)
+	assert.Equal(t, second.Id, list.List[0].Id)
+	assert.Nil(t, list.List[0].LastMessage)
+
+	// Час повідомлення задається явно, щоб порядок не залежав від того,
+	// скільки минуло між запитами
+	_, err := repos.Message.Create(context.Background(), models.Message{
+		ChatId: first.Id, Author: bob.id, Text: "hello", SentAt: models.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, alice.do(http.MethodGet, fmt.Sprintf("/users/%d/public", alice.id), nil, &list))
+	require.Len(t, list.List, 2)
+	assert.Equal(t, first.Id, list.List[0].Id)
+	require.NotNil(t, list.List[0].LastMessage)
+	assert.Equal(t, "hello", list.List[0].LastMessage.Text)
+}
@@ -2,48 +2,77 @@ package handler
 
 import (
 	_ "cmd/docs"
+	"cmd/pkg/config"
 	auth2 "cmd/pkg/handler/auth"
 	chat2 "cmd/pkg/handler/chat"
+	"cmd/pkg/handler/images"
+	"cmd/pkg/handler/invites"
 	message2 "cmd/pkg/handler/message"
 	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/responses"
 	users2 "cmd/pkg/handler/users"
 	"cmd/pkg/handler/websocket"
 	"cmd/pkg/service"
+	"cmd/pkg/validation"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	echoSwagger "github.com/swaggo/echo-swagger"
+	"strconv"
 )
 
 type Handler struct {
 	services *service.Service
+	hub      *websocket.Hub
+	cnf      config.Server
 }
 
-func NewHandler(services *service.Service) *Handler {
-	return &Handler{services: services}
+func NewHandler(services *service.Service, hub *websocket.Hub, cnf config.Server) *Handler {
+	return &Handler{services: services, hub: hub, cnf: cnf}
 }
 
 func (h *Handler) InitRoutes() *echo.Echo {
 	router := echo.New()
+	router.HTTPErrorHandler = responses.ErrorHandler(h.cnf.LegacyErrors)
+	router.Validator = validation.Validator{}
 	router.Use(middleware.CORS())
 	middlewaresHandler := middlewares.NewMiddlewareHandler(h.services)
 	messageHandler := message2.NewMessageHandler(h.services)
-	chatHandler := chat2.NewChatHandler(h.services)
-	authHandler := auth2.NewAuthHandler(h.services)
-	usersHandler := users2.NewUsersHandler(h.services)
+	chatHandler := chat2.NewChatHandler(h.services, h.hub)
+	authHandler := auth2.NewAuthHandler(h.services, h.hub)
+	usersHandler := users2.NewUsersHandler(h.services, h.hub)
+	imagesHandler := images.NewImagesHandler(h.services)
+	invitesHandler := invites.NewInvitesHandler(h.services, h.hub)
 	//SWAGGER
 	router.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	//WebSocket
+	//Браузер не може передати заголовок Authorization під час відкриття
+	//WebSocket, тому токен передається параметром запиту token. Кімната -
+	//чат, учасником якого є користувач
 	router.GET("/ws/:roomId", func(c echo.Context) error {
-		roomId := c.Param("roomId")
-		websocket.ServeWs(c.Response(), c.Request(), roomId)
+		roomId, err := middlewares.GetParam(c, "roomId")
+		if err != nil {
+			return err
+		}
+		token := c.QueryParam("token")
+		if token == "" {
+			return service.ErrUnauthenticated
+		}
+		userId, err := h.services.Authorization.ParseToken(token)
+		if err != nil {
+			return service.ErrInvalidToken.Wrap(err)
+		}
+		if err := h.services.Chat.CheckMember(c.Request().Context(), userId, roomId); err != nil {
+			return service.Internal(err, "check chat member error")
+		}
+		h.hub.ServeWs(c.Response(), c.Request(), strconv.Itoa(roomId), userId)
 		return nil
 	})
 
-	api := router.Group("/api")
+	api := router.Group("/api", middlewares.Timeout(h.cnf.RequestTimeout))
 
 	//Посилання на зображення
-	api.Static("/image/", "./uploads/")
+	api.GET("/image/:name", imagesHandler.GetImage)
 
 	auth := api.Group("/auth")
 	{
@@ -59,11 +88,15 @@ func (h *Handler) InitRoutes() *echo.Echo {
 		auth.PUT("/change/username", authHandler.ChangeUsername, middlewaresHandler.UserIdentify)
 		//Змінити аватар
 		auth.PUT("/change/icon", authHandler.ChangeIcon, middlewaresHandler.UserIdentify)
+		//Отримати налаштування приватності
+		auth.GET("/settings", authHandler.GetSettings, middlewaresHandler.UserIdentify)
+		//Змінити налаштування приватності
+		auth.PUT("/settings", authHandler.UpdateSettings, middlewaresHandler.UserIdentify)
 	}
 
 	users := api.Group("/users/:id", middlewaresHandler.UserIdentify)
 	//Пошук користувачів за нікнеймом
-	api.GET("/users/search/:username", usersHandler.SearchUser)
+	api.GET("/users/search/:username", usersHandler.SearchUser, middlewaresHandler.UserIdentify)
 	{
 
 		//Отримати усі ПУБЛІЧНІ чати користувача
@@ -72,6 +105,10 @@ func (h *Handler) InitRoutes() *echo.Echo {
 		users.GET("/private", chatHandler.GetUserPrivateChats)
 		//Отримати дані користувача за його ID
 		users.GET("", usersHandler.GetUserById)
+		//Отримати час останньої активності користувача
+		users.GET("/presence", usersHandler.GetPresence)
+		//Отримати користувачів, яких ви можете знати
+		users.GET("/suggestions", usersHandler.GetSuggestions)
 		//Отримати список усіх користувачів, пов'язаних з вами
 		users.GET("/all", usersHandler.GetUserLists)
 		//Запит на дружбу
@@ -109,11 +146,30 @@ func (h *Handler) InitRoutes() *echo.Echo {
 		chat.PUT("/:id/delete", chatHandler.DeleteUserFromChat)
 		//Оновити зображення чату
 		chat.PUT("/:id/icon", chatHandler.ChangeChatIcon)
+		//Змінити назву, опис та тему чату
+		chat.PUT("/:id", chatHandler.UpdateChat)
 		//Видалити чат
 		chat.DELETE("/:id", chatHandler.DeleteChat)
 		//Пошук чатів за назвою
 		chat.GET("/search/:name", chatHandler.SearchChat)
-
+		//Створити посилання-запрошення до чату
+		chat.POST("/:id/invites", invitesHandler.CreateInvite)
+		//Отримати посилання-запрошення чату
+		chat.GET("/:id/invites", invitesHandler.GetInvites)
+		//Відкликати посилання-запрошення
+		chat.DELETE("/:id/invites/:inviteId", invitesHandler.RevokeInvite)
+		//Приєднатися до чату за посиланням-запрошенням
+		chat.POST("/join/:token", invitesHandler.Join)
+		//Приєднатися до відкритого чату або подати заявку на вступ
+		chat.POST("/:id/join", invitesHandler.JoinChat)
+		//Змінити видимість чату
+		chat.PUT("/:id/visibility", chatHandler.ChangeVisibility)
+		//Отримати заявки на вступ до чату
+		chat.GET("/:id/joins", invitesHandler.GetJoins)
+		//Схвалити заявку на вступ
+		chat.PUT("/:id/joins/:joinId/approve", invitesHandler.ApproveJoin)
+		//Відхилити заявку на вступ
+		chat.PUT("/:id/joins/:joinId/reject", invitesHandler.RejectJoin)
 	}
 
 	message := chat.Group("/:chatId/messages")
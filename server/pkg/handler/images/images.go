@@ -0,0 +1,74 @@
+package images
+
+import (
+	"cmd/pkg/service"
+	"cmd/pkg/storage"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+const ParamName = "name"
+
+// immutable - заголовок кешування для файлів, що адресуються вмістом
+const immutable = "public, max-age=31536000, immutable"
+
+type ImagesHandler struct {
+	services *service.Service
+}
+
+func NewImagesHandler(services *service.Service) *ImagesHandler {
+	return &ImagesHandler{services: services}
+}
+
+// GetImage godoc
+// @Summary      Get uploaded image
+// @Description  Отримує ім'я файлу зображення.
+// @Description  Повертає зображення або перенаправляє на тимчасове посилання сховища.
+// @Tags         images
+// @Produce      image/jpeg
+// @Param        name		path     string   true  "File name"
+// @Success      200 	{file}   file	 "image"
+// @Success      302 	{string} string	 "redirect to signed url"
+// @Success      304 	{string} string	 "not modified"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "image_not_found: image not found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get image error"
+// @Router       /image/{name} [get]
+func (h *ImagesHandler) GetImage(c echo.Context) error {
+
+	// Отримуємо ім'я файлу
+	name := c.Param(ParamName)
+
+	// Якщо сховище вміє створювати посилання, перенаправляємо на нього
+	url, err := h.services.Upload.SignedURL(c.Request().Context(), name)
+	if err == nil {
+		return c.Redirect(http.StatusFound, url)
+	}
+	if !errors.Is(err, storage.ErrNoSignedURL) {
+		return service.Internal(err, "get image error")
+	}
+
+	// Вміст файлів, названих за SHA-256, ніколи не змінюється,
+	// тому їх можна кешувати без обмежень
+	cacheable := service.IsContentAddressed(name)
+	etag := `"` + name + `"`
+	if cacheable && c.Request().Header.Get("If-None-Match") == etag {
+		c.Response().Header().Set(echo.HeaderCacheControl, immutable)
+		c.Response().Header().Set("ETag", etag)
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	// Інакше віддаємо файл самостійно
+	// Для відсутнього файлу повертається service.ErrImageNotFound
+	file, err := h.services.Upload.Open(c.Request().Context(), name)
+	if err != nil {
+		return service.Internal(err, "get image error")
+	}
+	defer file.Close()
+
+	if cacheable {
+		c.Response().Header().Set(echo.HeaderCacheControl, immutable)
+		c.Response().Header().Set("ETag", etag)
+	}
+	return c.Stream(http.StatusOK, storage.ContentType(name), file)
+}
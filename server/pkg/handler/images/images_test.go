@@ -0,0 +1,131 @@
+package images
+
+import (
+	"cmd/pkg/handler/responses"
+	"cmd/pkg/service"
+	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/storage"
+	"cmd/pkg/validation"
+	"errors"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const hashName = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.png"
+
+func TestImagesHandler_GetImage(t *testing.T) {
+	type mockBehavior func(s *mockService.MockUpload, name string)
+
+	testTable := []struct {
+		name                 string
+		inputName            string
+		inputETag            string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedLocation     string
+		expectedCacheControl string
+		expectedResponseBody string
+	}{
+		{
+			name:      "ok",
+			inputName: "upload-1.jpeg",
+			mockBehavior: func(s *mockService.MockUpload, name string) {
+				s.EXPECT().SignedURL(gomock.Any(), name).Return("", storage.ErrNoSignedURL)
+				s.EXPECT().Open(gomock.Any(), name).Return(io.NopCloser(strings.NewReader("image")), nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: "image",
+		},
+		{
+			name:      "Content addressed image is cached",
+			inputName: hashName,
+			mockBehavior: func(s *mockService.MockUpload, name string) {
+				s.EXPECT().SignedURL(gomock.Any(), name).Return("", storage.ErrNoSignedURL)
+				s.EXPECT().Open(gomock.Any(), name).Return(io.NopCloser(strings.NewReader("image")), nil)
+			},
+			expectedStatusCode:   200,
+			expectedCacheControl: immutable,
+			expectedResponseBody: "image",
+		},
+		{
+			name:      "Not modified",
+			inputName: hashName,
+			inputETag: `"` + hashName + `"`,
+			mockBehavior: func(s *mockService.MockUpload, name string) {
+				s.EXPECT().SignedURL(gomock.Any(), name).Return("", storage.ErrNoSignedURL)
+			},
+			expectedStatusCode:   304,
+			expectedCacheControl: immutable,
+			expectedResponseBody: "",
+		},
+		{
+			name:      "Redirect to signed url",
+			inputName: "upload-1.jpeg",
+			mockBehavior: func(s *mockService.MockUpload, name string) {
+				s.EXPECT().SignedURL(gomock.Any(), name).Return("http://minio/upload-1.jpeg?sig", nil)
+			},
+			expectedStatusCode:   302,
+			expectedLocation:     "http://minio/upload-1.jpeg?sig",
+			expectedResponseBody: "",
+		},
+		{
+			name:      "Image not found",
+			inputName: "upload-1.jpeg",
+			mockBehavior: func(s *mockService.MockUpload, name string) {
+				s.EXPECT().SignedURL(gomock.Any(), name).Return("", storage.ErrNoSignedURL)
+				s.EXPECT().Open(gomock.Any(), name).Return(nil, service.ErrImageNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"image_not_found","message":"image not found"}` + "\n",
+		},
+		{
+			name:      "Get image error",
+			inputName: "upload-1.jpeg",
+			mockBehavior: func(s *mockService.MockUpload, name string) {
+				s.EXPECT().SignedURL(gomock.Any(), name).Return("", errors.New("some error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"get image error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			upload := mockService.NewMockUpload(c)
+			testCase.mockBehavior(upload, testCase.inputName)
+
+			services := &service.Service{Upload: upload}
+			handler := NewImagesHandler(services)
+
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if testCase.inputETag != "" {
+				req.Header.Set("If-None-Match", testCase.inputETag)
+			}
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/image/:name")
+			ctx.SetParamNames(ParamName)
+			ctx.SetParamValues(testCase.inputName)
+
+			if err := handler.GetImage(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedLocation, rec.Header().Get(echo.HeaderLocation))
+			assert.Equal(t, testCase.expectedCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
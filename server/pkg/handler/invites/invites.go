@@ -0,0 +1,346 @@
+package invites
+
+import (
+	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/websocket"
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"cmd/pkg/service"
+	"context"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"time"
+)
+
+type InvitesHandler struct {
+	services *service.Service
+	events   websocket.Publisher
+}
+
+func NewInvitesHandler(services *service.Service, events websocket.Publisher) *InvitesHandler {
+	return &InvitesHandler{services: services, events: events}
+}
+
+// publishJoin повідомляє учасників чату про нового учасника, а
+// адміністраторів - про нову заявку на вступ. Помилка не скасовує вступ
+func (h *InvitesHandler) publishJoin(ctx context.Context, join models.ChatJoin) {
+	websocket.PublishJoin(ctx, h.events, h.services.Invite.GetAdmins, join)
+}
+
+// CreateInvite godoc
+// @Summary      Create chat invite link
+// @Description  Учасник публічного чату створює посилання-запрошення з необов'язковими
+// @Description  часом дії (expires_in, секунди), кількістю використань (max_uses) та
+// @Description  схваленням вступу адміністратором (approval). 0 - без обмеження.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Accept       json
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Param        invite	body     InviteInput   true  "Invite options"
+// @Success      200 	{object} InviteResponse   "created invite with token"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not a member or not a public chat"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "create invite error"
+// @Router       /chats/{id}/invites [post]
+func (h *InvitesHandler) CreateInvite(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Отримуємо налаштування посилання
+	var input InviteInput
+	if err := middlewares.Bind(c, &input); err != nil {
+		return err
+	}
+	invite := models.ChatInvite{ChatId: chatId, MaxUses: input.MaxUses, Approval: input.Approval}
+	if input.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(input.ExpiresIn) * time.Second)
+		invite.ExpiresAt = &expiresAt
+	}
+
+	// Створюємо посилання
+	invite, err := h.services.Invite.CreateInvite(c.Request().Context(), userId, invite)
+	if err != nil {
+		return service.Internal(err, "create invite error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"invite": invite,
+	})
+}
+
+// GetInvites godoc
+// @Summary      Get chat invite links
+// @Description  Повертає посилання-запрошення чату, включно з відкликаними. Лише для адміністраторів.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Success      200 	{object} InviteListResponse   "list of invites"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get invites error"
+// @Router       /chats/{id}/invites [get]
+func (h *InvitesHandler) GetInvites(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Отримуємо список посилань
+	invites, err := h.services.Invite.GetInvites(c.Request().Context(), userId, chatId)
+	if err != nil {
+		return service.Internal(err, "get invites error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"list": invites,
+	})
+}
+
+// RevokeInvite godoc
+// @Summary      Revoke chat invite link
+// @Description  Відкликає посилання-запрошення. Доступно адміністраторам чату та автору посилання.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        id			path     int   true  "Chat ID"
+// @Param        inviteId	path     int   true  "Invite ID"
+// @Success      200 	{object} MessageResponse   "invite revoked"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "invite_not_found"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "revoke invite error"
+// @Router       /chats/{id}/invites/{inviteId} [delete]
+func (h *InvitesHandler) RevokeInvite(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату та посилання
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+	inviteId, errParam := middlewares.GetParam(c, middlewares.InviteId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Відкликаємо посилання
+	if err := h.services.Invite.RevokeInvite(c.Request().Context(), userId, chatId, inviteId); err != nil {
+		return service.Internal(err, "revoke invite error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"message": "invite revoked",
+	})
+}
+
+// Join godoc
+// @Summary      Join chat by invite link
+// @Description  Приєднує активного користувача до чату за токеном посилання-запрошення.
+// @Description  Якщо посилання потребує схвалення, повертає заявку зі статусом pending;
+// @Description  використання такого посилання зараховується лише після схвалення.
+// @Description  Учасники чату отримують подію member_joined у кімнаті чату,
+// @Description  а адміністратори про заявку - подію join_requested.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        token	path     string   true  "Invite token"
+// @Success      200 	{object} JoinResponse   "join record (joined or pending)"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "blocked: a chat admin has blocked the user"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "invite_invalid: revoked, expired or used up"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "already_in_chat"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "join_pending"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "join chat error"
+// @Router       /chats/join/{token} [post]
+func (h *InvitesHandler) Join(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Вступаємо до чату за токеном
+	join, err := h.services.Invite.Join(c.Request().Context(), userId, c.Param(middlewares.Token))
+	if err != nil {
+		return service.Internal(err, "join chat error")
+	}
+	h.publishJoin(c.Request().Context(), join)
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"join": join,
+	})
+}
+
+// JoinChat godoc
+// @Summary      Join public chat
+// @Description  Приєднує активного користувача до відкритого чату (visibility open) або
+// @Description  створює заявку на вступ до чату з заявками (visibility request).
+// @Description  Учасники чату отримують подію member_joined у кімнаті чату,
+// @Description  а адміністратори про заявку - подію join_requested.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Success      200 	{object} JoinResponse   "join record (joined or pending)"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "blocked: a chat admin has blocked the user"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found: no such chat, private or hidden chat"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "already_in_chat"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "join_pending"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "join chat error"
+// @Router       /chats/{id}/join [post]
+func (h *InvitesHandler) JoinChat(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Вступаємо до чату або подаємо заявку
+	join, err := h.services.Invite.JoinChat(c.Request().Context(), userId, chatId)
+	if err != nil {
+		return service.Internal(err, "join chat error")
+	}
+	h.publishJoin(c.Request().Context(), join)
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"join": join,
+	})
+}
+
+// GetJoins godoc
+// @Summary      Get chat join requests
+// @Description  Повертає вступи до чату зі статусом status (за замовчуванням pending). Лише для адміністраторів.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Param        status	query    string   false "pending, joined or rejected"
+// @Success      200 	{object} JoinListResponse   "list of joins"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get joins error"
+// @Router       /chats/{id}/joins [get]
+func (h *InvitesHandler) GetJoins(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Отримуємо статус вступів
+	var query JoinsQuery
+	if err := middlewares.Bind(c, &query); err != nil {
+		return err
+	}
+	if query.Status == "" {
+		query.Status = repository.JoinPending
+	}
+
+	// Отримуємо список вступів
+	joins, err := h.services.Invite.GetJoins(c.Request().Context(), userId, chatId, query.Status)
+	if err != nil {
+		return service.Internal(err, "get joins error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"list": joins,
+	})
+}
+
+// ApproveJoin godoc
+// @Summary      Approve join request
+// @Description  Адміністратор схвалює заявку на вступ, і її автор стає учасником чату.
+// @Description  Схвалення зараховує використання посилання, за яким подано заявку.
+// @Description  Учасники чату отримують подію member_joined у кімнаті чату,
+// @Description  а автор заявки - подію join_approved.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Param        joinId	path     int   true  "Join ID"
+// @Success      200 	{object} JoinResponse   "approved join"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "join_not_found"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "invite_invalid: invite revoked or used up"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "join_resolved"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "approve join error"
+// @Router       /chats/{id}/joins/{joinId}/approve [put]
+func (h *InvitesHandler) ApproveJoin(c echo.Context) error {
+	return h.resolve(c, h.services.Invite.ApproveJoin, websocket.EventJoinApproved, "approve join error")
+}
+
+// RejectJoin godoc
+// @Summary      Reject join request
+// @Description  Адміністратор відхиляє заявку на вступ до чату.
+// @Description  Автор заявки отримує подію join_rejected.
+// @Security ApiKeyAuth
+// @Tags         invites
+// @Produce      json
+// @Param        id		path     int   true  "Chat ID"
+// @Param        joinId	path     int   true  "Join ID"
+// @Success      200 	{object} JoinResponse   "rejected join"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: not an admin"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "join_not_found"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "join_resolved"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "reject join error"
+// @Router       /chats/{id}/joins/{joinId}/reject [put]
+func (h *InvitesHandler) RejectJoin(c echo.Context) error {
+	return h.resolve(c, h.services.Invite.RejectJoin, websocket.EventJoinRejected, "reject join error")
+}
+
+// resolve розглядає заявку на вступ з параметрів запиту функцією fn та
+// надсилає автору заявки подію event
+func (h *InvitesHandler) resolve(c echo.Context, fn func(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error), event, message string) error {
+
+	// Отримуємо ID активного користувача
+	userId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID чату та заявки
+	chatId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+	joinId, errParam := middlewares.GetParam(c, middlewares.JoinId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Розглядаємо заявку
+	join, err := fn(c.Request().Context(), userId, chatId, joinId)
+	if err != nil {
+		return service.Internal(err, message)
+	}
+	h.events.Notify(userId, []int{join.UserId}, websocket.Event{Type: event, ChatId: chatId, Data: join})
+	h.publishJoin(c.Request().Context(), join)
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"join": join,
+	})
+}
@@ -0,0 +1,441 @@
+package invites
+
+import (
+	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/responses"
+	"cmd/pkg/handler/websocket"
+	"cmd/pkg/repository/models"
+	"cmd/pkg/service"
+	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/validation"
+	"github.com/golang/mock/gomock"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// events запам'ятовує надіслані події: події кімнат та події окремим
+// користувачам за їх ID
+type events struct {
+	published []websocket.Event
+	notified  map[int][]websocket.Event
+}
+
+func (e *events) Publish(userId int, event websocket.Event) {
+	e.published = append(e.published, event)
+}
+
+func (e *events) Notify(userId int, recipients []int, event websocket.Event) {
+	if e.notified == nil {
+		e.notified = map[int][]websocket.Event{}
+	}
+	for _, id := range recipients {
+		e.notified[id] = append(e.notified[id], event)
+	}
+}
+
+func TestInvitesHandler_CreateInvite(t *testing.T) {
+	type mockBehavior func(s *mockService.MockInvite, userId int, invite models.ChatInvite)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputChatId          int
+		inputBody            string
+		inputInvite          models.ChatInvite
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "ok",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"max_uses":5,"approval":true}`,
+			inputInvite: models.ChatInvite{ChatId: 4, MaxUses: 5, Approval: true},
+			mockBehavior: func(s *mockService.MockInvite, userId int, invite models.ChatInvite) {
+				invite.Id, invite.Token, invite.CreatedBy, invite.CreatedAt = 1, "token", userId, created
+				s.EXPECT().CreateInvite(gomock.Any(), userId, gomock.Any()).Return(invite, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"invite":{"id":1,"chat_id":4,"token":"token","created_by":2,"expires_at":null,"max_uses":5,"uses":0,"approval":true,"created_at":"2024-01-02T03:04:05Z","revoked_at":null}}` + "\n",
+		},
+		{
+			name:        "Negative max uses",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{"max_uses":-1}`,
+			mockBehavior: func(s *mockService.MockInvite, userId int, invite models.ChatInvite) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"max_uses","rule":"min","message":"must be at least 0"}]}` + "\n",
+		},
+		{
+			name:        "Not a member",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputBody:   `{}`,
+			inputInvite: models.ChatInvite{ChatId: 4},
+			mockBehavior: func(s *mockService.MockInvite, userId int, invite models.ChatInvite) {
+				s.EXPECT().CreateInvite(gomock.Any(), userId, invite).Return(invite, service.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"access denied"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invite := mockService.NewMockInvite(c)
+			testCase.mockBehavior(invite, testCase.inputUserId, testCase.inputInvite)
+
+			services := &service.Service{Invite: invite}
+			handler := NewInvitesHandler(services, &events{})
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodPost, "/api/chats/:id/invites",
+				strings.NewReader(testCase.inputBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+
+			//Перевірка результатів
+			if err := handler.CreateInvite(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
+
+func TestInvitesHandler_Join(t *testing.T) {
+	type mockBehavior func(s *mockService.MockInvite, userId int, token string)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	inviteId := 3
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputToken           string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedEvents       []websocket.Event
+		expectedNotified     map[int][]websocket.Event
+	}{
+		{
+			name:        "ok",
+			inputUserId: 2,
+			inputToken:  "token",
+			mockBehavior: func(s *mockService.MockInvite, userId int, token string) {
+				s.EXPECT().Join(gomock.Any(), userId, token).Return(models.ChatJoin{Id: 1, ChatId: 4, UserId: userId, InviteId: &inviteId, Status: "joined", CreatedAt: created}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"join":{"id":1,"chat_id":4,"user_id":2,"invite_id":3,"status":"joined","created_at":"2024-01-02T03:04:05Z"}}` + "\n",
+			expectedEvents: []websocket.Event{{Type: websocket.EventMemberJoined, ChatId: 4,
+				Data: models.ChatJoin{Id: 1, ChatId: 4, UserId: 2, InviteId: &inviteId, Status: "joined", CreatedAt: created}}},
+		},
+		{
+			name:        "Pending approval",
+			inputUserId: 2,
+			inputToken:  "token",
+			mockBehavior: func(s *mockService.MockInvite, userId int, token string) {
+				s.EXPECT().Join(gomock.Any(), userId, token).Return(models.ChatJoin{Id: 1, ChatId: 4, UserId: userId, InviteId: &inviteId, Status: "pending", CreatedAt: created}, nil)
+				s.EXPECT().GetAdmins(gomock.Any(), 4).Return([]int{1, 5}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"join":{"id":1,"chat_id":4,"user_id":2,"invite_id":3,"status":"pending","created_at":"2024-01-02T03:04:05Z"}}` + "\n",
+			expectedNotified: map[int][]websocket.Event{
+				1: {{Type: websocket.EventJoinRequested, ChatId: 4, Data: models.ChatJoin{Id: 1, ChatId: 4, UserId: 2, InviteId: &inviteId, Status: "pending", CreatedAt: created}}},
+				5: {{Type: websocket.EventJoinRequested, ChatId: 4, Data: models.ChatJoin{Id: 1, ChatId: 4, UserId: 2, InviteId: &inviteId, Status: "pending", CreatedAt: created}}},
+			},
+		},
+		{
+			name:        "Invalid invite",
+			inputUserId: 2,
+			inputToken:  "expired",
+			mockBehavior: func(s *mockService.MockInvite, userId int, token string) {
+				s.EXPECT().Join(gomock.Any(), userId, token).Return(models.ChatJoin{}, service.ErrInviteInvalid)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"invite_invalid","message":"invite link is invalid or expired"}` + "\n",
+		},
+		{
+			name:        "Already in chat",
+			inputUserId: 2,
+			inputToken:  "token",
+			mockBehavior: func(s *mockService.MockInvite, userId int, token string) {
+				s.EXPECT().Join(gomock.Any(), userId, token).Return(models.ChatJoin{}, service.ErrAlreadyInChat)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"already_in_chat","message":"user is already in chat"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invite := mockService.NewMockInvite(c)
+			testCase.mockBehavior(invite, testCase.inputUserId, testCase.inputToken)
+
+			published := &events{}
+			services := &service.Service{Invite: invite}
+			handler := NewInvitesHandler(services, published)
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodPost, "/api/chats/join/:token", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("token")
+			ctx.SetParamValues(testCase.inputToken)
+
+			//Перевірка результатів
+			if err := handler.Join(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedEvents, published.published)
+			assert.Equal(t, testCase.expectedNotified, published.notified)
+		})
+	}
+}
+
+func TestInvitesHandler_JoinChat(t *testing.T) {
+	type mockBehavior func(s *mockService.MockInvite, userId, chatId int)
+
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputChatId          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+		expectedEvents       []websocket.Event
+	}{
+		{
+			name:        "Open chat",
+			inputUserId: 2,
+			inputChatId: "4",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+				s.EXPECT().JoinChat(gomock.Any(), userId, chatId).Return(models.ChatJoin{Id: 1, ChatId: chatId, UserId: userId, Status: "joined", CreatedAt: created}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"join":{"id":1,"chat_id":4,"user_id":2,"invite_id":null,"status":"joined","created_at":"2024-01-02T03:04:05Z"}}` + "\n",
+			expectedEvents: []websocket.Event{{Type: websocket.EventMemberJoined, ChatId: 4,
+				Data: models.ChatJoin{Id: 1, ChatId: 4, UserId: 2, Status: "joined", CreatedAt: created}}},
+		},
+		{
+			name:        "Hidden chat",
+			inputUserId: 2,
+			inputChatId: "4",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+				s.EXPECT().JoinChat(gomock.Any(), userId, chatId).Return(models.ChatJoin{}, service.ErrChatNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+		{
+			name:        "Pending request",
+			inputUserId: 2,
+			inputChatId: "4",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+				s.EXPECT().JoinChat(gomock.Any(), userId, chatId).Return(models.ChatJoin{}, service.ErrJoinPending)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"join_pending","message":"join request is already pending"}` + "\n",
+		},
+		{
+			name:        "Incorrect chat ID",
+			inputUserId: 2,
+			inputChatId: "chat",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"invalid_param","message":"incorrect id parameter"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invite := mockService.NewMockInvite(c)
+			chatId, _ := strconv.Atoi(testCase.inputChatId)
+			testCase.mockBehavior(invite, testCase.inputUserId, chatId)
+
+			published := &events{}
+			services := &service.Service{Invite: invite}
+			handler := NewInvitesHandler(services, published)
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodPost, "/api/chats/:id/join", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(testCase.inputChatId)
+
+			//Перевірка результатів
+			if err := handler.JoinChat(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedEvents, published.published)
+		})
+	}
+}
+
+func TestInvitesHandler_RejectJoin(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := gomock.NewController(t)
+	defer c.Finish()
+
+	// Автор заявки дізнається про рішення, а учасники чату - ні
+	invite := mockService.NewMockInvite(c)
+	rejected := models.ChatJoin{Id: 7, ChatId: 4, UserId: 3, Status: "rejected", CreatedAt: created}
+	invite.EXPECT().RejectJoin(gomock.Any(), 2, 4, 7).Return(rejected, nil)
+	published := &events{}
+	handler := NewInvitesHandler(&service.Service{Invite: invite}, published)
+
+	e := echo.New()
+	e.HTTPErrorHandler = responses.ErrorHandler(false)
+	req := httptest.NewRequest(http.MethodPut, "/api/chats/:id/joins/:joinId/reject", nil)
+	rec := httptest.NewRecorder()
+	ctx := e.NewContext(req, rec)
+	ctx.Set(middlewares.UserCtx, 2)
+	ctx.SetParamNames("id", "joinId")
+	ctx.SetParamValues("4", "7")
+
+	if err := handler.RejectJoin(ctx); err != nil {
+		e.HTTPErrorHandler(err, ctx)
+	}
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `{"join":{"id":7,"chat_id":4,"user_id":3,"invite_id":null,"status":"rejected","created_at":"2024-01-02T03:04:05Z"}}`+"\n", rec.Body.String())
+	assert.Empty(t, published.published)
+	assert.Equal(t, map[int][]websocket.Event{3: {{Type: websocket.EventJoinRejected, ChatId: 4, Data: rejected}}}, published.notified)
+}
+
+func TestInvitesHandler_GetJoins(t *testing.T) {
+	type mockBehavior func(s *mockService.MockInvite, userId, chatId int, status string)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputChatId          int
+		inputQuery           string
+		inputStatus          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:        "Pending by default",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputStatus: "pending",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int, status string) {
+				s.EXPECT().GetJoins(gomock.Any(), userId, chatId, status).Return(nil, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"list":null}` + "\n",
+		},
+		{
+			name:        "Rejected",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputQuery:  "?status=rejected",
+			inputStatus: "rejected",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int, status string) {
+				s.EXPECT().GetJoins(gomock.Any(), userId, chatId, status).Return(nil, service.ErrForbidden)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"access denied"}` + "\n",
+		},
+		{
+			name:        "Unknown status",
+			inputUserId: 2,
+			inputChatId: 4,
+			inputQuery:  "?status=left",
+			mockBehavior: func(s *mockService.MockInvite, userId, chatId int, status string) {
+			},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"status","rule":"oneof","message":"must be one of pending, joined, rejected"}]}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			// Початкові значення
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			invite := mockService.NewMockInvite(c)
+			testCase.mockBehavior(invite, testCase.inputUserId, testCase.inputChatId, testCase.inputStatus)
+
+			services := &service.Service{Invite: invite}
+			handler := NewInvitesHandler(services, &events{})
+
+			//Тестовий сервер
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			//Тестовий запит
+			req := httptest.NewRequest(http.MethodGet, "/api/chats/:id/joins"+testCase.inputQuery, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(strconv.Itoa(testCase.inputChatId))
+
+			//Перевірка результатів
+			if err := handler.GetJoins(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
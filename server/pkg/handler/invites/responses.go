@@ -0,0 +1,35 @@
+package invites
+
+import "cmd/pkg/repository/models"
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+type InviteInput struct {
+	// ExpiresIn - час дії посилання у секундах, 0 - без обмеження
+	ExpiresIn int `json:"expires_in" validate:"min=0,max=31536000"`
+	// MaxUses - найбільша кількість використань, 0 - без обмеження
+	MaxUses  int  `json:"max_uses" validate:"min=0"`
+	Approval bool `json:"approval"`
+}
+
+type JoinsQuery struct {
+	Status string `json:"status" query:"status" validate:"omitempty,oneof=pending joined rejected"`
+}
+
+type InviteResponse struct {
+	Invite models.ChatInvite `json:"invite"`
+}
+
+type InviteListResponse struct {
+	List []models.ChatInvite `json:"list"`
+}
+
+type JoinResponse struct {
+	Join models.ChatJoin `json:"join"`
+}
+
+type JoinListResponse struct {
+	List []models.ChatJoin `json:"list"`
+}
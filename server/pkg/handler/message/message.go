@@ -2,12 +2,10 @@ package messages
 
 import (
 	"cmd/pkg/handler/middlewares"
-	"cmd/pkg/handler/responses"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
 	"github.com/labstack/echo/v4"
 	"net/http"
-	"time"
 )
 
 type MessageHandler struct {
@@ -29,20 +27,20 @@ func NewMessageHandler(services *service.Service) *MessageHandler {
 // @Param        chatId		path     int   true  "Chat ID"
 // @Param        message_text	body     TextInput   true  "Message text"
 // @Success      200 	{object} IdResponse			"return message ID"
-// @Failure 	 400 	{object} responses.ErrorResponse	 "body is empty"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "invalid_request: incorrect request data"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "blocked: user has blocked you"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "privacy_restricted: user's privacy settings do not allow this"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "chat_not_found"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "create message error"
 // @Router       /chats/{chatId}/messages [post]
 func (h *MessageHandler) CreateMessage(c echo.Context) error {
 
 	// Отримуємо дані з сайту (текст повідомлення)
-	var msg models.Message
-	if err := c.Bind(&msg); err != nil {
+	var input TextInput
+	if err := middlewares.Bind(c, &input); err != nil {
 		return err
 	}
-	if msg.Text == "" {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "body is empty")
-		return nil
-	}
 
 	// Отримуємо ID чату
 	chatId, errParam := middlewares.GetParam(c, middlewares.ChatId)
@@ -57,15 +55,19 @@ func (h *MessageHandler) CreateMessage(c echo.Context) error {
 	}
 
 	// Заповнюємо форму повідомлення
-	msg.ChatId = chatId
-	msg.Author = userId
-	msg.SentAt = time.Now().Round(20 * time.Millisecond)
+	msg := models.Message{
+		ChatId: chatId,
+		Author: userId,
+		Text:   input.Text,
+		SentAt: models.Now(),
+	}
 
 	// Створюємо нове повідомлення
-	id, err := h.services.Message.Create(msg)
+	// Для відсутнього чату повертається service.ErrChatNotFound, а для
+	// приватного чату з користувачем, що заблокував автора, - service.ErrBlocked
+	id, err := h.services.Message.Create(c.Request().Context(), msg)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "create message error")
-		return nil
+		return service.Internal(err, "create message error")
 	}
 
 	// Відгук сервера
@@ -89,6 +91,7 @@ func (h *MessageHandler) CreateMessage(c echo.Context) error {
 // @Param        chatId		path     int   true  "Chat ID"
 // @Param        id		path     int   true  "Message ID"
 // @Success      200 	{object} MessageResponse			"return message ID"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "message_not_found"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "get message error"
 // @Router       /chats/{chatId}/messages/{id} [get]
 func (h *MessageHandler) GetMessage(c echo.Context) error {
@@ -97,9 +100,9 @@ func (h *MessageHandler) GetMessage(c echo.Context) error {
 		return errParam
 	}
 
-	msg, err := h.services.Message.Get(msgId)
+	msg, err := h.services.Message.Get(c.Request().Context(), msgId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get message error")
+		return service.Internal(err, "get message error")
 	}
 
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
@@ -139,10 +142,9 @@ func (h *MessageHandler) GetLimitMessages(c echo.Context) error {
 	}
 
 	// Отримуємо список повідомлень зі зворотним порядком
-	msg, err := h.services.Message.GetLimit(chatId, limit)
+	msg, err := h.services.Message.GetLimit(c.Request().Context(), chatId, limit)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get limit error")
-		return nil
+		return service.Internal(err, "get limit error")
 	}
 
 	// Повертаємо правильний порядок
@@ -2,9 +2,11 @@ package messages
 
 import (
 	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/responses"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
 	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/validation"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
@@ -37,7 +39,7 @@ func TestMessageHandler_CreateMessage(t *testing.T) {
 				SentAt: time.Now().Round(20 * time.Millisecond),
 			},
 			mockBehavior: func(s *mockService.MockMessage, msg models.Message) {
-				s.EXPECT().Create(msg).Return(1, nil)
+				s.EXPECT().Create(gomock.Any(), msg).Return(1, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":1}` + "\n",
@@ -48,7 +50,37 @@ func TestMessageHandler_CreateMessage(t *testing.T) {
 			mockBehavior: func(s *mockService.MockMessage, msg models.Message) {
 			},
 			expectedStatusCode:   400,
-			expectedResponseBody: `{"message":"body is empty"}` + "\n",
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"text","rule":"required","message":"is required"}]}` + "\n",
+		},
+		{
+			name:      "chat not found",
+			inputText: `{"text":"test body"}`,
+			inputMessage: models.Message{
+				Author: 5,
+				ChatId: 3,
+				Text:   "test body",
+				SentAt: time.Now().Round(20 * time.Millisecond),
+			},
+			mockBehavior: func(s *mockService.MockMessage, msg models.Message) {
+				s.EXPECT().Create(gomock.Any(), msg).Return(0, service.ErrChatNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+		{
+			name:      "blocked",
+			inputText: `{"text":"test body"}`,
+			inputMessage: models.Message{
+				Author: 5,
+				ChatId: 3,
+				Text:   "test body",
+				SentAt: time.Now().Round(20 * time.Millisecond),
+			},
+			mockBehavior: func(s *mockService.MockMessage, msg models.Message) {
+				s.EXPECT().Create(gomock.Any(), msg).Return(0, service.ErrBlocked)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"blocked","message":"user has blocked you"}` + "\n",
 		},
 		{
 			name:      "server error",
@@ -60,10 +92,10 @@ func TestMessageHandler_CreateMessage(t *testing.T) {
 				SentAt: time.Now().Round(20 * time.Millisecond),
 			},
 			mockBehavior: func(s *mockService.MockMessage, msg models.Message) {
-				s.EXPECT().Create(msg).Return(0, errors.New("create message error"))
+				s.EXPECT().Create(gomock.Any(), msg).Return(0, errors.New("create message error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"create message error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"create message error"}` + "\n",
 		},
 	}
 
@@ -83,6 +115,8 @@ func TestMessageHandler_CreateMessage(t *testing.T) {
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodPost, "/api/chats/:chatId/messages",
@@ -96,10 +130,11 @@ func TestMessageHandler_CreateMessage(t *testing.T) {
 			ctx.SetParamValues("3")
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.CreateMessage(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.CreateMessage(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -137,7 +172,7 @@ func TestMessageHandler_GetLimitMessagesMessage(t *testing.T) {
 						SentAt: time.Date(2023, 10, 10, 10, 11, 10, 10, time.UTC),
 					},
 				}
-				s.EXPECT().GetLimit(chatId, limit).Return(ret, nil)
+				s.EXPECT().GetLimit(gomock.Any(), chatId, limit).Return(ret, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"list":[{"id":15,"chat_id":13,"author":5,"text":"test body","sent_at":"2023-10-10T10:11:10.00000001Z"},{"id":14,"chat_id":13,"author":5,"text":"test body","sent_at":"2023-10-10T10:10:10.00000001Z"}]}` + "\n",
@@ -147,10 +182,10 @@ func TestMessageHandler_GetLimitMessagesMessage(t *testing.T) {
 			inputChatId: 13,
 			inputLimit:  2,
 			mockBehavior: func(s *mockService.MockMessage, chatId, limit int) {
-				s.EXPECT().GetLimit(chatId, limit).Return(nil, errors.New("get limit error"))
+				s.EXPECT().GetLimit(gomock.Any(), chatId, limit).Return(nil, errors.New("get limit error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get limit error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"get limit error"}` + "\n",
 		},
 	}
 
@@ -170,6 +205,8 @@ func TestMessageHandler_GetLimitMessagesMessage(t *testing.T) {
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/chats/:chatId/messages/limit/:id", nil)
@@ -182,10 +219,11 @@ func TestMessageHandler_GetLimitMessagesMessage(t *testing.T) {
 			ctx.SetParamValues("13", "2")
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetLimitMessages(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetLimitMessages(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -15,5 +15,5 @@ type MessageListResponse struct {
 }
 
 type TextInput struct {
-	Text string `json:"text"`
+	Text string `json:"text" validate:"required,max=4096"`
 }
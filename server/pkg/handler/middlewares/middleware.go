@@ -1,24 +1,16 @@
 package middlewares
 
 import (
-	"cmd/pkg/handler/responses"
 	"cmd/pkg/service"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
-	"github.com/muesli/smartcrop"
-	"github.com/muesli/smartcrop/nfnt"
-	"github.com/nfnt/resize"
-	"image"
-	"image/gif"
-	"image/jpeg"
-	"image/png"
 	"io"
-	"math"
+	"log"
 	"net/http"
-	"os"
 	"strconv"
-	"strings"
+	"time"
 )
 
 type MiddlewareHandler struct {
@@ -36,144 +28,120 @@ const (
 	ChatId              = "chatId"
 	Username            = "username"
 	ChatName            = "name"
-	imageSize           = 100
-	imgWidth            = 10
-	imgHeight           = 10
+	InviteId            = "inviteId"
+	JoinId              = "joinId"
+	Token               = "token"
+	maxUploadSize       = 10 << 20
 )
 
+// UserIdentify зберігає ID користувача з токена в контексті та оновлює час
+// його останньої активності. Помилка оновлення не перериває запит
 func (h *MiddlewareHandler) UserIdentify(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
 
 		header := c.Request().Header.Get(authorizationHeader)
 		if header == "" {
-			responses.NewErrorResponse(c, http.StatusNoContent, "empty auth header")
-			return nil
+			return service.ErrUnauthenticated
 		}
 
 		userId, err := h.services.Authorization.ParseToken(header)
 		if err != nil {
-			responses.NewErrorResponse(c, http.StatusResetContent, "token old or wrong")
-			return nil
+			return service.ErrInvalidToken.Wrap(err)
 		}
 		c.Set(UserCtx, userId)
+		if err := h.services.Settings.Seen(c.Request().Context(), userId); err != nil {
+			log.Printf("error : %v", err)
+		}
 		return next(c)
 	}
 }
 
+// Timeout обмежує час, протягом якого запит може звертатися до БД та
+// сховища. Після спливу timeout або розриву з'єднання клієнтом запити
+// скасовуються. Нульовий timeout вимикає обмеження
+func Timeout(timeout time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if timeout <= 0 {
+				return next(c)
+			}
+			ctx, cancel := context.WithTimeout(c.Request().Context(), timeout)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
 func GetUserId(c echo.Context) (int, error) {
 	id := c.Get(UserCtx)
-	if id == 0 {
-		responses.NewErrorResponse(c, http.StatusNotFound, "user id not found")
-		return 0, errors.New("user id not found")
+	if id == nil || id == 0 {
+		return 0, service.ErrUnauthenticated.WithMessage("user id not found")
 	}
 	idInt, ok := id.(int)
 	if !ok {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "user id is of valid type")
-		return 0, errors.New("user id is of valid type")
+		return 0, service.Internal(errors.New("user id is of valid type"), "user id is of valid type")
 	}
 	return idInt, nil
 }
 
+// Bind заповнює input даними запиту та перевіряє його за тегами validate.
+// Якщо запит не вдалося розібрати, повертає service.ErrInvalidRequest,
+// якщо дані некоректні - validation.Errors з усіма такими полями
+func Bind(c echo.Context, input interface{}) error {
+	if err := c.Bind(input); err != nil {
+		return service.ErrInvalidRequest.Wrap(err)
+	}
+	return c.Validate(input)
+}
+
+// GetParam повертає числовий параметр шляху name або
+// service.ErrInvalidParam, якщо параметр не є числом
 func GetParam(c echo.Context, name string) (int, error) {
-	return strconv.Atoi(c.Param(name))
+	value, err := strconv.Atoi(c.Param(name))
+	if err != nil {
+		return 0, service.ErrInvalidParam.WithMessage(fmt.Sprintf("incorrect %s parameter", name)).Wrap(err)
+	}
+	return value, nil
 }
 
-func UploadImage(c echo.Context) (string, error) {
+func UploadImage(c echo.Context, upload service.Upload) (string, error) {
 
 	//Обмежуємо розмір завантажуваних файлів
-	c.Request().ParseMultipartForm(10 << 20)
+	c.Request().Body = http.MaxBytesReader(c.Response(), c.Request().Body, maxUploadSize+1<<20)
 
 	//Отримуємо файл зображення
 	file, err := c.FormFile("image")
-	if err != nil {
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect file error")
-		return "", err
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		return "", service.ErrImageTooLarge.WithMessage("file is too large").Wrap(err)
 	}
-
-	resizeFile, resizeHand, err := c.Request().FormFile("image")
 	if err != nil {
-		return "", err
+		return "", service.ErrInvalidRequest.WithMessage("incorrect file error").Wrap(err)
+	}
+	if file.Size > maxUploadSize {
+		return "", service.ErrImageTooLarge.WithMessage("file is too large")
 	}
-	defer resizeFile.Close()
 
 	//Відкриваємо дані файлу
 	handler, err := file.Open()
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusConflict, "open file error")
-		return "", err
+		return "", service.Internal(err, "open file error")
 	}
-
 	defer handler.Close()
 
-	//Створюємо порожні файли за необхідних розташуванням
-	tempFile, err := os.CreateTemp("uploads", "upload-*.jpeg")
+	fileBytes, err := io.ReadAll(handler)
 	if err != nil {
-		errDel := os.Remove(tempFile.Name())
-		if errDel != nil {
-			return "", errDel
-		}
-		defer tempFile.Close()
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "create file error")
-		return "", err
+		return "", service.Internal(err, "open file error")
 	}
 
-	resFile, err := os.Create(fmt.Sprintf("uploads/resize-%s", strings.TrimPrefix(tempFile.Name(), "uploads/")))
+	//Обробка та збереження зображення. Формат визначається за вмістом
+	//файлу, а не за його назвою. Помилки обробки сервіс повертає як
+	//service.ErrUnsupportedType, service.ErrImageTooLarge та
+	//service.ErrCorruptImage
+	name, err := upload.SaveImage(c.Request().Context(), fileBytes)
 	if err != nil {
-		errDel := os.Remove(tempFile.Name())
-		if errDel != nil {
-			return "", errDel
-		}
-		defer tempFile.Close()
-		defer resFile.Close()
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "create file error")
-		return "", err
-	}
-	defer tempFile.Close()
-	defer resFile.Close()
-
-	//Розкодування зображення за типом
-	var img image.Image
-	imgFmt := strings.Split(resizeHand.Filename, ".")
-
-	switch imgFmt[len(imgFmt)-1] {
-	case "jpeg":
-		img, err = jpeg.Decode(resizeFile)
-		break
-	case "jpg":
-		img, err = jpeg.Decode(resizeFile)
-		break
-	case "png":
-		img, err = png.Decode(resizeFile)
-		break
-	case "gif":
-		img, err = gif.Decode(resizeFile)
-		break
-	default:
-		responses.NewErrorResponse(c, http.StatusBadRequest, "incorrect file type error")
-		return "", err
-	}
-
-	//Приведення зображень до необхідних форми й розмірів
-	var crop = []int{imgWidth, imgHeight}
-	if crop != nil && len(crop) == 2 {
-		analyzer := smartcrop.NewAnalyzer(nfnt.NewDefaultResizer())
-		topCrop, _ := analyzer.FindBestCrop(img, crop[0], crop[1])
-		type SubImager interface {
-			SubImage(r image.Rectangle) image.Image
-		}
-		img = img.(SubImager).SubImage(topCrop)
+		return "", service.Internal(err, "create file error")
 	}
-	imgWidth := uint(math.Min(float64(imageSize), float64(img.Bounds().Max.X)))
-	resizedImg := resize.Resize(imgWidth, 0, img, resize.Lanczos3)
-
-	//Збереження зображень у новосотворених файлах
-	fileBytes, err := io.ReadAll(handler)
-	if err != nil {
-		return "", err
-	}
-
-	tempFile.Write(fileBytes)
-
-	err = jpeg.Encode(resFile, resizedImg, nil)
-	return strings.TrimPrefix(tempFile.Name(), "uploads/"), nil
+	return name, nil
 }
@@ -1,8 +1,10 @@
 package middlewares
 
 import (
+	"cmd/pkg/handler/responses"
 	"cmd/pkg/service"
 	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/validation"
 	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
@@ -11,6 +13,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestHandler_userIdentify(t *testing.T) {
@@ -22,6 +25,7 @@ func TestHandler_userIdentify(t *testing.T) {
 		headerValue          string
 		token                string
 		mockBehavior         mockBehavior
+		seenErr              error
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
@@ -35,6 +39,17 @@ func TestHandler_userIdentify(t *testing.T) {
 			expectedStatusCode:   200,
 			expectedResponseBody: "1" + "\n",
 		},
+		{
+			name:       "Seen error is ignored",
+			headerName: "Authorization",
+			token:      "token",
+			mockBehavior: func(s *mockService.MockAuthorization, token string) {
+				s.EXPECT().ParseToken(token).Return(1, nil).AnyTimes()
+			},
+			seenErr:              errors.New("some error"),
+			expectedStatusCode:   200,
+			expectedResponseBody: "1" + "\n",
+		},
 		{
 			name:       "Empty auth header",
 			headerName: "Authorization",
@@ -42,17 +57,17 @@ func TestHandler_userIdentify(t *testing.T) {
 			mockBehavior: func(s *mockService.MockAuthorization, token string) {
 			},
 			expectedStatusCode:   401,
-			expectedResponseBody: `{"message":"empty auth header"}` + "\n",
+			expectedResponseBody: `{"code":"unauthenticated","message":"empty auth header"}` + "\n",
 		},
 		{
-			name:       "Empty auth header",
+			name:       "Invalid token",
 			headerName: "Authorization",
 			token:      "token",
 			mockBehavior: func(s *mockService.MockAuthorization, token string) {
 				s.EXPECT().ParseToken(token).Return(0, errors.New("some error")).AnyTimes()
 			},
 			expectedStatusCode:   401,
-			expectedResponseBody: `{"message":"create token error"}` + "\n",
+			expectedResponseBody: `{"code":"invalid_token","message":"token old or wrong"}` + "\n",
 		},
 	}
 
@@ -64,10 +79,15 @@ func TestHandler_userIdentify(t *testing.T) {
 			auth := mockService.NewMockAuthorization(c)
 			testCase.mockBehavior(auth, testCase.token)
 
-			services := &service.Service{Authorization: auth}
+			settings := mockService.NewMockSettings(c)
+			settings.EXPECT().Seen(gomock.Any(), 1).Return(testCase.seenErr).AnyTimes()
+
+			services := &service.Service{Authorization: auth, Settings: settings}
 			handler := NewMiddlewareHandler(services)
 
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 			e.GET("/protected", nil, handler.UserIdentify, func(next echo.HandlerFunc) echo.HandlerFunc {
 				return func(c echo.Context) error {
 					id := c.Get(UserCtx).(int)
@@ -111,6 +131,10 @@ func Test_GetParam(t *testing.T) {
 	} else {
 		t.Logf("PASSED. Exepted %d, got %d", want, ok.param)
 	}
+
+	ctx.SetParamValues("four")
+	_, err := GetParam(ctx, "value")
+	assert.ErrorIs(t, err, service.ErrInvalidParam)
 }
 
 func Test_GetUserId(t *testing.T) {
@@ -134,3 +158,20 @@ func Test_GetUserId(t *testing.T) {
 		t.Logf("PASSED. Exepted %d, got %d", want, ok.param)
 	}
 }
+
+func TestTimeout(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var deadline bool
+	handler := func(c echo.Context) error {
+		_, deadline = c.Request().Context().Deadline()
+		return nil
+	}
+
+	assert.NoError(t, Timeout(time.Second)(handler)(e.NewContext(req, httptest.NewRecorder())))
+	assert.True(t, deadline)
+
+	assert.NoError(t, Timeout(0)(handler)(e.NewContext(req, httptest.NewRecorder())))
+	assert.False(t, deadline)
+}
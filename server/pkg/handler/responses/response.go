@@ -1,14 +1,104 @@
 package responses
 
-import "github.com/labstack/echo/v4"
+import (
+	"cmd/pkg/service"
+	"cmd/pkg/validation"
+	"context"
+	"errors"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"strings"
+)
+
+// Заголовок, яким клієнт у режимі сумісності обирає стандартні коди
+// відповідей для помилок
+const (
+	ErrorModelHeader   = "X-Error-Model"
+	ErrorModelStandard = "standard"
+)
 
 type ErrorResponse struct {
+	// Code - машинозчитуваний код помилки, наприклад "username_taken"
+	Code    string `json:"code,omitempty"`
 	Message string `json:"message"`
+	// Fields - поля запиту, що не пройшли перевірку
+	Fields validation.Errors `json:"fields,omitempty"`
+}
+
+// kindStatus - коди відповідей для видів помилок предметної області
+var kindStatus = map[service.Kind]int{
+	service.KindInternal:     http.StatusInternalServerError,
+	service.KindInvalid:      http.StatusBadRequest,
+	service.KindUnauthorized: http.StatusUnauthorized,
+	service.KindForbidden:    http.StatusForbidden,
+	service.KindNotFound:     http.StatusNotFound,
+	service.KindConflict:     http.StatusConflict,
+	service.KindTooLarge:     http.StatusRequestEntityTooLarge,
+	service.KindUnsupported:  http.StatusUnsupportedMediaType,
+}
+
+// legacyStatus - коди відповідей, на які розраховує попередня версія
+// веб-клієнта. Використовуються лише в режимі сумісності
+var legacyStatus = map[string]int{
+	service.ErrUsernameTaken.Code:   http.StatusAccepted,
+	service.ErrUnknownUser.Code:     http.StatusAccepted,
+	service.ErrWrongPassword.Code:   http.StatusAccepted,
+	service.ErrUnauthenticated.Code: http.StatusNoContent,
+	service.ErrInvalidToken.Code:    http.StatusResetContent,
+}
+
+// ErrorHandler повертає обробник помилок echo, що відповідає на помилки
+// предметної області стандартними кодами HTTP та тілом ErrorResponse.
+// Якщо legacy = true, помилки, на які розраховує попередня версія
+// веб-клієнта, повертаються з попередніми кодами, доки клієнт не надішле
+// заголовок "X-Error-Model: standard"
+func ErrorHandler(legacy bool) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		status, res := Error(err)
+		if legacy && c.Request().Header.Get(ErrorModelHeader) != ErrorModelStandard {
+			if code, ok := legacyStatus[res.Code]; ok {
+				status = code
+			}
+		}
+		if status >= http.StatusInternalServerError {
+			c.Logger().Error(err)
+		}
+
+		if c.Request().Method == http.MethodHead || status == http.StatusNoContent {
+			err = c.NoContent(status)
+		} else {
+			err = c.JSON(status, res)
+		}
+		if err != nil {
+			c.Logger().Error(err)
+		}
+	}
 }
 
-func NewErrorResponse(c echo.Context, statusCode int, message string) {
-	errRes := c.JSON(statusCode, ErrorResponse{Message: message})
-	if errRes != nil {
-		return
+// Error повертає код відповіді та тіло відповіді для помилки
+func Error(err error) (int, ErrorResponse) {
+	var domain *service.Error
+	var httpErr *echo.HTTPError
+	var fields validation.Errors
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout, ErrorResponse{Code: "timeout", Message: "request timed out"}
+	case errors.As(err, &fields):
+		return http.StatusBadRequest, ErrorResponse{Code: "validation_failed", Message: "request validation failed", Fields: fields}
+	case errors.As(err, &domain):
+		return kindStatus[domain.Kind], ErrorResponse{Code: domain.Code, Message: domain.Message}
+	case errors.As(err, &httpErr):
+		message := http.StatusText(httpErr.Code)
+		if m, ok := httpErr.Message.(string); ok {
+			message = m
+		}
+		code := strings.ToLower(strings.ReplaceAll(http.StatusText(httpErr.Code), " ", "_"))
+		return httpErr.Code, ErrorResponse{Code: code, Message: message}
+	default:
+		return http.StatusInternalServerError, ErrorResponse{Code: "internal", Message: "internal server error"}
 	}
 }
@@ -0,0 +1,106 @@
+package responses
+
+import (
+	"cmd/pkg/service"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorHandler(t *testing.T) {
+	testTable := []struct {
+		name                 string
+		legacy               bool
+		header               string
+		err                  error
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:                 "Domain error",
+			err:                  service.ErrUsernameTaken,
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"username_taken","message":"username is already used"}` + "\n",
+		},
+		{
+			name:                 "Wrapped domain error",
+			err:                  fmt.Errorf("sign up: %w", service.ErrChatNotFound.Wrap(errors.New("record not found"))),
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+		{
+			name:                 "Internal error",
+			err:                  service.Internal(errors.New("connection refused"), "create user error"),
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"create user error"}` + "\n",
+		},
+		{
+			name:                 "Unknown error",
+			err:                  errors.New("connection refused"),
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"internal server error"}` + "\n",
+		},
+		{
+			name:                 "Timeout",
+			err:                  service.Internal(context.DeadlineExceeded, "get chats error"),
+			expectedStatusCode:   504,
+			expectedResponseBody: `{"code":"timeout","message":"request timed out"}` + "\n",
+		},
+		{
+			name:                 "Echo error",
+			err:                  echo.ErrNotFound,
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"not_found","message":"Not Found"}` + "\n",
+		},
+		{
+			name:                 "Legacy status",
+			legacy:               true,
+			err:                  service.ErrUsernameTaken,
+			expectedStatusCode:   202,
+			expectedResponseBody: `{"code":"username_taken","message":"username is already used"}` + "\n",
+		},
+		{
+			name:                 "Legacy no content",
+			legacy:               true,
+			err:                  service.ErrUnauthenticated,
+			expectedStatusCode:   204,
+			expectedResponseBody: "",
+		},
+		{
+			name:                 "Legacy mode keeps other statuses",
+			legacy:               true,
+			err:                  service.ErrChatNotFound,
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"chat_not_found","message":"chat not found"}` + "\n",
+		},
+		{
+			name:                 "Legacy mode with standard model header",
+			legacy:               true,
+			header:               ErrorModelStandard,
+			err:                  service.ErrInvalidToken,
+			expectedStatusCode:   401,
+			expectedResponseBody: `{"code":"invalid_token","message":"token old or wrong"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if testCase.header != "" {
+				req.Header.Set(ErrorModelHeader, testCase.header)
+			}
+			rec := httptest.NewRecorder()
+
+			ErrorHandler(testCase.legacy)(testCase.err, e.NewContext(req, rec))
+
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+}
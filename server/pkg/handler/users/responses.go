@@ -24,4 +24,25 @@ type StatusesListResponse struct {
 	OnBlacklist []models.User `json:"onBlacklist"`
 	Invites     []models.User `json:"invites"`
 	Requires    []models.User `json:"requires"`
+	NextOffset  int           `json:"next_offset,omitempty"`
+}
+
+// defaultListsPage - кількість записів відносин на сторінці, якщо limit
+// не задано
+const defaultListsPage = 200
+
+type ListsQuery struct {
+	Offset int `json:"offset" query:"offset" validate:"min=0"`
+	Limit  int `json:"limit" query:"limit" validate:"omitempty,min=1,max=500"`
+}
+
+// defaultSuggestions - кількість пропозицій, якщо limit не задано
+const defaultSuggestions = 20
+
+type SuggestionsQuery struct {
+	Limit int `json:"limit" query:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+type SuggestionsResponse struct {
+	List []models.Suggestion `json:"list"`
 }
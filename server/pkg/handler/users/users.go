@@ -2,9 +2,7 @@ package users
 
 import (
 	"cmd/pkg/handler/middlewares"
-	"cmd/pkg/handler/responses"
-	"cmd/pkg/repository"
-	"cmd/pkg/repository/models"
+	"cmd/pkg/handler/websocket"
 	"cmd/pkg/service"
 	"github.com/labstack/echo/v4"
 	"net/http"
@@ -12,10 +10,11 @@ import (
 
 type UsersHandler struct {
 	services *service.Service
+	blocks   websocket.Blocks
 }
 
-func NewUsersHandler(services *service.Service) *UsersHandler {
-	return &UsersHandler{services: services}
+func NewUsersHandler(services *service.Service, blocks websocket.Blocks) *UsersHandler {
+	return &UsersHandler{services: services, blocks: blocks}
 }
 
 // GetUserById godoc
@@ -28,6 +27,7 @@ func NewUsersHandler(services *service.Service) *UsersHandler {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} UserResponse			"return user`s data"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "get user error"
 // @Router       /users/{id} [get]
 func (h *UsersHandler) GetUserById(c echo.Context) error {
@@ -39,10 +39,9 @@ func (h *UsersHandler) GetUserById(c echo.Context) error {
 	}
 
 	// Отримуємо дані користувача
-	user, err := h.services.Status.GetUserById(userId)
+	user, err := h.services.Status.GetUserById(c.Request().Context(), userId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "get user error")
-		return nil
+		return service.Internal(err, "get user error")
 	}
 
 	// Відгук сервера
@@ -59,17 +58,18 @@ func (h *UsersHandler) GetUserById(c echo.Context) error {
 // @Summary      Get user`s relationship lists by ID
 // @Description  Отримує ID користувача.
 // @Description  Повертає списки відносин між користувачем та іншими користувачами.
+// @Description  Списки діляться на сторінки по limit записів відносин; next_offset
+// @Description  вказує на наступну сторінку і відсутній на останній.
 // @Security ApiKeyAuth
 // @Tags         users
 // @Accept       json
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
+// @Param        offset	query    int   false "Relations to skip"
+// @Param        limit	query    int   false "Relations per page (1-500, default 200)"
 // @Success      200 	{object} StatusesListResponse		"return user`s status lists"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "friends list error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "black list error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "on black list error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "friend invites list error"
-// @Failure 	 500 	{object} responses.ErrorResponse	 "friend requires list error"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "relationship lists error"
 // @Router       /users/{id}/all [get]
 func (h *UsersHandler) GetUserLists(c echo.Context) error {
 
@@ -79,53 +79,24 @@ func (h *UsersHandler) GetUserLists(c echo.Context) error {
 		return errParam
 	}
 
-	// Отримуємо список друзів
-	friends, errFr := h.services.Status.GetFriends(userId)
-	if errFr != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "friends list error")
-		return nil
-	}
-
-	// Отримуємо список заблокованих користувачів
-	bl, errBL := h.services.Status.GetBlackList(userId)
-	if errBL != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "black list error")
-		return nil
-	}
-
-	// Отримуємо список користувачів, що заблокували користувача
-	onBL, errOnBL := h.services.Status.GetBlackListToUser(userId)
-	if errOnBL != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "on black list error")
-		return nil
+	// Отримуємо сторінку списків
+	var page ListsQuery
+	if err := middlewares.Bind(c, &page); err != nil {
+		return err
 	}
-
-	// Отримуємо список користувачів, яким відправлено запрошення в друзі
-	invites, errInv := h.services.Status.GetSentInvites(userId)
-	if errInv != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "friend invites list error")
-		return nil
+	if page.Limit == 0 {
+		page.Limit = defaultListsPage
 	}
 
-	// Отримуємо список користувачів, які отримали від користувача запрошення у друзі
-	requires, errReq := h.services.Status.GetInvites(userId)
-	if errReq != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "friend requires list error")
-		return nil
+	// Отримуємо друзів, заблокованих, тих, хто заблокував користувача, та
+	// запрошення в обох напрямках одним запитом
+	lists, err := h.services.Status.GetUserLists(c.Request().Context(), userId, page.Offset, page.Limit)
+	if err != nil {
+		return service.Internal(err, "relationship lists error")
 	}
 
 	//Відгук сервера
-	errRes := c.JSON(http.StatusOK, map[string]interface{}{
-		"friends":     friends,
-		"blacklist":   bl,
-		"onBlacklist": onBL,
-		"invites":     invites,
-		"requires":    requires,
-	})
-	if errRes != nil {
-		return errRes
-	}
-	return nil
+	return c.JSON(http.StatusOK, lists)
 }
 
 // InvitedToFriends godoc
@@ -138,31 +109,26 @@ func (h *UsersHandler) GetUserLists(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} IdResponse			"require is sent"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "blocked: user has blocked you"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "add status error"
 // @Router       /users/{id}/invite [post]
 func (h *UsersHandler) InvitedToFriends(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	senderId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	recipientId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusInvitation,
-	}
-
-	//Створюємо нові відносини
-	id, err := h.services.Status.AddStatus(status)
+	// Надсилаємо запрошення у друзі
+	id, err := h.services.Status.Invite(c.Request().Context(), userId, otherId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "add status error")
-		return nil
+		return service.Internal(err, "add status error")
 	}
 
 	// Відгук сервера
@@ -185,33 +151,23 @@ func (h *UsersHandler) InvitedToFriends(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} MessageResponse			"invite deleted"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "delete status error"
 // @Router       /users/{id}/cancel [delete]
 func (h *UsersHandler) CancelInvite(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	senderId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	recipientId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusInvitation,
-	}
-
-	// Видаляємо відносини за моделлю
-	err := h.services.Status.DeleteStatus(status)
-	if err != nil {
-		if err.Error() != "record not found" {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete status error")
-			return nil
-		}
+	// Скасовуємо надіслане запрошення
+	if err := h.services.Status.Cancel(c.Request().Context(), userId, otherId); err != nil {
+		return service.Internal(err, "delete status error")
 	}
 
 	// Відгук сервера
@@ -234,31 +190,23 @@ func (h *UsersHandler) CancelInvite(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} MessageResponse			"invitation accepted"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "update status error"
 // @Router       /users/{id}/accept [put]
 func (h *UsersHandler) AcceptInvitation(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	recipientId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	senderId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusFriends,
-	}
-
-	// Оновлюємо відносини за моделлю
-	err := h.services.Status.UpdateStatus(status)
-	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "update status error")
-		return nil
+	// Приймаємо отримане запрошення
+	if err := h.services.Status.Accept(c.Request().Context(), userId, otherId); err != nil {
+		return service.Internal(err, "update status error")
 	}
 
 	// Відгук сервера
@@ -281,33 +229,23 @@ func (h *UsersHandler) AcceptInvitation(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} MessageResponse			"invitation refused"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "delete status error"
 // @Router       /users/{id}/refuse [put]
 func (h *UsersHandler) RefuseInvitation(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	recipientId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	senderId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusInvitation,
-	}
-
-	// Видаляємо відносини за моделлю
-	err := h.services.Status.DeleteStatus(status)
-	if err != nil {
-		if err.Error() != "record not found" {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete status error")
-			return nil
-		}
+	// Відхиляємо отримане запрошення
+	if err := h.services.Status.Refuse(c.Request().Context(), userId, otherId); err != nil {
+		return service.Internal(err, "delete status error")
 	}
 
 	// Відгук сервера
@@ -330,46 +268,23 @@ func (h *UsersHandler) RefuseInvitation(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} MessageResponse			"friend deleted"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "delete status error"
 // @Router       /users/{id}/deleteFriend [delete]
 func (h *UsersHandler) DeleteFriend(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	senderId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	recipientId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusFriends,
-	}
-
-	// Видаляємо відносини за моделлю
-	err := h.services.Status.DeleteStatus(status)
-	if err != nil {
-		if err.Error() != "record not found" {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete status error")
-			return nil
-		}
-	}
-
-	// Заповнюємо модель відносин
-	status.SenderId = recipientId
-	status.RecipientId = senderId
-
-	// Видаляємо відносини за моделлю
-	err = h.services.Status.DeleteStatus(status)
-	if err != nil {
-		if err.Error() != "record not found" {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete status error")
-			return nil
-		}
+	// Видаляємо дружбу
+	if err := h.services.Status.Unfriend(c.Request().Context(), userId, otherId); err != nil {
+		return service.Internal(err, "delete status error")
 	}
 
 	// Відгук сервера
@@ -392,33 +307,31 @@ func (h *UsersHandler) DeleteFriend(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} IdResponse			"user is blocked"
+// @Failure 	 404 	{object} responses.ErrorResponse	 "user_not_found"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "add status error"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "update status error"
 // @Router       /users/{id}/addToBL [post]
 func (h *UsersHandler) AddToBlackList(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	senderId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	recipientId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusBL,
-	}
-
-	//Створюємо нові відносини
-	id, err := h.services.Status.AddStatus(status)
+	// Блокуємо користувача. Запрошення та дружба між користувачами видаляються
+	id, err := h.services.Status.Block(c.Request().Context(), userId, otherId)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "add status error")
-		return nil
+		return service.Internal(err, "add status error")
 	}
 
+	// З'єднання заблокованого перечитують, кому не надсилати його повідомлення
+	h.blocks.BlocksChanged(otherId)
+
 	// Відгук сервера
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
 		"id": id,
@@ -439,34 +352,25 @@ func (h *UsersHandler) AddToBlackList(c echo.Context) error {
 // @Produce      json
 // @Param        id		path     int   true  "User ID"
 // @Success      200 	{object} MessageResponse			"user deleted from black list"
+// @Failure 	 409 	{object} responses.ErrorResponse	 "invalid_transition: relationship change is not allowed"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "delete status error"
 // @Router       /users/{id}/deleteFromBlacklist [delete]
 func (h *UsersHandler) DeleteFromBlacklist(c echo.Context) error {
 
 	// Отримуємо ID активного користувача
-	senderId := c.Get(middlewares.UserCtx).(int)
+	userId := c.Get(middlewares.UserCtx).(int)
 
-	// Отримуємо ID запрошуваного користувача
-	recipientId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	// Отримуємо ID іншого користувача
+	otherId, errParam := middlewares.GetParam(c, middlewares.ParamId)
 	if errParam != nil {
 		return errParam
 	}
 
-	// Заповнюємо модель відносин
-	var status = models.Status{
-		SenderId:     senderId,
-		RecipientId:  recipientId,
-		Relationship: repository.StatusBL,
-	}
-
-	// Видаляємо відносини за моделлю
-	err := h.services.Status.DeleteStatus(status)
-	if err != nil {
-		if err.Error() != "record not found" {
-			responses.NewErrorResponse(c, http.StatusInternalServerError, "delete status error")
-			return nil
-		}
+	// Знімаємо блокування
+	if err := h.services.Status.Unblock(c.Request().Context(), userId, otherId); err != nil {
+		return service.Internal(err, "delete status error")
 	}
+	h.blocks.BlocksChanged(otherId)
 
 	// Відгук сервера
 	errRes := c.JSON(http.StatusOK, map[string]interface{}{
@@ -488,21 +392,28 @@ func (h *UsersHandler) DeleteFromBlacklist(c echo.Context) error {
 // @Produce      json
 // @Param        username		path     string   true  "Slice of users"
 // @Success      200 	{object} ListResponse			"lisl of found chats"
+// @Failure 	 401 	{object} responses.ErrorResponse	 "unauthenticated"
 // @Failure 	 500 	{object} responses.ErrorResponse	 "search users error"
 // @Router       /users/search/{username} [get]
 func (h *UsersHandler) SearchUser(c echo.Context) error {
 
+	// Отримуємо ID активного користувача
+	userId, errId := middlewares.GetUserId(c)
+	if errId != nil {
+		return errId
+	}
+
 	// Отримуємо фрагмент імені користувача
 	username := c.Param(middlewares.Username)
 	if len(username) == 0 {
 		return nil
 	}
 
-	// Отримуємо список користувачів, що мають в імені отриманий фрагмент
-	users, err := h.services.Status.SearchUser(username)
+	// Отримуємо список користувачів, що мають в імені отриманий фрагмент,
+	// крім тих, що заблокували активного користувача
+	users, err := h.services.Status.SearchUser(c.Request().Context(), userId, username)
 	if err != nil {
-		responses.NewErrorResponse(c, http.StatusInternalServerError, "search users error")
-		return nil
+		return service.Internal(err, "search users error")
 	}
 
 	// Відгук сервера
@@ -514,3 +425,94 @@ func (h *UsersHandler) SearchUser(c echo.Context) error {
 	}
 	return nil
 }
+
+// GetPresence godoc
+// @Summary      Get user`s last seen time
+// @Description  Отримує ID користувача.
+// @Description  Повертає час його останньої активності. Якщо налаштування приватності
+// @Description  користувача не дозволяють його показувати або користувач вас заблокував,
+// @Description  last_seen_at дорівнює null.
+// @Security ApiKeyAuth
+// @Tags         users
+// @Produce      json
+// @Param        id		path     int   true  "User ID"
+// @Success      200 	{object} models.Presence			"last seen time"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "get presence error"
+// @Router       /users/{id}/presence [get]
+func (h *UsersHandler) GetPresence(c echo.Context) error {
+
+	// Отримуємо власний ID з контексту
+	viewerId := c.Get(middlewares.UserCtx).(int)
+
+	// Отримуємо ID користувача
+	userId, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+
+	// Отримуємо час активності, якщо його можна показати
+	presence, err := h.services.Settings.GetPresence(c.Request().Context(), viewerId, userId)
+	if err != nil {
+		return service.Internal(err, "get presence error")
+	}
+
+	// Відгук сервера
+	return c.JSON(http.StatusOK, presence)
+}
+
+// GetSuggestions godoc
+// @Summary      Get friend suggestions
+// @Description  Повертає користувачів, яких ви можете знати, за спаданням кількості
+// @Description  спільних друзів (mutual_friends) та спільних публічних чатів (shared_chats).
+// @Description  Друзі, запрошені та заблоковані користувачі не пропонуються.
+// @Security ApiKeyAuth
+// @Tags         users
+// @Produce      json
+// @Param        id		path     int   true  "Active user ID"
+// @Param        limit	query    int   false "Suggestions count (1-50, default 20)"
+// @Success      200 	{object} SuggestionsResponse		"list of suggested users"
+// @Failure 	 400 	{object} responses.ErrorResponse	 "validation_failed: list of invalid fields"
+// @Failure 	 403 	{object} responses.ErrorResponse	 "forbidden: suggestions of another user"
+// @Failure 	 500 	{object} responses.ErrorResponse	 "suggestions error"
+// @Router       /users/{id}/suggestions [get]
+func (h *UsersHandler) GetSuggestions(c echo.Context) error {
+
+	// Отримуємо ID активного користувача
+	userId, errId := middlewares.GetUserId(c)
+	if errId != nil {
+		return errId
+	}
+
+	// Пропозиції розкривають друзів користувача, тож доступні лише йому
+	id, errParam := middlewares.GetParam(c, middlewares.ParamId)
+	if errParam != nil {
+		return errParam
+	}
+	if id != userId {
+		return service.ErrForbidden.WithMessage("suggestions of another user")
+	}
+
+	// Отримуємо кількість пропозицій
+	var query SuggestionsQuery
+	if err := middlewares.Bind(c, &query); err != nil {
+		return err
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultSuggestions
+	}
+
+	// Отримуємо список пропозицій
+	suggestions, err := h.services.Status.GetSuggestions(c.Request().Context(), userId, query.Limit)
+	if err != nil {
+		return service.Internal(err, "suggestions error")
+	}
+
+	// Відгук сервера
+	errRes := c.JSON(http.StatusOK, map[string]interface{}{
+		"list": suggestions,
+	})
+	if errRes != nil {
+		return errRes
+	}
+	return nil
+}
@@ -2,9 +2,11 @@ package users
 
 import (
 	"cmd/pkg/handler/middlewares"
+	"cmd/pkg/handler/responses"
 	"cmd/pkg/repository/models"
 	"cmd/pkg/service"
 	mockService "cmd/pkg/service/mocks"
+	"cmd/pkg/validation"
 	"errors"
 	"github.com/golang/mock/gomock"
 	"github.com/labstack/echo/v4"
@@ -13,8 +15,18 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 )
 
+// blocks запам'ятовує, чиї блокування змінилися
+type blocks struct {
+	changed []int
+}
+
+func (b *blocks) BlocksChanged(userId int) {
+	b.changed = append(b.changed, userId)
+}
+
 func TestUsersHandler_GetUserById(t *testing.T) {
 	type mockBehavior func(s *mockService.MockStatus, userId int)
 
@@ -33,7 +45,7 @@ func TestUsersHandler_GetUserById(t *testing.T) {
 					Id:       13,
 					Username: "user",
 				}
-				s.EXPECT().GetUserById(userId).Return(ret, nil)
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(ret, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"user":{"id":13,"username":"user","password":"","icon":""}}` + "\n",
@@ -43,10 +55,10 @@ func TestUsersHandler_GetUserById(t *testing.T) {
 			inputUserId: 13,
 			mockBehavior: func(s *mockService.MockStatus, userId int) {
 				var ret models.User
-				s.EXPECT().GetUserById(userId).Return(ret, errors.New("some error"))
+				s.EXPECT().GetUserById(gomock.Any(), userId).Return(ret, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"get user error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"get user error"}` + "\n",
 		},
 	}
 
@@ -62,10 +74,12 @@ func TestUsersHandler_GetUserById(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputUserId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/users/:id", nil)
@@ -77,209 +91,81 @@ func TestUsersHandler_GetUserById(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputUserId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetUserById(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetUserById(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
 }
 
 func TestUsersHandler_GetUserLists(t *testing.T) {
-	type mockBehavior func(s *mockService.MockStatus, userId int)
+	type mockBehavior func(s *mockService.MockStatus, userId, offset, limit int)
 
 	testTable := []struct {
 		name                 string
 		inputUserId          int
+		inputQuery           string
+		expectedOffset       int
+		expectedLimit        int
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
 	}{
 		{
-			name:        "Ok",
-			inputUserId: 13,
-			mockBehavior: func(s *mockService.MockStatus, userId int) {
-				friends := []models.User{
-					{
-						Id:       13,
-						Username: "user",
-					},
-					{
-						Id:       2,
-						Username: "friend",
-					},
+			name:          "Ok",
+			inputUserId:   13,
+			expectedLimit: 200,
+			mockBehavior: func(s *mockService.MockStatus, userId, offset, limit int) {
+				lists := models.UserLists{
+					Friends:     []models.User{{Id: 2, Username: "friend"}},
+					Blacklist:   []models.User{{Id: 7, Username: "blocked"}},
+					OnBlacklist: []models.User{{Id: 19, Username: "on block"}},
+					Invites:     []models.User{{Id: 21, Username: "invites"}},
+					Requires:    []models.User{{Id: 23, Username: "requires"}},
 				}
-				s.EXPECT().GetFriends(userId).Return(friends, nil)
-				bl := []models.User{
-					{
-						Id:       7,
-						Username: "blocked",
-					},
-				}
-				s.EXPECT().GetBlackList(userId).Return(bl, nil)
-				onBL := []models.User{
-					{
-						Id:       19,
-						Username: "on block",
-					},
-				}
-				s.EXPECT().GetBlackListToUser(userId).Return(onBL, nil)
-				invites := []models.User{
-					{
-						Id:       21,
-						Username: "invites",
-					},
-				}
-				s.EXPECT().GetSentInvites(userId).Return(invites, nil)
-				requires := []models.User{
-					{
-						Id:       4,
-						Username: "requires",
-					},
-				}
-				s.EXPECT().GetInvites(userId).Return(requires, nil)
-
+				s.EXPECT().GetUserLists(gomock.Any(), userId, offset, limit).Return(lists, nil)
 			},
-			expectedStatusCode:   200,
-			expectedResponseBody: `{"blacklist":[{"id":7,"username":"blocked","password":"","icon":""}],"friends":[{"id":13,"username":"user","password":"","icon":""},{"id":2,"username":"friend","password":"","icon":""}],"invites":[{"id":21,"username":"invites","password":"","icon":""}],"onBlacklist":[{"id":19,"username":"on block","password":"","icon":""}],"requires":[{"id":4,"username":"requires","password":"","icon":""}]}` + "\n",
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"friends":[{"id":2,"username":"friend","password":"","icon":""}],` +
+				`"blacklist":[{"id":7,"username":"blocked","password":"","icon":""}],` +
+				`"onBlacklist":[{"id":19,"username":"on block","password":"","icon":""}],` +
+				`"invites":[{"id":21,"username":"invites","password":"","icon":""}],` +
+				`"requires":[{"id":23,"username":"requires","password":"","icon":""}]}` + "\n",
 		},
 		{
-			name:        "Friends list error",
-			inputUserId: 13,
-			mockBehavior: func(s *mockService.MockStatus, userId int) {
-				var friends []models.User
-				s.EXPECT().GetFriends(userId).Return(friends, errors.New("some error"))
+			name:           "Page",
+			inputUserId:    13,
+			inputQuery:     "?offset=2&limit=1",
+			expectedOffset: 2,
+			expectedLimit:  1,
+			mockBehavior: func(s *mockService.MockStatus, userId, offset, limit int) {
+				lists := models.UserLists{Friends: []models.User{{Id: 2, Username: "friend"}}, NextOffset: 3}
+				s.EXPECT().GetUserLists(gomock.Any(), userId, offset, limit).Return(lists, nil)
 			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"friends list error"}` + "\n",
+			expectedStatusCode: 200,
+			expectedResponseBody: `{"friends":[{"id":2,"username":"friend","password":"","icon":""}],` +
+				`"blacklist":null,"onBlacklist":null,"invites":null,"requires":null,"next_offset":3}` + "\n",
 		},
 		{
-			name:        "Black list error",
-			inputUserId: 13,
-			mockBehavior: func(s *mockService.MockStatus, userId int) {
-				friends := []models.User{
-					{
-						Id:       13,
-						Username: "user",
-					},
-					{
-						Id:       2,
-						Username: "friend",
-					},
-				}
-				s.EXPECT().GetFriends(userId).Return(friends, nil)
-				var bl []models.User
-				s.EXPECT().GetBlackList(userId).Return(bl, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"black list error"}` + "\n",
-		},
-		{
-			name:        "On black list error",
-			inputUserId: 13,
-			mockBehavior: func(s *mockService.MockStatus, userId int) {
-				friends := []models.User{
-					{
-						Id:       13,
-						Username: "user",
-					},
-					{
-						Id:       2,
-						Username: "friend",
-					},
-				}
-				s.EXPECT().GetFriends(userId).Return(friends, nil)
-				bl := []models.User{
-					{
-						Id:       7,
-						Username: "blocked",
-					},
-				}
-				s.EXPECT().GetBlackList(userId).Return(bl, nil)
-				var onBL []models.User
-				s.EXPECT().GetBlackListToUser(userId).Return(onBL, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"on black list error"}` + "\n",
-		},
-		{
-			name:        "Friend invites list error",
-			inputUserId: 13,
-			mockBehavior: func(s *mockService.MockStatus, userId int) {
-				friends := []models.User{
-					{
-						Id:       13,
-						Username: "user",
-					},
-					{
-						Id:       2,
-						Username: "friend",
-					},
-				}
-				s.EXPECT().GetFriends(userId).Return(friends, nil)
-				bl := []models.User{
-					{
-						Id:       7,
-						Username: "blocked",
-					},
-				}
-				s.EXPECT().GetBlackList(userId).Return(bl, nil)
-				onBL := []models.User{
-					{
-						Id:       19,
-						Username: "on block",
-					},
-				}
-				s.EXPECT().GetBlackListToUser(userId).Return(onBL, nil)
-				var invites []models.User
-				s.EXPECT().GetSentInvites(userId).Return(invites, errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"friend invites list error"}` + "\n",
+			name:                 "Invalid page",
+			inputUserId:          13,
+			inputQuery:           "?offset=-1",
+			mockBehavior:         func(s *mockService.MockStatus, userId, offset, limit int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"offset","rule":"min","message":"must be at least 0"}]}` + "\n",
 		},
 		{
-			name:        "Friend requires list error",
-			inputUserId: 13,
-			mockBehavior: func(s *mockService.MockStatus, userId int) {
-				friends := []models.User{
-					{
-						Id:       13,
-						Username: "user",
-					},
-					{
-						Id:       2,
-						Username: "friend",
-					},
-				}
-				s.EXPECT().GetFriends(userId).Return(friends, nil)
-				bl := []models.User{
-					{
-						Id:       7,
-						Username: "blocked",
-					},
-				}
-				s.EXPECT().GetBlackList(userId).Return(bl, nil)
-				onBL := []models.User{
-					{
-						Id:       19,
-						Username: "on block",
-					},
-				}
-				s.EXPECT().GetBlackListToUser(userId).Return(onBL, nil)
-				invites := []models.User{
-					{
-						Id:       21,
-						Username: "invites",
-					},
-				}
-				s.EXPECT().GetSentInvites(userId).Return(invites, nil)
-				var requires []models.User
-				s.EXPECT().GetInvites(userId).Return(requires, errors.New("some error"))
-
+			name:          "Lists error",
+			inputUserId:   13,
+			expectedLimit: 200,
+			mockBehavior: func(s *mockService.MockStatus, userId, offset, limit int) {
+				s.EXPECT().GetUserLists(gomock.Any(), userId, offset, limit).Return(models.UserLists{}, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"friend requires list error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"relationship lists error"}` + "\n",
 		},
 	}
 
@@ -292,17 +178,18 @@ func TestUsersHandler_GetUserLists(t *testing.T) {
 			defer c.Finish()
 
 			status := mockService.NewMockStatus(c)
-			testCase.mockBehavior(status, testCase.inputUserId)
+			testCase.mockBehavior(status, testCase.inputUserId, testCase.expectedOffset, testCase.expectedLimit)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
-			req := httptest.NewRequest(http.MethodGet, "/api/users/:id/all", nil)
-			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req := httptest.NewRequest(http.MethodGet, "/api/users/"+strconv.Itoa(testCase.inputUserId)+"/all"+testCase.inputQuery, nil)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
 			ctx.SetPath("/api/users/:id/all")
@@ -310,10 +197,11 @@ func TestUsersHandler_GetUserLists(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputUserId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.GetUserLists(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.GetUserLists(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -335,32 +223,51 @@ func TestUsersHandler_InvitedToFriends(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "invitation",
-				}
-				res := 2
-				s.EXPECT().AddStatus(status).Return(res, nil)
+				s.EXPECT().Invite(gomock.Any(), senderId, recipientId).Return(2, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"id":2}` + "\n",
 		},
+		{
+			name:             "Invitation already sent",
+			inputSenderId:    13,
+			inputRecipientId: 2,
+			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
+				err := service.ErrInvalidTransition.WithMessage("invitation is already sent")
+				s.EXPECT().Invite(gomock.Any(), senderId, recipientId).Return(0, err)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"invalid_transition","message":"invitation is already sent"}` + "\n",
+		},
+		{
+			name:             "Blocked by user",
+			inputSenderId:    13,
+			inputRecipientId: 2,
+			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
+				s.EXPECT().Invite(gomock.Any(), senderId, recipientId).Return(0, service.ErrBlocked)
+			},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"blocked","message":"user has blocked you"}` + "\n",
+		},
+		{
+			name:             "User not found",
+			inputSenderId:    13,
+			inputRecipientId: 2,
+			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
+				s.EXPECT().Invite(gomock.Any(), senderId, recipientId).Return(0, service.ErrUserNotFound)
+			},
+			expectedStatusCode:   404,
+			expectedResponseBody: `{"code":"user_not_found","message":"user not found"}` + "\n",
+		},
 		{
 			name:             "Add status error",
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "invitation",
-				}
-				res := 0
-				s.EXPECT().AddStatus(status).Return(res, errors.New("some error"))
+				s.EXPECT().Invite(gomock.Any(), senderId, recipientId).Return(0, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"add status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"add status error"}` + "\n",
 		},
 	}
 
@@ -376,10 +283,12 @@ func TestUsersHandler_InvitedToFriends(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodPost, "/api/users/:id/invite", nil)
@@ -392,10 +301,11 @@ func TestUsersHandler_InvitedToFriends(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputRecipientId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.InvitedToFriends(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.InvitedToFriends(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -417,30 +327,31 @@ func TestUsersHandler_CancelInvite(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "invitation",
-				}
-				s.EXPECT().DeleteStatus(status).Return(nil)
+				s.EXPECT().Cancel(gomock.Any(), senderId, recipientId).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"invite deleted"}` + "\n",
 		},
+		{
+			name:             "No invitation",
+			inputSenderId:    13,
+			inputRecipientId: 2,
+			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
+				err := service.ErrInvalidTransition.WithMessage("no invitation to the user")
+				s.EXPECT().Cancel(gomock.Any(), senderId, recipientId).Return(err)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"invalid_transition","message":"no invitation to the user"}` + "\n",
+		},
 		{
 			name:             "Delete status error",
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "invitation",
-				}
-				s.EXPECT().DeleteStatus(status).Return(errors.New("some error"))
+				s.EXPECT().Cancel(gomock.Any(), senderId, recipientId).Return(errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"delete status error"}` + "\n",
 		},
 	}
 
@@ -456,10 +367,12 @@ func TestUsersHandler_CancelInvite(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodDelete, "/api/users/:id/cancel", nil)
@@ -472,10 +385,11 @@ func TestUsersHandler_CancelInvite(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputRecipientId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.CancelInvite(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.CancelInvite(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -497,12 +411,7 @@ func TestUsersHandler_AcceptInvitation(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "friends",
-				}
-				s.EXPECT().UpdateStatus(status).Return(nil)
+				s.EXPECT().Accept(gomock.Any(), recipientId, senderId).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"invitation accepted"}` + "\n",
@@ -512,15 +421,10 @@ func TestUsersHandler_AcceptInvitation(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "friends",
-				}
-				s.EXPECT().UpdateStatus(status).Return(errors.New("some error"))
+				s.EXPECT().Accept(gomock.Any(), recipientId, senderId).Return(errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"update status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"update status error"}` + "\n",
 		},
 	}
 
@@ -536,10 +440,12 @@ func TestUsersHandler_AcceptInvitation(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodPut, "/api/users/:id/accept", nil)
@@ -552,10 +458,11 @@ func TestUsersHandler_AcceptInvitation(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputSenderId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.AcceptInvitation(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.AcceptInvitation(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -577,12 +484,7 @@ func TestUsersHandler_RefuseInvitation(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "invitation",
-				}
-				s.EXPECT().DeleteStatus(status).Return(nil)
+				s.EXPECT().Refuse(gomock.Any(), recipientId, senderId).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"invitation refused"}` + "\n",
@@ -592,15 +494,10 @@ func TestUsersHandler_RefuseInvitation(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "invitation",
-				}
-				s.EXPECT().DeleteStatus(status).Return(errors.New("some error"))
+				s.EXPECT().Refuse(gomock.Any(), recipientId, senderId).Return(errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"delete status error"}` + "\n",
 		},
 	}
 
@@ -616,10 +513,12 @@ func TestUsersHandler_RefuseInvitation(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodDelete, "/api/users/:id/refuse", nil)
@@ -632,10 +531,11 @@ func TestUsersHandler_RefuseInvitation(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputSenderId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.RefuseInvitation(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.RefuseInvitation(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -657,15 +557,7 @@ func TestUsersHandler_DeleteFriend(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "friends",
-				}
-				s.EXPECT().DeleteStatus(status).Return(errors.New("record not found"))
-				status.SenderId = recipientId
-				status.RecipientId = senderId
-				s.EXPECT().DeleteStatus(status).Return(nil)
+				s.EXPECT().Unfriend(gomock.Any(), senderId, recipientId).Return(nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"message":"friend deleted"}` + "\n",
@@ -675,33 +567,10 @@ func TestUsersHandler_DeleteFriend(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "friends",
-				}
-				s.EXPECT().DeleteStatus(status).Return(errors.New("some error"))
-			},
-			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete status error"}` + "\n",
-		},
-		{
-			name:             "Second try delete status error",
-			inputSenderId:    13,
-			inputRecipientId: 2,
-			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "friends",
-				}
-				s.EXPECT().DeleteStatus(status).Return(errors.New("record not found"))
-				status.SenderId = recipientId
-				status.RecipientId = senderId
-				s.EXPECT().DeleteStatus(status).Return(errors.New("some error"))
+				s.EXPECT().Unfriend(gomock.Any(), senderId, recipientId).Return(errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"delete status error"}` + "\n",
 		},
 	}
 
@@ -717,10 +586,12 @@ func TestUsersHandler_DeleteFriend(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodDelete, "/api/users/:id/refuse", nil)
@@ -733,10 +604,11 @@ func TestUsersHandler_DeleteFriend(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputRecipientId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.DeleteFriend(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.DeleteFriend(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
 
@@ -752,38 +624,39 @@ func TestUsersHandler_AddToBlackList(t *testing.T) {
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
+		expectedChanged      []int
 	}{
 		{
 			name:             "Ok",
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "black_list",
-				}
-				res := 2
-				s.EXPECT().AddStatus(status).Return(res, nil)
+				s.EXPECT().Block(gomock.Any(), senderId, recipientId).Return(2, nil)
 			},
 			expectedStatusCode:   200,
+			expectedChanged:      []int{2},
 			expectedResponseBody: `{"id":2}` + "\n",
 		},
+		{
+			name:             "Already blocked",
+			inputSenderId:    13,
+			inputRecipientId: 2,
+			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
+				err := service.ErrInvalidTransition.WithMessage("user is already blocked")
+				s.EXPECT().Block(gomock.Any(), senderId, recipientId).Return(0, err)
+			},
+			expectedStatusCode:   409,
+			expectedResponseBody: `{"code":"invalid_transition","message":"user is already blocked"}` + "\n",
+		},
 		{
 			name:             "Add status error",
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "black_list",
-				}
-				res := 0
-				s.EXPECT().AddStatus(status).Return(res, errors.New("some error"))
+				s.EXPECT().Block(gomock.Any(), senderId, recipientId).Return(0, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"add status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"add status error"}` + "\n",
 		},
 	}
 
@@ -799,10 +672,13 @@ func TestUsersHandler_AddToBlackList(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			changes := &blocks{}
+			handler := NewUsersHandler(services, changes)
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodPost, "/api/users/:id/addToBL", nil)
@@ -815,10 +691,12 @@ func TestUsersHandler_AddToBlackList(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputRecipientId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.AddToBlackList(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.AddToBlackList(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedChanged, changes.changed)
 		})
 	}
 
@@ -834,20 +712,17 @@ func TestUsersHandler_DeleteFromBlacklist(t *testing.T) {
 		mockBehavior         mockBehavior
 		expectedStatusCode   int
 		expectedResponseBody string
+		expectedChanged      []int
 	}{
 		{
 			name:             "Ok",
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "black_list",
-				}
-				s.EXPECT().DeleteStatus(status).Return(nil)
+				s.EXPECT().Unblock(gomock.Any(), senderId, recipientId).Return(nil)
 			},
 			expectedStatusCode:   200,
+			expectedChanged:      []int{2},
 			expectedResponseBody: `{"message":"user deleted from black list"}` + "\n",
 		},
 		{
@@ -855,15 +730,10 @@ func TestUsersHandler_DeleteFromBlacklist(t *testing.T) {
 			inputSenderId:    13,
 			inputRecipientId: 2,
 			mockBehavior: func(s *mockService.MockStatus, senderId, recipientId int) {
-				status := models.Status{
-					SenderId:     senderId,
-					RecipientId:  recipientId,
-					Relationship: "black_list",
-				}
-				s.EXPECT().DeleteStatus(status).Return(errors.New("some error"))
+				s.EXPECT().Unblock(gomock.Any(), senderId, recipientId).Return(errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"delete status error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"delete status error"}` + "\n",
 		},
 	}
 
@@ -879,10 +749,13 @@ func TestUsersHandler_DeleteFromBlacklist(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputSenderId, testCase.inputRecipientId)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			changes := &blocks{}
+			handler := NewUsersHandler(services, changes)
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodDelete, "/api/users/:id/deleteFromBlacklist", nil)
@@ -895,10 +768,12 @@ func TestUsersHandler_DeleteFromBlacklist(t *testing.T) {
 			ctx.SetParamValues(strconv.Itoa(testCase.inputRecipientId))
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.DeleteFromBlacklist(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.DeleteFromBlacklist(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			assert.Equal(t, testCase.expectedChanged, changes.changed)
 		})
 	}
 
@@ -928,7 +803,7 @@ func TestUsersHandler_SearchUser(t *testing.T) {
 						Username: "fifth",
 					},
 				}
-				s.EXPECT().SearchUser(name).Return(ret, nil)
+				s.EXPECT().SearchUser(gomock.Any(), 1, name).Return(ret, nil)
 			},
 			expectedStatusCode:   200,
 			expectedResponseBody: `{"list":[{"id":3,"username":"first","password":"","icon":""},{"id":6,"username":"fifth","password":"","icon":""}]}` + "\n",
@@ -938,10 +813,10 @@ func TestUsersHandler_SearchUser(t *testing.T) {
 			inputName: "fi",
 			mockBehavior: func(s *mockService.MockStatus, name string) {
 				var ret []models.User
-				s.EXPECT().SearchUser(name).Return(ret, errors.New("some error"))
+				s.EXPECT().SearchUser(gomock.Any(), 1, name).Return(ret, errors.New("some error"))
 			},
 			expectedStatusCode:   500,
-			expectedResponseBody: `{"message":"search users error"}` + "\n",
+			expectedResponseBody: `{"code":"internal","message":"search users error"}` + "\n",
 		},
 	}
 
@@ -957,26 +832,215 @@ func TestUsersHandler_SearchUser(t *testing.T) {
 			testCase.mockBehavior(status, testCase.inputName)
 
 			services := &service.Service{Status: status}
-			handler := NewUsersHandler(services)
+			handler := NewUsersHandler(services, &blocks{})
 
 			//Тестовий сервер
 			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
 
 			//Тестовий запит
 			req := httptest.NewRequest(http.MethodGet, "/api/users/search/:username", nil)
 			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 			rec := httptest.NewRecorder()
 			ctx := e.NewContext(req, rec)
+			ctx.Set(middlewares.UserCtx, 1)
 			ctx.SetPath("/api/users/search/:username")
 			ctx.SetParamNames("username")
 			ctx.SetParamValues(testCase.inputName)
 
 			//Перевірка результатів
-			if assert.NoError(t, handler.SearchUser(ctx)) {
-				assert.Equal(t, testCase.expectedStatusCode, rec.Code)
-				assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+			if err := handler.SearchUser(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
+
+}
+
+func TestUsersHandler_GetPresence(t *testing.T) {
+	type mockBehavior func(s *mockService.MockSettings, viewerId, userId int)
+
+	seen := time.Date(2022, 5, 1, 12, 30, 0, 0, time.UTC)
+	testTable := []struct {
+		name                 string
+		inputViewerId        int
+		inputUserId          string
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:          "ok",
+			inputViewerId: 1,
+			inputUserId:   "13",
+			mockBehavior: func(s *mockService.MockSettings, viewerId, userId int) {
+				s.EXPECT().GetPresence(gomock.Any(), viewerId, userId).Return(models.Presence{UserId: userId, LastSeenAt: &seen}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"user_id":13,"last_seen_at":"2022-05-01T12:30:00Z"}` + "\n",
+		},
+		{
+			name:          "Hidden",
+			inputViewerId: 1,
+			inputUserId:   "13",
+			mockBehavior: func(s *mockService.MockSettings, viewerId, userId int) {
+				s.EXPECT().GetPresence(gomock.Any(), viewerId, userId).Return(models.Presence{UserId: userId}, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"user_id":13,"last_seen_at":null}` + "\n",
+		},
+		{
+			name:                 "Incorrect user ID",
+			inputViewerId:        1,
+			inputUserId:          "user",
+			mockBehavior:         func(s *mockService.MockSettings, viewerId, userId int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"invalid_param","message":"incorrect id parameter"}` + "\n",
+		},
+		{
+			name:          "Get presence error",
+			inputViewerId: 1,
+			inputUserId:   "13",
+			mockBehavior: func(s *mockService.MockSettings, viewerId, userId int) {
+				s.EXPECT().GetPresence(gomock.Any(), viewerId, userId).Return(models.Presence{}, errors.New("some error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"get presence error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			settings := mockService.NewMockSettings(c)
+			userId, _ := strconv.Atoi(testCase.inputUserId)
+			testCase.mockBehavior(settings, testCase.inputViewerId, userId)
+
+			services := &service.Service{Settings: settings}
+			handler := NewUsersHandler(services, &blocks{})
+
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users/:id/presence", nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/api/users/:id/presence")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(testCase.inputUserId)
+			ctx.Set(middlewares.UserCtx, testCase.inputViewerId)
+
+			if err := handler.GetPresence(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
 			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
 		})
 	}
+}
 
+func TestUsersHandler_GetSuggestions(t *testing.T) {
+	type mockBehavior func(s *mockService.MockStatus, userId, limit int)
+
+	testTable := []struct {
+		name                 string
+		inputUserId          int
+		inputParam           string
+		inputQuery           string
+		expectedLimit        int
+		mockBehavior         mockBehavior
+		expectedStatusCode   int
+		expectedResponseBody string
+	}{
+		{
+			name:          "ok",
+			inputUserId:   1,
+			inputParam:    "1",
+			expectedLimit: 20,
+			mockBehavior: func(s *mockService.MockStatus, userId, limit int) {
+				ret := []models.Suggestion{{User: models.User{Id: 3, Username: "user"}, MutualFriends: 2, SharedChats: 1}}
+				s.EXPECT().GetSuggestions(gomock.Any(), userId, limit).Return(ret, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"list":[{"id":3,"username":"user","password":"","icon":"","mutual_friends":2,"shared_chats":1}]}` + "\n",
+		},
+		{
+			name:          "Custom limit",
+			inputUserId:   1,
+			inputParam:    "1",
+			inputQuery:    "?limit=5",
+			expectedLimit: 5,
+			mockBehavior: func(s *mockService.MockStatus, userId, limit int) {
+				s.EXPECT().GetSuggestions(gomock.Any(), userId, limit).Return(nil, nil)
+			},
+			expectedStatusCode:   200,
+			expectedResponseBody: `{"list":null}` + "\n",
+		},
+		{
+			name:                 "Invalid limit",
+			inputUserId:          1,
+			inputParam:           "1",
+			inputQuery:           "?limit=100",
+			mockBehavior:         func(s *mockService.MockStatus, userId, limit int) {},
+			expectedStatusCode:   400,
+			expectedResponseBody: `{"code":"validation_failed","message":"request validation failed","fields":[{"field":"limit","rule":"max","message":"must be at most 50"}]}` + "\n",
+		},
+		{
+			name:                 "Another user",
+			inputUserId:          1,
+			inputParam:           "2",
+			mockBehavior:         func(s *mockService.MockStatus, userId, limit int) {},
+			expectedStatusCode:   403,
+			expectedResponseBody: `{"code":"forbidden","message":"suggestions of another user"}` + "\n",
+		},
+		{
+			name:          "Suggestions error",
+			inputUserId:   1,
+			inputParam:    "1",
+			expectedLimit: 20,
+			mockBehavior: func(s *mockService.MockStatus, userId, limit int) {
+				s.EXPECT().GetSuggestions(gomock.Any(), userId, limit).Return(nil, errors.New("some error"))
+			},
+			expectedStatusCode:   500,
+			expectedResponseBody: `{"code":"internal","message":"suggestions error"}` + "\n",
+		},
+	}
+
+	for _, testCase := range testTable {
+		t.Run(testCase.name, func(t *testing.T) {
+
+			c := gomock.NewController(t)
+			defer c.Finish()
+
+			status := mockService.NewMockStatus(c)
+			testCase.mockBehavior(status, testCase.inputUserId, testCase.expectedLimit)
+
+			services := &service.Service{Status: status}
+			handler := NewUsersHandler(services, &blocks{})
+
+			e := echo.New()
+			e.HTTPErrorHandler = responses.ErrorHandler(false)
+			e.Validator = validation.Validator{}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/users/"+testCase.inputParam+"/suggestions"+testCase.inputQuery, nil)
+			rec := httptest.NewRecorder()
+			ctx := e.NewContext(req, rec)
+			ctx.SetPath("/api/users/:id/suggestions")
+			ctx.SetParamNames("id")
+			ctx.SetParamValues(testCase.inputParam)
+			ctx.Set(middlewares.UserCtx, testCase.inputUserId)
+
+			if err := handler.GetSuggestions(ctx); err != nil {
+				e.HTTPErrorHandler(err, ctx)
+			}
+			assert.Equal(t, testCase.expectedStatusCode, rec.Code)
+			assert.Equal(t, testCase.expectedResponseBody, rec.Body.String())
+		})
+	}
 }
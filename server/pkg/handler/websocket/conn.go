@@ -7,59 +7,60 @@ import (
 	"time"
 )
 
-const (
-	// Time allowed to write a message to the peer.
-	writeWait = 10 * time.Second
-
-	// Time allowed to read the next pong message from the peer.
-	pongWait = 60 * time.Second
-
-	// Send pings to peer with this period. Must be less than pongWait.
-	pingPeriod = (pongWait * 9) / 10
-
-	// Maximum message size allowed from peer.
-	maxMessageSize = 512
-)
-
 var upgrader = websocket.Upgrader{
 	ReadBufferSize:  1024,
 	WriteBufferSize: 1024,
 }
 
 type connection struct {
-	ws   *websocket.Conn
-	send chan []byte
+	hub    *Hub
+	ws     *websocket.Conn
+	send   chan []byte
+	userId int
+	// hidden - ID користувачів, що заблокували userId. Список читається під
+	// час підключення та перечитується лише після сигналу refresh
+	hidden  map[int]bool
+	refresh chan struct{}
 }
 
-var Hub = NewHub(H)
-
-func ServeWs(w http.ResponseWriter, r *http.Request, roomId string) {
+// ServeWs підключає користувача userId до кімнати roomId. Членство
+// користувача в чаті кімнати перевіряє викликач
+func (h *Hub) ServeWs(w http.ResponseWriter, r *http.Request, roomId string, userId int) {
 	upgrader.CheckOrigin = func(r *http.Request) bool {
 		return true
 	}
+	// Без списку блокувань повідомлення користувача могли б отримати ті,
+	// хто його заблокував
+	hidden, err := h.blockers(userId)
+	if err != nil {
+		log.Printf("error : %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
 	ws, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Println(err.Error())
 		return
 	}
-	c := &connection{send: make(chan []byte, 256), ws: ws}
+	c := &connection{hub: h, send: make(chan []byte, 256), ws: ws, userId: userId, hidden: hidden, refresh: make(chan struct{}, 1)}
 	s := subscription{c, roomId}
 
-	Hub.register <- s
+	h.register <- s
 	go s.writePump()
 	go s.readPump()
 }
 
 func (s subscription) readPump() {
 	c := s.conn
+	cnf := c.hub.cnf
 	defer func() {
-		Hub.unregister <- s
+		c.hub.unregister <- s
 		c.ws.Close()
 	}()
-	c.ws.SetReadLimit(maxMessageSize)
-	c.ws.SetReadDeadline(time.Now().Add(pongWait))
+	c.ws.SetReadLimit(cnf.MaxMessageSize)
+	c.ws.SetReadDeadline(time.Now().Add(cnf.PongWait))
 	c.ws.SetPongHandler(func(string) error {
-		c.ws.SetReadDeadline(time.Now().Add(pongWait))
+		c.ws.SetReadDeadline(time.Now().Add(cnf.PongWait))
 		return nil
 	})
 	for {
@@ -70,14 +71,29 @@ func (s subscription) readPump() {
 			}
 			break
 		}
-		m := message{msg, s.room}
-		Hub.broadcast <- m
+		// Після зміни блокувань список перечитується, а без нього
+		// повідомлення не надсилається, щоб не доставити його тим, хто
+		// заблокував автора
+		select {
+		case <-c.refresh:
+			hidden, err := c.hub.blockers(c.userId)
+			if err != nil {
+				log.Printf("error : %v", err)
+				c.signal()
+				continue
+			}
+			c.hidden = hidden
+		default:
+		}
+		m := message{msg, s.room, c.hidden}
+		c.hub.broadcast <- m
 	}
 }
 
 func (s subscription) writePump() {
 	c := s.conn
-	ticker := time.NewTicker(pingPeriod)
+	// Ping надсилається частіше, ніж спливає час очікування pong
+	ticker := time.NewTicker(c.hub.cnf.PongWait * 9 / 10)
 	defer func() {
 		ticker.Stop()
 		c.ws.Close()
@@ -99,7 +115,17 @@ func (s subscription) writePump() {
 		}
 	}
 }
+
+// signal просить з'єднання перечитати список блокувань. Сигнал не
+// блокує: якщо попередній ще не оброблено, нового не потрібно
+func (c *connection) signal() {
+	select {
+	case c.refresh <- struct{}{}:
+	default:
+	}
+}
+
 func (c *connection) write(mt int, payload []byte) error {
-	c.ws.SetWriteDeadline(time.Now().Add(writeWait))
+	c.ws.SetWriteDeadline(time.Now().Add(c.hub.cnf.WriteWait))
 	return c.ws.WriteMessage(mt, payload)
 }
@@ -1,34 +1,211 @@
 package websocket
 
+import (
+	"cmd/pkg/config"
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"encoding/json"
+	"log"
+	"strconv"
+)
+
 type subscription struct {
 	conn *connection
 	room string
 }
 
-type hub struct {
-	rooms      map[string]map[*connection]bool
+// Blacklist повертає користувачів, що ЗАБЛОКУВАЛИ userId
+type Blacklist interface {
+	GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error)
+}
+
+// Hub розсилає повідомлення усім з'єднанням кімнати, крім з'єднань
+// користувачів, що заблокували автора повідомлення, а події окремим
+// користувачам - усім їх з'єднанням
+type Hub struct {
+	cnf       config.Websocket
+	blacklist Blacklist
+	rooms     map[string]map[*connection]bool
+	// users - кімнати з'єднань за ID користувача
+	users      map[int]map[*connection]string
 	broadcast  chan message
+	direct     chan direct
 	register   chan subscription
 	unregister chan subscription
+	// refresh - ID користувачів, список блокувань яких змінився
+	refresh chan int
 }
 
-func NewHub(hub hub) *hub {
-	return &hub
-}
-
-var H = hub{
-	broadcast:  make(chan message),
-	register:   make(chan subscription),
-	unregister: make(chan subscription),
-	rooms:      make(map[string]map[*connection]bool),
+func NewHub(cnf config.Websocket, blacklist Blacklist) *Hub {
+	return &Hub{
+		cnf:        cnf,
+		blacklist:  blacklist,
+		broadcast:  make(chan message),
+		direct:     make(chan direct),
+		register:   make(chan subscription),
+		unregister: make(chan subscription),
+		refresh:    make(chan int, 256),
+		rooms:      make(map[string]map[*connection]bool),
+		users:      make(map[int]map[*connection]string),
+	}
 }
 
 type message struct {
 	data []byte
 	room string
+	// hidden - ID користувачів, яким повідомлення не надсилається
+	hidden map[int]bool
+}
+
+// direct - подія для окремих користувачів
+type direct struct {
+	data    []byte
+	userIds []int
+}
+
+// blockers повертає ID користувачів, що заблокували userId. Запит
+// обмежено часом на запис повідомлення
+func (h *Hub) blockers(userId int) (map[int]bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), h.cnf.WriteWait)
+	defer cancel()
+	users, err := h.blacklist.GetBlackListToUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	hidden := make(map[int]bool, len(users))
+	for _, user := range users {
+		hidden[user.Id] = true
+	}
+	return hidden, nil
+}
+
+const (
+	// EventUserUpdated - співрозмовник змінив ім'я чи зображення
+	EventUserUpdated = "user_updated"
+	// EventMemberJoined - користувач приєднався до чату
+	EventMemberJoined = "member_joined"
+	// EventJoinRequested - користувач подав заявку на вступ до чату.
+	// Надсилається адміністраторам чату
+	EventJoinRequested = "join_requested"
+	// EventJoinApproved та EventJoinRejected - рішення щодо заявки на вступ.
+	// Надсилаються автору заявки
+	EventJoinApproved = "join_approved"
+	EventJoinRejected = "join_rejected"
+)
+
+// Event - подія сервера, що надсилається клієнтам кімнати чату у форматі
+// JSON. Клієнти відрізняють її від власних повідомлень за полем type
+type Event struct {
+	Type string `json:"type"`
+	// ChatId - чат події. Подія, що стосується кількох чатів (наприклад,
+	// user_updated), надсилається без нього
+	ChatId int         `json:"chat_id,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// Blocks повідомляє з'єднання про зміну блокувань
+type Blocks interface {
+	// BlocksChanged просить усі з'єднання userId перечитати список тих,
+	// хто його заблокував
+	BlocksChanged(userId int)
+}
+
+// BlocksChanged просить усі з'єднання userId перечитати список тих, хто
+// його заблокував, перед наступним повідомленням
+func (h *Hub) BlocksChanged(userId int) {
+	h.refresh <- userId
+}
+
+// Publisher надсилає події сервера клієнтам кімнат чатів
+type Publisher interface {
+	// Publish надсилає подію, спричинену userId, усім, хто підключений до
+	// кімнати чату event.ChatId, крім тих, хто заблокував userId
+	Publish(userId int, event Event)
+	// Notify надсилає подію, спричинену userId, усім з'єднанням користувачів
+	// recipients незалежно від кімнати, крім тих, хто заблокував userId.
+	// Виклик не чекає на доставку
+	Notify(userId int, recipients []int, event Event)
+}
+
+// PublishJoin повідомляє учасників чату про нового учасника, а
+// адміністраторів, яких повертає admins, - про нову заявку на вступ.
+// Помилка не скасовує вступ
+func PublishJoin(ctx context.Context, events Publisher, admins func(ctx context.Context, chatId int) ([]int, error), join models.ChatJoin) {
+	switch join.Status {
+	case repository.JoinJoined:
+		events.Publish(join.UserId, Event{Type: EventMemberJoined, ChatId: join.ChatId, Data: join})
+	case repository.JoinPending:
+		recipients, err := admins(ctx, join.ChatId)
+		if err != nil {
+			log.Printf("error : %v", err)
+			return
+		}
+		events.Notify(join.UserId, recipients, Event{Type: EventJoinRequested, ChatId: join.ChatId, Data: join})
+	}
 }
 
-func (h *hub) Run() {
+// Publish надсилає подію, спричинену userId, кімнаті чату event.ChatId.
+// Користувачі, що заблокували userId, її не отримують. Помилки лише
+// записуються до журналу: подія не скасовує зміну, про яку повідомляє
+func (h *Hub) Publish(userId int, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("error : %v", err)
+		return
+	}
+	hidden, err := h.blockers(userId)
+	if err != nil {
+		log.Printf("error : %v", err)
+		return
+	}
+	h.broadcast <- message{data, strconv.Itoa(event.ChatId), hidden}
+}
+
+// Notify надсилає подію, спричинену userId, усім з'єднанням користувачів
+// recipients, крім тих, хто заблокував userId. Список блокувань читається
+// та подія надсилається у фоні, тож запит, що спричинив подію, не чекає
+// на хаб. Помилки лише записуються до журналу
+func (h *Hub) Notify(userId int, recipients []int, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("error : %v", err)
+		return
+	}
+	go func() {
+		hidden, err := h.blockers(userId)
+		if err != nil {
+			log.Printf("error : %v", err)
+			return
+		}
+		var userIds []int
+		for _, id := range recipients {
+			if !hidden[id] {
+				userIds = append(userIds, id)
+			}
+		}
+		h.direct <- direct{data, userIds}
+	}()
+}
+
+// remove відключає з'єднання від кімнати та закриває його канал
+func (h *Hub) remove(room string, c *connection) {
+	connections := h.rooms[room]
+	if _, ok := connections[c]; !ok {
+		return
+	}
+	delete(connections, c)
+	close(c.send)
+	if len(connections) == 0 {
+		delete(h.rooms, room)
+	}
+	delete(h.users[c.userId], c)
+	if len(h.users[c.userId]) == 0 {
+		delete(h.users, c.userId)
+	}
+}
+
+func (h *Hub) Run() {
 	for {
 		select {
 		case s := <-h.register:
@@ -38,27 +215,35 @@ func (h *hub) Run() {
 				h.rooms[s.room] = connections
 			}
 			h.rooms[s.room][s.conn] = true
-		case s := <-h.unregister:
-			connections := h.rooms[s.room]
-			if connections != nil {
-				if _, ok := connections[s.conn]; ok {
-					delete(connections, s.conn)
-					close(s.conn.send)
-					if len(connections) == 0 {
-						delete(h.rooms, s.room)
-					}
-				}
+			if h.users[s.conn.userId] == nil {
+				h.users[s.conn.userId] = make(map[*connection]string)
 			}
+			h.users[s.conn.userId][s.conn] = s.room
+		case s := <-h.unregister:
+			h.remove(s.room, s.conn)
 		case m := <-h.broadcast:
 			connections := h.rooms[m.room]
 			for c := range connections {
+				if m.hidden[c.userId] {
+					continue
+				}
 				select {
 				case c.send <- m.data:
 				default:
-					close(c.send)
-					delete(connections, c)
-					if len(connections) == 0 {
-						delete(h.rooms, m.room)
+					h.remove(m.room, c)
+				}
+			}
+		case userId := <-h.refresh:
+			for c := range h.users[userId] {
+				c.signal()
+			}
+		case d := <-h.direct:
+			for _, userId := range d.userIds {
+				for c, room := range h.users[userId] {
+					select {
+					case c.send <- d.data:
+					default:
+						h.remove(room, c)
 					}
 				}
 			}
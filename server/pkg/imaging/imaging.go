@@ -0,0 +1,206 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"net/http"
+
+	"github.com/muesli/smartcrop"
+	"github.com/muesli/smartcrop/nfnt"
+	"github.com/nfnt/resize"
+	"golang.org/x/image/webp"
+)
+
+const (
+	// MaxPixels обмежує кількість пікселів зображення, щоб розпакування
+	// маленького файлу не зайняло всю пам'ять сервера
+	MaxPixels = 25_000_000
+	// MaxSide обмежує довжину будь-якої сторони зображення
+	MaxSide = 10_000
+
+	thumbnailSize = 100
+	jpegQuality   = 90
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+)
+
+// DefaultSizes розміри квадратних копій зображення за замовчуванням
+var DefaultSizes = []int{32, 64, 128, 512}
+
+var (
+	// ErrUnsupportedFormat повертається, якщо вміст файлу не є зображенням підтримуваного формату
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrTooLarge повертається, якщо розміри зображення перевищують ліміти
+	ErrTooLarge = errors.New("image dimensions are too large")
+	// ErrCorrupt повертається, якщо зображення не вдалося розкодувати
+	ErrCorrupt = errors.New("corrupt image")
+)
+
+// Result містить оброблене зображення
+type Result struct {
+	// Format формат, у якому закодовано Original та Thumbnail
+	Format string
+	// Original повністю перекодоване зображення без метаданих
+	Original []byte
+	// Thumbnail зменшена квадратна копія зображення (100px) для старих клієнтів
+	Thumbnail []byte
+	// Renditions квадратні копії зображення за їх розміром
+	Renditions map[int][]byte
+}
+
+// Ext повертає розширення файлу для формату результату
+func (r Result) Ext() string {
+	return "." + r.Format
+}
+
+// Process визначає формат за вмістом файлу, перевіряє розміри зображення
+// до розкодування, повертає зображення у правильну орієнтацію та
+// перекодовує його, відкидаючи метадані (EXIF, GPS). Для кожного розміру
+// з sizes створюється квадратна копія
+func Process(data []byte, sizes []int) (Result, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Перевіряємо розміри за заголовком, не розкодовуючи пікселі
+	cfg, err := decodeConfig(format, data)
+	if err != nil {
+		return Result{}, ErrCorrupt
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return Result{}, ErrCorrupt
+	}
+	if cfg.Width > MaxSide || cfg.Height > MaxSide || cfg.Width*cfg.Height > MaxPixels {
+		return Result{}, ErrTooLarge
+	}
+
+	img, err := decode(format, data)
+	if err != nil {
+		return Result{}, ErrCorrupt
+	}
+	if format == FormatJPEG {
+		img = Orient(img, Orientation(data))
+	}
+
+	// GIF та WebP зберігаємо як PNG: анімація для аватарів не потрібна,
+	// а кодувальника WebP у стандартній бібліотеці немає
+	out := format
+	if out == FormatGIF || out == FormatWebP {
+		out = FormatPNG
+	}
+
+	res := Result{Format: out, Renditions: make(map[int][]byte, len(sizes))}
+	if res.Original, err = encode(out, img); err != nil {
+		return Result{}, err
+	}
+
+	// Область обрізання обирається один раз для усіх копій
+	square := Square(img)
+	if res.Thumbnail, err = encode(out, Resize(square, thumbnailSize)); err != nil {
+		return Result{}, err
+	}
+	for _, size := range sizes {
+		if res.Renditions[size], err = encode(out, Resize(square, size)); err != nil {
+			return Result{}, err
+		}
+	}
+	return res, nil
+}
+
+// Detect визначає формат зображення за першими байтами файлу
+func Detect(data []byte) (string, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return FormatJPEG, nil
+	case "image/png":
+		return FormatPNG, nil
+	case "image/gif":
+		return FormatGIF, nil
+	case "image/webp":
+		return FormatWebP, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Square обирає найцікавішу квадратну область зображення
+func Square(img image.Image) image.Image {
+	side := img.Bounds().Dx()
+	if img.Bounds().Dy() < side {
+		side = img.Bounds().Dy()
+	}
+	analyzer := smartcrop.NewAnalyzer(nfnt.NewDefaultResizer())
+	crop, err := analyzer.FindBestCrop(img, side, side)
+	if err != nil {
+		return img
+	}
+	return toNRGBA(img).SubImage(crop)
+}
+
+// Resize зменшує зображення до ширини size (або залишає як є, якщо
+// зображення менше)
+func Resize(img image.Image, size int) image.Image {
+	width := uint(math.Min(float64(size), float64(img.Bounds().Dx())))
+	return resize.Resize(width, 0, img, resize.Lanczos3)
+}
+
+func decodeConfig(format string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.DecodeConfig(r)
+	case FormatPNG:
+		return png.DecodeConfig(r)
+	case FormatWebP:
+		return webp.DecodeConfig(r)
+	default:
+		return gif.DecodeConfig(r)
+	}
+}
+
+func decode(format string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch format {
+	case FormatJPEG:
+		return jpeg.Decode(r)
+	case FormatPNG:
+		return png.Decode(r)
+	case FormatWebP:
+		return webp.Decode(r)
+	default:
+		return gif.Decode(r)
+	}
+}
+
+func encode(format string, img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if format == FormatPNG {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return buf.Bytes(), err
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if n, ok := img.(*image.NRGBA); ok {
+		return n
+	}
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
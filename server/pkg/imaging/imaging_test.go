@@ -0,0 +1,169 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 10), G: uint8(y * 10), B: 100, A: 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+// withExif вставляє після SOI сегмент APP1 з тегом Orientation
+func withExif(t *testing.T, img image.Image, orientation uint16) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, nil))
+	data := buf.Bytes()
+
+	tiff := []byte{'I', 'I', 0x2A, 0, 8, 0, 0, 0, 1, 0}
+	entry := make([]byte, 12)
+	binary.LittleEndian.PutUint16(entry[0:], orientationTag)
+	binary.LittleEndian.PutUint16(entry[2:], 3)
+	binary.LittleEndian.PutUint32(entry[4:], 1)
+	binary.LittleEndian.PutUint16(entry[8:], orientation)
+	tiff = append(tiff, entry...)
+	tiff = append(tiff, 0, 0, 0, 0)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func TestDetect(t *testing.T) {
+	var gifBuf bytes.Buffer
+	require.NoError(t, gif.Encode(&gifBuf, testImage(4, 4), nil))
+
+	format, err := Detect(encodePNG(t, testImage(4, 4)))
+	require.NoError(t, err)
+	assert.Equal(t, FormatPNG, format)
+
+	format, err = Detect(gifBuf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, FormatGIF, format)
+
+	_, err = Detect([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestProcess_Png(t *testing.T) {
+	res, err := Process(encodePNG(t, testImage(200, 120)), []int{32, 64, 512})
+	require.NoError(t, err)
+	assert.Equal(t, FormatPNG, res.Format)
+	assert.Equal(t, ".png", res.Ext())
+
+	thumb, err := png.Decode(bytes.NewReader(res.Thumbnail))
+	require.NoError(t, err)
+	assert.Equal(t, 100, thumb.Bounds().Dx())
+	assert.Equal(t, 100, thumb.Bounds().Dy())
+
+	// Копії квадратні, а більші за зображення не збільшуються
+	require.Len(t, res.Renditions, 3)
+	for size, want := range map[int]int{32: 32, 64: 64, 512: 120} {
+		img, err := png.Decode(bytes.NewReader(res.Renditions[size]))
+		require.NoError(t, err)
+		assert.Equal(t, want, img.Bounds().Dx())
+		assert.Equal(t, want, img.Bounds().Dy())
+	}
+}
+
+// Найменший WebP файл без втрат (1x1 прозорий піксель)
+var tinyWebP = []byte("RIFF\x1a\x00\x00\x00WEBPVP8L\x0d\x00\x00\x00\x2f\x00\x00\x00\x10\x07\x10\x11\x11\x88\x88\xfe\x07\x00")
+
+func TestProcess_WebP(t *testing.T) {
+	format, err := Detect(tinyWebP)
+	require.NoError(t, err)
+	assert.Equal(t, FormatWebP, format)
+
+	res, err := Process(tinyWebP, []int{32})
+	require.NoError(t, err)
+	assert.Equal(t, FormatPNG, res.Format)
+	assert.Len(t, res.Renditions, 1)
+}
+
+func TestProcess_Gif(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, gif.Encode(&buf, testImage(30, 30), nil))
+
+	res, err := Process(buf.Bytes(), DefaultSizes)
+	require.NoError(t, err)
+	assert.Equal(t, FormatPNG, res.Format)
+}
+
+func TestProcess_DecompressionBomb(t *testing.T) {
+	data := encodePNG(t, testImage(4, 4))
+
+	// Підміняємо розміри у заголовку IHDR та перераховуємо контрольну суму
+	binary.BigEndian.PutUint32(data[16:], 50000)
+	binary.BigEndian.PutUint32(data[20:], 50000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+
+	_, err := Process(data, DefaultSizes)
+	assert.ErrorIs(t, err, ErrTooLarge)
+}
+
+func TestProcess_Corrupt(t *testing.T) {
+	data := encodePNG(t, testImage(4, 4))
+	_, err := Process(data[:len(data)/2], nil)
+	assert.ErrorIs(t, err, ErrCorrupt)
+}
+
+func TestProcess_Orientation(t *testing.T) {
+	data := withExif(t, testImage(40, 20), 6)
+	assert.Equal(t, 6, Orientation(data))
+
+	res, err := Process(data, DefaultSizes)
+	require.NoError(t, err)
+	assert.Equal(t, FormatJPEG, res.Format)
+
+	img, err := jpeg.Decode(bytes.NewReader(res.Original))
+	require.NoError(t, err)
+	assert.Equal(t, 20, img.Bounds().Dx())
+	assert.Equal(t, 40, img.Bounds().Dy())
+
+	// Метадані не переносяться до перекодованого файлу
+	assert.False(t, bytes.Contains(res.Original, []byte("Exif")))
+	assert.Equal(t, 1, Orientation(res.Original))
+}
+
+func TestOrient(t *testing.T) {
+	src := testImage(3, 2)
+	corner := src.At(0, 0)
+
+	// Ліва верхня точка після повороту на 90° за годинниковою стрілкою
+	// опиняється у правому верхньому куті
+	rotated := Orient(src, 6)
+	assert.Equal(t, image.Rect(0, 0, 2, 3), rotated.Bounds())
+	assert.Equal(t, corner, rotated.At(1, 0))
+
+	flipped := Orient(src, 2)
+	assert.Equal(t, corner, flipped.At(2, 0))
+
+	assert.Equal(t, src, Orient(src, 1))
+}
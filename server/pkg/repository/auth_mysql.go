@@ -2,8 +2,8 @@ package repository
 
 import (
 	"cmd/pkg/repository/models"
-	"fmt"
-	"github.com/jinzhu/gorm"
+	"context"
+	"gorm.io/gorm"
 )
 
 type AuthRepository struct {
@@ -15,36 +15,34 @@ func NewAuthRepository(db *gorm.DB) *AuthRepository {
 }
 
 // CreateUser отримує ім'я та пароль ТА створює нового користувача
-func (a *AuthRepository) CreateUser(user models.User) (int, error) {
-	err := a.db.Table(UsersTable).Select("username", "password_hash").Create(&user).Error
-	return user.Id, err
+func (a *AuthRepository) CreateUser(ctx context.Context, user models.User) (int, error) {
+	err := a.db.WithContext(ctx).Table(UsersTable).Select("username", "password_hash").Create(&user).Error
+	return user.Id, translate(err)
 }
 
 // GetUser отримує ім'я та пароль ТА повертає його дані
-func (a *AuthRepository) GetUser(username, password string) (models.User, error) {
+func (a *AuthRepository) GetUser(ctx context.Context, username, password string) (models.User, error) {
 	var user models.User
-	err := a.db.Table(UsersTable).Where("username = ? and password_hash = ?", username, password).First(&user).Error
+	err := a.db.WithContext(ctx).Table(UsersTable).Where("username = ? and password_hash = ?", username, password).First(&user).Error
 	return user, err
 }
 
 // GetUserById отримує ID користувача ТА повертає його дані
-func (a *AuthRepository) GetUserById(userId int) (models.User, error) {
+func (a *AuthRepository) GetUserById(ctx context.Context, userId int) (models.User, error) {
 	var user models.User
-	query := fmt.Sprintf("SELECT id, username, icon FROM %s WHERE id = ?", UsersTable)
-	err := a.db.Raw(query, userId).Scan(&user).Error
+	err := a.db.WithContext(ctx).Table(UsersTable).Select("id", "username", "icon").Where("id = ?", userId).Take(&user).Error
 	return user, err
 }
 
 // GetByName отримує ім'я користувача ТА повертає його дані
-func (a *AuthRepository) GetByName(username string) (models.User, error) {
+func (a *AuthRepository) GetByName(ctx context.Context, username string) (models.User, error) {
 	var user models.User
-	query := fmt.Sprintf("SELECT id, username, icon FROM %s WHERE username = ?", UsersTable)
-	err := a.db.Raw(query, username).Scan(&user).Error
+	err := a.db.WithContext(ctx).Table(UsersTable).Select("id", "username", "icon").Where("username = ?", username).Take(&user).Error
 	return user, err
 }
 
 // UpdateUser отримує дані користувача ТА оновлює їх
-func (a *AuthRepository) UpdateUser(user models.User) error {
-	err := a.db.Table(UsersTable).Select("username", "icon").Where("id = ?", user.Id).Updates(&user).Error
-	return err
+func (a *AuthRepository) UpdateUser(ctx context.Context, user models.User) error {
+	err := a.db.WithContext(ctx).Table(UsersTable).Select("username", "icon").Where("id = ?", user.Id).Updates(&user).Error
+	return translate(err)
 }
@@ -2,123 +2,209 @@ package repository
 
 import (
 	"cmd/pkg/repository/models"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/jinzhu/gorm"
+	"gorm.io/gorm"
 )
 
 type ChatRepository struct {
 	db *gorm.DB
 }
 
+// chatRow - рядок чату з кількістю учасників та останнім повідомленням
+type chatRow struct {
+	models.Chat
+	LastMessageId     *int
+	LastMessageAuthor int
+	LastMessageText   string
+}
+
+// chat повертає дані чату з останнім повідомленням, якщо воно є
+func (r chatRow) chat() models.Chat {
+	chat := r.Chat
+	if r.LastMessageId != nil && r.LastMessageAt != nil {
+		chat.LastMessage = &models.Message{Id: *r.LastMessageId, ChatId: chat.Id, Author: r.LastMessageAuthor, Text: r.LastMessageText, SentAt: *r.LastMessageAt}
+	}
+	return chat
+}
+
+// chatQuery повертає запит чатів з кількістю учасників та останнім
+// повідомленням, доповнений умовами tail. Останнє повідомлення - з
+// найбільшим ID, тож його знаходить індекс messages_chat_id
+func chatQuery(tail string) string {
+	return fmt.Sprintf(`SELECT ch.*, (SELECT COUNT(*) FROM %s cu WHERE cu.chat_id = ch.id) AS member_count,
+		lm.id AS last_message_id, lm.author AS last_message_author, lm.text AS last_message_text, lm.sent_at AS last_message_at
+		FROM %s ch LEFT JOIN %s lm ON lm.id = (SELECT MAX(m.id) FROM %s m WHERE m.chat_id = ch.id) `,
+		ChatUsersList, ChatsTable, MessagesTable, MessagesTable) + tail
+}
+
+// scanChats виконує запит чатів ТА повертає їх з останніми повідомленнями
+func (c *ChatRepository) scanChats(ctx context.Context, query string, values ...interface{}) ([]models.Chat, error) {
+	var rows []chatRow
+	if err := c.db.WithContext(ctx).Raw(query, values...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	var chats []models.Chat
+	for _, row := range rows {
+		chats = append(chats, row.chat())
+	}
+	return chats, nil
+}
+
 func NewChatRepository(db *gorm.DB) *ChatRepository {
 	return &ChatRepository{db: db}
 }
 
-// Create отримує назву чату ТА створює новий чат
-func (c *ChatRepository) Create(chat models.Chat) (int, error) {
-	err := c.db.Table(ChatsTable).Select("name", "types").Create(&chat).Error
+// Create отримує дані чату ТА створює новий чат. Без видимості чат
+// відкритий
+func (c *ChatRepository) Create(ctx context.Context, chat models.Chat) (int, error) {
+	if chat.Visibility == "" {
+		chat.Visibility = VisibilityOpen
+	}
+	err := c.db.WithContext(ctx).Table(ChatsTable).
+		Select("name", "types", "visibility", "description", "topic", "created_by", "created_at").Create(&chat).Error
 	return chat.Id, err
 }
 
-// Get отримує ID чату ТА повертає дані чату за його ID
-func (c *ChatRepository) Get(chatId int) (models.Chat, error) {
-	var chat models.Chat
-	err := c.db.Table(ChatsTable).First(&chat, chatId).Error
-	return chat, err
+// Get отримує ID чату ТА повертає дані чату за його ID з кількістю
+// учасників та останнім повідомленням
+func (c *ChatRepository) Get(ctx context.Context, chatId int) (models.Chat, error) {
+	chats, err := c.scanChats(ctx, chatQuery("WHERE ch.id = ?"), chatId)
+	if err != nil {
+		return models.Chat{}, err
+	}
+	if len(chats) == 0 {
+		return models.Chat{}, gorm.ErrRecordNotFound
+	}
+	return chats[0], nil
 }
 
-// Update отримує дані чату ТА оновлює їх
-func (c *ChatRepository) Update(chat models.Chat) error {
-	err := c.db.Table(ChatsTable).Select("name", "icon").Where("id = ?", chat.Id).Updates(&chat).Error
+// Update отримує дані чату ТА оновлює назву, опис, тему, зображення та
+// видимість
+func (c *ChatRepository) Update(ctx context.Context, chat models.Chat) error {
+	err := c.db.WithContext(ctx).Table(ChatsTable).
+		Select("name", "description", "topic", "icon", "visibility").Where("id = ?", chat.Id).Updates(&chat).Error
 	return err
 }
 
-// Delete отримує ID чату ТА видаляє чат
-func (c *ChatRepository) Delete(chatId int) error {
-	err := c.db.Table(ChatsTable).Delete(&models.Chat{}, chatId).Error
+// Delete отримує ID чату ТА видаляє чат. Учасники та повідомлення чату
+// видаляються каскадно
+func (c *ChatRepository) Delete(ctx context.Context, chatId int) error {
+	err := c.db.WithContext(ctx).Table(ChatsTable).Delete(&models.Chat{}, chatId).Error
 	return err
 }
 
-// AddUser отримує ID чату ТА ID користувача, та додає користувача до чату
-func (c *ChatRepository) AddUser(user models.ChatUsers) (int, error) {
-	err := c.db.Select(ChatUsersList, "chat_id", "user_id").Create(&user).Error
-	return user.Id, err
+// AddUser отримує ID чату ТА ID користувача, та додає користувача до чату.
+// Без ролі користувач стає звичайним учасником
+func (c *ChatRepository) AddUser(ctx context.Context, user models.ChatUsers) (int, error) {
+	if user.Role == "" {
+		user.Role = RoleMember
+	}
+	err := c.db.WithContext(ctx).Table(ChatUsersList).Select("chat_id", "user_id", "role").Create(&user).Error
+	return user.Id, translate(err)
 }
 
 // GetUsers отримує ID чату ТА повертає масив користувачів, що приєднані до чату
-func (c *ChatRepository) GetUsers(chatId int) ([]models.User, error) {
+func (c *ChatRepository) GetUsers(ctx context.Context, chatId int) ([]models.User, error) {
 	var users []models.User
 	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chl ON u.id = chl.user_id WHERE chl.chat_id = ?", UsersTable, ChatUsersList)
-	err := c.db.Raw(query, chatId).Scan(&users).Error
+	err := c.db.WithContext(ctx).Raw(query, chatId).Scan(&users).Error
 	return users, err
 }
 
-// GetPrivates отримує ID двох користувачів, ТА повертає масиви приватних
-// чатів, до яких належать кожен із користувачів
-func (c *ChatRepository) GetPrivates(firstUser, secondUser int) ([]models.Chat, []models.Chat, error) {
-	var first []models.Chat
-	var second []models.Chat
-	tx := c.db.Begin()
-	queryFirst := fmt.Sprintf("SELECT chl.id FROM %s chl INNER JOIN %s chul ON chl.id = chul.chat_id WHERE chul.user_id = ? and chl.types = ?",
-		ChatsTable, ChatUsersList)
-	errFirst := c.db.Raw(queryFirst, firstUser, ChatPrivate).Scan(&first).Error
-	if errFirst != nil {
-		tx.Rollback()
-		return nil, nil, errFirst
-	}
-	querySecond := fmt.Sprintf("SELECT chl.id FROM %s chl INNER JOIN %s chul ON chl.id = chul.chat_id WHERE chul.user_id = ? and chl.types = ?",
-		ChatsTable, ChatUsersList)
-	errSecond := c.db.Raw(querySecond, secondUser, ChatPrivate).Scan(&second).Error
-	if errSecond != nil {
-		tx.Rollback()
-		return nil, nil, errSecond
+// GetMember отримує ID чату та користувача ТА повертає запис учасника з
+// його роллю
+func (c *ChatRepository) GetMember(ctx context.Context, chatId, userId int) (models.ChatUsers, error) {
+	var member models.ChatUsers
+	err := c.db.WithContext(ctx).Table(ChatUsersList).Where("chat_id = ? AND user_id = ?", chatId, userId).Take(&member).Error
+	return member, err
+}
+
+// GetMembers отримує ID чату ТА повертає записи учасників у порядку вступу
+func (c *ChatRepository) GetMembers(ctx context.Context, chatId int) ([]models.ChatUsers, error) {
+	var members []models.ChatUsers
+	err := c.db.WithContext(ctx).Table(ChatUsersList).Where("chat_id = ?", chatId).Order("id").Find(&members).Error
+	return members, err
+}
+
+// UpdateRole отримує запис учасника ТА змінює його роль
+func (c *ChatRepository) UpdateRole(ctx context.Context, member models.ChatUsers) error {
+	return c.db.WithContext(ctx).Table(ChatUsersList).Where("chat_id = ? AND user_id = ?", member.ChatId, member.UserId).Update("role", member.Role).Error
+}
+
+// GetPrivate отримує ID двох користувачів у будь-якому порядку ТА повертає
+// ID їх приватного чату або 0, якщо чату немає
+func (c *ChatRepository) GetPrivate(ctx context.Context, firstUser, secondUser int) (int, error) {
+	var chat models.PrivateChat
+	pair := PrivatePair(firstUser, secondUser)
+	err := c.db.WithContext(ctx).Table(PrivateChats).Where("user_low = ? AND user_high = ?", pair.UserLow, pair.UserHigh).Take(&chat).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
 	}
-	return first, second, nil
+	return chat.ChatId, err
 }
 
-// GetPrivateChats отримує ID користувача ТА повертає масив ПРИВАТНИХ чатів,
-// до яких він належить
-func (c *ChatRepository) GetPrivateChats(userId int) ([]models.Chat, error) {
+// AddPrivate закріплює чат chatId за парою користувачів. Повертає
+// ErrDuplicate, якщо пара вже має чат
+func (c *ChatRepository) AddPrivate(ctx context.Context, firstUser, secondUser, chatId int) error {
+	pair := PrivatePair(firstUser, secondUser)
+	pair.ChatId = chatId
+	return translate(c.db.WithContext(ctx).Table(PrivateChats).Create(&pair).Error)
+}
+
+// DeletePrivate відкріплює приватний чат від пари його учасників
+func (c *ChatRepository) DeletePrivate(ctx context.Context, chatId int) error {
+	return c.db.WithContext(ctx).Table(PrivateChats).Where("chat_id = ?", chatId).Delete(&models.PrivateChat{}).Error
+}
+
+// GetPrivateChats отримує ID користувача ТА повертає масив ПРИВАТНИХ чатів
+// з іншим учасником. Назва та зображення чату - ім'я та зображення
+// співрозмовника; особисті чати не повертаються
+func (c *ChatRepository) GetPrivateChats(ctx context.Context, userId int) ([]models.Chat, error) {
 	var chats []models.Chat
-	query := fmt.Sprintf("SELECT * FROM %s ch INNER JOIN %s chl ON ch.id = chl.chat_id WHERE chl.user_id = ? and ch.types = ?", ChatsTable, ChatUsersList)
-	err := c.db.Raw(query, userId, ChatPrivate).Scan(&chats).Error
+	query := fmt.Sprintf(`SELECT ch.id, ch.types, u.username AS name, u.icon, u.id AS partner_id FROM %s ch
+		INNER JOIN %s chl ON ch.id = chl.chat_id AND chl.user_id = @user
+		INNER JOIN %s other ON ch.id = other.chat_id AND other.user_id <> @user
+		INNER JOIN %s u ON u.id = other.user_id
+		WHERE ch.types = @types ORDER BY ch.id`, ChatsTable, ChatUsersList, ChatUsersList, UsersTable)
+	err := c.db.WithContext(ctx).Raw(query, map[string]interface{}{"user": userId, "types": ChatPrivate}).Scan(&chats).Error
 	return chats, err
 }
 
 // GetPublicChats отримує ID користувача ТА повертає масив ПУБЛІЧНИХ чатів,
-// до яких він належить
-func (c *ChatRepository) GetPublicChats(userId int) ([]models.Chat, error) {
-	var chats []models.Chat
-	query := fmt.Sprintf("SELECT * FROM %s ch INNER JOIN %s chl ON ch.id = chl.chat_id WHERE chl.user_id = ? and ch.types = ?", ChatsTable, ChatUsersList)
-	err := c.db.Raw(query, userId, ChatPublic).Scan(&chats).Error
-	return chats, err
+// до яких він належить, з останніми повідомленнями. Спершу йдуть чати з
+// найновішою активністю - останнім повідомленням або створенням чату,
+// чати без відомої активності - в кінці
+func (c *ChatRepository) GetPublicChats(ctx context.Context, userId int) ([]models.Chat, error) {
+	query := chatQuery(fmt.Sprintf(`INNER JOIN %s chl ON ch.id = chl.chat_id WHERE chl.user_id = ? AND ch.types = ?
+		ORDER BY CASE WHEN COALESCE(lm.sent_at, ch.created_at) IS NULL THEN 1 ELSE 0 END,
+		COALESCE(lm.sent_at, ch.created_at) DESC, ch.id DESC`, ChatUsersList))
+	return c.scanChats(ctx, query, userId, ChatPublic)
 }
 
 // DeleteUser отримує ID чату ТА ID користувача, та видаляє користувача із чату
-func (c *ChatRepository) DeleteUser(userId, chatId int) error {
-	err := c.db.Table(ChatUsersList).Where("user_id = ? and chat_id = ?", userId, chatId).Delete(&models.ChatUsers{}).Error
+func (c *ChatRepository) DeleteUser(ctx context.Context, userId, chatId int) error {
+	err := c.db.WithContext(ctx).Table(ChatUsersList).Where("user_id = ? and chat_id = ?", userId, chatId).Delete(&models.ChatUsers{}).Error
 	return err
 }
 
-// SearchChat отримує назву чату (або його частину) ТА повертає масив чатів,
-// назви яких збігаються з аргументом
-func (c *ChatRepository) SearchChat(name string) ([]models.Chat, error) {
-	var chats []models.Chat
-	query := fmt.Sprintf("SELECT * FROM %s WHERE types = ? AND name LIKE ? LIMIT 16", ChatsTable)
-	err := c.db.Raw(query, ChatPublic, fmt.Sprintf("%%%s%%", name)).Scan(&chats).Error
-	return chats, err
+// SearchChat отримує назву чату (або його частину) ТА повертає масив
+// публічних чатів, назви яких збігаються з аргументом, крім прихованих
+func (c *ChatRepository) SearchChat(ctx context.Context, name string) ([]models.Chat, error) {
+	query := chatQuery("WHERE ch.types = ? AND ch.visibility <> ? AND LOWER(ch.name) LIKE LOWER(?) ORDER BY ch.id LIMIT 16")
+	return c.scanChats(ctx, query, ChatPublic, VisibilityHidden, fmt.Sprintf("%%%s%%", name))
 }
 
 // DeleteAllMessages отримує ID чату ТА видаляє його повідомлення
-func (c *ChatRepository) DeleteAllMessages(chatId int) error {
-	err := c.db.Table(MessagesTable).Where("chat_id = ?", chatId).Delete(&models.Message{}).Error
+func (c *ChatRepository) DeleteAllMessages(ctx context.Context, chatId int) error {
+	err := c.db.WithContext(ctx).Table(MessagesTable).Where("chat_id = ?", chatId).Delete(&models.Message{}).Error
 	return err
 }
 
 // GetUserById отримує ID користувача ТА повертає його дані
-func (c *ChatRepository) GetUserById(userId int) (models.User, error) {
+func (c *ChatRepository) GetUserById(ctx context.Context, userId int) (models.User, error) {
 	var user models.User
-	query := fmt.Sprintf("SELECT id, username, icon FROM %s WHERE id = ?", UsersTable)
-	err := c.db.Raw(query, userId).Scan(&user).Error
+	err := c.db.WithContext(ctx).Table(UsersTable).Select("id", "username", "icon").Where("id = ?", userId).Take(&user).Error
 	return user, err
 }
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+)
+
+var (
+	// ErrDuplicate - запис з такими унікальними даними вже існує
+	ErrDuplicate = errors.New("record already exists")
+	// ErrReference - запис посилається на об'єкт, якого не існує
+	ErrReference = errors.New("referenced record does not exist")
+)
+
+// Коди помилок MySQL, що перетворюються на помилки репозиторію
+const (
+	mysqlDuplicate = 1062
+	// mysqlNoReferenced та mysqlNoReferencedOld - порушення зовнішнього ключа
+	mysqlNoReferenced    = 1452
+	mysqlNoReferencedOld = 1216
+)
+
+// Коди помилок PostgreSQL (SQLSTATE), що перетворюються на помилки репозиторію
+const (
+	postgresDuplicate    = "23505"
+	postgresNoReferenced = "23503"
+)
+
+// translate перетворює порушення обмежень БД на помилки репозиторію.
+// Інші помилки повертаються без змін
+func translate(err error) error {
+	var mysqlErr *mysql.MySQLError
+	var pgErr *pgconn.PgError
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.As(err, &mysqlErr):
+		switch mysqlErr.Number {
+		case mysqlDuplicate:
+			return fmt.Errorf("%w: %s", ErrDuplicate, mysqlErr.Message)
+		case mysqlNoReferenced, mysqlNoReferencedOld:
+			return fmt.Errorf("%w: %s", ErrReference, mysqlErr.Message)
+		}
+	case errors.As(err, &pgErr):
+		switch pgErr.Code {
+		case postgresDuplicate:
+			return fmt.Errorf("%w: %s", ErrDuplicate, pgErr.Message)
+		case postgresNoReferenced:
+			return fmt.Errorf("%w: %s", ErrReference, pgErr.Message)
+		}
+	case errors.As(err, &sqliteErr):
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return fmt.Errorf("%w: %s", ErrDuplicate, sqliteErr.Error())
+		case sqlite3.ErrConstraintForeignKey:
+			return fmt.Errorf("%w: %s", ErrReference, sqliteErr.Error())
+		}
+	}
+	return err
+}
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	assert.ErrorIs(t, translate(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}), ErrDuplicate)
+	assert.ErrorIs(t, translate(&mysql.MySQLError{Number: 1452, Message: "Cannot add or update a child row"}), ErrReference)
+	assert.ErrorIs(t, translate(&pgconn.PgError{Code: "23505"}), ErrDuplicate)
+	assert.ErrorIs(t, translate(&pgconn.PgError{Code: "23503"}), ErrReference)
+	assert.ErrorIs(t, translate(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}), ErrDuplicate)
+	assert.ErrorIs(t, translate(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintForeignKey}), ErrReference)
+
+	other := errors.New("some error")
+	assert.Equal(t, other, translate(other))
+	assert.Nil(t, translate(nil))
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"cmd/pkg/repository/models"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type InviteRepository struct {
+	db *gorm.DB
+}
+
+func NewInviteRepository(db *gorm.DB) *InviteRepository {
+	return &InviteRepository{db: db}
+}
+
+// Create отримує дані посилання-запрошення ТА повертає його ID
+func (i *InviteRepository) Create(ctx context.Context, invite models.ChatInvite) (int, error) {
+	err := i.db.WithContext(ctx).Table(ChatInvites).Omit("id").Create(&invite).Error
+	return invite.Id, translate(err)
+}
+
+// Get отримує ID посилання ТА повертає його дані
+func (i *InviteRepository) Get(ctx context.Context, inviteId int) (models.ChatInvite, error) {
+	var invite models.ChatInvite
+	err := i.db.WithContext(ctx).Table(ChatInvites).Where("id = ?", inviteId).Take(&invite).Error
+	return invite, err
+}
+
+// GetByToken отримує токен посилання ТА повертає його дані
+func (i *InviteRepository) GetByToken(ctx context.Context, token string) (models.ChatInvite, error) {
+	var invite models.ChatInvite
+	err := i.db.WithContext(ctx).Table(ChatInvites).Where("token = ?", token).Take(&invite).Error
+	return invite, err
+}
+
+// GetByChat отримує ID чату ТА повертає його посилання від найстаршого
+func (i *InviteRepository) GetByChat(ctx context.Context, chatId int) ([]models.ChatInvite, error) {
+	var invites []models.ChatInvite
+	err := i.db.WithContext(ctx).Table(ChatInvites).Where("chat_id = ?", chatId).Order("id").Find(&invites).Error
+	return invites, err
+}
+
+// Revoke отримує ID посилання та час ТА відкликає посилання. Час
+// відкликання вже відкликаного посилання не змінюється
+func (i *InviteRepository) Revoke(ctx context.Context, inviteId int, at time.Time) error {
+	return i.db.WithContext(ctx).Table(ChatInvites).Where("id = ? AND revoked_at IS NULL", inviteId).Update("revoked_at", at).Error
+}
+
+// Use отримує ID посилання та час ТА збільшує кількість його використань,
+// якщо на цей час посилання дійсне. Перевірка та збільшення виконуються
+// одним запитом, тож одночасні вступи не перевищать MaxUses
+func (i *InviteRepository) Use(ctx context.Context, inviteId int, at time.Time) (bool, error) {
+	result := i.db.WithContext(ctx).Table(ChatInvites).
+		Where("id = ? AND revoked_at IS NULL", inviteId).
+		Where("expires_at IS NULL OR expires_at > ?", at).
+		Where("max_uses = 0 OR uses < max_uses").
+		Update("uses", gorm.Expr("uses + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+type JoinRepository struct {
+	db *gorm.DB
+}
+
+func NewJoinRepository(db *gorm.DB) *JoinRepository {
+	return &JoinRepository{db: db}
+}
+
+// Create отримує дані вступу до чату ТА повертає його ID. Повертає
+// ErrReference, якщо чату, користувача чи посилання не існує, та
+// ErrDuplicate, якщо користувач вже має заявку до чату, що чекає рішення
+func (j *JoinRepository) Create(ctx context.Context, join models.ChatJoin) (int, error) {
+	err := j.db.WithContext(ctx).Table(ChatJoins).Omit("id").Create(&join).Error
+	return join.Id, translate(err)
+}
+
+// Get отримує ID вступу ТА повертає його дані
+func (j *JoinRepository) Get(ctx context.Context, joinId int) (models.ChatJoin, error) {
+	var join models.ChatJoin
+	err := j.db.WithContext(ctx).Table(ChatJoins).Where("id = ?", joinId).Take(&join).Error
+	return join, err
+}
+
+// GetByChat отримує ID чату та статус ТА повертає вступи до чату з цим
+// статусом від найстаршого
+func (j *JoinRepository) GetByChat(ctx context.Context, chatId int, status string) ([]models.ChatJoin, error) {
+	var joins []models.ChatJoin
+	err := j.db.WithContext(ctx).Table(ChatJoins).Where("chat_id = ? AND status = ?", chatId, status).Order("id").Find(&joins).Error
+	return joins, err
+}
+
+// UpdateStatus отримує дані вступу ТА змінює його статус
+func (j *JoinRepository) UpdateStatus(ctx context.Context, join models.ChatJoin) error {
+	return j.db.WithContext(ctx).Table(ChatJoins).Where("id = ?", join.Id).Update("status", join.Status).Error
+}
@@ -0,0 +1,92 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+type AuthRepository struct {
+	db db
+}
+
+// CreateUser отримує ім'я та пароль ТА створює нового користувача
+func (a *AuthRepository) CreateUser(ctx context.Context, user models.User) (int, error) {
+	err := a.db.write(ctx, func(s *store) error {
+		for _, u := range s.users {
+			if u.Username == user.Username {
+				return duplicate("username %q", user.Username)
+			}
+		}
+		user = models.User{Id: s.nextId(repository.UsersTable), Username: user.Username, Password: user.Password}
+		s.users = append(s.users, user)
+		return nil
+	})
+	return user.Id, err
+}
+
+// GetUser отримує ім'я та пароль ТА повертає його дані
+func (a *AuthRepository) GetUser(ctx context.Context, username, password string) (models.User, error) {
+	var user models.User
+	err := a.db.read(ctx, func(s *store) error {
+		for _, u := range s.users {
+			if u.Username == username && u.Password == password {
+				user = u
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return user, err
+}
+
+// GetUserById отримує ID користувача ТА повертає його дані
+func (a *AuthRepository) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	return getUserById(ctx, a.db, userId)
+}
+
+func getUserById(ctx context.Context, d db, userId int) (models.User, error) {
+	var user models.User
+	err := d.read(ctx, func(s *store) error {
+		u, ok := s.user(userId)
+		if !ok {
+			return gorm.ErrRecordNotFound
+		}
+		user = public(u)
+		return nil
+	})
+	return user, err
+}
+
+// GetByName отримує ім'я користувача ТА повертає його дані
+func (a *AuthRepository) GetByName(ctx context.Context, username string) (models.User, error) {
+	var user models.User
+	err := a.db.read(ctx, func(s *store) error {
+		for _, u := range s.users {
+			if u.Username == username {
+				user = public(u)
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return user, err
+}
+
+// UpdateUser отримує дані користувача ТА оновлює ім'я та зображення
+func (a *AuthRepository) UpdateUser(ctx context.Context, user models.User) error {
+	return a.db.write(ctx, func(s *store) error {
+		for _, u := range s.users {
+			if u.Username == user.Username && u.Id != user.Id {
+				return duplicate("username %q", user.Username)
+			}
+		}
+		for i := range s.users {
+			if s.users[i].Id == user.Id {
+				s.users[i].Username, s.users[i].Icon = user.Username, user.Icon
+			}
+		}
+		return nil
+	})
+}
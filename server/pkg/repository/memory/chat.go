@@ -0,0 +1,385 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// searchLimit - найбільша кількість результатів пошуку
+const searchLimit = 16
+
+type ChatRepository struct {
+	db db
+}
+
+// Create отримує дані чату ТА створює новий чат. Без видимості чат
+// відкритий
+func (c *ChatRepository) Create(ctx context.Context, chat models.Chat) (int, error) {
+	err := c.db.write(ctx, func(s *store) error {
+		if chat.Visibility == "" {
+			chat.Visibility = repository.VisibilityOpen
+		}
+		chat = models.Chat{
+			Id: s.nextId(repository.ChatsTable), Name: chat.Name, Types: chat.Types, Visibility: chat.Visibility,
+			Description: chat.Description, Topic: chat.Topic, CreatedBy: copyInt(chat.CreatedBy), CreatedAt: copyTime(chat.CreatedAt),
+		}
+		s.chats = append(s.chats, chat)
+		return nil
+	})
+	return chat.Id, err
+}
+
+// Get отримує ID чату ТА повертає дані чату за його ID з кількістю
+// учасників та останнім повідомленням
+func (c *ChatRepository) Get(ctx context.Context, chatId int) (models.Chat, error) {
+	var chat models.Chat
+	err := c.db.read(ctx, func(s *store) error {
+		var ok bool
+		if chat, ok = s.chat(chatId); !ok {
+			return gorm.ErrRecordNotFound
+		}
+		chat = s.withStats(chat)
+		return nil
+	})
+	return chat, err
+}
+
+// Update отримує дані чату ТА оновлює назву, опис, тему, зображення та
+// видимість
+func (c *ChatRepository) Update(ctx context.Context, chat models.Chat) error {
+	return c.db.write(ctx, func(s *store) error {
+		for i := range s.chats {
+			if s.chats[i].Id == chat.Id {
+				s.chats[i].Name, s.chats[i].Icon, s.chats[i].Visibility = chat.Name, chat.Icon, chat.Visibility
+				s.chats[i].Description, s.chats[i].Topic = chat.Description, chat.Topic
+			}
+		}
+		return nil
+	})
+}
+
+// Delete отримує ID чату ТА видаляє чат разом з його учасниками та повідомленнями
+func (c *ChatRepository) Delete(ctx context.Context, chatId int) error {
+	return c.db.write(ctx, func(s *store) error {
+		var chats []models.Chat
+		for _, chat := range s.chats {
+			if chat.Id != chatId {
+				chats = append(chats, chat)
+			}
+		}
+		s.chats = chats
+		s.deleteMembers(func(m models.ChatUsers) bool { return m.ChatId == chatId })
+		s.deleteMessages(chatId)
+		s.deletePrivate(chatId)
+		s.deleteInvites(chatId)
+		return nil
+	})
+}
+
+// AddUser отримує ID чату ТА ID користувача, та додає користувача до чату.
+// Без ролі користувач стає звичайним учасником
+func (c *ChatRepository) AddUser(ctx context.Context, user models.ChatUsers) (int, error) {
+	err := c.db.write(ctx, func(s *store) error {
+		if _, ok := s.chat(user.ChatId); !ok {
+			return reference("chat %d", user.ChatId)
+		}
+		if _, ok := s.user(user.UserId); !ok {
+			return reference("user %d", user.UserId)
+		}
+		for _, m := range s.members {
+			if m.ChatId == user.ChatId && m.UserId == user.UserId {
+				return duplicate("user %d in chat %d", user.UserId, user.ChatId)
+			}
+		}
+		if user.Role == "" {
+			user.Role = repository.RoleMember
+		}
+		user = models.ChatUsers{Id: s.nextId(repository.ChatUsersList), ChatId: user.ChatId, UserId: user.UserId, Role: user.Role}
+		s.members = append(s.members, user)
+		return nil
+	})
+	return user.Id, err
+}
+
+// GetUsers отримує ID чату ТА повертає масив користувачів, що приєднані до чату
+func (c *ChatRepository) GetUsers(ctx context.Context, chatId int) ([]models.User, error) {
+	var users []models.User
+	err := c.db.read(ctx, func(s *store) error {
+		for _, m := range s.members {
+			if user, ok := s.user(m.UserId); ok && m.ChatId == chatId {
+				users = append(users, public(user))
+			}
+		}
+		return nil
+	})
+	return users, err
+}
+
+// GetMember отримує ID чату та користувача ТА повертає запис учасника з
+// його роллю
+func (c *ChatRepository) GetMember(ctx context.Context, chatId, userId int) (models.ChatUsers, error) {
+	var member models.ChatUsers
+	err := c.db.read(ctx, func(s *store) error {
+		for _, m := range s.members {
+			if m.ChatId == chatId && m.UserId == userId {
+				member = m
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return member, err
+}
+
+// GetMembers отримує ID чату ТА повертає записи учасників у порядку вступу
+func (c *ChatRepository) GetMembers(ctx context.Context, chatId int) ([]models.ChatUsers, error) {
+	var members []models.ChatUsers
+	err := c.db.read(ctx, func(s *store) error {
+		for _, m := range s.members {
+			if m.ChatId == chatId {
+				members = append(members, m)
+			}
+		}
+		return nil
+	})
+	return members, err
+}
+
+// UpdateRole отримує запис учасника ТА змінює його роль
+func (c *ChatRepository) UpdateRole(ctx context.Context, member models.ChatUsers) error {
+	return c.db.write(ctx, func(s *store) error {
+		for i := range s.members {
+			if s.members[i].ChatId == member.ChatId && s.members[i].UserId == member.UserId {
+				s.members[i].Role = member.Role
+			}
+		}
+		return nil
+	})
+}
+
+// GetPrivate отримує ID двох користувачів у будь-якому порядку ТА повертає
+// ID їх приватного чату або 0, якщо чату немає
+func (c *ChatRepository) GetPrivate(ctx context.Context, firstUser, secondUser int) (int, error) {
+	var chatId int
+	pair := repository.PrivatePair(firstUser, secondUser)
+	err := c.db.read(ctx, func(s *store) error {
+		for _, private := range s.privates {
+			if private.UserLow == pair.UserLow && private.UserHigh == pair.UserHigh {
+				chatId = private.ChatId
+			}
+		}
+		return nil
+	})
+	return chatId, err
+}
+
+// AddPrivate закріплює чат chatId за парою користувачів. Повертає
+// ErrDuplicate, якщо пара вже має чат
+func (c *ChatRepository) AddPrivate(ctx context.Context, firstUser, secondUser, chatId int) error {
+	pair := repository.PrivatePair(firstUser, secondUser)
+	pair.ChatId = chatId
+	return c.db.write(ctx, func(s *store) error {
+		if _, ok := s.chat(chatId); !ok {
+			return reference("chat %d", chatId)
+		}
+		for _, id := range []int{pair.UserLow, pair.UserHigh} {
+			if _, ok := s.user(id); !ok {
+				return reference("user %d", id)
+			}
+		}
+		for _, private := range s.privates {
+			if private.ChatId == chatId || (private.UserLow == pair.UserLow && private.UserHigh == pair.UserHigh) {
+				return duplicate("private chat of users %d and %d", pair.UserLow, pair.UserHigh)
+			}
+		}
+		s.privates = append(s.privates, pair)
+		return nil
+	})
+}
+
+// DeletePrivate відкріплює приватний чат від пари його учасників
+func (c *ChatRepository) DeletePrivate(ctx context.Context, chatId int) error {
+	return c.db.write(ctx, func(s *store) error {
+		s.deletePrivate(chatId)
+		return nil
+	})
+}
+
+// GetPrivateChats отримує ID користувача ТА повертає масив ПРИВАТНИХ чатів
+// з іншим учасником. Назва та зображення чату - ім'я та зображення
+// співрозмовника; особисті чати не повертаються
+func (c *ChatRepository) GetPrivateChats(ctx context.Context, userId int) ([]models.Chat, error) {
+	var chats []models.Chat
+	err := c.db.read(ctx, func(s *store) error {
+		for _, chat := range s.userChats(userId, repository.ChatPrivate) {
+			for _, m := range s.members {
+				if m.ChatId != chat.Id || m.UserId == userId {
+					continue
+				}
+				if user, ok := s.user(m.UserId); ok {
+					chats = append(chats, models.Chat{Id: chat.Id, Name: user.Username, Types: chat.Types, Icon: user.Icon, PartnerId: user.Id})
+				}
+			}
+		}
+		sort.Slice(chats, func(i, j int) bool { return chats[i].Id < chats[j].Id })
+		return nil
+	})
+	return chats, err
+}
+
+// GetPublicChats отримує ID користувача ТА повертає масив ПУБЛІЧНИХ чатів,
+// до яких він належить, з останніми повідомленнями. Спершу йдуть чати з
+// найновішою активністю - останнім повідомленням або створенням чату,
+// чати без відомої активності - в кінці
+func (c *ChatRepository) GetPublicChats(ctx context.Context, userId int) ([]models.Chat, error) {
+	var chats []models.Chat
+	err := c.db.read(ctx, func(s *store) error {
+		for _, chat := range s.userChats(userId, repository.ChatPublic) {
+			chats = append(chats, s.withStats(chat))
+		}
+		sort.Slice(chats, func(i, j int) bool {
+			first, second := activity(chats[i]), activity(chats[j])
+			if (first == nil) != (second == nil) {
+				return second == nil
+			}
+			if first != nil && !first.Equal(*second) {
+				return first.After(*second)
+			}
+			return chats[i].Id > chats[j].Id
+		})
+		return nil
+	})
+	return chats, err
+}
+
+// DeleteUser отримує ID чату ТА ID користувача, та видаляє користувача із чату
+func (c *ChatRepository) DeleteUser(ctx context.Context, userId, chatId int) error {
+	return c.db.write(ctx, func(s *store) error {
+		s.deleteMembers(func(m models.ChatUsers) bool { return m.ChatId == chatId && m.UserId == userId })
+		return nil
+	})
+}
+
+// SearchChat отримує назву чату (або його частину) ТА повертає масив
+// публічних чатів, назви яких збігаються з аргументом, крім прихованих
+func (c *ChatRepository) SearchChat(ctx context.Context, name string) ([]models.Chat, error) {
+	var chats []models.Chat
+	err := c.db.read(ctx, func(s *store) error {
+		for _, chat := range s.chats {
+			if len(chats) == searchLimit {
+				break
+			}
+			if chat.Types == repository.ChatPublic && chat.Visibility != repository.VisibilityHidden && contains(chat.Name, name) {
+				chats = append(chats, s.withStats(chat))
+			}
+		}
+		return nil
+	})
+	return chats, err
+}
+
+// DeleteAllMessages отримує ID чату ТА видаляє його повідомлення
+func (c *ChatRepository) DeleteAllMessages(ctx context.Context, chatId int) error {
+	return c.db.write(ctx, func(s *store) error {
+		s.deleteMessages(chatId)
+		return nil
+	})
+}
+
+// GetUserById отримує ID користувача ТА повертає його дані
+func (c *ChatRepository) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	return getUserById(ctx, c.db, userId)
+}
+
+// userChats повертає чати типу types, до яких належить користувач
+func (s *store) userChats(userId int, types string) []models.Chat {
+	var chats []models.Chat
+	for _, m := range s.members {
+		if chat, ok := s.chat(m.ChatId); ok && m.UserId == userId && chat.Types == types {
+			chats = append(chats, chat)
+		}
+	}
+	return chats
+}
+
+// withStats доповнює чат кількістю учасників та останнім повідомленням -
+// повідомленням з найбільшим ID
+func (s *store) withStats(chat models.Chat) models.Chat {
+	chat.MemberCount, chat.LastMessage, chat.LastMessageAt = 0, nil, nil
+	for _, m := range s.members {
+		if m.ChatId == chat.Id {
+			chat.MemberCount++
+		}
+	}
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if msg := s.messages[i]; msg.ChatId == chat.Id {
+			chat.LastMessage, chat.LastMessageAt = &msg, &msg.SentAt
+			break
+		}
+	}
+	return chat
+}
+
+// activity повертає час останньої активності чату: останнього
+// повідомлення або створення чату
+func activity(chat models.Chat) *time.Time {
+	if chat.LastMessageAt != nil {
+		return chat.LastMessageAt
+	}
+	return chat.CreatedAt
+}
+
+// copyInt та copyTime повертають копію значення, щоб запис не змінювався
+// через вказівник викликача
+func copyInt(value *int) *int {
+	if value == nil {
+		return nil
+	}
+	v := *value
+	return &v
+}
+
+func copyTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+	v := *value
+	return &v
+}
+
+// deleteMembers видаляє записи учасників чатів, для яких match повертає true
+func (s *store) deleteMembers(match func(m models.ChatUsers) bool) {
+	var members []models.ChatUsers
+	for _, m := range s.members {
+		if !match(m) {
+			members = append(members, m)
+		}
+	}
+	s.members = members
+}
+
+// deleteMessages видаляє повідомлення чату
+func (s *store) deleteMessages(chatId int) {
+	var messages []models.Message
+	for _, msg := range s.messages {
+		if msg.ChatId != chatId {
+			messages = append(messages, msg)
+		}
+	}
+	s.messages = messages
+}
+
+// deletePrivate видаляє пару учасників приватного чату
+func (s *store) deletePrivate(chatId int) {
+	var privates []models.PrivateChat
+	for _, private := range s.privates {
+		if private.ChatId != chatId {
+			privates = append(privates, private)
+		}
+	}
+	s.privates = privates
+}
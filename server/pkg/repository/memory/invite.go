@@ -0,0 +1,204 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+type InviteRepository struct {
+	db db
+}
+
+// Create отримує дані посилання-запрошення ТА повертає його ID
+func (r *InviteRepository) Create(ctx context.Context, invite models.ChatInvite) (int, error) {
+	err := r.db.write(ctx, func(s *store) error {
+		if _, ok := s.chat(invite.ChatId); !ok {
+			return reference("chat %d", invite.ChatId)
+		}
+		if _, ok := s.user(invite.CreatedBy); !ok {
+			return reference("user %d", invite.CreatedBy)
+		}
+		for _, other := range s.invites {
+			if other.Token == invite.Token {
+				return duplicate("invite token %s", invite.Token)
+			}
+		}
+		invite.Id = s.nextId(repository.ChatInvites)
+		s.invites = append(s.invites, invite)
+		return nil
+	})
+	return invite.Id, err
+}
+
+// Get отримує ID посилання ТА повертає його дані
+func (r *InviteRepository) Get(ctx context.Context, inviteId int) (models.ChatInvite, error) {
+	return r.find(ctx, func(invite models.ChatInvite) bool { return invite.Id == inviteId })
+}
+
+// GetByToken отримує токен посилання ТА повертає його дані
+func (r *InviteRepository) GetByToken(ctx context.Context, token string) (models.ChatInvite, error) {
+	return r.find(ctx, func(invite models.ChatInvite) bool { return invite.Token == token })
+}
+
+func (r *InviteRepository) find(ctx context.Context, match func(invite models.ChatInvite) bool) (models.ChatInvite, error) {
+	var invite models.ChatInvite
+	err := r.db.read(ctx, func(s *store) error {
+		for _, other := range s.invites {
+			if match(other) {
+				invite = other
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return invite, err
+}
+
+// GetByChat отримує ID чату ТА повертає його посилання від найстаршого
+func (r *InviteRepository) GetByChat(ctx context.Context, chatId int) ([]models.ChatInvite, error) {
+	var invites []models.ChatInvite
+	err := r.db.read(ctx, func(s *store) error {
+		for _, invite := range s.invites {
+			if invite.ChatId == chatId {
+				invites = append(invites, invite)
+			}
+		}
+		return nil
+	})
+	return invites, err
+}
+
+// Revoke отримує ID посилання та час ТА відкликає посилання. Час
+// відкликання вже відкликаного посилання не змінюється
+func (r *InviteRepository) Revoke(ctx context.Context, inviteId int, at time.Time) error {
+	return r.db.write(ctx, func(s *store) error {
+		for i := range s.invites {
+			if s.invites[i].Id == inviteId && s.invites[i].RevokedAt == nil {
+				s.invites[i].RevokedAt = &at
+			}
+		}
+		return nil
+	})
+}
+
+// Use отримує ID посилання та час ТА збільшує кількість його використань,
+// якщо на цей час посилання дійсне
+func (r *InviteRepository) Use(ctx context.Context, inviteId int, at time.Time) (bool, error) {
+	var used bool
+	err := r.db.write(ctx, func(s *store) error {
+		for i := range s.invites {
+			invite := &s.invites[i]
+			if invite.Id != inviteId || invite.RevokedAt != nil {
+				continue
+			}
+			if (invite.ExpiresAt == nil || invite.ExpiresAt.After(at)) && (invite.MaxUses == 0 || invite.Uses < invite.MaxUses) {
+				invite.Uses++
+				used = true
+			}
+		}
+		return nil
+	})
+	return used, err
+}
+
+type JoinRepository struct {
+	db db
+}
+
+// Create отримує дані вступу до чату ТА повертає його ID. Повертає
+// ErrDuplicate, якщо користувач вже має заявку до чату, що чекає рішення
+func (r *JoinRepository) Create(ctx context.Context, join models.ChatJoin) (int, error) {
+	err := r.db.write(ctx, func(s *store) error {
+		if _, ok := s.chat(join.ChatId); !ok {
+			return reference("chat %d", join.ChatId)
+		}
+		if _, ok := s.user(join.UserId); !ok {
+			return reference("user %d", join.UserId)
+		}
+		if join.InviteId != nil && !s.invite(*join.InviteId) {
+			return reference("invite %d", *join.InviteId)
+		}
+		for _, other := range s.joins {
+			if join.Status == repository.JoinPending && other.Status == repository.JoinPending &&
+				other.ChatId == join.ChatId && other.UserId == join.UserId {
+				return duplicate("pending join of user %d to chat %d", join.UserId, join.ChatId)
+			}
+		}
+		join.Id = s.nextId(repository.ChatJoins)
+		s.joins = append(s.joins, join)
+		return nil
+	})
+	return join.Id, err
+}
+
+// Get отримує ID вступу ТА повертає його дані
+func (r *JoinRepository) Get(ctx context.Context, joinId int) (models.ChatJoin, error) {
+	var join models.ChatJoin
+	err := r.db.read(ctx, func(s *store) error {
+		for _, other := range s.joins {
+			if other.Id == joinId {
+				join = other
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return join, err
+}
+
+// GetByChat отримує ID чату та статус ТА повертає вступи до чату з цим
+// статусом від найстаршого
+func (r *JoinRepository) GetByChat(ctx context.Context, chatId int, status string) ([]models.ChatJoin, error) {
+	var joins []models.ChatJoin
+	err := r.db.read(ctx, func(s *store) error {
+		for _, join := range s.joins {
+			if join.ChatId == chatId && join.Status == status {
+				joins = append(joins, join)
+			}
+		}
+		return nil
+	})
+	return joins, err
+}
+
+// UpdateStatus отримує дані вступу ТА змінює його статус
+func (r *JoinRepository) UpdateStatus(ctx context.Context, join models.ChatJoin) error {
+	return r.db.write(ctx, func(s *store) error {
+		for i := range s.joins {
+			if s.joins[i].Id == join.Id {
+				s.joins[i].Status = join.Status
+			}
+		}
+		return nil
+	})
+}
+
+func (s *store) invite(inviteId int) bool {
+	for _, invite := range s.invites {
+		if invite.Id == inviteId {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteInvites видаляє посилання та вступи чату
+func (s *store) deleteInvites(chatId int) {
+	var invites []models.ChatInvite
+	for _, invite := range s.invites {
+		if invite.ChatId != chatId {
+			invites = append(invites, invite)
+		}
+	}
+	s.invites = invites
+	var joins []models.ChatJoin
+	for _, join := range s.joins {
+		if join.ChatId != chatId {
+			joins = append(joins, join)
+		}
+	}
+	s.joins = joins
+}
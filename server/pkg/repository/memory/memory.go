@@ -0,0 +1,222 @@
+// Package memory містить реалізацію репозиторіїв у пам'яті. Вона
+// відтворює поведінку БД (унікальність, зовнішні ключі, каскадне
+// видалення та порядок записів) і використовується у наскрізних тестах
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// store зберігає таблиці БД. Записи кожної таблиці впорядковані за ID
+type store struct {
+	// mu захищає таблиці, tx дозволяє змінювати їх лише одній транзакції
+	mu sync.RWMutex
+	tx sync.Mutex
+
+	users    []models.User
+	chats    []models.Chat
+	members  []models.ChatUsers
+	privates []models.PrivateChat
+	invites  []models.ChatInvite
+	joins    []models.ChatJoin
+	statuses []models.Status
+	messages []models.Message
+	uploads  []models.Upload
+	settings map[int]models.UserSettings
+	lastSeen map[int]time.Time
+	// lastId - останній виданий ID кожної таблиці
+	lastId map[string]int
+}
+
+// clone повертає копію таблиць для відкату транзакції
+func (s *store) clone() *store {
+	lastId := make(map[string]int, len(s.lastId))
+	for table, id := range s.lastId {
+		lastId[table] = id
+	}
+	settings := make(map[int]models.UserSettings, len(s.settings))
+	for userId, value := range s.settings {
+		settings[userId] = value
+	}
+	lastSeen := make(map[int]time.Time, len(s.lastSeen))
+	for userId, at := range s.lastSeen {
+		lastSeen[userId] = at
+	}
+	return &store{
+		users:    append([]models.User(nil), s.users...),
+		chats:    append([]models.Chat(nil), s.chats...),
+		members:  append([]models.ChatUsers(nil), s.members...),
+		privates: append([]models.PrivateChat(nil), s.privates...),
+		invites:  append([]models.ChatInvite(nil), s.invites...),
+		joins:    append([]models.ChatJoin(nil), s.joins...),
+		statuses: append([]models.Status(nil), s.statuses...),
+		messages: append([]models.Message(nil), s.messages...),
+		uploads:  append([]models.Upload(nil), s.uploads...),
+		settings: settings,
+		lastSeen: lastSeen,
+		lastId:   lastId,
+	}
+}
+
+// restore повертає таблиці до стану копії
+func (s *store) restore(from *store) {
+	s.users, s.chats, s.members, s.privates = from.users, from.chats, from.members, from.privates
+	s.invites, s.joins = from.invites, from.joins
+	s.statuses, s.messages, s.uploads = from.statuses, from.messages, from.uploads
+	s.settings, s.lastSeen = from.settings, from.lastSeen
+	s.lastId = from.lastId
+}
+
+// nextId повертає новий ID запису таблиці
+func (s *store) nextId(table string) int {
+	s.lastId[table]++
+	return s.lastId[table]
+}
+
+func (s *store) user(userId int) (models.User, bool) {
+	for _, user := range s.users {
+		if user.Id == userId {
+			return user, true
+		}
+	}
+	return models.User{}, false
+}
+
+func (s *store) chat(chatId int) (models.Chat, bool) {
+	for _, chat := range s.chats {
+		if chat.Id == chatId {
+			return chat, true
+		}
+	}
+	return models.Chat{}, false
+}
+
+// public повертає відкриті дані користувача без хешу паролю
+func public(user models.User) models.User {
+	return models.User{Id: user.Id, Username: user.Username, Icon: user.Icon}
+}
+
+// contains повторює LOWER(value) LIKE LOWER('%part%')
+func contains(value, part string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(part))
+}
+
+func duplicate(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", repository.ErrDuplicate, fmt.Sprintf(format, args...))
+}
+
+func reference(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", repository.ErrReference, fmt.Sprintf(format, args...))
+}
+
+// db надає репозиторіям доступ до сховища. Репозиторії транзакції
+// змінюють сховище без очікування блокування транзакції, яке вже утримують
+type db struct {
+	s  *store
+	tx bool
+}
+
+// read викликає fn для читання таблиць
+func (d db) read(ctx context.Context, fn func(s *store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	d.s.mu.RLock()
+	defer d.s.mu.RUnlock()
+	return fn(d.s)
+}
+
+// write викликає fn для зміни таблиць. Поза транзакцією зміна чекає
+// завершення поточної транзакції
+func (d db) write(ctx context.Context, fn func(s *store) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !d.tx {
+		d.s.tx.Lock()
+		defer d.s.tx.Unlock()
+	}
+	d.s.mu.Lock()
+	defer d.s.mu.Unlock()
+	return fn(d.s)
+}
+
+// NewRepository повертає репозиторії з порожнім сховищем у пам'яті
+func NewRepository() *repository.Repository {
+	s := &store{
+		settings: make(map[int]models.UserSettings),
+		lastSeen: make(map[int]time.Time),
+		lastId:   make(map[string]int),
+	}
+	repos := newRepository(db{s: s})
+	repos.Transactor = &Transactor{s: s}
+	return repos
+}
+
+func newRepository(d db) *repository.Repository {
+	return &repository.Repository{
+		Authorization: &AuthRepository{db: d},
+		Chat:          &ChatRepository{db: d},
+		Status:        &StatusRepository{db: d},
+		Message:       &MessageRepository{db: d},
+		Upload:        &UploadRepository{db: d},
+		Settings:      &SettingsRepository{db: d},
+		Invite:        &InviteRepository{db: d},
+		Join:          &JoinRepository{db: d},
+	}
+}
+
+// Transactor виконує транзакції над сховищем у пам'яті. Транзакції
+// виконуються по одній; зміни поза транзакцією чекають її завершення
+type Transactor struct {
+	s *store
+}
+
+// Transaction викликає fn з репозиторіями, що працюють у транзакції.
+// Якщо fn повертає помилку (або panic), усі зміни відкочуються
+func (t *Transactor) Transaction(ctx context.Context, fn func(repos *repository.Repository) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t.s.tx.Lock()
+	defer t.s.tx.Unlock()
+
+	t.s.mu.RLock()
+	snapshot := t.s.clone()
+	t.s.mu.RUnlock()
+
+	rollback := func() {
+		t.s.mu.Lock()
+		t.s.restore(snapshot)
+		t.s.mu.Unlock()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			rollback()
+			panic(r)
+		}
+	}()
+
+	repos := newRepository(db{s: t.s, tx: true})
+	repos.Transactor = inTransaction{repos: repos}
+	if err := fn(repos); err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+
+// inTransaction виконує вкладені виклики Transaction у поточній транзакції
+type inTransaction struct {
+	repos *repository.Repository
+}
+
+func (t inTransaction) Transaction(_ context.Context, fn func(repos *repository.Repository) error) error {
+	return fn(t.repos)
+}
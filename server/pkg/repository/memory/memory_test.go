@@ -0,0 +1,460 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func createUser(t *testing.T, repos *repository.Repository, username string) int {
+	id, err := repos.Authorization.CreateUser(context.Background(), models.User{Username: username, Password: "hash"})
+	require.NoError(t, err)
+	return id
+}
+
+func TestAuthRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+
+	id := createUser(t, repos, "first")
+	_, err := repos.Authorization.CreateUser(ctx, models.User{Username: "first", Password: "hash"})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+
+	user, err := repos.Authorization.GetUser(ctx, "first", "hash")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.Id)
+	_, err = repos.Authorization.GetByName(ctx, "nobody")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	createUser(t, repos, "second")
+	user.Username = "second"
+	assert.ErrorIs(t, repos.Authorization.UpdateUser(ctx, user), repository.ErrDuplicate)
+
+	// Пароль не повертається разом з даними користувача
+	user, err = repos.Authorization.GetUserById(ctx, id)
+	require.NoError(t, err)
+	assert.Empty(t, user.Password)
+}
+
+func TestChatRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	first, second := createUser(t, repos, "first"), createUser(t, repos, "second")
+
+	chatId, err := repos.Chat.Create(ctx, models.Chat{Name: "chat", Types: repository.ChatPrivate})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: first})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: first})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId + 1, UserId: second})
+	assert.ErrorIs(t, err, repository.ErrReference)
+
+	// Особистий чат не повертається, а приватний називається ім'ям
+	// співрозмовника
+	chats, err := repos.Chat.GetPrivateChats(ctx, first)
+	require.NoError(t, err)
+	assert.Empty(t, chats)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: second})
+	require.NoError(t, err)
+	chats, err = repos.Chat.GetPrivateChats(ctx, first)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, models.Chat{Id: chatId, Name: "second", Types: repository.ChatPrivate, PartnerId: second}, chats[0])
+	require.NoError(t, repos.Chat.AddPrivate(ctx, second, first, chatId))
+	assert.ErrorIs(t, repos.Chat.AddPrivate(ctx, first, second, chatId), repository.ErrDuplicate)
+	privateId, err := repos.Chat.GetPrivate(ctx, first, second)
+	require.NoError(t, err)
+	assert.Equal(t, chatId, privateId)
+	privateId, err = repos.Chat.GetPrivate(ctx, first, first)
+	require.NoError(t, err)
+	assert.Zero(t, privateId)
+
+	for i := 0; i < 3; i++ {
+		_, err = repos.Message.Create(ctx, models.Message{ChatId: chatId, Author: first, Text: fmt.Sprint(i)})
+		require.NoError(t, err)
+	}
+	messages, err := repos.Message.GetLimit(ctx, chatId, 2)
+	require.NoError(t, err)
+	require.Len(t, messages, 2)
+	assert.Equal(t, "2", messages[0].Text, "newest message goes first")
+
+	// Учасники та повідомлення видаляються разом з чатом
+	require.NoError(t, repos.Chat.Delete(ctx, chatId))
+	users, err := repos.Chat.GetUsers(ctx, chatId)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	messages, err = repos.Message.GetLimit(ctx, chatId, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	privateId, err = repos.Chat.GetPrivate(ctx, first, second)
+	require.NoError(t, err)
+	assert.Zero(t, privateId)
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+
+	errStop := errors.New("stop")
+	err := repos.Transaction(ctx, func(tx *repository.Repository) error {
+		createUser(t, tx, "rolled back")
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	_, err = repos.Authorization.GetByName(ctx, "rolled back")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = repos.Transaction(ctx, func(tx *repository.Repository) error {
+		createUser(t, tx, "committed")
+		return tx.Transaction(ctx, func(nested *repository.Repository) error {
+			createUser(t, nested, "nested")
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	_, err = repos.Authorization.GetByName(ctx, "nested")
+	assert.NoError(t, err)
+}
+
+func TestUploadRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+
+	require.NoError(t, repos.Upload.Acquire(ctx, "a.png"))
+	require.NoError(t, repos.Upload.Release(ctx, "a.png"))
+	require.NoError(t, repos.Upload.Release(ctx, "a.png"))
+
+	future := time.Now().Add(time.Hour)
+	orphans, err := repos.Upload.GetOrphans(ctx, future)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, 0, orphans[0].Refs)
+
+	id := createUser(t, repos, "first")
+	require.NoError(t, repos.Authorization.UpdateUser(ctx, models.User{Id: id, Username: "first", Icon: "a.png"}))
+	orphans, err = repos.Upload.GetOrphans(ctx, future)
+	require.NoError(t, err)
+	assert.Empty(t, orphans)
+}
+
+func TestStatusRepository_GetRelations(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	first, second, third, fourth := createUser(t, repos, "first"), createUser(t, repos, "second"), createUser(t, repos, "third"), createUser(t, repos, "fourth")
+	for _, status := range []models.Status{
+		{SenderId: first, RecipientId: second, Relationship: repository.StatusFriends},
+		{SenderId: third, RecipientId: first, Relationship: repository.StatusInvitation},
+		{SenderId: second, RecipientId: third, Relationship: repository.StatusBL},
+		{SenderId: first, RecipientId: fourth, Relationship: repository.StatusBL},
+	} {
+		_, err := repos.Status.AddStatus(ctx, status)
+		require.NoError(t, err)
+	}
+
+	relations, err := repos.Status.GetRelations(ctx, first, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Relation{
+		{User: models.User{Id: second, Username: "second"}, Relationship: repository.StatusFriends, Outgoing: true},
+		{User: models.User{Id: third, Username: "third"}, Relationship: repository.StatusInvitation, Outgoing: false},
+		{User: models.User{Id: fourth, Username: "fourth"}, Relationship: repository.StatusBL, Outgoing: true},
+	}, relations)
+
+	relations, err = repos.Status.GetRelations(ctx, first, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, relations, 1)
+	assert.Equal(t, third, relations[0].Id)
+}
+
+func TestStatusRepository_GetSuggestions(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	ids := map[string]int{}
+	for _, name := range []string{"me", "a", "b", "c", "d", "e", "f", "g", "h"} {
+		ids[name] = createUser(t, repos, name)
+	}
+	for _, status := range []struct{ sender, recipient, relationship string }{
+		{"me", "a", repository.StatusFriends}, {"b", "me", repository.StatusFriends},
+		{"a", "c", repository.StatusFriends}, {"c", "b", repository.StatusFriends}, {"a", "d", repository.StatusFriends},
+		// Запрошені, заблоковані та приховані з пошуку не пропонуються
+		{"a", "f", repository.StatusFriends}, {"me", "f", repository.StatusInvitation},
+		{"a", "g", repository.StatusFriends}, {"g", "me", repository.StatusBL},
+		{"a", "h", repository.StatusFriends},
+	} {
+		_, err := repos.Status.AddStatus(ctx, models.Status{SenderId: ids[status.sender], RecipientId: ids[status.recipient], Relationship: status.relationship})
+		require.NoError(t, err)
+	}
+	hidden := repository.DefaultSettings(ids["h"])
+	hidden.Searchable = false
+	require.NoError(t, repos.Settings.Save(ctx, hidden))
+
+	// Спільним вважається лише публічний чат
+	for types, members := range map[string][]string{repository.ChatPublic: {"me", "d", "e"}, repository.ChatPrivate: {"me", "c"}} {
+		chatId, err := repos.Chat.Create(ctx, models.Chat{Name: types, Types: types})
+		require.NoError(t, err)
+		for _, name := range members {
+			_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: ids[name]})
+			require.NoError(t, err)
+		}
+	}
+
+	suggestions, err := repos.Status.GetSuggestions(ctx, ids["me"], 10)
+	require.NoError(t, err)
+	var got []string
+	for _, s := range suggestions {
+		got = append(got, fmt.Sprintf("%s:%d:%d", s.Username, s.MutualFriends, s.SharedChats))
+	}
+	assert.Equal(t, []string{"c:2:0", "d:1:1", "e:0:1"}, got)
+
+	suggestions, err = repos.Status.GetSuggestions(ctx, ids["me"], 1)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+}
+
+func TestSettingsRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	first, second := createUser(t, repos, "first"), createUser(t, repos, "second")
+
+	settings, err := repos.Settings.Get(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, repository.DefaultSettings(first), settings)
+
+	settings.Searchable = false
+	require.NoError(t, repos.Settings.Save(ctx, settings))
+	assert.ErrorIs(t, repos.Settings.Save(ctx, repository.DefaultSettings(second+1)), repository.ErrReference)
+	users, err := repos.Status.SearchUser(ctx, second, "first")
+	require.NoError(t, err)
+	assert.Empty(t, users)
+
+	seen := time.Now()
+	require.NoError(t, repos.Settings.Seen(ctx, first, seen))
+	lastSeen, err := repos.Settings.GetLastSeen(ctx, first)
+	require.NoError(t, err)
+	require.NotNil(t, lastSeen)
+	assert.True(t, seen.Equal(*lastSeen))
+	lastSeen, err = repos.Settings.GetLastSeen(ctx, second)
+	require.NoError(t, err)
+	assert.Nil(t, lastSeen)
+}
+
+func TestConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	chatId, err := repos.Chat.Create(ctx, models.Chat{Name: "chat", Types: repository.ChatPublic})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = repos.Transaction(ctx, func(tx *repository.Repository) error {
+				_, err := tx.Message.Create(ctx, models.Message{ChatId: chatId, Text: fmt.Sprint(i)})
+				return err
+			})
+			_, _ = repos.Message.Create(ctx, models.Message{ChatId: chatId, Text: fmt.Sprint(i)})
+		}(i)
+	}
+	wg.Wait()
+
+	messages, err := repos.Message.GetLimit(ctx, chatId, 100)
+	require.NoError(t, err)
+	assert.Len(t, messages, 40)
+}
+
+func TestInviteRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	owner, guest := createUser(t, repos, "owner"), createUser(t, repos, "guest")
+	chatId, err := repos.Chat.Create(ctx, models.Chat{Name: "chat", Types: repository.ChatPublic})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: owner, Role: repository.RoleAdmin})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: guest})
+	require.NoError(t, err)
+
+	// Роль за замовчуванням - звичайний учасник
+	member, err := repos.Chat.GetMember(ctx, chatId, guest)
+	require.NoError(t, err)
+	assert.Equal(t, repository.RoleMember, member.Role)
+	member.Role = repository.RoleAdmin
+	require.NoError(t, repos.Chat.UpdateRole(ctx, member))
+	members, err := repos.Chat.GetMembers(ctx, chatId)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, []string{repository.RoleAdmin, repository.RoleAdmin}, []string{members[0].Role, members[1].Role})
+	_, err = repos.Chat.GetMember(ctx, chatId+100, guest)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	now := time.Now()
+	invite := models.ChatInvite{ChatId: chatId, Token: "token", CreatedBy: owner, MaxUses: 2, CreatedAt: now}
+	inviteId, err := repos.Invite.Create(ctx, invite)
+	require.NoError(t, err)
+	_, err = repos.Invite.Create(ctx, invite)
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	expired := now.Add(-time.Hour)
+	expiredId, err := repos.Invite.Create(ctx, models.ChatInvite{ChatId: chatId, Token: "expired", CreatedBy: owner, ExpiresAt: &expired, CreatedAt: now})
+	require.NoError(t, err)
+
+	// Посилання використовується не більше MaxUses разів і не після
+	// закінчення терміну дії
+	for i, want := range []bool{true, true, false} {
+		used, err := repos.Invite.Use(ctx, inviteId, now)
+		require.NoError(t, err)
+		assert.Equal(t, want, used, "use %d", i)
+	}
+	used, err := repos.Invite.Use(ctx, expiredId, now)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	found, err := repos.Invite.GetByToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, inviteId, found.Id)
+	assert.Equal(t, 2, found.Uses)
+	_, err = repos.Invite.GetByToken(ctx, "unknown")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, repos.Invite.Revoke(ctx, expiredId, now))
+	found, err = repos.Invite.Get(ctx, expiredId)
+	require.NoError(t, err)
+	assert.NotNil(t, found.RevokedAt)
+	invites, err := repos.Invite.GetByChat(ctx, chatId)
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+	assert.Equal(t, inviteId, invites[0].Id)
+
+	joinId, err := repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, InviteId: &inviteId, Status: repository.JoinPending, CreatedAt: now})
+	require.NoError(t, err)
+	_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId + 100, UserId: guest, Status: repository.JoinPending, CreatedAt: now})
+	assert.ErrorIs(t, err, repository.ErrReference)
+
+	// Користувач має лише одну заявку, що чекає рішення, а інші вступи не обмежені
+	_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, Status: repository.JoinPending, CreatedAt: now})
+	assert.ErrorIs(t, err, repository.ErrDuplicate)
+	for i := 0; i < 2; i++ {
+		_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, Status: repository.JoinRejected, CreatedAt: now})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repos.Join.UpdateStatus(ctx, models.ChatJoin{Id: joinId, Status: repository.JoinJoined}))
+	_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, Status: repository.JoinPending, CreatedAt: now})
+	require.NoError(t, err)
+	join, err := repos.Join.Get(ctx, joinId)
+	require.NoError(t, err)
+	assert.Equal(t, repository.JoinJoined, join.Status)
+	require.NotNil(t, join.InviteId)
+	assert.Equal(t, inviteId, *join.InviteId)
+	pending, err := repos.Join.GetByChat(ctx, chatId, repository.JoinPending)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// Посилання та вступи видаляються разом з чатом
+	require.NoError(t, repos.Chat.Delete(ctx, chatId))
+	invites, err = repos.Invite.GetByChat(ctx, chatId)
+	require.NoError(t, err)
+	assert.Empty(t, invites)
+	_, err = repos.Join.Get(ctx, joinId)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestChatRepository_Visibility(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	openId, err := repos.Chat.Create(ctx, models.Chat{Name: "room open", Types: repository.ChatPublic})
+	require.NoError(t, err)
+	hiddenId, err := repos.Chat.Create(ctx, models.Chat{Name: "room hidden", Types: repository.ChatPublic, Visibility: repository.VisibilityHidden})
+	require.NoError(t, err)
+
+	// Без видимості чат відкритий, прихований чат пошук не знаходить
+	chat, err := repos.Chat.Get(ctx, openId)
+	require.NoError(t, err)
+	assert.Equal(t, repository.VisibilityOpen, chat.Visibility)
+	chats, err := repos.Chat.SearchChat(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, openId, chats[0].Id)
+
+	chat.Visibility = repository.VisibilityRequest
+	require.NoError(t, repos.Chat.Update(ctx, chat))
+	chat, err = repos.Chat.Get(ctx, hiddenId)
+	require.NoError(t, err)
+	chat.Visibility = repository.VisibilityOpen
+	require.NoError(t, repos.Chat.Update(ctx, chat))
+	chats, err = repos.Chat.SearchChat(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, chats, 2)
+	assert.Equal(t, repository.VisibilityRequest, chats[0].Visibility)
+}
+
+func TestChatRepository_Metadata(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository()
+	first, second := createUser(t, repos, "first"), createUser(t, repos, "second")
+	created := time.Now().Add(-time.Hour)
+	quietId, err := repos.Chat.Create(ctx, models.Chat{Name: "quiet", Types: repository.ChatPublic, Description: "about", Topic: "news", CreatedBy: &first, CreatedAt: &created})
+	require.NoError(t, err)
+	busyId, err := repos.Chat.Create(ctx, models.Chat{Name: "busy", Types: repository.ChatPublic, CreatedBy: &first, CreatedAt: &created})
+	require.NoError(t, err)
+	legacyId, err := repos.Chat.Create(ctx, models.Chat{Name: "legacy", Types: repository.ChatPublic})
+	require.NoError(t, err)
+	for _, member := range []models.ChatUsers{{ChatId: quietId, UserId: first}, {ChatId: busyId, UserId: first}, {ChatId: busyId, UserId: second}, {ChatId: legacyId, UserId: first}} {
+		_, err := repos.Chat.AddUser(ctx, member)
+		require.NoError(t, err)
+	}
+	for _, text := range []string{"earlier", "latest"} {
+		_, err := repos.Message.Create(ctx, models.Message{ChatId: busyId, Author: second, Text: text, SentAt: time.Now()})
+		require.NoError(t, err)
+	}
+
+	// Кількість учасників та останнє повідомлення обчислюються запитом
+	chat, err := repos.Chat.Get(ctx, busyId)
+	require.NoError(t, err)
+	assert.Equal(t, 2, chat.MemberCount)
+	require.NotNil(t, chat.LastMessage)
+	assert.Equal(t, "latest", chat.LastMessage.Text)
+	assert.Equal(t, second, chat.LastMessage.Author)
+	require.NotNil(t, chat.LastMessageAt)
+	assert.WithinDuration(t, time.Now(), *chat.LastMessageAt, time.Minute)
+
+	chat, err = repos.Chat.Get(ctx, quietId)
+	require.NoError(t, err)
+	assert.Equal(t, "about", chat.Description)
+	assert.Equal(t, "news", chat.Topic)
+	require.NotNil(t, chat.CreatedBy)
+	assert.Equal(t, first, *chat.CreatedBy)
+	require.NotNil(t, chat.CreatedAt)
+	assert.WithinDuration(t, created, *chat.CreatedAt, time.Second)
+	assert.Equal(t, 1, chat.MemberCount)
+	assert.Nil(t, chat.LastMessage)
+	assert.Nil(t, chat.LastMessageAt)
+
+	chat.Description, chat.Topic = "", "sport"
+	require.NoError(t, repos.Chat.Update(ctx, chat))
+	chat, err = repos.Chat.Get(ctx, quietId)
+	require.NoError(t, err)
+	assert.Empty(t, chat.Description)
+	assert.Equal(t, "sport", chat.Topic)
+	assert.Equal(t, "quiet", chat.Name)
+
+	// Спершу чат з новим повідомленням, далі створений годину тому, а чат
+	// без відомої активності - в кінці
+	chats, err := repos.Chat.GetPublicChats(ctx, first)
+	require.NoError(t, err)
+	var ids []int
+	for _, chat := range chats {
+		ids = append(ids, chat.Id)
+	}
+	assert.Equal(t, []int{busyId, quietId, legacyId}, ids)
+	require.NotNil(t, chats[0].LastMessage)
+	assert.Equal(t, "latest", chats[0].LastMessage.Text)
+	assert.Nil(t, chats[2].CreatedAt)
+}
@@ -0,0 +1,63 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"gorm.io/gorm"
+)
+
+type MessageRepository struct {
+	db db
+}
+
+// Create отримує дані повідомлення ТА повертає його ID
+func (m *MessageRepository) Create(ctx context.Context, msg models.Message) (int, error) {
+	err := m.db.write(ctx, func(s *store) error {
+		if _, ok := s.chat(msg.ChatId); !ok {
+			return reference("chat %d", msg.ChatId)
+		}
+		msg.Id = s.nextId(repository.MessagesTable)
+		s.messages = append(s.messages, msg)
+		return nil
+	})
+	return msg.Id, err
+}
+
+// Get отримує ID повідомлення ТА повертає його дані
+func (m *MessageRepository) Get(ctx context.Context, msgId int) (models.Message, error) {
+	var msg models.Message
+	err := m.db.read(ctx, func(s *store) error {
+		for _, other := range s.messages {
+			if other.Id == msgId {
+				msg = other
+				return nil
+			}
+		}
+		return gorm.ErrRecordNotFound
+	})
+	return msg, err
+}
+
+// GetLimit отримує ID чату та ліміт кількості повідомлень ТА повертає
+// останні повідомлення чату, починаючи з найновішого
+func (m *MessageRepository) GetLimit(ctx context.Context, chatId, limit int) ([]models.Message, error) {
+	var messages []models.Message
+	err := m.db.read(ctx, func(s *store) error {
+		for i := len(s.messages) - 1; i >= 0 && len(messages) < limit; i-- {
+			if s.messages[i].ChatId == chatId {
+				messages = append(messages, s.messages[i])
+			}
+		}
+		return nil
+	})
+	return messages, err
+}
+
+// DeleteAll отримує ID чату ТА видаляє його повідомлення
+func (m *MessageRepository) DeleteAll(ctx context.Context, chatId int) error {
+	return m.db.write(ctx, func(s *store) error {
+		s.deleteMessages(chatId)
+		return nil
+	})
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"time"
+)
+
+type SettingsRepository struct {
+	db db
+}
+
+// Get отримує ID користувача ТА повертає його налаштування. Якщо
+// користувач їх не змінював, повертає repository.DefaultSettings
+func (r *SettingsRepository) Get(ctx context.Context, userId int) (models.UserSettings, error) {
+	settings := repository.DefaultSettings(userId)
+	err := r.db.read(ctx, func(s *store) error {
+		if saved, ok := s.settings[userId]; ok {
+			settings = saved
+		}
+		return nil
+	})
+	return settings, err
+}
+
+// Save отримує налаштування ТА створює або оновлює їх
+func (r *SettingsRepository) Save(ctx context.Context, settings models.UserSettings) error {
+	return r.db.write(ctx, func(s *store) error {
+		if _, ok := s.user(settings.UserId); !ok {
+			return reference("user %d", settings.UserId)
+		}
+		s.settings[settings.UserId] = settings
+		return nil
+	})
+}
+
+// Seen отримує ID користувача та час ТА зберігає його як час останньої
+// активності
+func (r *SettingsRepository) Seen(ctx context.Context, userId int, at time.Time) error {
+	return r.db.write(ctx, func(s *store) error {
+		if _, ok := s.user(userId); !ok {
+			return reference("user %d", userId)
+		}
+		s.lastSeen[userId] = at
+		return nil
+	})
+}
+
+// GetLastSeen отримує ID користувача ТА повертає час його останньої
+// активності або nil, якщо користувач ще не був активним
+func (r *SettingsRepository) GetLastSeen(ctx context.Context, userId int) (*time.Time, error) {
+	var lastSeen *time.Time
+	err := r.db.read(ctx, func(s *store) error {
+		if at, ok := s.lastSeen[userId]; ok {
+			lastSeen = &at
+		}
+		return nil
+	})
+	return lastSeen, err
+}
@@ -0,0 +1,293 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"sort"
+)
+
+type StatusRepository struct {
+	db db
+}
+
+// AddStatus отримує ID двох користувачів та їх тип відносин ТА повертає ID статусу
+func (s *StatusRepository) AddStatus(ctx context.Context, status models.Status) (int, error) {
+	err := s.db.write(ctx, func(st *store) error {
+		if _, ok := st.user(status.SenderId); !ok {
+			return reference("user %d", status.SenderId)
+		}
+		if _, ok := st.user(status.RecipientId); !ok {
+			return reference("user %d", status.RecipientId)
+		}
+		for _, other := range st.statuses {
+			if other.SenderId == status.SenderId && other.RecipientId == status.RecipientId {
+				return duplicate("status of users %d and %d", status.SenderId, status.RecipientId)
+			}
+		}
+		status.Id = st.nextId(repository.StatusesTable)
+		st.statuses = append(st.statuses, status)
+		return nil
+	})
+	return status.Id, err
+}
+
+// GetStatuses отримує ID двох користувачів ТА повертає усі записи їх
+// відносин в обох напрямках
+func (s *StatusRepository) GetStatuses(ctx context.Context, senderId, recipientId int) ([]models.Status, error) {
+	var statuses []models.Status
+	err := s.db.read(ctx, func(st *store) error {
+		for _, status := range st.statuses {
+			if (status.SenderId == senderId && status.RecipientId == recipientId) ||
+				(status.SenderId == recipientId && status.RecipientId == senderId) {
+				statuses = append(statuses, status)
+			}
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// UpdateStatus отримує ID двох користувачів та їх тип відносин ТА оновлює дані
+func (s *StatusRepository) UpdateStatus(ctx context.Context, status models.Status) error {
+	return s.db.write(ctx, func(st *store) error {
+		for i := range st.statuses {
+			if st.statuses[i].SenderId == status.SenderId && st.statuses[i].RecipientId == status.RecipientId && status.Relationship != "" {
+				st.statuses[i].Relationship = status.Relationship
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteStatus отримує ID двох користувачів та їх тип відносин ТА видаляє ці відносини
+func (s *StatusRepository) DeleteStatus(ctx context.Context, status models.Status) error {
+	return s.db.write(ctx, func(st *store) error {
+		var statuses []models.Status
+		for _, other := range st.statuses {
+			if other.SenderId != status.SenderId || other.RecipientId != status.RecipientId || other.Relationship != status.Relationship {
+				statuses = append(statuses, other)
+			}
+		}
+		st.statuses = statuses
+		return nil
+	})
+}
+
+// GetFriends отримує ID користувача ТА повертає масив користувачів, що є ДРУЗЯМИ,
+// в порядку створення дружби
+func (s *StatusRepository) GetFriends(ctx context.Context, userId int) ([]models.User, error) {
+	return s.related(ctx, func(st *store) []models.User {
+		var users []models.User
+		for _, id := range st.friends(userId) {
+			if user, ok := st.user(id); ok {
+				users = append(users, public(user))
+			}
+		}
+		return users
+	})
+}
+
+// GetRelations отримує ID користувача ТА повертає до limit пов'язаних з
+// ним користувачів, починаючи з offset, з типом та напрямком відносин.
+// Користувачі повертаються в порядку створення відносин
+func (s *StatusRepository) GetRelations(ctx context.Context, userId, offset, limit int) ([]models.Relation, error) {
+	var relations []models.Relation
+	err := s.db.read(ctx, func(st *store) error {
+		for _, status := range st.statuses {
+			if status.SenderId != userId && status.RecipientId != userId {
+				continue
+			}
+			if offset > 0 {
+				offset--
+				continue
+			}
+			if len(relations) == limit {
+				break
+			}
+			otherId := status.SenderId
+			if otherId == userId {
+				otherId = status.RecipientId
+			}
+			if user, ok := st.user(otherId); ok {
+				relations = append(relations, models.Relation{User: public(user), Relationship: status.Relationship, Outgoing: status.SenderId == userId})
+			}
+		}
+		return nil
+	})
+	return relations, err
+}
+
+// GetBlackList отримує ID користувача ТА повертає масив ЗАБЛОКОВАНИХ користувачів
+func (s *StatusRepository) GetBlackList(ctx context.Context, userId int) ([]models.User, error) {
+	return s.related(ctx, func(st *store) []models.User { return st.recipients(repository.StatusBL, userId) })
+}
+
+// GetBlackListToUser отримує ID користувача ТА повертає масив користувачів, що
+// ЗАБЛОКУВАЛИ його
+func (s *StatusRepository) GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error) {
+	return s.related(ctx, func(st *store) []models.User { return st.senders(repository.StatusBL, userId) })
+}
+
+// GetSentInvites отримує ID користувача ТА повертає масив користувачів, що
+// ОТРИМАЛИ його запрошення у друзі
+func (s *StatusRepository) GetSentInvites(ctx context.Context, userId int) ([]models.User, error) {
+	return s.related(ctx, func(st *store) []models.User { return st.recipients(repository.StatusInvitation, userId) })
+}
+
+// GetInvites отримує ID користувача ТА повертає масив користувачів, що
+// НАДІСЛАЛИ йому запрошення в друзі
+func (s *StatusRepository) GetInvites(ctx context.Context, userId int) ([]models.User, error) {
+	return s.related(ctx, func(st *store) []models.User { return st.senders(repository.StatusInvitation, userId) })
+}
+
+// SearchUser отримує ім'я (або його частину) ТА повертає масив користувачів, що
+// мають збіг з аргументом, крім тих, що ЗАБЛОКУВАЛИ userId або заборонили
+// пошук за іменем
+func (s *StatusRepository) SearchUser(ctx context.Context, userId int, username string) ([]models.User, error) {
+	var users []models.User
+	err := s.db.read(ctx, func(st *store) error {
+		blockers := map[int]bool{}
+		for _, user := range st.senders(repository.StatusBL, userId) {
+			blockers[user.Id] = true
+		}
+		for _, user := range st.users {
+			if len(users) == searchLimit {
+				break
+			}
+			settings, ok := st.settings[user.Id]
+			if ok && !settings.Searchable {
+				continue
+			}
+			if contains(user.Username, username) && !blockers[user.Id] {
+				users = append(users, public(user))
+			}
+		}
+		return nil
+	})
+	return users, err
+}
+
+// GetSuggestions отримує ID користувача ТА повертає до limit користувачів,
+// з якими він ще не має відносин, за спаданням кількості спільних друзів
+// та спільних публічних чатів. Користувачі, що заборонили пошук за іменем,
+// не пропонуються
+func (s *StatusRepository) GetSuggestions(ctx context.Context, userId, limit int) ([]models.Suggestion, error) {
+	var suggestions []models.Suggestion
+	err := s.db.read(ctx, func(st *store) error {
+		related := map[int]bool{userId: true}
+		for _, status := range st.statuses {
+			if status.SenderId == userId {
+				related[status.RecipientId] = true
+			}
+			if status.RecipientId == userId {
+				related[status.SenderId] = true
+			}
+		}
+		candidates := map[int]*models.Suggestion{}
+		candidate := func(id int) *models.Suggestion {
+			if related[id] {
+				return nil
+			}
+			if settings, ok := st.settings[id]; ok && !settings.Searchable {
+				return nil
+			}
+			if _, ok := candidates[id]; !ok {
+				user, ok := st.user(id)
+				if !ok {
+					return nil
+				}
+				candidates[id] = &models.Suggestion{User: public(user)}
+			}
+			return candidates[id]
+		}
+
+		for _, friend := range st.friends(userId) {
+			for _, other := range st.friends(friend) {
+				if c := candidate(other); c != nil {
+					c.MutualFriends++
+				}
+			}
+		}
+		for _, chat := range st.userChats(userId, repository.ChatPublic) {
+			for _, m := range st.members {
+				if c := candidate(m.UserId); m.ChatId == chat.Id && c != nil {
+					c.SharedChats++
+				}
+			}
+		}
+
+		for _, c := range candidates {
+			suggestions = append(suggestions, *c)
+		}
+		sort.Slice(suggestions, func(i, j int) bool {
+			a, b := suggestions[i], suggestions[j]
+			if a.MutualFriends != b.MutualFriends {
+				return a.MutualFriends > b.MutualFriends
+			}
+			if a.SharedChats != b.SharedChats {
+				return a.SharedChats > b.SharedChats
+			}
+			return a.Id < b.Id
+		})
+		if len(suggestions) > limit {
+			suggestions = suggestions[:limit]
+		}
+		return nil
+	})
+	return suggestions, err
+}
+
+// GetUserById отримує ID користувача ТА повертає його дані
+func (s *StatusRepository) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	return getUserById(ctx, s.db, userId)
+}
+
+// related повертає користувачів, яких fn вибирає зі сховища
+func (s *StatusRepository) related(ctx context.Context, fn func(st *store) []models.User) ([]models.User, error) {
+	var users []models.User
+	err := s.db.read(ctx, func(st *store) error {
+		users = fn(st)
+		return nil
+	})
+	return users, err
+}
+
+// senders повертає користувачів, що мають відносини relationship з recipientId
+func (s *store) senders(relationship string, recipientId int) []models.User {
+	var users []models.User
+	for _, status := range s.statuses {
+		if user, ok := s.user(status.SenderId); ok && status.Relationship == relationship && status.RecipientId == recipientId {
+			users = append(users, public(user))
+		}
+	}
+	return users
+}
+
+// recipients повертає користувачів, з якими senderId має відносини relationship
+func (s *store) recipients(relationship string, senderId int) []models.User {
+	var users []models.User
+	for _, status := range s.statuses {
+		if user, ok := s.user(status.RecipientId); ok && status.Relationship == relationship && status.SenderId == senderId {
+			users = append(users, public(user))
+		}
+	}
+	return users
+}
+
+// friends повертає ID друзів userId
+func (s *store) friends(userId int) []int {
+	var ids []int
+	for _, status := range s.statuses {
+		if status.Relationship != repository.StatusFriends {
+			continue
+		}
+		if status.SenderId == userId {
+			ids = append(ids, status.RecipientId)
+		}
+		if status.RecipientId == userId {
+			ids = append(ids, status.SenderId)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,119 @@
+package memory
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"time"
+)
+
+type UploadRepository struct {
+	db db
+}
+
+// Create отримує ім'я файлу ТА створює запис про нього без посилань або
+// оновлює час зміни наявного запису
+func (u *UploadRepository) Create(ctx context.Context, name string) error {
+	return u.upsert(ctx, name, func(upload *models.Upload) {})
+}
+
+// Acquire отримує ім'я файлу ТА збільшує кількість посилань на нього
+func (u *UploadRepository) Acquire(ctx context.Context, name string) error {
+	return u.upsert(ctx, name, func(upload *models.Upload) { upload.Refs++ })
+}
+
+// Release отримує ім'я файлу ТА зменшує кількість посилань на нього
+func (u *UploadRepository) Release(ctx context.Context, name string) error {
+	return u.upsert(ctx, name, func(upload *models.Upload) {
+		if upload.Refs > 0 {
+			upload.Refs--
+		}
+	})
+}
+
+// upsert знаходить або створює запис про файл, змінює його за допомогою
+// update та оновлює час зміни запису
+func (u *UploadRepository) upsert(ctx context.Context, name string, update func(upload *models.Upload)) error {
+	return u.db.write(ctx, func(s *store) error {
+		now := time.Now()
+		for i := range s.uploads {
+			if s.uploads[i].Name == name {
+				update(&s.uploads[i])
+				s.uploads[i].UpdatedAt = now
+				return nil
+			}
+		}
+		upload := models.Upload{Id: s.nextId(repository.UploadsTable), Name: name, CreatedAt: now, UpdatedAt: now}
+		update(&upload)
+		s.uploads = append(s.uploads, upload)
+		return nil
+	})
+}
+
+// GetOrphans отримує час ТА повертає файли без посилань, що не змінювалися
+// з цього часу та не використовуються жодним користувачем чи чатом
+func (u *UploadRepository) GetOrphans(ctx context.Context, before time.Time) ([]models.Upload, error) {
+	var uploads []models.Upload
+	err := u.db.read(ctx, func(s *store) error {
+		used := s.icons()
+		for _, upload := range s.uploads {
+			if upload.Refs == 0 && upload.UpdatedAt.Before(before) && !used[upload.Name] {
+				uploads = append(uploads, upload)
+			}
+		}
+		return nil
+	})
+	return uploads, err
+}
+
+// GetNames повертає імена усіх файлів, що обліковані або використовуються
+// користувачами чи чатами
+func (u *UploadRepository) GetNames(ctx context.Context) ([]string, error) {
+	var names []string
+	err := u.db.read(ctx, func(s *store) error {
+		known := s.icons()
+		for _, upload := range s.uploads {
+			known[upload.Name] = true
+		}
+		for name := range known {
+			names = append(names, name)
+		}
+		return nil
+	})
+	return names, err
+}
+
+// Delete отримує ім'я файлу та час ТА видаляє запис про нього, якщо на файл
+// досі немає посилань і його не змінювали з цього часу
+func (u *UploadRepository) Delete(ctx context.Context, name string, before time.Time) (bool, error) {
+	var deleted bool
+	err := u.db.write(ctx, func(s *store) error {
+		var uploads []models.Upload
+		for _, upload := range s.uploads {
+			if upload.Name == name && upload.Refs == 0 && upload.UpdatedAt.Before(before) {
+				deleted = true
+				continue
+			}
+			uploads = append(uploads, upload)
+		}
+		s.uploads = uploads
+		return nil
+	})
+	return deleted, err
+}
+
+// icons повертає імена зображень, що використовуються користувачами чи чатами
+func (s *store) icons() map[string]bool {
+	used := make(map[string]bool)
+	for _, user := range s.users {
+		if user.Icon != "" {
+			used[user.Icon] = true
+		}
+	}
+	for _, chat := range s.chats {
+		if chat.Icon != "" {
+			used[chat.Icon] = true
+		}
+	}
+	return used
+}
@@ -2,8 +2,9 @@ package repository
 
 import (
 	"cmd/pkg/repository/models"
+	"context"
 	"fmt"
-	"github.com/jinzhu/gorm"
+	"gorm.io/gorm"
 )
 
 type MessageRepository struct {
@@ -15,35 +16,35 @@ func NewMessageRepository(db *gorm.DB) *MessageRepository {
 }
 
 // Create отримує дані повідомлення ТА повертає його ID
-func (m *MessageRepository) Create(msg models.Message) (int, error) {
-	err := m.db.Table(MessagesTable).Create(&msg).Error
-	return msg.Id, err
+func (m *MessageRepository) Create(ctx context.Context, msg models.Message) (int, error) {
+	err := m.db.WithContext(ctx).Table(MessagesTable).Create(&msg).Error
+	return msg.Id, translate(err)
 }
 
 // Get отримує ID повідомлення ТА повертає його дані
-func (m *MessageRepository) Get(msgId int) (models.Message, error) {
+func (m *MessageRepository) Get(ctx context.Context, msgId int) (models.Message, error) {
 	var msg models.Message
-	err := m.db.Table(MessagesTable).First(&msg, msgId).Error
+	err := m.db.WithContext(ctx).Table(MessagesTable).First(&msg, msgId).Error
 	return msg, err
 }
 
 // GetAll отримує ID чату ТА повертає його повідомлення
-func (m *MessageRepository) GetAll(chatId int) ([]models.Message, error) {
+func (m *MessageRepository) GetAll(ctx context.Context, chatId int) ([]models.Message, error) {
 	var msg []models.Message
-	err := m.db.Table(MessagesTable).Where("chat_id = ?", chatId).Find(&msg).Error
+	err := m.db.WithContext(ctx).Table(MessagesTable).Where("chat_id = ?", chatId).Find(&msg).Error
 	return msg, err
 }
 
 // GetLimit отримує ID чату ліміт кількості повідомлень ТА повертає їх
-func (m *MessageRepository) GetLimit(chatId, limit int) ([]models.Message, error) {
+func (m *MessageRepository) GetLimit(ctx context.Context, chatId, limit int) ([]models.Message, error) {
 	var msg []models.Message
 	query := fmt.Sprintf("SELECT * FROM %s WHERE chat_id = ? ORDER BY id DESC LIMIT ?", MessagesTable)
-	err := m.db.Raw(query, chatId, limit).Scan(&msg).Error
+	err := m.db.WithContext(ctx).Raw(query, chatId, limit).Scan(&msg).Error
 	return msg, err
 }
 
 // DeleteAll отримує ID чату ТА видаляє його повідомлення
-func (m *MessageRepository) DeleteAll(chatId int) error {
-	err := m.db.Table(MessagesTable).Where("chat_id = ?", chatId).Delete(&models.Message{}).Error
+func (m *MessageRepository) DeleteAll(ctx context.Context, chatId int) error {
+	err := m.db.WithContext(ctx).Table(MessagesTable).Where("chat_id = ?", chatId).Delete(&models.Message{}).Error
 	return err
 }
@@ -0,0 +1,351 @@
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Table - таблиця, у якій зберігаються застосовані міграції
+const Table = "schema_migrations"
+
+//go:embed mysql/*.sql postgres/*.sql sqlite/*.sql
+var files embed.FS
+
+// Міграції схеми для кожного з драйверів БД
+var (
+	MySQL, _    = fs.Sub(files, "mysql")
+	Postgres, _ = fs.Sub(files, "postgres")
+	SQLite, _   = fs.Sub(files, "sqlite")
+)
+
+// Files отримує назву драйвера БД ТА повертає міграції схеми для нього
+func Files(driver string) (fs.FS, error) {
+	switch driver {
+	case "mysql", "":
+		return MySQL, nil
+	case "postgres":
+		return Postgres, nil
+	case "sqlite":
+		return SQLite, nil
+	}
+	return nil, fmt.Errorf("no migrations for database driver %q", driver)
+}
+
+var ErrNoDown = errors.New("migration has no down script")
+
+// ErrDirty - попередній запуск міграції не завершився, і схема може бути
+// змінена частково. Після ручного виправлення схеми стан задається Force
+var ErrDirty = errors.New("migration is dirty, fix the schema manually and run force")
+
+// Migration - одна версія схеми. Файли міграцій мають імена
+// <version>_<name>.up.sql та <version>_<name>.down.sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status описує міграцію та час її застосування
+type Status struct {
+	Migration
+	// AppliedAt нульовий, якщо міграцію ще не застосовано
+	AppliedAt time.Time
+	// Dirty - міграція почала виконуватися, але не завершилася
+	Dirty bool
+}
+
+// record - запис про міграцію у таблиці Table
+type record struct {
+	appliedAt time.Time
+	dirty     bool
+}
+
+// Load читає міграції з fsys ТА повертає їх у порядку версій
+func Load(fsys fs.FS) ([]Migration, error) {
+	names, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range names {
+		base := strings.TrimSuffix(path.Base(file), ".sql")
+		direction := path.Ext(base)
+		base = strings.TrimSuffix(base, direction)
+		if direction != ".up" && direction != ".down" {
+			return nil, fmt.Errorf("migration %s: expected .up.sql or .down.sql", file)
+		}
+		parts := strings.SplitN(base, "_", 2)
+		version, err := strconv.Atoi(parts[0])
+		if err != nil || len(parts) != 2 {
+			return nil, fmt.Errorf("migration %s: expected <version>_<name>", file)
+		}
+
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: parts[1]}
+			byVersion[version] = m
+		}
+		if m.Name != parts[1] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, parts[1])
+		}
+		if direction == ".up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up script", m.Version)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Statements розбиває скрипт на окремі запити. Запити розділяються
+// крапкою з комою в кінці рядка
+func Statements(script string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+type Migrator struct {
+	db         *sql.DB
+	driver     string
+	migrations []Migration
+}
+
+// NewMigrator отримує з'єднання з БД, назву її драйвера та файли міграцій
+// ТА повертає Migrator
+func NewMigrator(db *sql.DB, driver string, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// bind замінює параметри ? у запиті на $1, $2... для PostgreSQL
+func (m *Migrator) bind(query string) string {
+	if m.driver != "postgres" {
+		return query
+	}
+	var result strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			result.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		result.WriteRune(r)
+	}
+	return result.String()
+}
+
+func (m *Migrator) init() error {
+	_, err := m.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		version BIGINT NOT NULL PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		dirty BOOLEAN NOT NULL DEFAULT FALSE
+	)`, Table))
+	return err
+}
+
+// applied повертає записи про застосовані версії
+func (m *Migrator) applied() (map[int]record, error) {
+	if err := m.init(); err != nil {
+		return nil, err
+	}
+	rows, err := m.db.Query(fmt.Sprintf("SELECT version, applied_at, dirty FROM %s", Table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int]record)
+	for rows.Next() {
+		var version int
+		var r record
+		if err := rows.Scan(&version, &r.appliedAt, &r.dirty); err != nil {
+			return nil, err
+		}
+		result[version] = r
+	}
+	return result, rows.Err()
+}
+
+// clean повертає записи про застосовані версії або ErrDirty, якщо
+// якась із міграцій не завершилася
+func (m *Migrator) clean() (map[int]record, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	for _, migration := range m.migrations {
+		if applied[migration.Version].dirty {
+			return nil, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrDirty)
+		}
+	}
+	return applied, nil
+}
+
+// Status повертає усі міграції та час застосування кожної з них
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		r := applied[migration.Version]
+		result = append(result, Status{Migration: migration, AppliedAt: r.appliedAt, Dirty: r.dirty})
+	}
+	return result, nil
+}
+
+// Up застосовує усі незастосовані міграції ТА повертає їх. Повертає
+// ErrDirty, якщо попередня міграція не завершилася
+func (m *Migrator) Up() ([]Migration, error) {
+	applied, err := m.clean()
+	if err != nil {
+		return nil, err
+	}
+	var result []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		err := m.run(migration.Up,
+			step{fmt.Sprintf("INSERT INTO %s (version, name, dirty) VALUES (?, ?, ?)", Table), []interface{}{migration.Version, migration.Name, true}},
+			step{fmt.Sprintf("UPDATE %s SET dirty = ? WHERE version = ?", Table), []interface{}{false, migration.Version}})
+		if err != nil {
+			return result, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		result = append(result, migration)
+	}
+	return result, nil
+}
+
+// Down відкочує steps останніх застосованих міграцій ТА повертає їх.
+// Повертає ErrDirty, якщо попередня міграція не завершилася
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	applied, err := m.clean()
+	if err != nil {
+		return nil, err
+	}
+	var result []Migration
+	for i := len(m.migrations) - 1; i >= 0 && len(result) < steps; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return result, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, ErrNoDown)
+		}
+		err := m.run(migration.Down,
+			step{fmt.Sprintf("UPDATE %s SET dirty = ? WHERE version = ?", Table), []interface{}{true, migration.Version}},
+			step{fmt.Sprintf("DELETE FROM %s WHERE version = ?", Table), []interface{}{migration.Version}})
+		if err != nil {
+			return result, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		result = append(result, migration)
+	}
+	return result, nil
+}
+
+// Force знімає позначку незавершеної міграції version після ручного
+// виправлення схеми: applied - чи залишається міграція застосованою
+func (m *Migrator) Force(version int, applied bool) error {
+	if err := m.init(); err != nil {
+		return err
+	}
+	var err error
+	if applied {
+		_, err = m.db.Exec(m.bind(fmt.Sprintf("UPDATE %s SET dirty = ? WHERE version = ?", Table)), false, version)
+	} else {
+		_, err = m.db.Exec(m.bind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", Table)), version)
+	}
+	return err
+}
+
+// step - запит до таблиці Table з його параметрами
+type step struct {
+	query string
+	args  []interface{}
+}
+
+// execer - з'єднання з БД або транзакція
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// run виконує запит begin, скрипт міграції та запит end. PostgreSQL та
+// SQLite виконують усе в одній транзакції, тож невдала міграція не лишає
+// слідів. MySQL не відкочує зміни схеми, тому begin позначає міграцію
+// незавершеною окремим запитом, а end знімає позначку лише після
+// успішного скрипту. Незавершена міграція зупиняє Up та Down до виклику
+// Force
+func (m *Migrator) run(script string, begin, end step) error {
+	if m.driver == "mysql" || m.driver == "" {
+		return m.exec(m.db, script, begin, end)
+	}
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := m.exec(tx, script, begin, end); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) exec(db execer, script string, begin, end step) error {
+	if _, err := db.Exec(m.bind(begin.query), begin.args...); err != nil {
+		return err
+	}
+	for _, statement := range Statements(script) {
+		if _, err := db.Exec(statement); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(m.bind(end.query), end.args...)
+	return err
+}
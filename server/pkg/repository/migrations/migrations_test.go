@@ -0,0 +1,189 @@
+package migrations
+
+import (
+	"database/sql"
+	"testing"
+	"testing/fstest"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_users.up.sql":    {Data: []byte("create table users(\n  id integer primary key\n);\n")},
+	"0001_users.down.sql":  {Data: []byte("drop table users;")},
+	"0002_chats.up.sql":    {Data: []byte("create table chats(id integer);\n\n-- comment\ncreate index chats_id on chats(id);\n")},
+	"0002_chats.down.sql":  {Data: []byte("drop table chats;")},
+	"0003_broken.up.sql":   {Data: []byte("create table broken(id integer);\nthis is not sql;\n")},
+	"0003_broken.down.sql": {Data: []byte("drop table broken;")},
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testMigrations)
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "users", migrations[0].Name)
+	assert.Equal(t, "drop table users;", migrations[0].Down)
+
+	_, err = Load(fstest.MapFS{"0001_users.down.sql": {Data: []byte("drop table users;")}})
+	assert.Error(t, err)
+
+	_, err = Load(fstest.MapFS{"users.up.sql": {Data: []byte("select 1;")}})
+	assert.Error(t, err)
+}
+
+func TestFiles(t *testing.T) {
+	count := -1
+	for _, driver := range []string{"mysql", "postgres", "sqlite"} {
+		fsys, err := Files(driver)
+		require.NoError(t, err)
+		migrations, err := Load(fsys)
+		require.NoError(t, err)
+		require.NotEmpty(t, migrations)
+		for i, m := range migrations {
+			assert.Equal(t, i+1, m.Version, "%s: versions must be sequential", driver)
+			assert.NotEmpty(t, m.Down, "%s: migration %d has no down script", driver, m.Version)
+		}
+		// Схеми усіх драйверів мають однакові версії
+		if count >= 0 {
+			assert.Len(t, migrations, count, driver)
+		}
+		count = len(migrations)
+	}
+
+	_, err := Files("oracle")
+	assert.Error(t, err)
+}
+
+func TestMigrator_SQLite(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	migrator, err := NewMigrator(db, "sqlite", SQLite)
+	require.NoError(t, err)
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.NotEmpty(t, applied)
+
+	reverted, err := migrator.Down(len(applied))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(applied))
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'users'").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
+func TestMigrator_bind(t *testing.T) {
+	query := "INSERT INTO t (a, b) VALUES (?, ?)"
+	assert.Equal(t, query, (&Migrator{driver: "mysql"}).bind(query))
+	assert.Equal(t, "INSERT INTO t (a, b) VALUES ($1, $2)", (&Migrator{driver: "postgres"}).bind(query))
+}
+
+func TestStatements(t *testing.T) {
+	assert.Equal(t, []string{
+		"create table a(\n  id int\n)",
+		"insert into a values (1)",
+	}, Statements("-- comment\ncreate table a(\n  id int\n);\n\ninsert into a values (1)"))
+}
+
+func TestMigrator(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// Остання міграція зламана: застосовуються лише попередні
+	migrator, err := NewMigrator(db, "sqlite", testMigrations)
+	require.NoError(t, err)
+	applied, err := migrator.Up()
+	require.Error(t, err)
+	assert.Len(t, applied, 2)
+
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken'").Scan(&count))
+	assert.Equal(t, 0, count, "failed migration must be rolled back")
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	require.Len(t, status, 3)
+	assert.False(t, status[1].AppliedAt.IsZero())
+	assert.True(t, status[2].AppliedAt.IsZero())
+
+	// Повторний запуск нічого не застосовує
+	fixed := fstest.MapFS{}
+	for name, file := range testMigrations {
+		if name[:4] != "0003" {
+			fixed[name] = file
+		}
+	}
+	migrator, err = NewMigrator(db, "sqlite", fixed)
+	require.NoError(t, err)
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.Down(1)
+	require.NoError(t, err)
+	require.Len(t, reverted, 1)
+	assert.Equal(t, 2, reverted[0].Version)
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'chats'").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+}
+
+func TestMigrator_Dirty(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	// Як і в MySQL, зміни схеми зламаної міграції не відкочуються, а сама
+	// міграція залишається позначеною незавершеною
+	migrator, err := NewMigrator(db, "mysql", testMigrations)
+	require.NoError(t, err)
+	applied, err := migrator.Up()
+	require.Error(t, err)
+	assert.Len(t, applied, 2)
+	var count int
+	require.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'broken'").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	assert.False(t, status[1].Dirty)
+	assert.True(t, status[2].Dirty)
+	_, err = migrator.Up()
+	assert.ErrorIs(t, err, ErrDirty)
+	_, err = migrator.Down(1)
+	assert.ErrorIs(t, err, ErrDirty)
+
+	// Після ручного виправлення схеми міграцію можна повторити
+	_, err = db.Exec("DROP TABLE broken")
+	require.NoError(t, err)
+	require.NoError(t, migrator.Force(3, false))
+	status, err = migrator.Status()
+	require.NoError(t, err)
+	assert.False(t, status[2].Dirty)
+	assert.True(t, status[2].AppliedAt.IsZero())
+
+	fixed := fstest.MapFS{}
+	for name, file := range testMigrations {
+		fixed[name] = file
+	}
+	fixed["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("create table broken(id integer);\n")}
+	migrator, err = NewMigrator(db, "mysql", fixed)
+	require.NoError(t, err)
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, 1)
+	reverted, err := migrator.Down(3)
+	require.NoError(t, err)
+	assert.Len(t, reverted, 3)
+}
@@ -2,7 +2,7 @@ create table if not exists users(
         id bigint primary key auto_increment not null,
         username varchar(50) not null,
     password_hash varchar(100) not null,
-    icon varchar(50),
+    icon varchar(100),
     unique(id),
     unique(username)
     )
@@ -12,7 +12,7 @@ create table if not exists chats(
       id bigint primary key auto_increment not null,
       name varchar(50) not null,
     types varchar(20) not null,
-    icon varchar(50),
+    icon varchar(100),
     unique(id)
     )
     engine = InnoDB;
@@ -44,3 +44,14 @@ create table if not exists chat_users(
     unique(id)
     )
     engine = InnoDB;
+
+create table if not exists uploads(
+    id bigint primary key auto_increment not null,
+    name varchar(100) not null,
+    refs int not null default 0,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp,
+    unique(id),
+    unique(name)
+    )
+    engine = InnoDB;
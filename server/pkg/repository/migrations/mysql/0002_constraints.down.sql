@@ -0,0 +1,25 @@
+alter table chats
+    drop index chats_types_name;
+
+alter table users_relationship
+    drop foreign key users_relationship_sender_fk,
+    drop foreign key users_relationship_recipient_fk;
+
+alter table users_relationship
+    drop index users_relationship_pair,
+    drop index users_relationship_sender,
+    drop index users_relationship_recipient;
+
+alter table chat_users
+    drop foreign key chat_users_chat_fk,
+    drop foreign key chat_users_user_fk;
+
+alter table chat_users
+    drop index chat_users_chat_user,
+    drop index chat_users_user_chat;
+
+alter table messages
+    drop foreign key messages_chat_fk;
+
+alter table messages
+    drop index messages_chat_id;
@@ -0,0 +1,34 @@
+-- Записи, що посилаються на видалені чати чи користувачів, та повтори
+-- заважають створенню обмежень, тому видаляються першими
+
+delete from messages where chat_id not in (select id from chats);
+
+delete from chat_users where chat_id not in (select id from chats) or user_id not in (select id from users);
+
+delete cu from chat_users cu
+    inner join chat_users other on cu.chat_id = other.chat_id and cu.user_id = other.user_id and cu.id > other.id;
+
+delete from users_relationship where sender_id not in (select id from users) or recipient_id not in (select id from users);
+
+delete ur from users_relationship ur
+    inner join users_relationship other on ur.sender_id = other.sender_id and ur.recipient_id = other.recipient_id and ur.id > other.id;
+
+alter table messages
+    add index messages_chat_id (chat_id, id),
+    add constraint messages_chat_fk foreign key (chat_id) references chats (id) on delete cascade;
+
+alter table chat_users
+    add unique index chat_users_chat_user (chat_id, user_id),
+    add index chat_users_user_chat (user_id, chat_id),
+    add constraint chat_users_chat_fk foreign key (chat_id) references chats (id) on delete cascade,
+    add constraint chat_users_user_fk foreign key (user_id) references users (id) on delete cascade;
+
+alter table users_relationship
+    add unique index users_relationship_pair (sender_id, recipient_id),
+    add index users_relationship_sender (sender_id, relationship, recipient_id),
+    add index users_relationship_recipient (recipient_id, relationship, sender_id),
+    add constraint users_relationship_sender_fk foreign key (sender_id) references users (id) on delete cascade,
+    add constraint users_relationship_recipient_fk foreign key (recipient_id) references users (id) on delete cascade;
+
+alter table chats
+    add index chats_types_name (types, name);
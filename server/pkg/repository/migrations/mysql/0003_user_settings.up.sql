@@ -0,0 +1,13 @@
+-- Налаштування приватності та час останньої активності. Користувачі без
+-- запису мають налаштування за замовчуванням
+
+create table if not exists user_settings(
+    user_id bigint not null primary key,
+    direct_messages varchar(20) not null default 'everyone',
+    chat_invites varchar(20) not null default 'everyone',
+    searchable boolean not null default true,
+    last_seen varchar(20) not null default 'everyone',
+    last_seen_at timestamp null,
+    constraint user_settings_user_fk foreign key (user_id) references users (id) on delete cascade
+    )
+    engine = InnoDB;
@@ -0,0 +1,3 @@
+-- Об'єднані повторні чати не відновлюються
+
+drop table if exists private_chats;
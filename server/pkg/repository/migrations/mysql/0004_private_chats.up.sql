@@ -0,0 +1,48 @@
+-- Канонічна пара учасників приватного чату: user_low <= user_high, в
+-- особистому чаті обидва ID однакові. Кожна пара має не більше одного чату
+
+create table if not exists private_chats(
+    user_low bigint not null,
+    user_high bigint not null,
+    chat_id bigint not null,
+    primary key (user_low, user_high),
+    unique (chat_id),
+    check (user_low <= user_high),
+    constraint private_chats_low_fk foreign key (user_low) references users (id) on delete cascade,
+    constraint private_chats_high_fk foreign key (user_high) references users (id) on delete cascade,
+    constraint private_chats_chat_fk foreign key (chat_id) references chats (id) on delete cascade
+    )
+    engine = InnoDB;
+
+-- Пари отримують існуючі приватні чати з двома учасниками. Чат з одним
+-- учасником вважається особистим, лише якщо всі його повідомлення написав
+-- цей учасник: інакше співрозмовник залишив чат, і його історія не
+-- переноситься. Інші чати залишаються без пари
+create table private_chat_pairs as
+    select cu.chat_id as chat_id, min(cu.user_id) as user_low, max(cu.user_id) as user_high
+    from chat_users cu
+        inner join chats c on c.id = cu.chat_id
+    where c.types = 'private'
+    group by cu.chat_id
+    having count(*) = 2 or (count(*) = 1 and not exists (
+        select 1 from messages m
+            left join chat_users author on author.chat_id = m.chat_id and author.user_id = m.author
+        where m.chat_id = cu.chat_id and author.user_id is null));
+
+-- Повідомлення повторних чатів пари переносяться до найстаршого з них, а
+-- повторні чати видаляються разом з учасниками
+update messages set chat_id = (
+    select min(other.chat_id) from private_chat_pairs pair
+        inner join private_chat_pairs other on other.user_low = pair.user_low and other.user_high = pair.user_high
+    where pair.chat_id = messages.chat_id)
+where chat_id in (select chat_id from private_chat_pairs);
+
+delete from chats where id in (
+    select pair.chat_id from private_chat_pairs pair
+        inner join private_chat_pairs other
+            on other.user_low = pair.user_low and other.user_high = pair.user_high and other.chat_id < pair.chat_id);
+
+insert into private_chats (user_low, user_high, chat_id)
+    select user_low, user_high, min(chat_id) from private_chat_pairs group by user_low, user_high;
+
+drop table private_chat_pairs;
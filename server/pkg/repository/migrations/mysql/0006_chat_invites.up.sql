@@ -0,0 +1,53 @@
+-- Роль учасника чату: адміністратор керує посиланнями-запрошеннями та
+-- заявками на вступ. Адміністратором наявного публічного чату стає його
+-- перший учасник
+
+alter table chat_users add column role varchar(20) not null default 'member';
+
+update chat_users set role = 'admin' where id in (
+    select id from (
+        select min(cu.id) as id from chat_users cu
+            inner join chats c on c.id = cu.chat_id
+        where c.types = 'public'
+        group by cu.chat_id) first_members);
+
+-- Посилання-запрошення до чату. max_uses = 0 - без обмеження кількості
+-- використань, expires_at = null - без терміну дії
+create table if not exists chat_invites(
+    id bigint primary key auto_increment not null,
+    chat_id bigint not null,
+    token varchar(64) not null,
+    created_by bigint not null,
+    expires_at timestamp null,
+    max_uses int not null default 0,
+    uses int not null default 0,
+    approval boolean not null default false,
+    created_at timestamp not null,
+    revoked_at timestamp null,
+    unique (token),
+    index chat_invites_chat (chat_id, id),
+    constraint chat_invites_chat_fk foreign key (chat_id) references chats (id) on delete cascade,
+    constraint chat_invites_user_fk foreign key (created_by) references users (id) on delete cascade
+    )
+    engine = InnoDB;
+
+-- Вступи до чату: приєднані користувачі та заявки, що чекають рішення
+-- адміністратора. MySQL не має часткових індексів, тому pending_user
+-- містить user_id лише для заявки, що чекає рішення: унікальний ключ
+-- (chat_id, pending_user) не дає подати другу таку заявку, а значення
+-- null інших вступів не конфліктують
+create table if not exists chat_joins(
+    id bigint primary key auto_increment not null,
+    chat_id bigint not null,
+    user_id bigint not null,
+    invite_id bigint null,
+    status varchar(20) not null,
+    created_at timestamp not null,
+    pending_user bigint as (case when status = 'pending' then user_id end) virtual,
+    index chat_joins_chat (chat_id, status, id),
+    unique index chat_joins_pending (chat_id, pending_user),
+    constraint chat_joins_chat_fk foreign key (chat_id) references chats (id) on delete cascade,
+    constraint chat_joins_user_fk foreign key (user_id) references users (id) on delete cascade,
+    constraint chat_joins_invite_fk foreign key (invite_id) references chat_invites (id) on delete set null
+    )
+    engine = InnoDB;
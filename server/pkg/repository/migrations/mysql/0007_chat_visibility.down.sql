@@ -0,0 +1 @@
+alter table chats drop column visibility;
@@ -0,0 +1,5 @@
+-- Видимість публічного чату: open - вступ без схвалення, request - заявка,
+-- яку схвалює адміністратор, hidden - чат не знаходить пошук, вступ лише за
+-- посиланням-запрошенням. Наявні чати залишаються відкритими
+
+alter table chats add column visibility varchar(20) not null default 'open';
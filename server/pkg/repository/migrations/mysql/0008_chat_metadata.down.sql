@@ -0,0 +1,9 @@
+alter table chats drop foreign key chats_created_by_fk;
+
+alter table chats drop column created_at;
+
+alter table chats drop column created_by;
+
+alter table chats drop column topic;
+
+alter table chats drop column description;
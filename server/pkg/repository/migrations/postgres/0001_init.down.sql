@@ -0,0 +1,11 @@
+drop table if exists uploads;
+
+drop table if exists chat_users;
+
+drop table if exists users_relationship;
+
+drop table if exists messages;
+
+drop table if exists chats;
+
+drop table if exists users;
@@ -0,0 +1,44 @@
+create table if not exists users(
+    id bigserial primary key,
+    username varchar(50) not null,
+    password_hash varchar(100) not null,
+    icon varchar(100),
+    unique(username)
+);
+
+create table if not exists chats(
+    id bigserial primary key,
+    name varchar(50) not null,
+    types varchar(20) not null,
+    icon varchar(100)
+);
+
+create table if not exists messages(
+    id bigserial primary key,
+    chat_id bigint not null,
+    author bigint not null,
+    text text not null,
+    sent_at timestamptz default current_timestamp
+);
+
+create table if not exists users_relationship(
+    id bigserial primary key,
+    sender_id bigint not null,
+    recipient_id bigint not null,
+    relationship varchar(50) not null
+);
+
+create table if not exists chat_users(
+    id bigserial primary key,
+    chat_id bigint not null,
+    user_id bigint not null
+);
+
+create table if not exists uploads(
+    id bigserial primary key,
+    name varchar(100) not null,
+    refs int not null default 0,
+    created_at timestamptz default current_timestamp,
+    updated_at timestamptz default current_timestamp,
+    unique(name)
+);
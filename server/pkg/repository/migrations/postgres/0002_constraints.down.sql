@@ -0,0 +1,24 @@
+drop index if exists chats_types_name;
+
+alter table users_relationship
+    drop constraint if exists users_relationship_sender_fk,
+    drop constraint if exists users_relationship_recipient_fk;
+
+drop index if exists users_relationship_pair;
+
+drop index if exists users_relationship_sender;
+
+drop index if exists users_relationship_recipient;
+
+alter table chat_users
+    drop constraint if exists chat_users_chat_fk,
+    drop constraint if exists chat_users_user_fk;
+
+drop index if exists chat_users_chat_user;
+
+drop index if exists chat_users_user_chat;
+
+alter table messages
+    drop constraint if exists messages_chat_fk;
+
+drop index if exists messages_chat_id;
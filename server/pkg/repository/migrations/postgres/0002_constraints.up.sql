@@ -0,0 +1,24 @@
+create index messages_chat_id on messages (chat_id, id);
+
+alter table messages
+    add constraint messages_chat_fk foreign key (chat_id) references chats (id) on delete cascade;
+
+create unique index chat_users_chat_user on chat_users (chat_id, user_id);
+
+create index chat_users_user_chat on chat_users (user_id, chat_id);
+
+alter table chat_users
+    add constraint chat_users_chat_fk foreign key (chat_id) references chats (id) on delete cascade,
+    add constraint chat_users_user_fk foreign key (user_id) references users (id) on delete cascade;
+
+create unique index users_relationship_pair on users_relationship (sender_id, recipient_id);
+
+create index users_relationship_sender on users_relationship (sender_id, relationship, recipient_id);
+
+create index users_relationship_recipient on users_relationship (recipient_id, relationship, sender_id);
+
+alter table users_relationship
+    add constraint users_relationship_sender_fk foreign key (sender_id) references users (id) on delete cascade,
+    add constraint users_relationship_recipient_fk foreign key (recipient_id) references users (id) on delete cascade;
+
+create index chats_types_name on chats (types, name);
@@ -0,0 +1,12 @@
+-- Налаштування приватності та час останньої активності. Користувачі без
+-- запису мають налаштування за замовчуванням
+
+create table if not exists user_settings(
+    user_id bigint primary key,
+    direct_messages varchar(20) not null default 'everyone',
+    chat_invites varchar(20) not null default 'everyone',
+    searchable boolean not null default true,
+    last_seen varchar(20) not null default 'everyone',
+    last_seen_at timestamptz,
+    constraint user_settings_user_fk foreign key (user_id) references users (id) on delete cascade
+);
@@ -0,0 +1,6 @@
+-- Приватний чат знову називається ім'ям одного з учасників
+
+update chats set name = (
+    select u.username from private_chats p inner join users u on u.id = p.user_high
+    where p.chat_id = chats.id
+) where types = 'private' and id in (select chat_id from private_chats);
@@ -0,0 +1,4 @@
+-- Назва та зображення приватного чату обчислюються для кожного учасника з
+-- даних співрозмовника, тож збережені імена більше не використовуються
+
+update chats set name = '' where types = 'private';
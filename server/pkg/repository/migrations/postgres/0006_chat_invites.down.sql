@@ -0,0 +1,5 @@
+drop table if exists chat_joins;
+
+drop table if exists chat_invites;
+
+alter table chat_users drop column role;
@@ -0,0 +1,17 @@
+-- Опис, тема, автор та час створення чату. Автором наявного публічного
+-- чату стає його адміністратор, час створення наявних чатів невідомий.
+-- Кількість учасників та час останнього повідомлення не зберігаються, а
+-- обчислюються запитом
+
+alter table chats add column description varchar(500) not null default '';
+
+alter table chats add column topic varchar(100) not null default '';
+
+alter table chats add column created_by bigint references users (id) on delete set null;
+
+alter table chats add column created_at timestamptz;
+
+update chats set created_by = (
+    select min(cu.user_id) from chat_users cu
+    where cu.chat_id = chats.id and cu.role = 'admin')
+where types = 'public';
@@ -0,0 +1,47 @@
+-- SQLite не додає зовнішні ключі до наявних таблиць, тому вони
+-- створюються разом з таблицями
+
+create table if not exists users(
+    id integer primary key autoincrement,
+    username varchar(50) not null,
+    password_hash varchar(100) not null,
+    icon varchar(100),
+    unique(username)
+);
+
+create table if not exists chats(
+    id integer primary key autoincrement,
+    name varchar(50) not null,
+    types varchar(20) not null,
+    icon varchar(100)
+);
+
+create table if not exists messages(
+    id integer primary key autoincrement,
+    chat_id integer not null references chats (id) on delete cascade,
+    author integer not null,
+    text text not null,
+    sent_at timestamp default current_timestamp
+);
+
+create table if not exists users_relationship(
+    id integer primary key autoincrement,
+    sender_id integer not null references users (id) on delete cascade,
+    recipient_id integer not null references users (id) on delete cascade,
+    relationship varchar(50) not null
+);
+
+create table if not exists chat_users(
+    id integer primary key autoincrement,
+    chat_id integer not null references chats (id) on delete cascade,
+    user_id integer not null references users (id) on delete cascade
+);
+
+create table if not exists uploads(
+    id integer primary key autoincrement,
+    name varchar(100) not null,
+    refs integer not null default 0,
+    created_at timestamp default current_timestamp,
+    updated_at timestamp default current_timestamp,
+    unique(name)
+);
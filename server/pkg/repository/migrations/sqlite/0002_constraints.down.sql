@@ -0,0 +1,13 @@
+drop index if exists chats_types_name;
+
+drop index if exists users_relationship_pair;
+
+drop index if exists users_relationship_sender;
+
+drop index if exists users_relationship_recipient;
+
+drop index if exists chat_users_chat_user;
+
+drop index if exists chat_users_user_chat;
+
+drop index if exists messages_chat_id;
@@ -0,0 +1,13 @@
+create index messages_chat_id on messages (chat_id, id);
+
+create unique index chat_users_chat_user on chat_users (chat_id, user_id);
+
+create index chat_users_user_chat on chat_users (user_id, chat_id);
+
+create unique index users_relationship_pair on users_relationship (sender_id, recipient_id);
+
+create index users_relationship_sender on users_relationship (sender_id, relationship, recipient_id);
+
+create index users_relationship_recipient on users_relationship (recipient_id, relationship, sender_id);
+
+create index chats_types_name on chats (types, name);
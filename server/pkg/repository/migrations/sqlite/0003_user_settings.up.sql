@@ -0,0 +1,11 @@
+-- Налаштування приватності та час останньої активності. Користувачі без
+-- запису мають налаштування за замовчуванням
+
+create table if not exists user_settings(
+    user_id integer primary key references users (id) on delete cascade,
+    direct_messages varchar(20) not null default 'everyone',
+    chat_invites varchar(20) not null default 'everyone',
+    searchable boolean not null default true,
+    last_seen varchar(20) not null default 'everyone',
+    last_seen_at timestamp
+);
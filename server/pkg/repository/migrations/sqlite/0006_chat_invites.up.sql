@@ -0,0 +1,44 @@
+-- Роль учасника чату: адміністратор керує посиланнями-запрошеннями та
+-- заявками на вступ. Адміністратором наявного публічного чату стає його
+-- перший учасник
+
+alter table chat_users add column role varchar(20) not null default 'member';
+
+update chat_users set role = 'admin' where id in (
+    select id from (
+        select min(cu.id) as id from chat_users cu
+            inner join chats c on c.id = cu.chat_id
+        where c.types = 'public'
+        group by cu.chat_id) first_members);
+
+-- Посилання-запрошення до чату. max_uses = 0 - без обмеження кількості
+-- використань, expires_at = null - без терміну дії
+create table if not exists chat_invites(
+    id integer primary key autoincrement,
+    chat_id integer not null references chats (id) on delete cascade,
+    token varchar(64) not null unique,
+    created_by integer not null references users (id) on delete cascade,
+    expires_at timestamp,
+    max_uses integer not null default 0,
+    uses integer not null default 0,
+    approval boolean not null default false,
+    created_at timestamp not null,
+    revoked_at timestamp
+);
+
+create index chat_invites_chat on chat_invites (chat_id, id);
+
+-- Вступи до чату: приєднані користувачі та заявки, що чекають рішення
+-- адміністратора. Користувач може мати лише одну заявку, що чекає рішення
+create table if not exists chat_joins(
+    id integer primary key autoincrement,
+    chat_id integer not null references chats (id) on delete cascade,
+    user_id integer not null references users (id) on delete cascade,
+    invite_id integer references chat_invites (id) on delete set null,
+    status varchar(20) not null,
+    created_at timestamp not null
+);
+
+create index chat_joins_chat on chat_joins (chat_id, status, id);
+
+create unique index chat_joins_pending on chat_joins (chat_id, user_id) where status = 'pending';
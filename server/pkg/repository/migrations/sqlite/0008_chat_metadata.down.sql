@@ -0,0 +1,7 @@
+alter table chats drop column created_at;
+
+alter table chats drop column created_by;
+
+alter table chats drop column topic;
+
+alter table chats drop column description;
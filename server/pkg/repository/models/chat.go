@@ -1,14 +1,44 @@
 package models
 
+import "time"
+
 type Chat struct {
 	Id    int    `json:"id"`
-	Name  string `json:"name" form:"name"  binding:"required"`
+	Name  string `json:"name"`
 	Types string `json:"types"`
-	Icon  string `json:"icon" form:"icon"  binding:"required"`
+	Icon  string `json:"icon"`
+	// Description - опис чату, Topic - поточна тема обговорення
+	Description string `json:"description,omitempty"`
+	Topic       string `json:"topic,omitempty"`
+	// Visibility - хто може знайти публічний чат та приєднатися до нього
+	Visibility string `json:"visibility,omitempty"`
+	// CreatedBy та CreatedAt - автор та час створення чату. Для чатів,
+	// створених до їх появи, вони можуть бути невідомі
+	CreatedBy *int       `json:"created_by,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty" gorm:"autoCreateTime:false"`
+	// MemberCount та LastMessageAt обчислюються запитом і не зберігаються
+	MemberCount   int        `json:"member_count,omitempty" gorm:"->"`
+	LastMessageAt *time.Time `json:"last_message_at,omitempty" gorm:"->"`
+	// PartnerId - ID співрозмовника у списку приватних чатів
+	PartnerId int `json:"partner_id,omitempty" gorm:"->"`
+	// LastMessage - останнє повідомлення чату для попереднього перегляду
+	LastMessage *Message `json:"last_message,omitempty" gorm:"-"`
+	// Icons містить посилання на квадратні копії зображення за їх розміром
+	Icons map[int]string `json:"icons,omitempty" gorm:"-"`
 }
 
 type ChatUsers struct {
 	Id     int `json:"id"`
 	ChatId int `json:"chat_id"`
 	UserId int `json:"user_id"`
+	// Role - роль учасника: адміністратор чи звичайний учасник
+	Role string `json:"role"`
+}
+
+// PrivateChat - канонічна пара учасників приватного чату: UserLow <= UserHigh,
+// в особистому чаті обидва ID однакові
+type PrivateChat struct {
+	UserLow  int `json:"user_low"`
+	UserHigh int `json:"user_high"`
+	ChatId   int `json:"chat_id"`
 }
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// ChatInvite - посилання-запрошення до чату. Будь-хто з токеном може
+// приєднатися до чату, доки посилання не відкликане, не минув ExpiresAt та
+// кількість використань Uses менша за MaxUses (0 - без обмеження). Якщо
+// Approval, вступ чекає рішення адміністратора
+type ChatInvite struct {
+	Id        int        `json:"id"`
+	ChatId    int        `json:"chat_id"`
+	Token     string     `json:"token"`
+	CreatedBy int        `json:"created_by"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	MaxUses   int        `json:"max_uses"`
+	Uses      int        `json:"uses"`
+	Approval  bool       `json:"approval"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// ChatJoin - запис про вступ користувача до чату. Status - joined для
+// учасника, що приєднався, pending для заявки, що чекає рішення
+// адміністратора, та rejected для відхиленої заявки
+type ChatJoin struct {
+	Id        int       `json:"id"`
+	ChatId    int       `json:"chat_id"`
+	UserId    int       `json:"user_id"`
+	InviteId  *int      `json:"invite_id"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
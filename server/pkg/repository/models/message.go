@@ -2,11 +2,21 @@ package models
 
 import "time"
 
+// TimePrecision - точність часу надсилання повідомлень та створення чатів.
+// Обидва часи округлюються однаково, тож список чатів порівнює їх
+// активність без зсуву між ними
+const TimePrecision = 20 * time.Millisecond
+
+// Now повертає поточний час з точністю TimePrecision
+func Now() time.Time {
+	return time.Now().Round(TimePrecision)
+}
+
 type Message struct {
 	Id     int       `json:"id" db:"id"`
 	ChatId int       `json:"chat_id"`
 	Author int       `json:"author"`
-	Text   string    `json:"text" form:"text"  binding:"required"`
+	Text   string    `json:"text"`
 	SentAt time.Time `json:"sent_at"`
 	//db:"sent_at" gorm:"->"
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// UserSettings - налаштування приватності користувача. Поля DirectMessages,
+// ChatInvites та LastSeen визначають, кому дозволено дію: усім, друзям
+// або нікому
+type UserSettings struct {
+	UserId int `json:"-"`
+	// DirectMessages - хто може писати користувачу в приватний чат
+	DirectMessages string `json:"direct_messages"`
+	// ChatInvites - хто може додавати користувача до публічних чатів
+	ChatInvites string `json:"chat_invites"`
+	// Searchable - чи знаходить користувача пошук за іменем
+	Searchable bool `json:"searchable"`
+	// LastSeen - кому показується час останньої активності
+	LastSeen string `json:"last_seen"`
+}
+
+// Presence - час останньої активності користувача. LastSeenAt порожній,
+// якщо користувач приховав його або ще не був активним
+type Presence struct {
+	UserId     int        `json:"user_id"`
+	LastSeenAt *time.Time `json:"last_seen_at"`
+}
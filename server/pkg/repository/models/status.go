@@ -2,7 +2,26 @@ package models
 
 type Status struct {
 	Id           int    `json:"id" db:"id"`
-	SenderId     int    `json:"sender_id" form:"sender_id" binding:"required"`
-	RecipientId  int    `json:"recipient_id" form:"recipient_id" binding:"required"`
-	Relationship string `json:"relationship" form:"relationship" binding:"required"`
+	SenderId     int    `json:"sender_id"`
+	RecipientId  int    `json:"recipient_id"`
+	Relationship string `json:"relationship"`
+}
+
+// Relation - користувач, пов'язаний з іншим, та тип їх відносин. Outgoing
+// означає, що запис відносин створив інший користувач
+type Relation struct {
+	User         `gorm:"embedded"`
+	Relationship string `json:"relationship"`
+	Outgoing     bool   `json:"outgoing"`
+}
+
+// UserLists - користувачі, пов'язані з користувачем, за типом відносин
+type UserLists struct {
+	Friends     []User `json:"friends"`
+	Blacklist   []User `json:"blacklist"`
+	OnBlacklist []User `json:"onBlacklist"`
+	Invites     []User `json:"invites"`
+	Requires    []User `json:"requires"`
+	// NextOffset - зсув наступної сторінки або 0, якщо сторінка остання
+	NextOffset int `json:"next_offset,omitempty"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// Suggestion - користувач, якого можна запросити у друзі. MutualFriends -
+// кількість спільних друзів, SharedChats - кількість спільних публічних чатів
+type Suggestion struct {
+	User          `gorm:"embedded"`
+	MutualFriends int `json:"mutual_friends"`
+	SharedChats   int `json:"shared_chats"`
+}
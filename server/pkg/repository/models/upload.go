@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Upload описує збережене у сховищі зображення. Файли зберігаються за
+// SHA-256 вмісту, тому один файл можуть використовувати кілька об'єктів;
+// Refs - кількість таких посилань
+type Upload struct {
+	Id        int       `json:"id" db:"id"`
+	Name      string    `json:"name"`
+	Refs      int       `json:"refs"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -2,7 +2,9 @@ package models
 
 type User struct {
 	Id       int    `json:"id" db:"id"`
-	Username string `json:"username" form:"username"  binding:"required"`
-	Password string `json:"password" gorm:"column:password_hash" form:"password"  binding:"required"`
-	Icon     string `json:"icon" form:"icon" binding:"required" `
+	Username string `json:"username"`
+	Password string `json:"password" gorm:"column:password_hash"`
+	Icon     string `json:"icon"`
+	// Icons містить посилання на квадратні копії зображення за їх розміром
+	Icons map[int]string `json:"icons,omitempty" gorm:"-"`
 }
@@ -1,37 +1,93 @@
 package repository
 
 import (
+	"cmd/pkg/config"
 	"fmt"
 	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"strings"
 )
 
 const (
-	UsersTable       = "users"
-	StatusesTable    = "users_relationship"
-	ChatUsersList    = "chat_users"
-	ChatsTable       = "chats"
-	MessagesTable    = "messages"
-	StatusFriends    = "friends"
-	StatusBL         = "black_list"
-	StatusInvitation = "invitation"
-	ChatPrivate      = "private"
-	ChatPublic       = "public"
+	UsersTable        = "users"
+	StatusesTable     = "users_relationship"
+	ChatUsersList     = "chat_users"
+	ChatsTable        = "chats"
+	MessagesTable     = "messages"
+	UploadsTable      = "uploads"
+	SettingsTable     = "user_settings"
+	PrivateChats      = "private_chats"
+	ChatInvites       = "chat_invites"
+	ChatJoins         = "chat_joins"
+	StatusFriends     = "friends"
+	StatusBL          = "black_list"
+	StatusInvitation  = "invitation"
+	ChatPrivate       = "private"
+	ChatPublic        = "public"
+	AudienceEveryone  = "everyone"
+	AudienceFriends   = "friends"
+	AudienceNobody    = "nobody"
+	RoleAdmin         = "admin"
+	RoleMember        = "member"
+	JoinPending       = "pending"
+	JoinJoined        = "joined"
+	JoinRejected      = "rejected"
+	VisibilityOpen    = "open"
+	VisibilityRequest = "request"
+	VisibilityHidden  = "hidden"
 )
 
-type Config struct {
-	Username string
-	Password string
-	Host     string
-	Url      string
-	DBName   string
-}
+// NewRepositoryDB відкриває з'єднання з БД, налаштовує пул з'єднань та
+// перевіряє доступність БД. Запити готуються один раз та кешуються
+func NewRepositoryDB(cnf config.DB) (*gorm.DB, error) {
+	dialector, err := newDialector(cnf)
+	if err != nil {
+		return nil, err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{PrepareStmt: true})
+	if err != nil {
+		return nil, err
+	}
 
-func NewRepositoryDB(cnf Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("%s@%s%s(%s)/%s?parseTime=true", cnf.Username, cnf.Password, cnf.Host, cnf.Url, cnf.DBName)
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, err
 	}
+	sqlDB.SetMaxOpenConns(cnf.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cnf.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cnf.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cnf.ConnMaxIdleTime)
+
+	if err := sqlDB.Ping(); err != nil {
+		return nil, err
+	}
 	return db, nil
 }
+
+func newDialector(cnf config.DB) (gorm.Dialector, error) {
+	switch cnf.Driver {
+	case config.DriverMySQL, "":
+		return mysql.Open(cnf.ConnectionString()), nil
+	case config.DriverPostgres:
+		return postgres.Open(cnf.ConnectionString()), nil
+	case config.DriverSQLite:
+		return sqlite.Open(sqliteDSN(cnf.ConnectionString())), nil
+	}
+	return nil, fmt.Errorf("unknown database driver %q", cnf.Driver)
+}
+
+// sqliteDSN вмикає зовнішні ключі та очікування блокувань для кожного
+// з'єднання. Транзакції одразу захоплюють блокування запису, щоб
+// конкурентні транзакції чекали, а не завершувалися помилкою
+func sqliteDSN(dsn string) string {
+	if strings.Contains(dsn, "_foreign_keys") {
+		return dsn
+	}
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + "_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL&_txlock=immediate"
+}
@@ -2,93 +2,216 @@ package repository
 
 import (
 	"cmd/pkg/repository/models"
-	"github.com/jinzhu/gorm"
+	"context"
+	"gorm.io/gorm"
+	"time"
 )
 
 type Authorization interface {
-	// CreateUser отримує ім'я та пароль ТА створює нового користувача
-	CreateUser(user models.User) (int, error)
+	// CreateUser отримує ім'я та пароль ТА створює нового користувача.
+	// Повертає ErrDuplicate, якщо ім'я вже зайняте
+	CreateUser(ctx context.Context, user models.User) (int, error)
 	// GetUser отримує ім'я та пароль ТА повертає його дані
-	GetUser(username, password string) (models.User, error)
+	GetUser(ctx context.Context, username, password string) (models.User, error)
 	// GetUserById отримує ID користувача ТА повертає його дані
-	GetUserById(userId int) (models.User, error)
+	GetUserById(ctx context.Context, userId int) (models.User, error)
 	// GetByName отримує ім'я користувача ТА повертає його дані
-	GetByName(username string) (models.User, error)
-	// UpdateUser отримує дані користувача ТА оновлює їх
-	UpdateUser(user models.User) error
+	GetByName(ctx context.Context, username string) (models.User, error)
+	// UpdateUser отримує дані користувача ТА оновлює їх.
+	// Повертає ErrDuplicate, якщо ім'я вже зайняте
+	UpdateUser(ctx context.Context, user models.User) error
 }
 
 type Chat interface {
-	// Create отримує назву чату ТА створює новий чат
-	Create(chat models.Chat) (int, error)
-	// Get отримує ID чату ТА повертає дані чату за його ID
-	Get(chatId int) (models.Chat, error)
-	// Delete отримує ID чату ТА видаляє чат
-	Delete(chatId int) error
-	// Update отримує ID чату ТА оновлює дані чату
-	Update(chat models.Chat) error
-	// AddUser отримує ID чату ТА ID користувача, та додає користувача до чату
-	AddUser(users models.ChatUsers) (int, error)
+	// Create отримує дані чату ТА створює новий чат. Без видимості чат
+	// відкритий
+	Create(ctx context.Context, chat models.Chat) (int, error)
+	// Get отримує ID чату ТА повертає дані чату за його ID з кількістю
+	// учасників та останнім повідомленням
+	Get(ctx context.Context, chatId int) (models.Chat, error)
+	// Delete отримує ID чату ТА видаляє чат разом з його учасниками та повідомленнями
+	Delete(ctx context.Context, chatId int) error
+	// Update отримує ID чату ТА оновлює назву, опис, тему, зображення та
+	// видимість чату
+	Update(ctx context.Context, chat models.Chat) error
+	// AddUser отримує ID чату ТА ID користувача, та додає користувача до чату.
+	// Повертає ErrDuplicate, якщо користувач вже у чаті, або ErrReference,
+	// якщо чату чи користувача не існує
+	AddUser(ctx context.Context, users models.ChatUsers) (int, error)
 	// GetUsers отримує ID чату ТА повертає масив користувачів, що приєднані до чату
-	GetUsers(chatId int) ([]models.User, error)
-	// GetPrivates отримує ID двох користувачів, ТА повертає масив приватних
-	// чатів, до яких належать кожен із користувачів
-	GetPrivates(firstUser, secondUser int) ([]models.Chat, []models.Chat, error)
-	// GetPrivateChats отримує ID користувача ТА повертає масив ПРИВАТНИХ чатів,
-	// до яких він належить
-	GetPrivateChats(userId int) ([]models.Chat, error)
+	GetUsers(ctx context.Context, chatId int) ([]models.User, error)
+	// GetMember отримує ID чату та користувача ТА повертає запис учасника
+	// з його роллю. Повертає gorm.ErrRecordNotFound, якщо користувач не в чаті
+	GetMember(ctx context.Context, chatId, userId int) (models.ChatUsers, error)
+	// GetMembers отримує ID чату ТА повертає записи учасників у порядку вступу
+	GetMembers(ctx context.Context, chatId int) ([]models.ChatUsers, error)
+	// UpdateRole отримує запис учасника ТА змінює його роль
+	UpdateRole(ctx context.Context, member models.ChatUsers) error
+	// GetPrivate отримує ID двох користувачів у будь-якому порядку ТА повертає
+	// ID їх приватного чату або 0, якщо чату немає
+	GetPrivate(ctx context.Context, firstUser, secondUser int) (int, error)
+	// AddPrivate закріплює чат chatId за парою користувачів. Повертає
+	// ErrDuplicate, якщо пара вже має чат
+	AddPrivate(ctx context.Context, firstUser, secondUser, chatId int) error
+	// DeletePrivate відкріплює приватний чат від пари його учасників
+	DeletePrivate(ctx context.Context, chatId int) error
+	// GetPrivateChats отримує ID користувача ТА повертає масив ПРИВАТНИХ чатів
+	// з іншим учасником, названих ім'ям співрозмовника та з його зображенням
+	GetPrivateChats(ctx context.Context, userId int) ([]models.Chat, error)
 	// GetPublicChats отримує ID користувача ТА повертає масив ПУБЛІЧНИХ чатів,
-	// до яких він належить
-	GetPublicChats(userId int) ([]models.Chat, error)
+	// до яких він належить, з останніми повідомленнями. Чати впорядковані
+	// від найновішої активності, чати без відомої активності - в кінці
+	GetPublicChats(ctx context.Context, userId int) ([]models.Chat, error)
 	// DeleteUser отримує ID чату ТА ID користувача, та видаляє користувача із чату
-	DeleteUser(userId, chatId int) error
-	// SearchChat отримує назву чату (або його частину) ТА повертає масив чатів,
-	// назви яких збігаються з аргументом
-	SearchChat(name string) ([]models.Chat, error)
+	DeleteUser(ctx context.Context, userId, chatId int) error
+	// SearchChat отримує назву чату (або його частину) ТА повертає масив
+	// публічних чатів, назви яких збігаються з аргументом, крім прихованих
+	SearchChat(ctx context.Context, name string) ([]models.Chat, error)
 	// DeleteAllMessages отримує ID чату ТА видаляє його повідомлення
-	DeleteAllMessages(chatId int) error
+	DeleteAllMessages(ctx context.Context, chatId int) error
 	// GetUserById отримує ID користувача ТА повертає його дані
-	GetUserById(userId int) (models.User, error)
+	GetUserById(ctx context.Context, userId int) (models.User, error)
+}
+
+type Invite interface {
+	// Create отримує дані посилання-запрошення ТА повертає його ID.
+	// Повертає ErrDuplicate, якщо токен вже використаний
+	Create(ctx context.Context, invite models.ChatInvite) (int, error)
+	// Get отримує ID посилання ТА повертає його дані
+	Get(ctx context.Context, inviteId int) (models.ChatInvite, error)
+	// GetByToken отримує токен посилання ТА повертає його дані
+	GetByToken(ctx context.Context, token string) (models.ChatInvite, error)
+	// GetByChat отримує ID чату ТА повертає його посилання від найстаршого
+	GetByChat(ctx context.Context, chatId int) ([]models.ChatInvite, error)
+	// Revoke отримує ID посилання та час ТА відкликає посилання
+	Revoke(ctx context.Context, inviteId int, at time.Time) error
+	// Use отримує ID посилання та час ТА збільшує кількість його
+	// використань, якщо на цей час посилання не відкликане, не прострочене
+	// та не вичерпане. Повертає, чи було використано посилання
+	Use(ctx context.Context, inviteId int, at time.Time) (bool, error)
+}
+
+type Join interface {
+	// Create отримує дані вступу до чату ТА повертає його ID. Повертає
+	// ErrDuplicate, якщо користувач вже має заявку до чату, що чекає рішення
+	Create(ctx context.Context, join models.ChatJoin) (int, error)
+	// Get отримує ID вступу ТА повертає його дані
+	Get(ctx context.Context, joinId int) (models.ChatJoin, error)
+	// GetByChat отримує ID чату та статус ТА повертає вступи до чату з цим
+	// статусом від найстаршого
+	GetByChat(ctx context.Context, chatId int, status string) ([]models.ChatJoin, error)
+	// UpdateStatus отримує дані вступу ТА змінює його статус
+	UpdateStatus(ctx context.Context, join models.ChatJoin) error
 }
 
 type Status interface {
-	// AddStatus отримує ID двох користувачів та їх тип відносин ТА повертає ID створеного статусу
-	AddStatus(status models.Status) (int, error)
-	// GetStatuses отримує ID двох користувачів ТА повертає дані їх відносин
-	GetStatuses(senderId, recipientId int) ([]models.Status, error)
+	// AddStatus отримує ID двох користувачів та їх тип відносин ТА повертає ID створеного статусу.
+	// Повертає ErrDuplicate, якщо відносини вже існують, або ErrReference,
+	// якщо користувача не існує
+	AddStatus(ctx context.Context, status models.Status) (int, error)
+	// GetStatuses отримує ID двох користувачів ТА повертає усі записи їх
+	// відносин в обох напрямках
+	GetStatuses(ctx context.Context, senderId, recipientId int) ([]models.Status, error)
 	// UpdateStatus отримує ID двох користувачів та їх тип відносин ТА оновлює дані
-	UpdateStatus(status models.Status) error
+	UpdateStatus(ctx context.Context, status models.Status) error
 	// DeleteStatus отримує ID двох користувачів та їх тип відносин ТА видаляє ці відносини
-	DeleteStatus(status models.Status) error
+	DeleteStatus(ctx context.Context, status models.Status) error
 	// GetFriends отримує ID користувача ТА повертає масив користувачів, що є ДРУЗЯМИ
-	GetFriends(userId int) ([]models.User, error)
+	GetFriends(ctx context.Context, userId int) ([]models.User, error)
+	// GetRelations отримує ID користувача ТА повертає до limit пов'язаних з
+	// ним користувачів, починаючи з offset, з типом та напрямком відносин
+	GetRelations(ctx context.Context, userId, offset, limit int) ([]models.Relation, error)
 	// GetBlackList отримує ID користувача ТА повертає масив ЗАБЛОКОВАНИХ користувачів
-	GetBlackList(userId int) ([]models.User, error)
+	GetBlackList(ctx context.Context, userId int) ([]models.User, error)
 	// GetBlackListToUser отримує ID користувача ТА повертає масив користувачів, що
 	// ЗАБЛОКУВАЛИ його
-	GetBlackListToUser(userId int) ([]models.User, error)
+	GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error)
 	// GetSentInvites отримує ID користувача ТА повертає масив користувачів, що
 	// ОТРИМАЛИ його запрошення у друзі
-	GetSentInvites(userId int) ([]models.User, error)
+	GetSentInvites(ctx context.Context, userId int) ([]models.User, error)
 	// GetInvites отримує ID користувача ТА повертає масив користувачів, що
 	// НАДІСЛАЛИ йому запрошення в друзі
-	GetInvites(userId int) ([]models.User, error)
+	GetInvites(ctx context.Context, userId int) ([]models.User, error)
 	// SearchUser отримує ім'я (або його частину) ТА повертає масив користувачів, що
-	// мають збіг з аргументом
-	SearchUser(username string) ([]models.User, error)
+	// мають збіг з аргументом, крім тих, що ЗАБЛОКУВАЛИ userId або заборонили
+	// пошук за іменем
+	SearchUser(ctx context.Context, userId int, username string) ([]models.User, error)
+	// GetSuggestions отримує ID користувача ТА повертає до limit користувачів,
+	// з якими він ще не має відносин, за спаданням кількості спільних друзів
+	// та спільних публічних чатів
+	GetSuggestions(ctx context.Context, userId, limit int) ([]models.Suggestion, error)
 	// GetUserById отримує ID користувача ТА повертає його дані
-	GetUserById(userId int) (models.User, error)
+	GetUserById(ctx context.Context, userId int) (models.User, error)
 }
 
 type Message interface {
-	// Create отримує дані повідомлення ТА повертає його ID
-	Create(msg models.Message) (int, error)
+	// Create отримує дані повідомлення ТА повертає його ID.
+	// Повертає ErrReference, якщо чату не існує
+	Create(ctx context.Context, msg models.Message) (int, error)
 	// Get отримує ID повідомлення ТА повертає його дані
-	Get(msgId int) (models.Message, error)
+	Get(ctx context.Context, msgId int) (models.Message, error)
 	// GetLimit отримує ID чату ліміт кількості повідомлень ТА повертає їх
-	GetLimit(chatId, limit int) ([]models.Message, error)
+	GetLimit(ctx context.Context, chatId, limit int) ([]models.Message, error)
 	// DeleteAll отримує ID чату ТА видаляє його повідомлення
-	DeleteAll(chatId int) error
+	DeleteAll(ctx context.Context, chatId int) error
+}
+
+type Upload interface {
+	// Create отримує ім'я файлу ТА створює запис про нього без посилань
+	// або оновлює час зміни наявного запису
+	Create(ctx context.Context, name string) error
+	// Acquire отримує ім'я файлу ТА збільшує кількість посилань на нього
+	Acquire(ctx context.Context, name string) error
+	// Release отримує ім'я файлу ТА зменшує кількість посилань на нього
+	Release(ctx context.Context, name string) error
+	// GetOrphans отримує час ТА повертає файли без посилань, що не
+	// змінювалися з цього часу та не використовуються жодним користувачем чи чатом
+	GetOrphans(ctx context.Context, before time.Time) ([]models.Upload, error)
+	// GetNames повертає імена усіх файлів, що обліковані або використовуються
+	// користувачами чи чатами
+	GetNames(ctx context.Context) ([]string, error)
+	// Delete отримує ім'я файлу та час ТА видаляє запис про нього, якщо на
+	// файл досі немає посилань. Повертає, чи було видалено запис
+	Delete(ctx context.Context, name string, before time.Time) (bool, error)
+}
+
+type Settings interface {
+	// Get отримує ID користувача ТА повертає його налаштування. Якщо
+	// користувач їх не змінював, повертає DefaultSettings
+	Get(ctx context.Context, userId int) (models.UserSettings, error)
+	// Save отримує налаштування ТА створює або оновлює їх
+	Save(ctx context.Context, settings models.UserSettings) error
+	// Seen отримує ID користувача та час ТА зберігає його як час останньої
+	// активності користувача
+	Seen(ctx context.Context, userId int, at time.Time) error
+	// GetLastSeen отримує ID користувача ТА повертає час його останньої
+	// активності або nil, якщо користувач ще не був активним
+	GetLastSeen(ctx context.Context, userId int) (*time.Time, error)
+}
+
+// PrivatePair повертає ID учасників приватного чату в канонічному порядку
+func PrivatePair(firstUser, secondUser int) models.PrivateChat {
+	if firstUser > secondUser {
+		firstUser, secondUser = secondUser, firstUser
+	}
+	return models.PrivateChat{UserLow: firstUser, UserHigh: secondUser}
+}
+
+// DefaultSettings повертає налаштування користувача, який їх не змінював
+func DefaultSettings(userId int) models.UserSettings {
+	return models.UserSettings{
+		UserId:         userId,
+		DirectMessages: AudienceEveryone,
+		ChatInvites:    AudienceEveryone,
+		Searchable:     true,
+		LastSeen:       AudienceEveryone,
+	}
+}
+
+type Transactor interface {
+	// Transaction викликає fn з репозиторіями, що працюють в одній
+	// транзакції. Якщо fn повертає помилку, усі зміни відкочуються
+	Transaction(ctx context.Context, fn func(repos *Repository) error) error
 }
 
 type Repository struct {
@@ -96,13 +219,28 @@ type Repository struct {
 	Chat
 	Status
 	Message
+	Upload
+	Settings
+	Invite
+	Join
+	Transactor
 }
 
 func NewRepository(db *gorm.DB) *Repository {
+	repos := newRepository(db)
+	repos.Transactor = NewTransactor(db)
+	return repos
+}
+
+func newRepository(db *gorm.DB) *Repository {
 	return &Repository{
 		Authorization: NewAuthRepository(db),
 		Chat:          NewChatRepository(db),
 		Status:        NewStatusRepository(db),
 		Message:       NewMessageRepository(db),
+		Upload:        NewUploadRepository(db),
+		Settings:      NewSettingsRepository(db),
+		Invite:        NewInviteRepository(db),
+		Join:          NewJoinRepository(db),
 	}
 }
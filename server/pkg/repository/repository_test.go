@@ -0,0 +1,670 @@
+package repository
+
+import (
+	"cmd/pkg/config"
+	"cmd/pkg/repository/migrations"
+	"cmd/pkg/repository/models"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// testDB повертає БД з актуальною схемою та порожніми таблицями. За
+// замовчуванням тести працюють з тимчасовою БД SQLite. Для інших БД
+// задаються TEST_DB_DRIVER та TEST_DB_DSN, наприклад
+// TEST_DB_DRIVER=mysql TEST_DB_DSN="root:@root@tcp(localhost:3307)/chatTest?parseTime=True"
+func testDB(t testing.TB) *gorm.DB {
+	driver, dsn := os.Getenv("TEST_DB_DRIVER"), os.Getenv("TEST_DB_DSN")
+	if driver == "" {
+		driver, dsn = config.DriverSQLite, filepath.Join(t.TempDir(), "test.db")
+	}
+	db, err := NewRepositoryDB(config.DB{Driver: driver, DSN: dsn, MaxOpenConns: 5, MaxIdleConns: 5})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	_, err = testMigrator(t, db).Up()
+	require.NoError(t, err)
+
+	for _, table := range []string{MessagesTable, ChatUsersList, StatusesTable, SettingsTable, PrivateChats, ChatJoins, ChatInvites, ChatsTable, UsersTable, UploadsTable} {
+		require.NoError(t, db.Exec("DELETE FROM "+table).Error)
+	}
+	return db
+}
+
+// testMigrator повертає мігратор схеми БД db
+func testMigrator(t testing.TB, db *gorm.DB) *migrations.Migrator {
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	files, err := migrations.Files(db.Dialector.Name())
+	require.NoError(t, err)
+	migrator, err := migrations.NewMigrator(sqlDB, db.Dialector.Name(), files)
+	require.NoError(t, err)
+	return migrator
+}
+
+func createUser(t testing.TB, repos *Repository, username string) int {
+	id, err := repos.Authorization.CreateUser(context.Background(), models.User{Username: username, Password: "hash"})
+	require.NoError(t, err)
+	return id
+}
+
+func TestAuthRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+
+	id := createUser(t, repos, "first")
+	_, err := repos.Authorization.CreateUser(ctx, models.User{Username: "first", Password: "hash"})
+	assert.ErrorIs(t, err, ErrDuplicate)
+
+	user, err := repos.Authorization.GetUser(ctx, "first", "hash")
+	require.NoError(t, err)
+	assert.Equal(t, id, user.Id)
+
+	_, err = repos.Authorization.GetByName(ctx, "nobody")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	_, err = repos.Authorization.GetUserById(ctx, id+100)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	createUser(t, repos, "second")
+	user.Username = "second"
+	assert.ErrorIs(t, repos.Authorization.UpdateUser(ctx, user), ErrDuplicate)
+
+	user.Username, user.Icon = "renamed", "icon.png"
+	require.NoError(t, repos.Authorization.UpdateUser(ctx, user))
+	user, err = repos.Authorization.GetUserById(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", user.Username)
+	assert.Equal(t, "icon.png", user.Icon)
+}
+
+func TestChatRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	first, second := createUser(t, repos, "first"), createUser(t, repos, "second")
+
+	chatId, err := repos.Chat.Create(ctx, models.Chat{Name: "chat", Types: ChatPrivate})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: first})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: second})
+	require.NoError(t, err)
+
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: first})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId + 100, UserId: first})
+	assert.ErrorIs(t, err, ErrReference)
+
+	users, err := repos.Chat.GetUsers(ctx, chatId)
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+
+	// Чати повертаються з власними ID, а не ID записів учасників, та
+	// називаються ім'ям співрозмовника
+	chats, err := repos.Chat.GetPrivateChats(ctx, first)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, chatId, chats[0].Id)
+	assert.Equal(t, "second", chats[0].Name)
+	assert.Equal(t, second, chats[0].PartnerId)
+	chats, err = repos.Chat.GetPrivateChats(ctx, second)
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, "first", chats[0].Name)
+	assert.Equal(t, first, chats[0].PartnerId)
+
+	require.NoError(t, repos.Chat.AddPrivate(ctx, second, first, chatId))
+	assert.ErrorIs(t, repos.Chat.AddPrivate(ctx, first, second, chatId), ErrDuplicate)
+	privateId, err := repos.Chat.GetPrivate(ctx, first, second)
+	require.NoError(t, err)
+	assert.Equal(t, chatId, privateId)
+	privateId, err = repos.Chat.GetPrivate(ctx, first, first)
+	require.NoError(t, err)
+	assert.Zero(t, privateId)
+
+	_, err = repos.Message.Create(ctx, models.Message{ChatId: chatId, Author: first, Text: "hello", SentAt: time.Now()})
+	require.NoError(t, err)
+	_, err = repos.Message.Create(ctx, models.Message{ChatId: chatId + 100, Author: first, Text: "hello", SentAt: time.Now()})
+	assert.ErrorIs(t, err, ErrReference)
+
+	// Учасники та повідомлення видаляються разом з чатом
+	require.NoError(t, repos.Chat.Delete(ctx, chatId))
+	users, err = repos.Chat.GetUsers(ctx, chatId)
+	require.NoError(t, err)
+	assert.Empty(t, users)
+	messages, err := repos.Message.GetLimit(ctx, chatId, 10)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+	privateId, err = repos.Chat.GetPrivate(ctx, first, second)
+	require.NoError(t, err)
+	assert.Zero(t, privateId)
+}
+
+func TestPrivateChatsMigration(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	repos := NewRepository(db)
+	first, second, third := createUser(t, repos, "first"), createUser(t, repos, "second"), createUser(t, repos, "third")
+
+	// Схема до появи пар приватних чатів
+	migrator := testMigrator(t, db)
+	for {
+		reverted, err := migrator.Down(1)
+		require.NoError(t, err)
+		require.Len(t, reverted, 1)
+		if reverted[0].Name == "private_chats" {
+			break
+		}
+	}
+
+	// Чати та учасники додаються лише з полями, які вже є у схемі
+	chat := func(types string, members ...int) int {
+		created := models.Chat{Name: "chat", Types: types}
+		require.NoError(t, db.Table(ChatsTable).Select("name", "types").Create(&created).Error)
+		chatId := created.Id
+		for _, userId := range members {
+			require.NoError(t, db.Exec(fmt.Sprintf("INSERT INTO %s (chat_id, user_id) VALUES (?, ?)", ChatUsersList), chatId, userId).Error)
+		}
+		_, err := repos.Message.Create(ctx, models.Message{ChatId: chatId, Author: members[0], Text: "hello", SentAt: time.Now()})
+		require.NoError(t, err)
+		return chatId
+	}
+	original, duplicate := chat(ChatPrivate, first, second), chat(ChatPrivate, second, first)
+	personal, other, public := chat(ChatPrivate, third), chat(ChatPrivate, first, third), chat(ChatPublic, first, second)
+	// Чат, який залишив співрозмовник, не стає особистим чатом учасника
+	abandoned := chat(ChatPrivate, first)
+	_, err := repos.Message.Create(ctx, models.Message{ChatId: abandoned, Author: second, Text: "bye", SentAt: time.Now()})
+	require.NoError(t, err)
+	firstPersonal := chat(ChatPrivate, first)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	for _, want := range []struct{ first, second, chatId int }{
+		{first, second, original}, {third, third, personal}, {third, first, other}, {first, first, firstPersonal},
+	} {
+		chatId, err := repos.Chat.GetPrivate(ctx, want.first, want.second)
+		require.NoError(t, err)
+		assert.Equal(t, want.chatId, chatId)
+	}
+	// Повторний чат об'єднано з найстаршим разом з повідомленнями
+	_, err = repos.Chat.Get(ctx, duplicate)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	messages, err := repos.Message.GetLimit(ctx, original, 10)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+	messages, err = repos.Message.GetLimit(ctx, abandoned, 10)
+	require.NoError(t, err)
+	assert.Len(t, messages, 2)
+	// Назви приватних чатів більше не зберігаються
+	stored, err := repos.Chat.Get(ctx, original)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Name)
+	stored, err = repos.Chat.Get(ctx, public)
+	require.NoError(t, err)
+	assert.Equal(t, "chat", stored.Name)
+	assert.Equal(t, VisibilityOpen, stored.Visibility)
+
+	// Перший учасник публічного чату стає адміністратором
+	for userId, role := range map[int]string{first: RoleAdmin, second: RoleMember} {
+		member, err := repos.Chat.GetMember(ctx, public, userId)
+		require.NoError(t, err)
+		assert.Equal(t, role, member.Role)
+	}
+}
+
+func TestStatusRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	first, second, third := createUser(t, repos, "first"), createUser(t, repos, "second"), createUser(t, repos, "third")
+
+	_, err := repos.Status.AddStatus(ctx, models.Status{SenderId: first, RecipientId: second, Relationship: StatusFriends})
+	require.NoError(t, err)
+	_, err = repos.Status.AddStatus(ctx, models.Status{SenderId: third, RecipientId: first, Relationship: StatusFriends})
+	require.NoError(t, err)
+	_, err = repos.Status.AddStatus(ctx, models.Status{SenderId: first, RecipientId: second, Relationship: StatusBL})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	_, err = repos.Status.AddStatus(ctx, models.Status{SenderId: first, RecipientId: third + 100, Relationship: StatusBL})
+	assert.ErrorIs(t, err, ErrReference)
+
+	friends, err := repos.Status.GetFriends(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, friends, 2)
+
+	statuses, err := repos.Status.GetStatuses(ctx, second, first)
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, StatusFriends, statuses[0].Relationship)
+
+	_, err = repos.Status.AddStatus(ctx, models.Status{SenderId: second, RecipientId: first, Relationship: StatusBL})
+	require.NoError(t, err)
+	statuses, err = repos.Status.GetStatuses(ctx, first, second)
+	require.NoError(t, err)
+	require.Len(t, statuses, 2, "both directions")
+	assert.Equal(t, StatusBL, statuses[1].Relationship)
+
+	users, err := repos.Status.SearchUser(ctx, first, "s")
+	require.NoError(t, err)
+	require.Len(t, users, 1, "user who blocked the searcher is hidden")
+	assert.Equal(t, "first", users[0].Username)
+	users, err = repos.Status.SearchUser(ctx, third, "s")
+	require.NoError(t, err)
+	assert.Len(t, users, 2)
+	require.NoError(t, repos.Status.DeleteStatus(ctx, statuses[1]))
+
+	statuses, err = repos.Status.GetStatuses(ctx, second, third)
+	require.NoError(t, err)
+	assert.Empty(t, statuses)
+
+	require.NoError(t, repos.Status.DeleteStatus(ctx, models.Status{SenderId: first, RecipientId: second, Relationship: StatusFriends}))
+	friends, err = repos.Status.GetFriends(ctx, first)
+	require.NoError(t, err)
+	assert.Len(t, friends, 1)
+}
+
+func TestStatusRepository_GetRelations(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	first, second, third, fourth := createUser(t, repos, "first"), createUser(t, repos, "second"), createUser(t, repos, "third"), createUser(t, repos, "fourth")
+	for _, status := range []models.Status{
+		{SenderId: first, RecipientId: second, Relationship: StatusFriends},
+		{SenderId: third, RecipientId: first, Relationship: StatusInvitation},
+		{SenderId: second, RecipientId: third, Relationship: StatusBL},
+		{SenderId: first, RecipientId: fourth, Relationship: StatusBL},
+	} {
+		_, err := repos.Status.AddStatus(ctx, status)
+		require.NoError(t, err)
+	}
+
+	relations, err := repos.Status.GetRelations(ctx, first, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []models.Relation{
+		{User: models.User{Id: second, Username: "second"}, Relationship: StatusFriends, Outgoing: true},
+		{User: models.User{Id: third, Username: "third"}, Relationship: StatusInvitation, Outgoing: false},
+		{User: models.User{Id: fourth, Username: "fourth"}, Relationship: StatusBL, Outgoing: true},
+	}, relations)
+
+	relations, err = repos.Status.GetRelations(ctx, first, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, relations, 1)
+	assert.Equal(t, third, relations[0].Id)
+}
+
+func TestStatusRepository_GetSuggestions(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	ids := map[string]int{}
+	for _, name := range []string{"me", "a", "b", "c", "d", "e", "f", "g", "h"} {
+		ids[name] = createUser(t, repos, name)
+	}
+	for _, status := range []struct{ sender, recipient, relationship string }{
+		{"me", "a", StatusFriends}, {"b", "me", StatusFriends},
+		{"a", "c", StatusFriends}, {"c", "b", StatusFriends}, {"a", "d", StatusFriends},
+		// Запрошені, заблоковані та приховані з пошуку не пропонуються
+		{"a", "f", StatusFriends}, {"me", "f", StatusInvitation},
+		{"a", "g", StatusFriends}, {"g", "me", StatusBL},
+		{"a", "h", StatusFriends},
+	} {
+		_, err := repos.Status.AddStatus(ctx, models.Status{SenderId: ids[status.sender], RecipientId: ids[status.recipient], Relationship: status.relationship})
+		require.NoError(t, err)
+	}
+	hidden := DefaultSettings(ids["h"])
+	hidden.Searchable = false
+	require.NoError(t, repos.Settings.Save(ctx, hidden))
+
+	// Спільним вважається лише публічний чат
+	for types, members := range map[string][]string{ChatPublic: {"me", "d", "e"}, ChatPrivate: {"me", "c"}} {
+		chatId, err := repos.Chat.Create(ctx, models.Chat{Name: types, Types: types})
+		require.NoError(t, err)
+		for _, name := range members {
+			_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: ids[name]})
+			require.NoError(t, err)
+		}
+	}
+
+	suggestions, err := repos.Status.GetSuggestions(ctx, ids["me"], 10)
+	require.NoError(t, err)
+	var got []string
+	for _, s := range suggestions {
+		got = append(got, fmt.Sprintf("%s:%d:%d", s.Username, s.MutualFriends, s.SharedChats))
+	}
+	assert.Equal(t, []string{"c:2:0", "d:1:1", "e:0:1"}, got)
+
+	suggestions, err = repos.Status.GetSuggestions(ctx, ids["me"], 1)
+	require.NoError(t, err)
+	assert.Len(t, suggestions, 1)
+}
+
+// BenchmarkUserLists порівнює отримання списків відносин п'ятьма запитами
+// та одним запитом GetRelations
+func BenchmarkUserLists(b *testing.B) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(b))
+	userId := createUser(b, repos, "user")
+	relationships := []string{StatusFriends, StatusInvitation, StatusBL}
+	for i := 0; i < 300; i++ {
+		status := models.Status{SenderId: userId, RecipientId: createUser(b, repos, fmt.Sprintf("user%d", i)), Relationship: relationships[i%3]}
+		if i%2 == 0 {
+			status.SenderId, status.RecipientId = status.RecipientId, status.SenderId
+		}
+		_, err := repos.Status.AddStatus(ctx, status)
+		require.NoError(b, err)
+	}
+
+	b.Run("five queries", func(b *testing.B) {
+		lists := []func(ctx context.Context, userId int) ([]models.User, error){
+			repos.Status.GetFriends, repos.Status.GetBlackList, repos.Status.GetBlackListToUser,
+			repos.Status.GetSentInvites, repos.Status.GetInvites,
+		}
+		for i := 0; i < b.N; i++ {
+			for _, list := range lists {
+				if _, err := list(ctx, userId); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("one query", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := repos.Status.GetRelations(ctx, userId, 0, 500); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestSettingsRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	first, second := createUser(t, repos, "first"), createUser(t, repos, "second")
+
+	settings, err := repos.Settings.Get(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultSettings(first), settings)
+	lastSeen, err := repos.Settings.GetLastSeen(ctx, first)
+	require.NoError(t, err)
+	assert.Nil(t, lastSeen)
+
+	// Час активності створює налаштування за замовчуванням
+	seen := time.Now().UTC().Truncate(time.Second)
+	require.NoError(t, repos.Settings.Seen(ctx, first, seen))
+	settings.DirectMessages, settings.Searchable = AudienceFriends, false
+	require.NoError(t, repos.Settings.Save(ctx, settings))
+	require.NoError(t, repos.Settings.Seen(ctx, first, seen.Add(time.Minute)))
+
+	saved, err := repos.Settings.Get(ctx, first)
+	require.NoError(t, err)
+	assert.Equal(t, settings, saved)
+	lastSeen, err = repos.Settings.GetLastSeen(ctx, first)
+	require.NoError(t, err)
+	require.NotNil(t, lastSeen)
+	assert.True(t, seen.Add(time.Minute).Equal(*lastSeen), "saving settings keeps last seen time")
+
+	users, err := repos.Status.SearchUser(ctx, second, "first")
+	require.NoError(t, err)
+	assert.Empty(t, users, "user is not searchable")
+}
+
+func TestUploadRepository(t *testing.T) {
+	ctx := context.Background()
+	db := testDB(t)
+	repos := NewRepository(db)
+
+	require.NoError(t, repos.Upload.Create(ctx, "a.png"))
+	require.NoError(t, repos.Upload.Acquire(ctx, "a.png"))
+	require.NoError(t, repos.Upload.Acquire(ctx, "a.png"))
+	require.NoError(t, repos.Upload.Release(ctx, "a.png"))
+	// Файл, завантажений до обліку, отримує запис без посилань
+	require.NoError(t, repos.Upload.Release(ctx, "legacy.png"))
+
+	future := time.Now().Add(time.Hour)
+	orphans, err := repos.Upload.GetOrphans(ctx, future)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, "legacy.png", orphans[0].Name)
+
+	require.NoError(t, repos.Upload.Release(ctx, "a.png"))
+	orphans, err = repos.Upload.GetOrphans(ctx, future)
+	require.NoError(t, err)
+	assert.Len(t, orphans, 2)
+
+	// Файл, який використовує користувач, не вважається покинутим
+	id := createUser(t, repos, "first")
+	require.NoError(t, repos.Authorization.UpdateUser(ctx, models.User{Id: id, Username: "first", Icon: "a.png"}))
+	orphans, err = repos.Upload.GetOrphans(ctx, future)
+	require.NoError(t, err)
+	assert.Len(t, orphans, 1)
+
+	names, err := repos.Upload.GetNames(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a.png", "legacy.png"}, names)
+
+	ok, err := repos.Upload.Delete(ctx, "legacy.png", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.False(t, ok, "recently released upload must be kept")
+	ok, err = repos.Upload.Delete(ctx, "legacy.png", future)
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestTransaction(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+
+	errStop := errors.New("stop")
+	err := repos.Transaction(ctx, func(tx *Repository) error {
+		createUser(t, tx, "rolled back")
+		return errStop
+	})
+	assert.ErrorIs(t, err, errStop)
+	_, err = repos.Authorization.GetByName(ctx, "rolled back")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = repos.Transaction(ctx, func(tx *Repository) error {
+		createUser(t, tx, "committed")
+		// Вкладена транзакція працює у зовнішній
+		return tx.Transaction(ctx, func(nested *Repository) error {
+			createUser(t, nested, "nested")
+			return nil
+		})
+	})
+	require.NoError(t, err)
+	_, err = repos.Authorization.GetByName(ctx, "nested")
+	assert.NoError(t, err)
+}
+
+func TestInviteRepository(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	owner, guest := createUser(t, repos, "owner"), createUser(t, repos, "guest")
+	chatId, err := repos.Chat.Create(ctx, models.Chat{Name: "chat", Types: ChatPublic})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: owner, Role: RoleAdmin})
+	require.NoError(t, err)
+	_, err = repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: guest})
+	require.NoError(t, err)
+
+	// Роль за замовчуванням - звичайний учасник
+	member, err := repos.Chat.GetMember(ctx, chatId, guest)
+	require.NoError(t, err)
+	assert.Equal(t, RoleMember, member.Role)
+	member.Role = RoleAdmin
+	require.NoError(t, repos.Chat.UpdateRole(ctx, member))
+	members, err := repos.Chat.GetMembers(ctx, chatId)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	assert.Equal(t, []string{RoleAdmin, RoleAdmin}, []string{members[0].Role, members[1].Role})
+	_, err = repos.Chat.GetMember(ctx, chatId+100, guest)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	now := time.Now()
+	invite := models.ChatInvite{ChatId: chatId, Token: "token", CreatedBy: owner, MaxUses: 2, CreatedAt: now}
+	inviteId, err := repos.Invite.Create(ctx, invite)
+	require.NoError(t, err)
+	_, err = repos.Invite.Create(ctx, invite)
+	assert.ErrorIs(t, err, ErrDuplicate)
+	expired := now.Add(-time.Hour)
+	expiredId, err := repos.Invite.Create(ctx, models.ChatInvite{ChatId: chatId, Token: "expired", CreatedBy: owner, ExpiresAt: &expired, CreatedAt: now})
+	require.NoError(t, err)
+
+	// Посилання використовується не більше MaxUses разів і не після
+	// закінчення терміну дії
+	for i, want := range []bool{true, true, false} {
+		used, err := repos.Invite.Use(ctx, inviteId, now)
+		require.NoError(t, err)
+		assert.Equal(t, want, used, "use %d", i)
+	}
+	used, err := repos.Invite.Use(ctx, expiredId, now)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	found, err := repos.Invite.GetByToken(ctx, "token")
+	require.NoError(t, err)
+	assert.Equal(t, inviteId, found.Id)
+	assert.Equal(t, 2, found.Uses)
+	_, err = repos.Invite.GetByToken(ctx, "unknown")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	require.NoError(t, repos.Invite.Revoke(ctx, expiredId, now))
+	found, err = repos.Invite.Get(ctx, expiredId)
+	require.NoError(t, err)
+	assert.NotNil(t, found.RevokedAt)
+	invites, err := repos.Invite.GetByChat(ctx, chatId)
+	require.NoError(t, err)
+	require.Len(t, invites, 2)
+	assert.Equal(t, inviteId, invites[0].Id)
+
+	joinId, err := repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, InviteId: &inviteId, Status: JoinPending, CreatedAt: now})
+	require.NoError(t, err)
+	_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId + 100, UserId: guest, Status: JoinPending, CreatedAt: now})
+	assert.ErrorIs(t, err, ErrReference)
+
+	// Користувач має лише одну заявку, що чекає рішення, а інші вступи не обмежені
+	_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, Status: JoinPending, CreatedAt: now})
+	assert.ErrorIs(t, err, ErrDuplicate)
+	for i := 0; i < 2; i++ {
+		_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, Status: JoinRejected, CreatedAt: now})
+		require.NoError(t, err)
+	}
+	require.NoError(t, repos.Join.UpdateStatus(ctx, models.ChatJoin{Id: joinId, Status: JoinJoined}))
+	_, err = repos.Join.Create(ctx, models.ChatJoin{ChatId: chatId, UserId: guest, Status: JoinPending, CreatedAt: now})
+	require.NoError(t, err)
+	join, err := repos.Join.Get(ctx, joinId)
+	require.NoError(t, err)
+	assert.Equal(t, JoinJoined, join.Status)
+	require.NotNil(t, join.InviteId)
+	assert.Equal(t, inviteId, *join.InviteId)
+	pending, err := repos.Join.GetByChat(ctx, chatId, JoinPending)
+	require.NoError(t, err)
+	assert.Len(t, pending, 1)
+
+	// Посилання та вступи видаляються разом з чатом
+	require.NoError(t, repos.Chat.Delete(ctx, chatId))
+	invites, err = repos.Invite.GetByChat(ctx, chatId)
+	require.NoError(t, err)
+	assert.Empty(t, invites)
+	_, err = repos.Join.Get(ctx, joinId)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestChatRepository_Visibility(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	openId, err := repos.Chat.Create(ctx, models.Chat{Name: "room open", Types: ChatPublic})
+	require.NoError(t, err)
+	hiddenId, err := repos.Chat.Create(ctx, models.Chat{Name: "room hidden", Types: ChatPublic, Visibility: VisibilityHidden})
+	require.NoError(t, err)
+
+	// Без видимості чат відкритий, прихований чат пошук не знаходить
+	chat, err := repos.Chat.Get(ctx, openId)
+	require.NoError(t, err)
+	assert.Equal(t, VisibilityOpen, chat.Visibility)
+	chats, err := repos.Chat.SearchChat(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, chats, 1)
+	assert.Equal(t, openId, chats[0].Id)
+
+	chat.Visibility = VisibilityRequest
+	require.NoError(t, repos.Chat.Update(ctx, chat))
+	chat, err = repos.Chat.Get(ctx, hiddenId)
+	require.NoError(t, err)
+	chat.Visibility = VisibilityOpen
+	require.NoError(t, repos.Chat.Update(ctx, chat))
+	chats, err = repos.Chat.SearchChat(ctx, "room")
+	require.NoError(t, err)
+	require.Len(t, chats, 2)
+	assert.Equal(t, VisibilityRequest, chats[0].Visibility)
+}
+
+func TestChatRepository_Metadata(t *testing.T) {
+	ctx := context.Background()
+	repos := NewRepository(testDB(t))
+	first, second := createUser(t, repos, "first"), createUser(t, repos, "second")
+	created := time.Now().Add(-time.Hour)
+	quietId, err := repos.Chat.Create(ctx, models.Chat{Name: "quiet", Types: ChatPublic, Description: "about", Topic: "news", CreatedBy: &first, CreatedAt: &created})
+	require.NoError(t, err)
+	busyId, err := repos.Chat.Create(ctx, models.Chat{Name: "busy", Types: ChatPublic, CreatedBy: &first, CreatedAt: &created})
+	require.NoError(t, err)
+	legacyId, err := repos.Chat.Create(ctx, models.Chat{Name: "legacy", Types: ChatPublic})
+	require.NoError(t, err)
+	for _, member := range []models.ChatUsers{{ChatId: quietId, UserId: first}, {ChatId: busyId, UserId: first}, {ChatId: busyId, UserId: second}, {ChatId: legacyId, UserId: first}} {
+		_, err := repos.Chat.AddUser(ctx, member)
+		require.NoError(t, err)
+	}
+	for _, text := range []string{"earlier", "latest"} {
+		_, err := repos.Message.Create(ctx, models.Message{ChatId: busyId, Author: second, Text: text, SentAt: time.Now()})
+		require.NoError(t, err)
+	}
+
+	// Кількість учасників та останнє повідомлення обчислюються запитом
+	chat, err := repos.Chat.Get(ctx, busyId)
+	require.NoError(t, err)
+	assert.Equal(t, 2, chat.MemberCount)
+	require.NotNil(t, chat.LastMessage)
+	assert.Equal(t, "latest", chat.LastMessage.Text)
+	assert.Equal(t, second, chat.LastMessage.Author)
+	require.NotNil(t, chat.LastMessageAt)
+	assert.WithinDuration(t, time.Now(), *chat.LastMessageAt, time.Minute)
+
+	chat, err = repos.Chat.Get(ctx, quietId)
+	require.NoError(t, err)
+	assert.Equal(t, "about", chat.Description)
+	assert.Equal(t, "news", chat.Topic)
+	require.NotNil(t, chat.CreatedBy)
+	assert.Equal(t, first, *chat.CreatedBy)
+	require.NotNil(t, chat.CreatedAt)
+	assert.WithinDuration(t, created, *chat.CreatedAt, time.Second)
+	assert.Equal(t, 1, chat.MemberCount)
+	assert.Nil(t, chat.LastMessage)
+	assert.Nil(t, chat.LastMessageAt)
+
+	chat.Description, chat.Topic = "", "sport"
+	require.NoError(t, repos.Chat.Update(ctx, chat))
+	chat, err = repos.Chat.Get(ctx, quietId)
+	require.NoError(t, err)
+	assert.Empty(t, chat.Description)
+	assert.Equal(t, "sport", chat.Topic)
+	assert.Equal(t, "quiet", chat.Name)
+
+	// Спершу чат з новим повідомленням, далі створений годину тому, а чат
+	// без відомої активності - в кінці
+	chats, err := repos.Chat.GetPublicChats(ctx, first)
+	require.NoError(t, err)
+	var ids []int
+	for _, chat := range chats {
+		ids = append(ids, chat.Id)
+	}
+	assert.Equal(t, []int{busyId, quietId, legacyId}, ids)
+	require.NotNil(t, chats[0].LastMessage)
+	assert.Equal(t, "latest", chats[0].LastMessage.Text)
+	assert.Nil(t, chats[2].CreatedAt)
+}
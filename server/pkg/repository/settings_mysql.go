@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"cmd/pkg/repository/models"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type SettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewSettingsRepository(db *gorm.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// settingsColumns - стовпці налаштувань, які змінює користувач
+var settingsColumns = []string{"direct_messages", "chat_invites", "searchable", "last_seen"}
+
+// Get отримує ID користувача ТА повертає його налаштування. Якщо
+// користувач їх не змінював, повертає DefaultSettings
+func (s *SettingsRepository) Get(ctx context.Context, userId int) (models.UserSettings, error) {
+	var settings models.UserSettings
+	err := s.db.WithContext(ctx).Table(SettingsTable).Select("user_id", settingsColumns).Where("user_id = ?", userId).Take(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultSettings(userId), nil
+	}
+	return settings, err
+}
+
+// Save отримує налаштування ТА створює або оновлює їх. Час останньої
+// активності не змінюється
+func (s *SettingsRepository) Save(ctx context.Context, settings models.UserSettings) error {
+	return s.db.WithContext(ctx).Table(SettingsTable).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns(settingsColumns),
+	}).Create(&settings).Error
+}
+
+// Seen отримує ID користувача та час ТА зберігає його як час останньої
+// активності. Якщо налаштувань ще немає, створює їх за замовчуванням
+func (s *SettingsRepository) Seen(ctx context.Context, userId int, at time.Time) error {
+	defaults := DefaultSettings(userId)
+	row := map[string]interface{}{
+		"user_id":         userId,
+		"direct_messages": defaults.DirectMessages,
+		"chat_invites":    defaults.ChatInvites,
+		"searchable":      defaults.Searchable,
+		"last_seen":       defaults.LastSeen,
+		"last_seen_at":    at,
+	}
+	return s.db.WithContext(ctx).Table(SettingsTable).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"last_seen_at"}),
+	}).Create(row).Error
+}
+
+// GetLastSeen отримує ID користувача ТА повертає час його останньої
+// активності або nil, якщо користувач ще не був активним
+func (s *SettingsRepository) GetLastSeen(ctx context.Context, userId int) (*time.Time, error) {
+	var row struct{ LastSeenAt *time.Time }
+	err := s.db.WithContext(ctx).Table(SettingsTable).Select("last_seen_at").Where("user_id = ?", userId).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return row.LastSeenAt, err
+}
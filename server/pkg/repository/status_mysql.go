@@ -2,8 +2,9 @@ package repository
 
 import (
 	"cmd/pkg/repository/models"
+	"context"
 	"fmt"
-	"github.com/jinzhu/gorm"
+	"gorm.io/gorm"
 )
 
 type StatusRepository struct {
@@ -15,110 +16,135 @@ func NewStatusRepository(db *gorm.DB) *StatusRepository {
 }
 
 // AddStatus отримує ID двох користувачів та їх тип відносин ТА повертає ID статусу
-func (s *StatusRepository) AddStatus(status models.Status) (int, error) {
-	err := s.db.Table(StatusesTable).Create(&status).Error
-	return status.Id, err
+func (s *StatusRepository) AddStatus(ctx context.Context, status models.Status) (int, error) {
+	err := s.db.WithContext(ctx).Table(StatusesTable).Create(&status).Error
+	return status.Id, translate(err)
 }
 
-// GetStatuses отримує ID двох користувачів ТА повертає дані їх відносин
-func (s *StatusRepository) GetStatuses(senderId, recipientId int) ([]models.Status, error) {
-	var status []models.Status
-	err := s.db.Table(StatusesTable).Where("(sender_id = ? and recipient_id = ?) or (sender_id = ? and recipient_id = ? and relationship = ?)",
-		senderId, recipientId, recipientId, senderId, StatusFriends).First(&status).Error
-	return status, err
+// GetStatuses отримує ID двох користувачів ТА повертає усі записи їх
+// відносин в обох напрямках
+func (s *StatusRepository) GetStatuses(ctx context.Context, senderId, recipientId int) ([]models.Status, error) {
+	var statuses []models.Status
+	err := s.db.WithContext(ctx).Table(StatusesTable).Where("(sender_id = ? and recipient_id = ?) or (sender_id = ? and recipient_id = ?)",
+		senderId, recipientId, recipientId, senderId).Order("id").Find(&statuses).Error
+	return statuses, err
 }
 
 // UpdateStatus отримує ID двох користувачів та їх тип відносин ТА оновлює дані
-func (s *StatusRepository) UpdateStatus(status models.Status) error {
-	err := s.db.Table(StatusesTable).Where("sender_id = ? and recipient_id = ?", status.SenderId, status.RecipientId).Updates(&status).Error
-	return err
+func (s *StatusRepository) UpdateStatus(ctx context.Context, status models.Status) error {
+	err := s.db.WithContext(ctx).Table(StatusesTable).Where("sender_id = ? and recipient_id = ?", status.SenderId, status.RecipientId).Updates(&status).Error
+	return translate(err)
 }
 
 // DeleteStatus отримує ID двох користувачів та їх тип відносин ТА видаляє ці відносини
-func (s *StatusRepository) DeleteStatus(status models.Status) error {
+func (s *StatusRepository) DeleteStatus(ctx context.Context, status models.Status) error {
 	query := fmt.Sprintf("DELETE FROM %s WHERE (relationship = ? and sender_id = ? and recipient_id = ?)", StatusesTable)
-	err := s.db.Raw(query, status.Relationship, status.SenderId, status.RecipientId).Scan(&status).Error
-	return err
+	return s.db.WithContext(ctx).Exec(query, status.Relationship, status.SenderId, status.RecipientId).Error
 }
 
-// GetFriends отримує ID користувача ТА повертає масив користувачів, що є ДРУЗЯМИ
-func (s *StatusRepository) GetFriends(userId int) ([]models.User, error) {
-	var usersId []models.User
-	var result []models.User
-	tx := s.db.Begin()
+// otherUser - ID другого учасника запису відносин r з користувачем @user
+const otherUser = "CASE WHEN r.sender_id = @user THEN r.recipient_id ELSE r.sender_id END"
 
-	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chul ON chul.sender_id = u.id WHERE relationship = ? and recipient_id = ?", UsersTable, StatusesTable)
-	err := tx.Raw(query, StatusFriends, userId).Scan(&usersId).Error
-	if err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-	result = usersId
-
-	querySec := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chul ON chul.recipient_id = u.id WHERE relationship = ? and sender_id = ?", UsersTable, StatusesTable)
-	errSec := tx.Raw(querySec, StatusFriends, userId).Scan(&usersId).Error
-	if errSec != nil {
-		tx.Rollback()
-		return nil, errSec
-	}
-	for i := range usersId {
-		result = append(result, usersId[i])
-	}
-	return result, tx.Commit().Error
+// GetFriends отримує ID користувача ТА повертає масив користувачів, що є ДРУЗЯМИ,
+// в порядку створення дружби
+func (s *StatusRepository) GetFriends(ctx context.Context, userId int) ([]models.User, error) {
+	var users []models.User
+	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s r INNER JOIN %s u ON u.id = %s "+
+		"WHERE r.relationship = @friends AND (r.sender_id = @user OR r.recipient_id = @user) ORDER BY r.id", StatusesTable, UsersTable, otherUser)
+	err := s.db.WithContext(ctx).Raw(query, map[string]interface{}{"friends": StatusFriends, "user": userId}).Scan(&users).Error
+	return users, err
+}
+
+// GetRelations отримує ID користувача ТА повертає до limit пов'язаних з
+// ним користувачів, починаючи з offset, з типом та напрямком відносин.
+// Користувачі повертаються в порядку створення відносин одним запитом;
+// умова OR користується індексами за відправником та отримувачем
+func (s *StatusRepository) GetRelations(ctx context.Context, userId, offset, limit int) ([]models.Relation, error) {
+	var relations []models.Relation
+	query := fmt.Sprintf("SELECT u.id, u.username, u.icon, r.relationship, r.sender_id = @user AS outgoing FROM %s r "+
+		"INNER JOIN %s u ON u.id = CASE WHEN r.sender_id = @user THEN r.recipient_id ELSE r.sender_id END "+
+		"WHERE r.sender_id = @user OR r.recipient_id = @user ORDER BY r.id LIMIT @limit OFFSET @offset", StatusesTable, UsersTable)
+	err := s.db.WithContext(ctx).Raw(query, map[string]interface{}{"user": userId, "limit": limit, "offset": offset}).Scan(&relations).Error
+	return relations, err
 }
 
 // GetBlackList отримує ID користувача ТА повертає масив ЗАБЛОКОВАНИХ користувачів
-func (s *StatusRepository) GetBlackList(userId int) ([]models.User, error) {
+func (s *StatusRepository) GetBlackList(ctx context.Context, userId int) ([]models.User, error) {
 	var users []models.User
 	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chul ON chul.recipient_id = u.id WHERE relationship = ? and sender_id = ?", UsersTable, StatusesTable)
-	err := s.db.Raw(query, StatusBL, userId).Scan(&users).Error
+	err := s.db.WithContext(ctx).Raw(query, StatusBL, userId).Scan(&users).Error
 
 	return users, err
 }
 
 // GetBlackListToUser отримує ID користувача ТА повертає масив користувачів, що
 // ЗАБЛОКУВАЛИ його
-func (s *StatusRepository) GetBlackListToUser(userId int) ([]models.User, error) {
+func (s *StatusRepository) GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error) {
 	var users []models.User
 	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chul ON chul.sender_id = u.id WHERE relationship = ? and recipient_id = ?", UsersTable, StatusesTable)
-	err := s.db.Raw(query, StatusBL, userId).Scan(&users).Error
+	err := s.db.WithContext(ctx).Raw(query, StatusBL, userId).Scan(&users).Error
 
 	return users, err
 }
 
 // GetSentInvites отримує ID користувача ТА повертає масив користувачів, що
 // ОТРИМАЛИ його запрошення у друзі
-func (s *StatusRepository) GetSentInvites(userId int) ([]models.User, error) {
+func (s *StatusRepository) GetSentInvites(ctx context.Context, userId int) ([]models.User, error) {
 	var users []models.User
 	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chul ON chul.recipient_id = u.id WHERE relationship = ? and sender_id = ?", UsersTable, StatusesTable)
-	err := s.db.Raw(query, StatusInvitation, userId).Scan(&users).Error
+	err := s.db.WithContext(ctx).Raw(query, StatusInvitation, userId).Scan(&users).Error
 
 	return users, err
 }
 
 // GetInvites отримує ID користувача ТА повертає масив користувачів, що
 // НАДІСЛАЛИ йому запрошення в друзі
-func (s *StatusRepository) GetInvites(userId int) ([]models.User, error) {
+func (s *StatusRepository) GetInvites(ctx context.Context, userId int) ([]models.User, error) {
 	var users []models.User
 	query := fmt.Sprintf("SELECT u.id, u.username, u.icon FROM %s u INNER JOIN %s chul ON chul.sender_id = u.id WHERE relationship = ? and recipient_id = ?", UsersTable, StatusesTable)
-	err := s.db.Raw(query, StatusInvitation, userId).Scan(&users).Error
+	err := s.db.WithContext(ctx).Raw(query, StatusInvitation, userId).Scan(&users).Error
 
 	return users, err
 }
 
 // SearchUser отримує ім'я (або його частину) ТА повертає масив користувачів, що
-// мають збіг з аргументом
-func (s *StatusRepository) SearchUser(username string) ([]models.User, error) {
+// мають збіг з аргументом, крім тих, що ЗАБЛОКУВАЛИ userId або заборонили
+// пошук за іменем
+func (s *StatusRepository) SearchUser(ctx context.Context, userId int, username string) ([]models.User, error) {
 	var users []models.User
-	query := fmt.Sprintf("SELECT id, username, icon FROM %s WHERE username LIKE ? LIMIT 16", UsersTable)
-	err := s.db.Raw(query, fmt.Sprintf("%%%s%%", username)).Scan(&users).Error
+	query := fmt.Sprintf("SELECT id, username, icon FROM %s WHERE LOWER(username) LIKE LOWER(?) AND id NOT IN (SELECT sender_id FROM %s WHERE relationship = ? and recipient_id = ?) AND id NOT IN (SELECT user_id FROM %s WHERE searchable = ?) LIMIT 16", UsersTable, StatusesTable, SettingsTable)
+	err := s.db.WithContext(ctx).Raw(query, fmt.Sprintf("%%%s%%", username), StatusBL, userId, false).Scan(&users).Error
 	return users, err
 }
 
+// GetSuggestions отримує ID користувача ТА повертає до limit користувачів,
+// з якими він ще не має відносин, за спаданням кількості спільних друзів
+// та спільних публічних чатів. Користувачі, що заборонили пошук за іменем,
+// не пропонуються. Кандидати рахуються одним запитом: кожен друг друга дає
+// один спільний друг, кожен спільний публічний чат - один спільний чат
+func (s *StatusRepository) GetSuggestions(ctx context.Context, userId, limit int) ([]models.Suggestion, error) {
+	var suggestions []models.Suggestion
+	friends := fmt.Sprintf("SELECT sender_id AS user_id, recipient_id AS friend_id FROM %[1]s WHERE relationship = @friends "+
+		"UNION ALL SELECT recipient_id, sender_id FROM %[1]s WHERE relationship = @friends", StatusesTable)
+	query := fmt.Sprintf("SELECT u.id, u.username, u.icon, SUM(c.mutual) AS mutual_friends, SUM(c.shared) AS shared_chats FROM ("+
+		"SELECT f2.friend_id AS user_id, 1 AS mutual, 0 AS shared FROM (%[1]s) f1 INNER JOIN (%[1]s) f2 ON f2.user_id = f1.friend_id WHERE f1.user_id = @user "+
+		"UNION ALL SELECT other.user_id, 0, 1 FROM %[2]s mine INNER JOIN %[3]s ch ON ch.id = mine.chat_id AND ch.types = @public "+
+		"INNER JOIN %[2]s other ON other.chat_id = mine.chat_id WHERE mine.user_id = @user"+
+		") c INNER JOIN %[4]s u ON u.id = c.user_id "+
+		"WHERE c.user_id <> @user AND c.user_id NOT IN (SELECT recipient_id FROM %[5]s WHERE sender_id = @user) "+
+		"AND c.user_id NOT IN (SELECT sender_id FROM %[5]s WHERE recipient_id = @user) "+
+		"AND c.user_id NOT IN (SELECT user_id FROM %[6]s WHERE searchable = @hidden) "+
+		"GROUP BY u.id, u.username, u.icon ORDER BY mutual_friends DESC, shared_chats DESC, u.id LIMIT @limit",
+		friends, ChatUsersList, ChatsTable, UsersTable, StatusesTable, SettingsTable)
+	err := s.db.WithContext(ctx).Raw(query, map[string]interface{}{
+		"friends": StatusFriends, "public": ChatPublic, "user": userId, "hidden": false, "limit": limit,
+	}).Scan(&suggestions).Error
+	return suggestions, err
+}
+
 // GetUserById отримує ID користувача ТА повертає його дані
-func (s *StatusRepository) GetUserById(userId int) (models.User, error) {
+func (s *StatusRepository) GetUserById(ctx context.Context, userId int) (models.User, error) {
 	var user models.User
-	query := fmt.Sprintf("SELECT id, username, icon FROM %s WHERE id = ?", UsersTable)
-	err := s.db.Raw(query, userId).Scan(&user).Error
+	err := s.db.WithContext(ctx).Table(UsersTable).Select("id", "username", "icon").Where("id = ?", userId).Take(&user).Error
 	return user, err
 }
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+	"gorm.io/gorm"
+)
+
+type TxRepository struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) *TxRepository {
+	return &TxRepository{db: db}
+}
+
+// Transaction відкриває транзакцію ТА викликає fn з репозиторіями, що
+// працюють у ній. Транзакція підтверджується, якщо fn не повернула помилку,
+// інакше (або при panic) відкочується
+func (t *TxRepository) Transaction(ctx context.Context, fn func(repos *Repository) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		repos := newRepository(tx)
+		// Вкладені виклики використовують ту саму транзакцію
+		repos.Transactor = inTransaction{repos: repos}
+		return fn(repos)
+	})
+}
+
+// inTransaction виконує вкладені виклики Transaction у поточній транзакції
+type inTransaction struct {
+	repos *Repository
+}
+
+func (t inTransaction) Transaction(_ context.Context, fn func(repos *Repository) error) error {
+	return fn(t.repos)
+}
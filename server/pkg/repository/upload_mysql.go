@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"cmd/pkg/repository/models"
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type UploadRepository struct {
+	db *gorm.DB
+}
+
+func NewUploadRepository(db *gorm.DB) *UploadRepository {
+	return &UploadRepository{db: db}
+}
+
+// Create отримує ім'я файлу ТА створює запис про нього без посилань.
+// Якщо запис вже існує, оновлює час його зміни, щоб прибиральник не
+// видалив файл, який щойно завантажили повторно
+func (u *UploadRepository) Create(ctx context.Context, name string) error {
+	return u.upsert(ctx, name, 0, nil)
+}
+
+// Acquire отримує ім'я файлу ТА збільшує кількість посилань на нього.
+// Якщо запису про файл ще немає (файл завантажено до обліку), створює його
+func (u *UploadRepository) Acquire(ctx context.Context, name string) error {
+	refs := gorm.Expr(fmt.Sprintf("%s.refs + 1", UploadsTable))
+	return u.upsert(ctx, name, 1, clause.Set{{Column: clause.Column{Name: "refs"}, Value: refs}})
+}
+
+// Release отримує ім'я файлу ТА зменшує кількість посилань на нього.
+// Якщо запису про файл ще немає (файл завантажено до обліку), створює його
+func (u *UploadRepository) Release(ctx context.Context, name string) error {
+	refs := gorm.Expr(fmt.Sprintf("CASE WHEN %[1]s.refs > 0 THEN %[1]s.refs - 1 ELSE 0 END", UploadsTable))
+	return u.upsert(ctx, name, 0, clause.Set{{Column: clause.Column{Name: "refs"}, Value: refs}})
+}
+
+// upsert створює запис про файл з refs посиланнями. Якщо запис вже існує,
+// виконує оновлення update та оновлює час зміни запису
+func (u *UploadRepository) upsert(ctx context.Context, name string, refs int, update clause.Set) error {
+	now := time.Now()
+	upload := models.Upload{Name: name, Refs: refs, CreatedAt: now, UpdatedAt: now}
+	return u.db.WithContext(ctx).Table(UploadsTable).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "name"}},
+		DoUpdates: append(update, clause.Assignment{Column: clause.Column{Name: "updated_at"}, Value: now}),
+	}).Create(&upload).Error
+}
+
+// GetOrphans отримує час ТА повертає файли без посилань, що не змінювалися
+// з цього часу та не використовуються жодним користувачем чи чатом
+func (u *UploadRepository) GetOrphans(ctx context.Context, before time.Time) ([]models.Upload, error) {
+	var uploads []models.Upload
+	query := fmt.Sprintf(`SELECT id, name, refs, created_at, updated_at FROM %s up
+		WHERE up.refs = 0 AND up.updated_at < ?
+		AND NOT EXISTS (SELECT 1 FROM %s u WHERE u.icon = up.name)
+		AND NOT EXISTS (SELECT 1 FROM %s ch WHERE ch.icon = up.name)`, UploadsTable, UsersTable, ChatsTable)
+	err := u.db.WithContext(ctx).Raw(query, before).Scan(&uploads).Error
+	return uploads, err
+}
+
+// GetNames повертає імена усіх файлів, що обліковані або використовуються
+// користувачами чи чатами
+func (u *UploadRepository) GetNames(ctx context.Context) ([]string, error) {
+	var names []string
+	query := fmt.Sprintf("SELECT name FROM %s UNION SELECT icon FROM %s WHERE icon <> '' UNION SELECT icon FROM %s WHERE icon <> ''",
+		UploadsTable, UsersTable, ChatsTable)
+	rows, err := u.db.WithContext(ctx).Raw(query).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
+
+// Delete отримує ім'я файлу та час ТА видаляє запис про нього, якщо на файл
+// досі немає посилань і його не змінювали з цього часу. Повертає, чи було
+// видалено запис
+func (u *UploadRepository) Delete(ctx context.Context, name string, before time.Time) (bool, error) {
+	res := u.db.WithContext(ctx).Table(UploadsTable).Where("name = ? AND refs = 0 AND updated_at < ?", name, before).Delete(&models.Upload{})
+	return res.RowsAffected > 0, res.Error
+}
@@ -1,22 +1,21 @@
 package service
 
 import (
+	"cmd/pkg/config"
 	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
+	"context"
 	"crypto/sha1"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
-	"os"
 	"time"
 )
 
-const (
-	tokenTTL = 48 * time.Hour
-)
-
 type AuthService struct {
 	repository repository.Authorization
+	icons      icons
+	cnf        config.Auth
 }
 
 type tokenClaims struct {
@@ -24,45 +23,48 @@ type tokenClaims struct {
 	UserId int `json:"user_id"`
 }
 
-func NewAuthService(repository repository.Authorization) *AuthService {
-	return &AuthService{repository: repository}
+func NewAuthService(repository repository.Authorization, icons icons, cnf config.Auth) *AuthService {
+	return &AuthService{repository: repository, icons: icons, cnf: cnf}
 }
 
 // CreateUser кодує пароль викликає створення нового користувача
-func (a *AuthService) CreateUser(user models.User) (int, error) {
-	user.Password = CreatePasswordHash(user.Password)
-	return a.repository.CreateUser(user)
+func (a *AuthService) CreateUser(ctx context.Context, user models.User) (int, error) {
+	user.Password = a.passwordHash(user.Password)
+	id, err := a.repository.CreateUser(ctx, user)
+	return id, translate(err, nil, ErrUsernameTaken, nil)
 }
 
 // GetByName викликає повернення даних користувача за ім'ям
-func (a *AuthService) GetByName(username string) (models.User, error) {
-	return a.repository.GetByName(username)
+func (a *AuthService) GetByName(ctx context.Context, username string) (models.User, error) {
+	user, err := a.repository.GetByName(ctx, username)
+	return a.icons.user(user), translate(err, ErrUserNotFound, nil, nil)
 }
 
 // GetUserById викликає отримання даних користувача за його ID
-func (a *AuthService) GetUserById(userId int) (models.User, error) {
-	return a.repository.GetUserById(userId)
+func (a *AuthService) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	user, err := a.repository.GetUserById(ctx, userId)
+	return a.icons.user(user), translate(err, ErrUserNotFound, nil, nil)
 }
 
 // GenerateToken отримує за ім'ям та паролем користувача його ID,
 // далі цей ID зашифровується у токен та повертається токен
-func (a *AuthService) GenerateToken(username, password string) (string, error) {
-	user, err := a.repository.GetUser(username, CreatePasswordHash(password))
+func (a *AuthService) GenerateToken(ctx context.Context, username, password string) (string, error) {
+	user, err := a.repository.GetUser(ctx, username, a.passwordHash(password))
 	if err != nil {
-		return "", err
+		return "", translate(err, ErrWrongPassword, nil, nil)
 	}
 	if user.Id == 0 {
-		return "", err
+		return "", ErrWrongPassword
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &tokenClaims{
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(tokenTTL).Unix(),
+			ExpiresAt: time.Now().Add(a.cnf.TokenTTL).Unix(),
 			IssuedAt:  time.Now().Unix(),
 		},
 		UserId: user.Id,
 	})
 
-	return token.SignedString([]byte(os.Getenv("signInKey")))
+	return token.SignedString([]byte(a.cnf.SignInKey))
 }
 
 // ParseToken отримує зашифрований токен, розшифровує його та
@@ -72,35 +74,35 @@ func (a *AuthService) ParseToken(accessToken string) (int, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("invalid signing method")
 		}
-		return []byte(os.Getenv("signInKey")), nil
+		return []byte(a.cnf.SignInKey), nil
 	})
 	if err != nil {
-		return 0, err
+		return 0, ErrInvalidToken.Wrap(err)
 	}
 
 	claims, ok := token.Claims.(*tokenClaims)
 	if !ok {
-		return 0, errors.New("token claims are not of type *tokenClaims")
+		return 0, ErrInvalidToken.Wrap(errors.New("token claims are not of type *tokenClaims"))
 	}
 	return claims.UserId, nil
 }
 
 // UpdateData оновлює ім'я або зображення
-func (a *AuthService) UpdateData(user models.User) error {
-	err := a.repository.UpdateUser(user)
-	return err
+func (a *AuthService) UpdateData(ctx context.Context, user models.User) error {
+	err := a.repository.UpdateUser(ctx, user)
+	return translate(err, ErrUserNotFound, ErrUsernameTaken, nil)
 }
 
 // UpdatePassword кодує пароль та оновлює його
-func (a *AuthService) UpdatePassword(user models.User) error {
-	user.Password = CreatePasswordHash(user.Password)
-	err := a.repository.UpdateUser(user)
-	return err
+func (a *AuthService) UpdatePassword(ctx context.Context, user models.User) error {
+	user.Password = a.passwordHash(user.Password)
+	err := a.repository.UpdateUser(ctx, user)
+	return translate(err, ErrUserNotFound, nil, nil)
 }
 
-// CreatePasswordHash шифрує пароль
-func CreatePasswordHash(password string) string {
+// passwordHash шифрує пароль
+func (a *AuthService) passwordHash(password string) string {
 	hash := sha1.New()
 	hash.Write([]byte(password))
-	return fmt.Sprintf("%x", hash.Sum([]byte(os.Getenv("salt"))))
+	return fmt.Sprintf("%x", hash.Sum([]byte(a.cnf.Salt)))
 }
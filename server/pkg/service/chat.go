@@ -3,123 +3,371 @@ package service
 import (
 	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
+	"context"
+	"errors"
 )
 
+// previewLength - найбільша кількість символів тексту останнього
+// повідомлення у списку чатів
+const previewLength = 100
+
 type ChatService struct {
 	repository repository.Chat
+	statuses   repository.Status
+	settings   repository.Settings
+	transactor repository.Transactor
+	icons      icons
 }
 
-func NewChatService(repository repository.Chat) *ChatService {
-	return &ChatService{repository: repository}
+func NewChatService(repository repository.Chat, statuses repository.Status, settings repository.Settings, transactor repository.Transactor, icons icons) *ChatService {
+	return &ChatService{repository: repository, statuses: statuses, settings: settings, transactor: transactor, icons: icons}
 }
 
-// Create викликає створення нового чату
-func (c *ChatService) Create(chat models.Chat) (int, error) {
-	return c.repository.Create(chat)
+// Create створює новий чат та додає до нього користувачів members
+// в одній транзакції ТА повертає ID чату. Перший з members (творець чату)
+// стає адміністратором та автором чату
+func (c *ChatService) Create(ctx context.Context, chat models.Chat, members ...int) (int, error) {
+	var chatId int
+	now := models.Now()
+	chat.CreatedAt = &now
+	if len(members) > 0 {
+		chat.CreatedBy = &members[0]
+	}
+	err := c.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		id, err := repos.Chat.Create(ctx, chat)
+		if err != nil {
+			return err
+		}
+		for i, userId := range members {
+			member := models.ChatUsers{ChatId: id, UserId: userId, Role: repository.RoleMember}
+			if i == 0 {
+				member.Role = repository.RoleAdmin
+			}
+			if _, err := repos.Chat.AddUser(ctx, member); err != nil {
+				return err
+			}
+		}
+		chatId = id
+		return nil
+	})
+	return chatId, err
 }
 
 // Get викликає отримання даних чату
-func (c *ChatService) Get(chatId int) (models.Chat, error) {
-	return c.repository.Get(chatId)
+func (c *ChatService) Get(ctx context.Context, chatId int) (models.Chat, error) {
+	chat, err := c.repository.Get(ctx, chatId)
+	return c.icons.chat(chat), translate(err, ErrChatNotFound, nil, nil)
+}
+
+// GetForUser повертає дані чату так, як їх бачить viewerId. Приватний чат
+// називається ім'ям співрозмовника та має його зображення, а сам
+// співрозмовник повертається другим значенням. В особистому чаті та в чаті,
+// який залишив співрозмовник, це сам viewerId
+func (c *ChatService) GetForUser(ctx context.Context, viewerId, chatId int) (models.Chat, models.User, error) {
+	var partner models.User
+	chat, err := c.repository.Get(ctx, chatId)
+	if err != nil || chat.Types != repository.ChatPrivate {
+		return c.icons.chat(chat), partner, translate(err, ErrChatNotFound, nil, nil)
+	}
+	users, err := c.repository.GetUsers(ctx, chatId)
+	if err != nil {
+		return chat, partner, err
+	}
+	for _, user := range users {
+		if user.Id != viewerId || len(users) == 1 {
+			partner = user
+		}
+	}
+	chat.Name, chat.Icon = partner.Username, partner.Icon
+	return c.icons.chat(chat), c.icons.user(partner), nil
 }
 
 // Update викликає оновлення даних чату
-func (c *ChatService) Update(chat models.Chat) error {
-	return c.repository.Update(chat)
+func (c *ChatService) Update(ctx context.Context, chat models.Chat) error {
+	return c.repository.Update(ctx, chat)
 }
 
-// Delete викликає видалення чату
-func (c *ChatService) Delete(chatId int) error {
-	return c.repository.Delete(chatId)
+// UpdateInfo змінює назву, опис та тему публічного чату адміністратором
+// userId ТА повертає оновлені дані чату
+func (c *ChatService) UpdateInfo(ctx context.Context, userId int, info models.Chat) (models.Chat, error) {
+	chat, err := c.GetForAdmin(ctx, userId, info.Id)
+	if err != nil {
+		return info, err
+	}
+	chat.Name, chat.Description, chat.Topic = info.Name, info.Description, info.Topic
+	if err := c.repository.Update(ctx, chat); err != nil {
+		return info, err
+	}
+	return c.icons.chat(chat), nil
 }
 
-// AddUser викликає додання користувача до чату
-func (c *ChatService) AddUser(users models.ChatUsers) (int, error) {
-	return c.repository.AddUser(users)
+// GetForAdmin повертає дані публічного чату для зміни, якщо userId - його
+// адміністратор. Інакше повертає ErrForbidden, а для відсутнього чату -
+// ErrChatNotFound
+func (c *ChatService) GetForAdmin(ctx context.Context, userId, chatId int) (models.Chat, error) {
+	if err := admin(ctx, c.repository, chatId, userId); err != nil {
+		return models.Chat{}, err
+	}
+	chat, err := c.repository.Get(ctx, chatId)
+	if err != nil {
+		return chat, translate(err, ErrChatNotFound, nil, nil)
+	}
+	if chat.Types != repository.ChatPublic {
+		return chat, ErrForbidden.WithMessage("chat details are available only for public chats")
+	}
+	return chat, nil
 }
 
-// GetUsers викликає отримання масиву користувачів чатом
-func (c *ChatService) GetUsers(chatId int) ([]models.User, error) {
-	return c.repository.GetUsers(chatId)
+// SetVisibility змінює видимість публічного чату адміністратором userId
+func (c *ChatService) SetVisibility(ctx context.Context, userId, chatId int, visibility string) error {
+	if err := admin(ctx, c.repository, chatId, userId); err != nil {
+		return err
+	}
+	chat, err := c.repository.Get(ctx, chatId)
+	if err != nil {
+		return translate(err, ErrChatNotFound, nil, nil)
+	}
+	if chat.Types != repository.ChatPublic {
+		return ErrForbidden.WithMessage("visibility is available only for public chats")
+	}
+	chat.Visibility = visibility
+	return c.repository.Update(ctx, chat)
 }
 
-// DeleteUser викликає видалення користувача із чату
-func (c *ChatService) DeleteUser(userId, chatId int) error {
-	return c.repository.DeleteUser(userId, chatId)
+// Delete видаляє чат разом з його учасниками, повідомленнями та
+// посиланням на зображення в одній транзакції, якщо userId - адміністратор
+// чату
+func (c *ChatService) Delete(ctx context.Context, userId, chatId int) error {
+	if err := admin(ctx, c.repository, chatId, userId); err != nil {
+		return err
+	}
+	return c.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		return deleteChat(ctx, repos, chatId)
+	})
 }
 
-// GetPrivates отримує два ID користувачів, повертає : при помилці - -1;
-// якщо чат вже існує - його ID; якщо чату немає - 0
-func (c *ChatService) GetPrivates(firstUser, secondUser int) (int, error) {
+// deleteChat видаляє чат та посилання на його зображення. Учасники та
+// повідомлення чату видаляються каскадно
+func deleteChat(ctx context.Context, repos *repository.Repository, chatId int) error {
+	chat, err := repos.Chat.Get(ctx, chatId)
+	if err != nil {
+		return err
+	}
+	if err := repos.Chat.Delete(ctx, chatId); err != nil {
+		return err
+	}
+	if chat.Icon == "" {
+		return nil
+	}
+	return repos.Upload.Release(ctx, chat.Icon)
+}
 
-	// Отримуємо список ПРИВАТНИХ чатів, в яких присутні перший чи другий користувачі
-	first, second, err := c.repository.GetPrivates(firstUser, secondUser)
+// AddUser викликає додання іншого користувача до публічного чату його
+// учасником userId. До чату з заявками та прихованого чату додає лише
+// адміністратор, а до приватного чату нікого додати не можна. Сам
+// користувач вступає до чату через InviteService.JoinChat. Користувача, що
+// заблокував userId або не приймає від нього запрошень, додати не можна
+func (c *ChatService) AddUser(ctx context.Context, userId int, users models.ChatUsers) (int, error) {
+	if users.UserId == userId {
+		return 0, ErrForbidden.WithMessage("join the chat instead of adding yourself")
+	}
+	chat, err := c.repository.Get(ctx, users.ChatId)
 	if err != nil {
-		return -1, err
+		return 0, translate(err, ErrNotFound.WithMessage("chat or user not found"), nil, nil)
+	}
+	if chat.Types != repository.ChatPublic {
+		return 0, ErrForbidden.WithMessage("users cannot be added to private chats")
+	}
+	if chat.Visibility == repository.VisibilityRequest || chat.Visibility == repository.VisibilityHidden {
+		err = admin(ctx, c.repository, users.ChatId, userId)
+	} else {
+		err = c.CheckMember(ctx, userId, users.ChatId)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := blocked(ctx, c.statuses, userId, users.UserId); err != nil {
+		return 0, err
+	}
+	if err := permitted(ctx, c.settings, c.statuses, userId, users.UserId, chatInvites); err != nil {
+		return 0, err
 	}
+	id, err := c.repository.AddUser(ctx, users)
+	return id, translate(err, nil, ErrAlreadyInChat, ErrNotFound.WithMessage("chat or user not found"))
+}
+
+// CheckMember повертає ErrForbidden, якщо userId не є учасником чату
+func (c *ChatService) CheckMember(ctx context.Context, userId, chatId int) error {
+	_, err := c.repository.GetMember(ctx, chatId, userId)
+	return translate(err, ErrForbidden.WithMessage("only chat members can do this"), nil, nil)
+}
 
-	// якщо обидва ID однакові, отже це особистий чат
-	//повертаємо ID особистого чату
-	if firstUser == secondUser {
-		for _, chat := range first {
-			list, err := c.repository.GetUsers(chat.Id)
-			if err != nil {
-				return -1, err
+// GetUsers викликає отримання масиву користувачів чатом
+func (c *ChatService) GetUsers(ctx context.Context, chatId int) ([]models.User, error) {
+	users, err := c.repository.GetUsers(ctx, chatId)
+	return c.icons.users(users), err
+}
+
+// DeleteUser видаляє користувача userId із чату за запитом actorId. Сам
+// користувач може лише вийти з чату, а видалити іншого учасника може лише
+// адміністратор. Якщо в чаті не залишилося користувачів, видаляє і чат.
+// Якщо публічний чат залишив останній адміністратор, ним стає найдавніший
+// учасник. Повертає, чи було видалено чат
+func (c *ChatService) DeleteUser(ctx context.Context, actorId, userId, chatId int) (bool, error) {
+	if actorId != userId {
+		if err := admin(ctx, c.repository, chatId, actorId); err != nil {
+			return false, err
+		}
+	}
+	var deleted bool
+	err := c.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		if err := repos.Chat.DeleteUser(ctx, userId, chatId); err != nil {
+			return err
+		}
+		// Покинутий приватний чат більше не належить парі, тож наступний
+		// PrivateChat створить новий
+		chat, err := repos.Chat.Get(ctx, chatId)
+		if err != nil {
+			return translate(err, ErrChatNotFound, nil, nil)
+		}
+		if chat.Types == repository.ChatPrivate {
+			if err := repos.Chat.DeletePrivate(ctx, chatId); err != nil {
+				return err
 			}
-			if len(list) == 1 {
-				return chat.Id, err
+		}
+		users, err := repos.Chat.GetUsers(ctx, chatId)
+		if err != nil {
+			return err
+		}
+		if len(users) > 0 {
+			if chat.Types != repository.ChatPublic {
+				return nil
 			}
+			return keepAdmin(ctx, repos.Chat, chatId)
 		}
-		return 0, err
+		deleted = true
+		return deleteChat(ctx, repos, chatId)
+	})
+	if err != nil {
+		return false, err
 	}
+	return deleted, nil
+}
 
-	//якщо ID різні, шукаємо спільний чат
-
-	// Збираємо усі ID чатів до єдиного масиву
-	var check []int
-	for i := range first {
-		check = append(check, first[i].Id)
+// keepAdmin призначає адміністратором найдавнішого учасника чату, якщо в
+// чаті не залишилося адміністраторів
+func keepAdmin(ctx context.Context, chats repository.Chat, chatId int) error {
+	members, err := chats.GetMembers(ctx, chatId)
+	if err != nil || len(members) == 0 {
+		return err
 	}
-	for i := range second {
-		check = append(check, second[i].Id)
+	for _, member := range members {
+		if member.Role == repository.RoleAdmin {
+			return nil
+		}
 	}
+	members[0].Role = repository.RoleAdmin
+	return chats.UpdateRole(ctx, members[0])
+}
 
-	// Перевіряємо наявність дублікатів
-	// Необхідний чат згадується двічі
-	result := make(map[int]int)
-	for _, v := range check {
-		result[v]++
+// GetPrivates отримує два ID користувачів, повертає : при помилці - -1;
+// якщо чат вже існує - його ID; якщо чату немає - 0
+func (c *ChatService) GetPrivates(ctx context.Context, firstUser, secondUser int) (int, error) {
+	chatId, err := c.repository.GetPrivate(ctx, firstUser, secondUser)
+	if err != nil {
+		return -1, err
+	}
+	return chatId, nil
+}
+
+// PrivateChat отримує ID двох користувачів ТА повертає ID їх приватного
+// чату. Якщо чату не існує, створює його. Якщо ID однакові, це особистий
+// чат користувача. Якщо userId заблокував creatorId, повертає ErrBlocked,
+// а якщо налаштування userId не дозволяють creatorId створити чат -
+// ErrPrivacy
+func (c *ChatService) PrivateChat(ctx context.Context, creatorId, userId int) (int, error) {
+	if creatorId != userId {
+		if err := blocked(ctx, c.statuses, creatorId, userId); err != nil {
+			return 0, err
+		}
+	}
+	chatId, err := c.GetPrivates(ctx, creatorId, userId)
+	if err != nil || chatId != 0 {
+		return chatId, err
+	}
+	if err := permitted(ctx, c.settings, c.statuses, creatorId, userId, directMessages); err != nil {
+		return 0, err
 	}
-	for k, v := range result {
-		if v == 2 {
-			return k, err
+
+	// Назва приватного чату не зберігається: кожен учасник бачить ім'я
+	// співрозмовника
+	now := models.Now()
+	chat := models.Chat{Types: repository.ChatPrivate, CreatedBy: &creatorId, CreatedAt: &now}
+
+	err = c.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		id, err := repos.Chat.Create(ctx, chat)
+		if err != nil {
+			return err
+		}
+		// Пара користувачів унікальна, тож з двох одночасних запитів чат
+		// створить лише один
+		if err := repos.Chat.AddPrivate(ctx, creatorId, userId, id); err != nil {
+			return err
+		}
+		members := []int{creatorId}
+		if creatorId != userId {
+			members = append(members, userId)
 		}
+		for _, member := range members {
+			if _, err := repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: id, UserId: member}); err != nil {
+				return err
+			}
+		}
+		chatId = id
+		return nil
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return c.GetPrivates(ctx, creatorId, userId)
 	}
-	return 0, nil
+	return chatId, translate(err, nil, nil, ErrUserNotFound)
 }
 
-// GetPrivateChats викликає отримання масиву публічних чатів користувача
-func (c *ChatService) GetPrivateChats(userId int) ([]models.Chat, error) {
-	return c.repository.GetPrivateChats(userId)
+// GetPrivateChats викликає отримання масиву приватних чатів користувача з
+// іншим учасником, названих ім'ям співрозмовника та з його зображенням
+func (c *ChatService) GetPrivateChats(ctx context.Context, userId int) ([]models.Chat, error) {
+	chats, err := c.repository.GetPrivateChats(ctx, userId)
+	return c.icons.chats(chats), err
 }
 
-// GetPublicChats викликає отримання масиву приватних чатів користувача
-func (c *ChatService) GetPublicChats(userId int) ([]models.Chat, error) {
-	return c.repository.GetPublicChats(userId)
+// GetPublicChats викликає отримання масиву публічних чатів користувача,
+// впорядкованих від найновішої активності. Текст останнього повідомлення
+// скорочується до previewLength символів
+func (c *ChatService) GetPublicChats(ctx context.Context, userId int) ([]models.Chat, error) {
+	chats, err := c.repository.GetPublicChats(ctx, userId)
+	for i := range chats {
+		if msg := chats[i].LastMessage; msg != nil {
+			if text := []rune(msg.Text); len(text) > previewLength {
+				msg.Text = string(text[:previewLength]) + "…"
+			}
+		}
+	}
+	return c.icons.chats(chats), err
 }
 
 // SearchChat викликає отримання масиву чатів, назви яких повністю чи
 // частково збігаються з аргументом
-func (c *ChatService) SearchChat(name string) ([]models.Chat, error) {
-	return c.repository.SearchChat(name)
+func (c *ChatService) SearchChat(ctx context.Context, name string) ([]models.Chat, error) {
+	chats, err := c.repository.SearchChat(ctx, name)
+	return c.icons.chats(chats), err
 }
 
 // DeleteAllMessages викликає видалення усіх повідомлень чата за його ID
-func (c *ChatService) DeleteAllMessages(chatId int) error {
-	return c.repository.DeleteAllMessages(chatId)
+func (c *ChatService) DeleteAllMessages(ctx context.Context, chatId int) error {
+	return c.repository.DeleteAllMessages(ctx, chatId)
 }
 
 // GetUserById викликає отримання даних користувача за його ID
-func (c *ChatService) GetUserById(userId int) (models.User, error) {
-	return c.repository.GetUserById(userId)
+func (c *ChatService) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	user, err := c.repository.GetUserById(ctx, userId)
+	return c.icons.user(user), translate(err, ErrUserNotFound, nil, nil)
 }
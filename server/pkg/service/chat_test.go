@@ -0,0 +1,520 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/memory"
+	"cmd/pkg/repository/models"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// transactor викликає fn з тими самими репозиторіями та рахує
+// підтверджені й відкочені транзакції
+type transactor struct {
+	repos      *repository.Repository
+	committed  int
+	rolledBack int
+}
+
+func (t *transactor) Transaction(ctx context.Context, fn func(repos *repository.Repository) error) error {
+	if err := fn(t.repos); err != nil {
+		t.rolledBack++
+		return err
+	}
+	t.committed++
+	return nil
+}
+
+// chatRepo - проста реалізація repository.Chat у пам'яті. Не реалізовані
+// методи викликають panic
+type chatRepo struct {
+	repository.Chat
+	chats   map[int]models.Chat
+	members map[int][]int
+	// roles - ролі учасників за ID чату та користувача
+	roles map[[2]int]string
+	// privates - ID приватних чатів за парою учасників
+	privates map[models.PrivateChat]int
+	// failUser - ID користувача, додавання якого завершується помилкою
+	failUser int
+}
+
+func newChatRepo() *chatRepo {
+	return &chatRepo{chats: map[int]models.Chat{}, members: map[int][]int{}, roles: map[[2]int]string{}, privates: map[models.PrivateChat]int{}}
+}
+
+func (r *chatRepo) Create(ctx context.Context, chat models.Chat) (int, error) {
+	chat.Id = len(r.chats) + 1
+	r.chats[chat.Id] = chat
+	return chat.Id, nil
+}
+
+func (r *chatRepo) Get(ctx context.Context, chatId int) (models.Chat, error) {
+	chat, ok := r.chats[chatId]
+	if !ok {
+		return chat, gorm.ErrRecordNotFound
+	}
+	return chat, nil
+}
+
+func (r *chatRepo) Delete(ctx context.Context, chatId int) error {
+	delete(r.chats, chatId)
+	delete(r.members, chatId)
+	return nil
+}
+
+func (r *chatRepo) AddUser(ctx context.Context, users models.ChatUsers) (int, error) {
+	if users.UserId == r.failUser {
+		return 0, repository.ErrReference
+	}
+	r.members[users.ChatId] = append(r.members[users.ChatId], users.UserId)
+	r.roles[[2]int{users.ChatId, users.UserId}] = users.Role
+	return len(r.members[users.ChatId]), nil
+}
+
+func (r *chatRepo) GetMember(ctx context.Context, chatId, userId int) (models.ChatUsers, error) {
+	for _, id := range r.members[chatId] {
+		if id == userId {
+			return models.ChatUsers{ChatId: chatId, UserId: userId, Role: r.roles[[2]int{chatId, userId}]}, nil
+		}
+	}
+	return models.ChatUsers{}, gorm.ErrRecordNotFound
+}
+
+func (r *chatRepo) GetUsers(ctx context.Context, chatId int) ([]models.User, error) {
+	var users []models.User
+	for _, id := range r.members[chatId] {
+		users = append(users, models.User{Id: id})
+	}
+	return users, nil
+}
+
+func (r *chatRepo) DeleteUser(ctx context.Context, userId, chatId int) error {
+	var rest []int
+	for _, id := range r.members[chatId] {
+		if id != userId {
+			rest = append(rest, id)
+		}
+	}
+	r.members[chatId] = rest
+	return nil
+}
+
+func (r *chatRepo) GetPrivate(ctx context.Context, firstUser, secondUser int) (int, error) {
+	return r.privates[repository.PrivatePair(firstUser, secondUser)], nil
+}
+
+func (r *chatRepo) AddPrivate(ctx context.Context, firstUser, secondUser, chatId int) error {
+	pair := repository.PrivatePair(firstUser, secondUser)
+	if _, ok := r.privates[pair]; ok {
+		return repository.ErrDuplicate
+	}
+	r.privates[pair] = chatId
+	return nil
+}
+
+func (r *chatRepo) DeletePrivate(ctx context.Context, chatId int) error {
+	for pair, id := range r.privates {
+		if id == chatId {
+			delete(r.privates, pair)
+		}
+	}
+	return nil
+}
+
+func (r *chatRepo) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	return models.User{Id: userId, Username: "user"}, nil
+}
+
+// statusRepo - реалізація repository.Status лише з блокуваннями та
+// дружбою: blockers[userId] - ID користувачів, що заблокували userId,
+// friends[userId] - друзі userId
+type statusRepo struct {
+	repository.Status
+	blockers map[int][]int
+	friends  map[int][]int
+}
+
+func (r *statusRepo) GetStatuses(ctx context.Context, userId, otherId int) ([]models.Status, error) {
+	var statuses []models.Status
+	for _, id := range r.friends[userId] {
+		if id == otherId {
+			statuses = append(statuses, models.Status{SenderId: userId, RecipientId: otherId, Relationship: repository.StatusFriends})
+		}
+	}
+	return statuses, nil
+}
+
+func (r *statusRepo) GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error) {
+	var users []models.User
+	for _, id := range r.blockers[userId] {
+		users = append(users, models.User{Id: id})
+	}
+	return users, nil
+}
+
+// settingsRepo - реалізація repository.Settings, що повертає збережені
+// налаштування або налаштування за замовчуванням
+type settingsRepo struct {
+	repository.Settings
+	settings map[int]models.UserSettings
+}
+
+func (r *settingsRepo) Get(ctx context.Context, userId int) (models.UserSettings, error) {
+	if settings, ok := r.settings[userId]; ok {
+		return settings, nil
+	}
+	return repository.DefaultSettings(userId), nil
+}
+
+func newTestChatService() (*ChatService, *chatRepo, *uploadRepo, *transactor) {
+	chats, uploads := newChatRepo(), newUploadRepo()
+	tx := &transactor{repos: &repository.Repository{Chat: chats, Upload: uploads}}
+	return NewChatService(chats, &statusRepo{}, &settingsRepo{}, tx, icons{}), chats, uploads, tx
+}
+
+func TestChatService_Create(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, _, tx := newTestChatService()
+
+	chatId, err := chats.Create(ctx, models.Chat{Name: "test", Types: repository.ChatPublic}, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, repo.members[chatId])
+	assert.Equal(t, 1, tx.committed)
+
+	// Помилка додавання користувача відкочує створення чату
+	repo.failUser = 3
+	_, err = chats.Create(ctx, models.Chat{Name: "test", Types: repository.ChatPublic}, 1, 3)
+	assert.ErrorIs(t, err, repository.ErrReference)
+	assert.Equal(t, 1, tx.rolledBack)
+}
+
+func TestChatService_DeleteUser(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, uploads, _ := newTestChatService()
+	chatId, err := chats.Create(ctx, models.Chat{Name: "test"}, 1, 2)
+	require.NoError(t, err)
+	chat := repo.chats[chatId]
+	chat.Icon = "icon.png"
+	repo.chats[chatId] = chat
+	require.NoError(t, uploads.Acquire(ctx, "icon.png"))
+
+	// Звичайний учасник не може видалити іншого
+	_, err = chats.DeleteUser(ctx, 2, 1, chatId)
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ElementsMatch(t, []int{1, 2}, repo.members[chatId])
+
+	deleted, err := chats.DeleteUser(ctx, 1, 1, chatId)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.Contains(t, repo.chats, chatId)
+
+	// Останній користувач видаляє чат разом з посиланням на зображення
+	deleted, err = chats.DeleteUser(ctx, 2, 2, chatId)
+	require.NoError(t, err)
+	assert.True(t, deleted)
+	assert.NotContains(t, repo.chats, chatId)
+	assert.Equal(t, 0, uploads.uploads["icon.png"].Refs)
+
+	// Адміністратор може видалити іншого учасника
+	chatId, err = chats.Create(ctx, models.Chat{Name: "test"}, 1, 2)
+	require.NoError(t, err)
+	deleted, err = chats.DeleteUser(ctx, 1, 2, chatId)
+	require.NoError(t, err)
+	assert.False(t, deleted)
+	assert.Equal(t, []int{1}, repo.members[chatId])
+}
+
+func TestChatService_Delete(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, _, _ := newTestChatService()
+	chatId, err := chats.Create(ctx, models.Chat{Name: "test"}, 1, 2)
+	require.NoError(t, err)
+
+	// Видалити чат може лише адміністратор
+	assert.ErrorIs(t, chats.Delete(ctx, 2, chatId), ErrForbidden)
+	assert.ErrorIs(t, chats.Delete(ctx, 3, chatId), ErrForbidden)
+	assert.Contains(t, repo.chats, chatId)
+
+	require.NoError(t, chats.Delete(ctx, 1, chatId))
+	assert.NotContains(t, repo.chats, chatId)
+}
+
+func TestChatService_PrivateChat(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, _, _ := newTestChatService()
+
+	chatId, err := chats.PrivateChat(ctx, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, repository.ChatPrivate, repo.chats[chatId].Types)
+	assert.ElementsMatch(t, []int{1, 2}, repo.members[chatId])
+
+	// Існуючий чат не створюється повторно
+	again, err := chats.PrivateChat(ctx, 2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, chatId, again)
+	assert.Len(t, repo.chats, 1)
+
+	// Особистий чат має одного учасника
+	personal, err := chats.PrivateChat(ctx, 1, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, chatId, personal)
+	assert.Equal(t, []int{1}, repo.members[personal])
+}
+
+func TestChatService_Blocked(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, _, _ := newTestChatService()
+	// Користувач 2 заблокував користувача 1
+	chats.statuses = &statusRepo{blockers: map[int][]int{1: {2}}}
+
+	_, err := chats.PrivateChat(ctx, 1, 2)
+	assert.ErrorIs(t, err, ErrBlocked)
+	assert.Empty(t, repo.chats)
+
+	// Той, хто заблокував, може відкрити чат
+	chatId, err := chats.PrivateChat(ctx, 2, 1)
+	require.NoError(t, err)
+
+	publicId, err := chats.Create(ctx, models.Chat{Name: "test", Types: repository.ChatPublic}, 1)
+	require.NoError(t, err)
+	_, err = chats.AddUser(ctx, 1, models.ChatUsers{ChatId: publicId, UserId: 2})
+	assert.ErrorIs(t, err, ErrBlocked)
+	assert.Equal(t, []int{1}, repo.members[publicId])
+
+	repo.members[publicId] = append(repo.members[publicId], 3)
+	_, err = chats.AddUser(ctx, 3, models.ChatUsers{ChatId: publicId, UserId: 2})
+	require.NoError(t, err)
+	_, err = chats.AddUser(ctx, 2, models.ChatUsers{ChatId: chatId, UserId: 3})
+	assert.ErrorIs(t, err, ErrForbidden, "private chats have no room for a third member")
+}
+
+func TestChatService_AddUser(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, _, _ := newTestChatService()
+	const admin, member, stranger, guest = 1, 2, 3, 4
+	openId, err := chats.Create(ctx, models.Chat{Name: "open", Types: repository.ChatPublic, Visibility: repository.VisibilityOpen}, admin, member)
+	require.NoError(t, err)
+	requestId, err := chats.Create(ctx, models.Chat{Name: "request", Types: repository.ChatPublic, Visibility: repository.VisibilityRequest}, admin, member)
+	require.NoError(t, err)
+	hiddenId, err := chats.Create(ctx, models.Chat{Name: "hidden", Types: repository.ChatPublic, Visibility: repository.VisibilityHidden}, admin, member)
+	require.NoError(t, err)
+	privateId, err := chats.PrivateChat(ctx, admin, member)
+	require.NoError(t, err)
+
+	// Додавати можуть лише учасники, а сам користувач вступає через JoinChat
+	_, err = chats.AddUser(ctx, stranger, models.ChatUsers{ChatId: openId, UserId: guest})
+	assert.ErrorIs(t, err, ErrForbidden)
+	for _, chatId := range []int{openId, requestId, hiddenId, privateId} {
+		_, err = chats.AddUser(ctx, stranger, models.ChatUsers{ChatId: chatId, UserId: stranger})
+		assert.ErrorIs(t, err, ErrForbidden)
+	}
+	_, err = chats.AddUser(ctx, member, models.ChatUsers{ChatId: openId, UserId: guest})
+	require.NoError(t, err)
+
+	// До чату з заявками та прихованого додає лише адміністратор
+	for _, chatId := range []int{requestId, hiddenId} {
+		_, err = chats.AddUser(ctx, member, models.ChatUsers{ChatId: chatId, UserId: guest})
+		assert.ErrorIs(t, err, ErrForbidden)
+		_, err = chats.AddUser(ctx, admin, models.ChatUsers{ChatId: chatId, UserId: guest})
+		require.NoError(t, err)
+	}
+
+	// До приватного чату не додати навіть його учаснику
+	_, err = chats.AddUser(ctx, admin, models.ChatUsers{ChatId: privateId, UserId: guest})
+	assert.ErrorIs(t, err, ErrForbidden)
+	assert.ElementsMatch(t, []int{admin, member}, repo.members[privateId])
+}
+
+func TestChatService_Privacy(t *testing.T) {
+	ctx := context.Background()
+	chats, repo, _, _ := newTestChatService()
+	// Користувач 2 приймає повідомлення лише від друзів, а запрошення до
+	// чатів - від усіх; користувач 3 друг користувача 2
+	chats.statuses = &statusRepo{friends: map[int][]int{3: {2}}}
+	chats.settings = &settingsRepo{settings: map[int]models.UserSettings{
+		2: {UserId: 2, DirectMessages: repository.AudienceFriends, ChatInvites: repository.AudienceFriends},
+	}}
+
+	_, err := chats.PrivateChat(ctx, 1, 2)
+	assert.ErrorIs(t, err, ErrPrivacy)
+	assert.Empty(t, repo.chats)
+
+	chatId, err := chats.PrivateChat(ctx, 3, 2)
+	require.NoError(t, err)
+	// Власник налаштувань може відкрити чат з ким завгодно
+	_, err = chats.PrivateChat(ctx, 2, 1)
+	require.NoError(t, err)
+
+	publicId, err := chats.Create(ctx, models.Chat{Name: "test", Types: repository.ChatPublic}, 1, 3)
+	require.NoError(t, err)
+	_, err = chats.AddUser(ctx, 1, models.ChatUsers{ChatId: publicId, UserId: 2})
+	assert.ErrorIs(t, err, ErrPrivacy)
+	_, err = chats.AddUser(ctx, 3, models.ChatUsers{ChatId: publicId, UserId: 2})
+	require.NoError(t, err)
+	_, err = chats.AddUser(ctx, 1, models.ChatUsers{ChatId: chatId + 100, UserId: 2})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestChatService_PrivateChatConcurrent(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"first", "second"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+
+	// Одночасні запити з обох сторін отримують той самий чат
+	const requests = 16
+	ids := make([]int, requests)
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			first, second := 1, 2
+			if i%2 == 1 {
+				first, second = second, first
+			}
+			var err error
+			ids[i], err = chats.PrivateChat(ctx, first, second)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+	for _, id := range ids {
+		assert.Equal(t, ids[0], id)
+	}
+	privates, err := repos.Chat.GetPrivateChats(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, privates, 1)
+
+	// Чат, який залишив учасник, більше не належить парі
+	_, err = chats.DeleteUser(ctx, 1, 1, ids[0])
+	require.NoError(t, err)
+	chatId, err := chats.PrivateChat(ctx, 2, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, ids[0], chatId)
+}
+
+func TestChatService_GetForUser(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"first", "second"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	chatId, err := chats.PrivateChat(ctx, 1, 2)
+	require.NoError(t, err)
+	personalId, err := chats.PrivateChat(ctx, 1, 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name             string
+		viewerId, chatId int
+		title            string
+		partnerId        int
+	}{
+		{name: "creator sees the partner", viewerId: 1, chatId: chatId, title: "second", partnerId: 2},
+		{name: "partner sees the creator", viewerId: 2, chatId: chatId, title: "first", partnerId: 1},
+		{name: "personal chat", viewerId: 1, chatId: personalId, title: "first", partnerId: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, partner, err := chats.GetForUser(ctx, tt.viewerId, tt.chatId)
+			require.NoError(t, err)
+			assert.Equal(t, tt.title, chat.Name)
+			assert.Equal(t, tt.partnerId, partner.Id)
+		})
+	}
+
+	// Назва співрозмовника не зберігається в чаті
+	stored, err := repos.Chat.Get(ctx, chatId)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Name)
+
+	_, _, err = chats.GetForUser(ctx, 1, chatId+100)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+}
+
+func TestChatService_UpdateInfo(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"owner", "member"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const owner, member = 1, 2
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	chatId, err := chats.Create(ctx, models.Chat{Name: "public", Types: repository.ChatPublic}, owner, member)
+	require.NoError(t, err)
+	privateId, err := chats.PrivateChat(ctx, owner, member)
+	require.NoError(t, err)
+
+	// Творець чату стає його автором
+	chat, err := chats.Get(ctx, chatId)
+	require.NoError(t, err)
+	require.NotNil(t, chat.CreatedBy)
+	assert.Equal(t, owner, *chat.CreatedBy)
+	assert.NotNil(t, chat.CreatedAt)
+	assert.Equal(t, 2, chat.MemberCount)
+
+	// Опис чату змінює лише адміністратор публічного чату
+	info := models.Chat{Id: chatId, Name: "renamed", Description: "about", Topic: "news"}
+	_, err = chats.UpdateInfo(ctx, member, info)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = chats.UpdateInfo(ctx, owner, models.Chat{Id: privateId, Name: "private"})
+	assert.ErrorIs(t, err, ErrForbidden)
+	updated, err := chats.UpdateInfo(ctx, owner, info)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", updated.Name)
+	assert.Equal(t, repository.VisibilityOpen, updated.Visibility)
+	chat, err = chats.Get(ctx, chatId)
+	require.NoError(t, err)
+	assert.Equal(t, "about", chat.Description)
+	assert.Equal(t, "news", chat.Topic)
+
+	// Дані для зміни зображення отримує лише адміністратор публічного чату
+	_, err = chats.GetForAdmin(ctx, member, chatId)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = chats.GetForAdmin(ctx, owner, privateId)
+	assert.ErrorIs(t, err, ErrForbidden)
+	chat, err = chats.GetForAdmin(ctx, owner, chatId)
+	require.NoError(t, err)
+	assert.Equal(t, "renamed", chat.Name)
+
+	// Список чатів показує скорочене останнє повідомлення
+	_, err = repos.Message.Create(ctx, models.Message{ChatId: chatId, Author: member, Text: strings.Repeat("я", previewLength+10), SentAt: time.Now()})
+	require.NoError(t, err)
+	list, err := chats.GetPublicChats(ctx, owner)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.NotNil(t, list[0].LastMessage)
+	assert.Equal(t, strings.Repeat("я", previewLength)+"…", list[0].LastMessage.Text)
+}
+
+func TestChatService_CheckMember(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"member", "stranger"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const member, stranger = 1, 2
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	chatId, err := chats.Create(ctx, models.Chat{Name: "room", Types: repository.ChatPublic}, member)
+	require.NoError(t, err)
+
+	assert.NoError(t, chats.CheckMember(ctx, member, chatId))
+	assert.ErrorIs(t, chats.CheckMember(ctx, stranger, chatId), ErrForbidden)
+	assert.ErrorIs(t, chats.CheckMember(ctx, member, chatId+1), ErrForbidden)
+}
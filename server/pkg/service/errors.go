@@ -0,0 +1,141 @@
+package service
+
+import (
+	"cmd/pkg/imaging"
+	"cmd/pkg/repository"
+	"cmd/pkg/storage"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// Kind - вид помилки предметної області, за яким обирається код відповіді
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindTooLarge
+	KindUnsupported
+)
+
+// Error - помилка предметної області. Code - машинозчитуваний код помилки,
+// Message - опис для користувача, Err - початкова помилка
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Err     error
+}
+
+func NewError(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is вважає помилки однаковими, якщо збігаються їх коди
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap повертає копію помилки з початковою помилкою err
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.Err = err
+	return &wrapped
+}
+
+// WithMessage повертає копію помилки з іншим описом
+func (e *Error) WithMessage(message string) *Error {
+	changed := *e
+	changed.Message = message
+	return &changed
+}
+
+var (
+	ErrInvalidRequest  = NewError(KindInvalid, "invalid_request", "incorrect request data")
+	ErrInvalidParam    = NewError(KindInvalid, "invalid_param", "incorrect path parameter")
+	ErrUnauthenticated = NewError(KindUnauthorized, "unauthenticated", "empty auth header")
+	ErrInvalidToken    = NewError(KindUnauthorized, "invalid_token", "token old or wrong")
+	ErrUnknownUser     = NewError(KindUnauthorized, "unknown_user", "user not found")
+	ErrWrongPassword   = NewError(KindUnauthorized, "wrong_password", "incorrect password")
+	ErrForbidden       = NewError(KindForbidden, "forbidden", "access denied")
+	ErrNotFound        = NewError(KindNotFound, "not_found", "not found")
+	ErrUserNotFound    = NewError(KindNotFound, "user_not_found", "user not found")
+	ErrChatNotFound    = NewError(KindNotFound, "chat_not_found", "chat not found")
+	ErrMessageNotFound = NewError(KindNotFound, "message_not_found", "message not found")
+	ErrImageNotFound   = NewError(KindNotFound, "image_not_found", "image not found")
+	ErrUsernameTaken   = NewError(KindConflict, "username_taken", "username is already used")
+	ErrAlreadyInChat   = NewError(KindConflict, "already_in_chat", "user is already in chat")
+	ErrStatusExists    = NewError(KindConflict, "status_exists", "status already exists")
+	ErrImageTooLarge   = NewError(KindTooLarge, "image_too_large", "image is too large")
+	ErrUnsupportedType = NewError(KindUnsupported, "unsupported_type", "incorrect file type error")
+	ErrCorruptImage    = NewError(KindInvalid, "corrupt_image", "incorrect image error")
+
+	// ErrInvalidTransition - зміна відносин, недопустима в їх поточному стані
+	ErrInvalidTransition = NewError(KindConflict, "invalid_transition", "relationship change is not allowed")
+	ErrBlocked           = NewError(KindForbidden, "blocked", "user has blocked you")
+	// ErrPrivacy - дію заборонено налаштуваннями приватності користувача
+	ErrPrivacy = NewError(KindForbidden, "privacy_restricted", "user's privacy settings do not allow this")
+
+	ErrInviteNotFound = NewError(KindNotFound, "invite_not_found", "invite link not found")
+	// ErrInviteInvalid - посилання-запрошення не існує, відкликане,
+	// прострочене або вичерпане
+	ErrInviteInvalid = NewError(KindNotFound, "invite_invalid", "invite link is invalid or expired")
+	ErrJoinNotFound  = NewError(KindNotFound, "join_not_found", "join request not found")
+	ErrJoinPending   = NewError(KindConflict, "join_pending", "join request is already pending")
+	ErrJoinResolved  = NewError(KindConflict, "join_resolved", "join request is already resolved")
+)
+
+// Internal повертає err, якщо це помилка предметної області, інакше -
+// внутрішню помилку з описом message
+func Internal(err error, message string) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Kind: KindInternal, Code: "internal", Message: message, Err: err}
+}
+
+// translate замінює помилки репозиторіїв, сховища та обробки зображень
+// помилками предметної області. Для відсутнього запису використовується
+// notFound, для порушення унікальності - duplicate, для посилання на
+// відсутній запис - reference. Порожні аргументи залишають помилку як є
+func translate(err error, notFound, duplicate, reference *Error) error {
+	var target *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, storage.ErrNotFound):
+		target = notFound
+	case errors.Is(err, repository.ErrDuplicate):
+		target = duplicate
+	case errors.Is(err, repository.ErrReference):
+		target = reference
+	case errors.Is(err, imaging.ErrUnsupportedFormat):
+		target = ErrUnsupportedType
+	case errors.Is(err, imaging.ErrTooLarge):
+		target = ErrImageTooLarge
+	case errors.Is(err, imaging.ErrCorrupt):
+		target = ErrCorruptImage
+	}
+	if target == nil {
+		return err
+	}
+	return target.Wrap(err)
+}
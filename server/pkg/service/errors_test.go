@@ -0,0 +1,52 @@
+package service
+
+import (
+	"cmd/pkg/imaging"
+	"cmd/pkg/repository"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestError_Is(t *testing.T) {
+	err := fmt.Errorf("add user: %w", ErrNotFound.WithMessage("chat or user not found").Wrap(repository.ErrReference))
+
+	assert.ErrorIs(t, err, ErrNotFound, "matched by code, not by message")
+	assert.ErrorIs(t, err, repository.ErrReference, "cause is kept")
+	assert.NotErrorIs(t, err, ErrUserNotFound)
+	assert.Equal(t, "chat or user not found: "+repository.ErrReference.Error(), errors.Unwrap(err).Error())
+	assert.Equal(t, "not found", ErrNotFound.Message, "sentinel is not changed")
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{name: "Nil", err: nil, want: nil},
+		{name: "Not found", err: gorm.ErrRecordNotFound, want: ErrChatNotFound},
+		{name: "Duplicate", err: fmt.Errorf("insert: %w", repository.ErrDuplicate), want: ErrAlreadyInChat},
+		{name: "Reference without target", err: repository.ErrReference, want: repository.ErrReference},
+		{name: "Image", err: imaging.ErrTooLarge, want: ErrImageTooLarge},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := translate(test.err, ErrChatNotFound, ErrAlreadyInChat, nil)
+			if test.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, test.want)
+		})
+	}
+
+	unknown := errors.New("connection refused")
+	assert.Equal(t, unknown, translate(unknown, ErrChatNotFound, nil, nil))
+	assert.ErrorIs(t, Internal(unknown, "create chat error"), unknown)
+	assert.Equal(t, ErrChatNotFound, Internal(ErrChatNotFound, "create chat error"))
+}
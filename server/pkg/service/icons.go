@@ -0,0 +1,44 @@
+package service
+
+import "cmd/pkg/repository/models"
+
+// icons додає до користувачів та чатів посилання на квадратні копії
+// їх зображень, щоб клієнт міг обрати потрібний розмір
+type icons struct {
+	sizes []int
+}
+
+func (i icons) urls(icon string) map[int]string {
+	if icon == "" || len(i.sizes) == 0 {
+		return nil
+	}
+	urls := make(map[int]string, len(i.sizes))
+	for _, size := range i.sizes {
+		urls[size] = ImagePath + RenditionName(icon, size)
+	}
+	return urls
+}
+
+func (i icons) user(user models.User) models.User {
+	user.Icons = i.urls(user.Icon)
+	return user
+}
+
+func (i icons) users(users []models.User) []models.User {
+	for k := range users {
+		users[k] = i.user(users[k])
+	}
+	return users
+}
+
+func (i icons) chat(chat models.Chat) models.Chat {
+	chat.Icons = i.urls(chat.Icon)
+	return chat
+}
+
+func (i icons) chats(chats []models.Chat) []models.Chat {
+	for k := range chats {
+		chats[k] = i.chat(chats[k])
+	}
+	return chats
+}
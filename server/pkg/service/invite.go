@@ -0,0 +1,291 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tokenSize - кількість випадкових байтів у токені посилання-запрошення
+const tokenSize = 16
+
+type InviteService struct {
+	chats      repository.Chat
+	invites    repository.Invite
+	joins      repository.Join
+	statuses   repository.Status
+	transactor repository.Transactor
+}
+
+func NewInviteService(chats repository.Chat, invites repository.Invite, joins repository.Join, statuses repository.Status, transactor repository.Transactor) *InviteService {
+	return &InviteService{chats: chats, invites: invites, joins: joins, statuses: statuses, transactor: transactor}
+}
+
+// CreateInvite створює посилання-запрошення до публічного чату від його
+// учасника userId ТА повертає посилання з токеном
+func (i *InviteService) CreateInvite(ctx context.Context, userId int, invite models.ChatInvite) (models.ChatInvite, error) {
+	chat, err := i.chats.Get(ctx, invite.ChatId)
+	if err != nil {
+		return invite, translate(err, ErrChatNotFound, nil, nil)
+	}
+	if chat.Types != repository.ChatPublic {
+		return invite, ErrForbidden.WithMessage("invite links are available only for public chats")
+	}
+	if _, err := i.chats.GetMember(ctx, invite.ChatId, userId); err != nil {
+		return invite, translate(err, ErrForbidden.WithMessage("only chat members can create invite links"), nil, nil)
+	}
+	if invite.Token, err = newToken(); err != nil {
+		return invite, err
+	}
+	invite.CreatedBy, invite.CreatedAt, invite.Uses, invite.RevokedAt = userId, time.Now(), 0, nil
+	invite.Id, err = i.invites.Create(ctx, invite)
+	return invite, err
+}
+
+// GetInvites повертає посилання-запрошення чату, якщо userId - його
+// адміністратор
+func (i *InviteService) GetInvites(ctx context.Context, userId, chatId int) ([]models.ChatInvite, error) {
+	if err := admin(ctx, i.chats, chatId, userId); err != nil {
+		return nil, err
+	}
+	return i.invites.GetByChat(ctx, chatId)
+}
+
+// RevokeInvite відкликає посилання-запрошення чату. Відкликати посилання
+// може адміністратор чату або автор посилання
+func (i *InviteService) RevokeInvite(ctx context.Context, userId, chatId, inviteId int) error {
+	invite, err := i.invites.Get(ctx, inviteId)
+	if err != nil {
+		return translate(err, ErrInviteNotFound, nil, nil)
+	}
+	if invite.ChatId != chatId {
+		return ErrInviteNotFound
+	}
+	if invite.CreatedBy != userId {
+		if err := admin(ctx, i.chats, chatId, userId); err != nil {
+			return err
+		}
+	}
+	return i.invites.Revoke(ctx, inviteId, time.Now())
+}
+
+// Join використовує посилання-запрошення з токеном token для вступу userId
+// до чату ТА повертає запис вступу. Якщо посилання потребує схвалення,
+// вступ чекає рішення адміністратора зі статусом pending, а використання
+// посилання зараховується лише після схвалення. Відкликане, прострочене
+// чи вичерпане посилання повертає ErrInviteInvalid, а вступ користувача,
+// якого заблокував адміністратор чату, - ErrBlocked
+func (i *InviteService) Join(ctx context.Context, userId int, token string) (models.ChatJoin, error) {
+	var join models.ChatJoin
+	err := i.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		invite, err := repos.Invite.GetByToken(ctx, token)
+		if err != nil {
+			return translate(err, ErrInviteInvalid, nil, nil)
+		}
+		if err := i.blockedByAdmins(ctx, repos.Chat, invite.ChatId, userId); err != nil {
+			return err
+		}
+		_, err = repos.Chat.GetMember(ctx, invite.ChatId, userId)
+		switch {
+		case err == nil:
+			return ErrAlreadyInChat
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		now := time.Now()
+		join = models.ChatJoin{ChatId: invite.ChatId, UserId: userId, InviteId: &invite.Id, Status: repository.JoinPending, CreatedAt: now}
+		if invite.Approval {
+			// Заявка лише перевіряє посилання: використання зараховує
+			// схвалення, тож відхилені заявки не вичерпують посилання
+			if !usable(invite, now) {
+				return ErrInviteInvalid
+			}
+		} else {
+			if err := use(ctx, repos.Invite, invite.Id, now); err != nil {
+				return err
+			}
+			join.Status = repository.JoinJoined
+			if _, err := repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: invite.ChatId, UserId: userId}); err != nil {
+				return translate(err, nil, ErrAlreadyInChat, ErrUserNotFound)
+			}
+		}
+		join.Id, err = repos.Join.Create(ctx, join)
+		return translate(err, nil, ErrJoinPending, nil)
+	})
+	return join, err
+}
+
+// JoinChat приєднує userId до публічного чату без посилання ТА повертає
+// запис вступу. До відкритого чату користувач вступає одразу, до чату з
+// заявками - після схвалення адміністратора. Приватні та приховані чати
+// повертають ErrChatNotFound, а вступ користувача, якого заблокував
+// адміністратор чату, - ErrBlocked
+func (i *InviteService) JoinChat(ctx context.Context, userId, chatId int) (models.ChatJoin, error) {
+	var join models.ChatJoin
+	err := i.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		chat, err := repos.Chat.Get(ctx, chatId)
+		if err != nil {
+			return translate(err, ErrChatNotFound, nil, nil)
+		}
+		if chat.Types != repository.ChatPublic || chat.Visibility == repository.VisibilityHidden {
+			return ErrChatNotFound
+		}
+		if err := i.blockedByAdmins(ctx, repos.Chat, chatId, userId); err != nil {
+			return err
+		}
+		_, err = repos.Chat.GetMember(ctx, chatId, userId)
+		switch {
+		case err == nil:
+			return ErrAlreadyInChat
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		join = models.ChatJoin{ChatId: chatId, UserId: userId, Status: repository.JoinPending, CreatedAt: time.Now()}
+		if chat.Visibility != repository.VisibilityRequest {
+			join.Status = repository.JoinJoined
+			if _, err := repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: userId}); err != nil {
+				return translate(err, nil, ErrAlreadyInChat, ErrUserNotFound)
+			}
+		}
+		join.Id, err = repos.Join.Create(ctx, join)
+		return translate(err, nil, ErrJoinPending, nil)
+	})
+	return join, err
+}
+
+// GetAdmins повертає ID адміністраторів чату
+func (i *InviteService) GetAdmins(ctx context.Context, chatId int) ([]int, error) {
+	return chatAdmins(ctx, i.chats, chatId)
+}
+
+// blockedByAdmins повертає ErrBlocked, якщо userId заблокував хтось з
+// адміністраторів чату
+func (i *InviteService) blockedByAdmins(ctx context.Context, chats repository.Chat, chatId, userId int) error {
+	admins, err := chatAdmins(ctx, chats, chatId)
+	if err != nil {
+		return err
+	}
+	return blocked(ctx, i.statuses, userId, admins...)
+}
+
+// GetJoins повертає вступи до чату зі статусом status, якщо userId -
+// адміністратор чату
+func (i *InviteService) GetJoins(ctx context.Context, userId, chatId int, status string) ([]models.ChatJoin, error) {
+	if err := admin(ctx, i.chats, chatId, userId); err != nil {
+		return nil, err
+	}
+	return i.joins.GetByChat(ctx, chatId, status)
+}
+
+// ApproveJoin схвалює заявку на вступ до чату адміністратором userId та
+// додає її автора до чату ТА повертає оновлений запис вступу
+func (i *InviteService) ApproveJoin(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error) {
+	return i.resolve(ctx, userId, chatId, joinId, repository.JoinJoined)
+}
+
+// RejectJoin відхиляє заявку на вступ до чату адміністратором userId ТА
+// повертає оновлений запис вступу
+func (i *InviteService) RejectJoin(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error) {
+	return i.resolve(ctx, userId, chatId, joinId, repository.JoinRejected)
+}
+
+// resolve змінює статус заявки pending на status. Схвалена заявка додає
+// її автора до чату та зараховує використання посилання, за яким її
+// подано. Строк дії посилання перевіряється на час подання заявки, тож
+// схвалити не можна лише заявку за відкликаним чи вичерпаним посиланням
+func (i *InviteService) resolve(ctx context.Context, userId, chatId, joinId int, status string) (models.ChatJoin, error) {
+	var join models.ChatJoin
+	if err := admin(ctx, i.chats, chatId, userId); err != nil {
+		return join, err
+	}
+	err := i.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		var err error
+		join, err = repos.Join.Get(ctx, joinId)
+		if err != nil {
+			return translate(err, ErrJoinNotFound, nil, nil)
+		}
+		if join.ChatId != chatId {
+			return ErrJoinNotFound
+		}
+		if join.Status != repository.JoinPending {
+			return ErrJoinResolved
+		}
+		// Автора заявки могли додати до чату іншим шляхом, тоді заявка
+		// просто позначається виконаною
+		if status == repository.JoinJoined {
+			if join.InviteId != nil {
+				if err := use(ctx, repos.Invite, *join.InviteId, join.CreatedAt); err != nil {
+					return err
+				}
+			}
+			_, err := repos.Chat.AddUser(ctx, models.ChatUsers{ChatId: chatId, UserId: join.UserId})
+			if err != nil && !errors.Is(err, repository.ErrDuplicate) {
+				return translate(err, nil, nil, ErrUserNotFound)
+			}
+		}
+		join.Status = status
+		return repos.Join.UpdateStatus(ctx, join)
+	})
+	return join, err
+}
+
+// admin повертає ErrForbidden, якщо userId не є адміністратором чату
+func admin(ctx context.Context, chats repository.Chat, chatId, userId int) error {
+	member, err := chats.GetMember(ctx, chatId, userId)
+	if err == nil && member.Role != repository.RoleAdmin {
+		err = gorm.ErrRecordNotFound
+	}
+	return translate(err, ErrForbidden.WithMessage("only chat admins can do this"), nil, nil)
+}
+
+// chatAdmins повертає ID адміністраторів чату
+func chatAdmins(ctx context.Context, chats repository.Chat, chatId int) ([]int, error) {
+	members, err := chats.GetMembers(ctx, chatId)
+	if err != nil {
+		return nil, err
+	}
+	var admins []int
+	for _, member := range members {
+		if member.Role == repository.RoleAdmin {
+			admins = append(admins, member.UserId)
+		}
+	}
+	return admins, nil
+}
+
+// usable повертає, чи дійсне посилання на час at, не змінюючи лічильник
+// його використань
+func usable(invite models.ChatInvite, at time.Time) bool {
+	return invite.RevokedAt == nil &&
+		(invite.ExpiresAt == nil || invite.ExpiresAt.After(at)) &&
+		(invite.MaxUses == 0 || invite.Uses < invite.MaxUses)
+}
+
+// use зараховує використання посилання, дійсного на час at, або повертає
+// ErrInviteInvalid. Умови посилання перевіряються та лічильник
+// збільшується одним запитом, тож одночасні вступи не перевищать MaxUses
+func use(ctx context.Context, invites repository.Invite, inviteId int, at time.Time) error {
+	used, err := invites.Use(ctx, inviteId, at)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInviteInvalid
+	}
+	return nil
+}
+
+// newToken повертає випадковий токен посилання-запрошення, придатний для URL
+func newToken() (string, error) {
+	buf := make([]byte, tokenSize)
+	if _, err := rand.Read(buf); err != nil {
+		return "", Internal(err, "generate invite token")
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/memory"
+	"cmd/pkg/repository/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInviteService(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"owner", "member", "guest", "other"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const owner, member, guest, other = 1, 2, 3, 4
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	invites := NewInviteService(repos.Chat, repos.Invite, repos.Join, repos.Status, repos.Transactor)
+
+	chatId, err := chats.Create(ctx, models.Chat{Name: "public", Types: repository.ChatPublic}, owner, member)
+	require.NoError(t, err)
+	privateId, err := chats.PrivateChat(ctx, owner, member)
+	require.NoError(t, err)
+
+	// Посилання створюють лише учасники публічних чатів
+	_, err = invites.CreateInvite(ctx, owner, models.ChatInvite{ChatId: privateId})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = invites.CreateInvite(ctx, guest, models.ChatInvite{ChatId: chatId})
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = invites.CreateInvite(ctx, owner, models.ChatInvite{ChatId: 100})
+	assert.ErrorIs(t, err, ErrChatNotFound)
+
+	invite, err := invites.CreateInvite(ctx, member, models.ChatInvite{ChatId: chatId, MaxUses: 1})
+	require.NoError(t, err)
+	assert.NotEmpty(t, invite.Token)
+	assert.Equal(t, member, invite.CreatedBy)
+
+	// Список посилань доступний лише адміністратору
+	_, err = invites.GetInvites(ctx, member, chatId)
+	assert.ErrorIs(t, err, ErrForbidden)
+	list, err := invites.GetInvites(ctx, owner, chatId)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	join, err := invites.Join(ctx, guest, invite.Token)
+	require.NoError(t, err)
+	assert.Equal(t, repository.JoinJoined, join.Status)
+	joined, err := repos.Chat.GetMember(ctx, chatId, guest)
+	require.NoError(t, err)
+	assert.Equal(t, repository.RoleMember, joined.Role)
+
+	_, err = invites.Join(ctx, guest, invite.Token)
+	assert.ErrorIs(t, err, ErrAlreadyInChat)
+	_, err = invites.Join(ctx, other, invite.Token)
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+	_, err = invites.Join(ctx, other, "unknown")
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+
+	// Автор відкликає своє посилання без прав адміністратора
+	unlimited, err := invites.CreateInvite(ctx, member, models.ChatInvite{ChatId: chatId})
+	require.NoError(t, err)
+	assert.ErrorIs(t, invites.RevokeInvite(ctx, guest, chatId, unlimited.Id), ErrForbidden)
+	assert.ErrorIs(t, invites.RevokeInvite(ctx, member, privateId, unlimited.Id), ErrInviteNotFound)
+	require.NoError(t, invites.RevokeInvite(ctx, member, chatId, unlimited.Id))
+	_, err = invites.Join(ctx, other, unlimited.Token)
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+
+	// Вступ за посиланням зі схваленням чекає рішення адміністратора
+	approval, err := invites.CreateInvite(ctx, owner, models.ChatInvite{ChatId: chatId, Approval: true})
+	require.NoError(t, err)
+	join, err = invites.Join(ctx, other, approval.Token)
+	require.NoError(t, err)
+	assert.Equal(t, repository.JoinPending, join.Status)
+	_, err = invites.Join(ctx, other, approval.Token)
+	assert.ErrorIs(t, err, ErrJoinPending)
+	_, err = repos.Chat.GetMember(ctx, chatId, other)
+	assert.Error(t, err)
+
+	_, err = invites.GetJoins(ctx, member, chatId, repository.JoinPending)
+	assert.ErrorIs(t, err, ErrForbidden)
+	pending, err := invites.GetJoins(ctx, owner, chatId, repository.JoinPending)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, other, pending[0].UserId)
+
+	_, err = invites.ApproveJoin(ctx, member, chatId, join.Id)
+	assert.ErrorIs(t, err, ErrForbidden)
+	approved, err := invites.ApproveJoin(ctx, owner, chatId, join.Id)
+	require.NoError(t, err)
+	assert.Equal(t, repository.JoinJoined, approved.Status)
+	_, err = repos.Chat.GetMember(ctx, chatId, other)
+	assert.NoError(t, err)
+	_, err = invites.RejectJoin(ctx, owner, chatId, join.Id)
+	assert.ErrorIs(t, err, ErrJoinResolved)
+	_, err = invites.RejectJoin(ctx, owner, chatId, 100)
+	assert.ErrorIs(t, err, ErrJoinNotFound)
+
+	// Коли чат залишає останній адміністратор, ним стає найдавніший учасник
+	_, err = chats.DeleteUser(ctx, owner, owner, chatId)
+	require.NoError(t, err)
+	promoted, err := repos.Chat.GetMember(ctx, chatId, member)
+	require.NoError(t, err)
+	assert.Equal(t, repository.RoleAdmin, promoted.Role)
+}
+
+func TestInviteService_JoinChat(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"owner", "member", "guest"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const owner, member, guest = 1, 2, 3
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	invites := NewInviteService(repos.Chat, repos.Invite, repos.Join, repos.Status, repos.Transactor)
+
+	chatId, err := chats.Create(ctx, models.Chat{Name: "public", Types: repository.ChatPublic}, owner)
+	require.NoError(t, err)
+	privateId, err := chats.PrivateChat(ctx, owner, member)
+	require.NoError(t, err)
+
+	// До відкритого чату користувач вступає одразу
+	join, err := invites.JoinChat(ctx, member, chatId)
+	require.NoError(t, err)
+	assert.Equal(t, repository.JoinJoined, join.Status)
+	assert.Nil(t, join.InviteId)
+	_, err = invites.JoinChat(ctx, member, chatId)
+	assert.ErrorIs(t, err, ErrAlreadyInChat)
+	_, err = invites.JoinChat(ctx, guest, privateId)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+
+	// Видимість змінює лише адміністратор
+	assert.ErrorIs(t, chats.SetVisibility(ctx, member, chatId, repository.VisibilityRequest), ErrForbidden)
+	require.NoError(t, chats.SetVisibility(ctx, owner, chatId, repository.VisibilityRequest))
+	admins, err := invites.GetAdmins(ctx, chatId)
+	require.NoError(t, err)
+	assert.Equal(t, []int{owner}, admins)
+
+	// До чату з заявками вступ чекає схвалення
+	join, err = invites.JoinChat(ctx, guest, chatId)
+	require.NoError(t, err)
+	assert.Equal(t, repository.JoinPending, join.Status)
+	_, err = invites.JoinChat(ctx, guest, chatId)
+	assert.ErrorIs(t, err, ErrJoinPending)
+	_, err = invites.RejectJoin(ctx, owner, chatId, join.Id)
+	require.NoError(t, err)
+
+	// Прихований чат не знаходить пошук і до нього не можна вступити без посилання
+	require.NoError(t, chats.SetVisibility(ctx, owner, chatId, repository.VisibilityHidden))
+	_, err = invites.JoinChat(ctx, guest, chatId)
+	assert.ErrorIs(t, err, ErrChatNotFound)
+	found, err := chats.SearchChat(ctx, "pub")
+	require.NoError(t, err)
+	assert.Empty(t, found)
+}
+
+func TestInviteService_ApprovalUses(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"owner", "first", "second", "third"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const owner, first, second, third = 1, 2, 3, 4
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	invites := NewInviteService(repos.Chat, repos.Invite, repos.Join, repos.Status, repos.Transactor)
+	chatId, err := chats.Create(ctx, models.Chat{Name: "public", Types: repository.ChatPublic}, owner)
+	require.NoError(t, err)
+	invite, err := invites.CreateInvite(ctx, owner, models.ChatInvite{ChatId: chatId, MaxUses: 1, Approval: true})
+	require.NoError(t, err)
+	uses := func() int {
+		found, err := repos.Invite.Get(ctx, invite.Id)
+		require.NoError(t, err)
+		return found.Uses
+	}
+
+	// Заявки та відмови не вичерпують посилання
+	rejected, err := invites.Join(ctx, first, invite.Token)
+	require.NoError(t, err)
+	_, err = invites.RejectJoin(ctx, owner, chatId, rejected.Id)
+	require.NoError(t, err)
+	approved, err := invites.Join(ctx, second, invite.Token)
+	require.NoError(t, err)
+	late, err := invites.Join(ctx, third, invite.Token)
+	require.NoError(t, err)
+	assert.Zero(t, uses())
+
+	// Використання зараховує лише схвалення
+	_, err = invites.ApproveJoin(ctx, owner, chatId, approved.Id)
+	require.NoError(t, err)
+	assert.Equal(t, 1, uses())
+
+	// Заявку за вичерпаним посиланням не схвалити і нову не подати
+	_, err = invites.ApproveJoin(ctx, owner, chatId, late.Id)
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+	_, err = repos.Chat.GetMember(ctx, chatId, third)
+	assert.Error(t, err)
+	_, err = invites.Join(ctx, first, invite.Token)
+	assert.ErrorIs(t, err, ErrInviteInvalid)
+	_, err = invites.RejectJoin(ctx, owner, chatId, late.Id)
+	require.NoError(t, err)
+}
+
+func TestInviteService_Blocked(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"owner", "member", "blocked"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const owner, member, blockedUser = 1, 2, 3
+	chats := NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons{})
+	invites := NewInviteService(repos.Chat, repos.Invite, repos.Join, repos.Status, repos.Transactor)
+	chatId, err := chats.Create(ctx, models.Chat{Name: "public", Types: repository.ChatPublic}, owner, member)
+	require.NoError(t, err)
+	invite, err := invites.CreateInvite(ctx, member, models.ChatInvite{ChatId: chatId})
+	require.NoError(t, err)
+	_, err = repos.Status.AddStatus(ctx, models.Status{SenderId: owner, RecipientId: blockedUser, Relationship: repository.StatusBL})
+	require.NoError(t, err)
+
+	// Заблокований адміністратором не вступає ні за посиланням, ні напряму
+	_, err = invites.Join(ctx, blockedUser, invite.Token)
+	assert.ErrorIs(t, err, ErrBlocked)
+	_, err = invites.JoinChat(ctx, blockedUser, chatId)
+	assert.ErrorIs(t, err, ErrBlocked)
+	require.NoError(t, chats.SetVisibility(ctx, owner, chatId, repository.VisibilityRequest))
+	_, err = invites.JoinChat(ctx, blockedUser, chatId)
+	assert.ErrorIs(t, err, ErrBlocked)
+	_, err = repos.Chat.GetMember(ctx, chatId, blockedUser)
+	assert.Error(t, err)
+	joins, err := invites.GetJoins(ctx, owner, chatId, repository.JoinPending)
+	require.NoError(t, err)
+	assert.Empty(t, joins)
+}
@@ -3,32 +3,69 @@ package service
 import (
 	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
+	"context"
 )
 
 type MessageService struct {
 	repository repository.Message
+	chats      repository.Chat
+	statuses   repository.Status
+	settings   repository.Settings
 }
 
-func NewMessageService(repository repository.Message) *MessageService {
-	return &MessageService{repository: repository}
+func NewMessageService(repository repository.Message, chats repository.Chat, statuses repository.Status, settings repository.Settings) *MessageService {
+	return &MessageService{repository: repository, chats: chats, statuses: statuses, settings: settings}
 }
 
-// Create викликає створення нового повідомлення та повертає його ID
-func (m *MessageService) Create(msg models.Message) (int, error) {
-	return m.repository.Create(msg)
+// Create викликає створення нового повідомлення та повертає його ID.
+// У приватний чат з користувачем, що заблокував автора (ErrBlocked) або
+// не приймає від нього повідомлень (ErrPrivacy), писати не можна.
+// Налаштування співрозмовника не діють, якщо чат створив він сам: інакше
+// той, кому він написав першим, не зміг би відповісти
+func (m *MessageService) Create(ctx context.Context, msg models.Message) (int, error) {
+	chat, err := m.chats.Get(ctx, msg.ChatId)
+	if err != nil {
+		return 0, translate(err, ErrChatNotFound, nil, nil)
+	}
+	if chat.Types == repository.ChatPrivate {
+		users, err := m.chats.GetUsers(ctx, chat.Id)
+		if err != nil {
+			return 0, err
+		}
+		var others []int
+		for _, user := range users {
+			if user.Id != msg.Author {
+				others = append(others, user.Id)
+			}
+		}
+		if err := blocked(ctx, m.statuses, msg.Author, others...); err != nil {
+			return 0, err
+		}
+		for _, other := range others {
+			if chat.CreatedBy != nil && *chat.CreatedBy == other {
+				continue
+			}
+			if err := permitted(ctx, m.settings, m.statuses, msg.Author, other, directMessages); err != nil {
+				return 0, err
+			}
+		}
+	}
+	id, err := m.repository.Create(ctx, msg)
+	return id, translate(err, nil, nil, ErrChatNotFound)
 }
 
 // Get викликає повернення повідомлення за його ID
-func (m *MessageService) Get(msgId int) (models.Message, error) {
-	return m.repository.Get(msgId)
+func (m *MessageService) Get(ctx context.Context, msgId int) (models.Message, error) {
+	msg, err := m.repository.Get(ctx, msgId)
+	return msg, translate(err, ErrMessageNotFound, nil, nil)
 }
 
 // GetLimit викликає повернення певної кількості повідомлень чату за його ID
-func (m *MessageService) GetLimit(chatId, limit int) ([]models.Message, error) {
-	return m.repository.GetLimit(chatId, limit)
+func (m *MessageService) GetLimit(ctx context.Context, chatId, limit int) ([]models.Message, error) {
+	return m.repository.GetLimit(ctx, chatId, limit)
 }
 
 // DeleteAll викликає видалення усіх повідомлень чата за його ID
-func (m *MessageService) DeleteAll(chatId int) error {
-	return m.repository.DeleteAll(chatId)
+func (m *MessageService) DeleteAll(ctx context.Context, chatId int) error {
+	return m.repository.DeleteAll(ctx, chatId)
 }
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// messageRepo - реалізація repository.Message, що лише зберігає
+// створені повідомлення
+type messageRepo struct {
+	repository.Message
+	messages []models.Message
+}
+
+func (r *messageRepo) Create(ctx context.Context, msg models.Message) (int, error) {
+	r.messages = append(r.messages, msg)
+	return len(r.messages), nil
+}
+
+func TestMessageService_Create(t *testing.T) {
+	ctx := context.Background()
+	chats, messages := newChatRepo(), &messageRepo{}
+	// Користувач 2 заблокував користувача 1
+	statuses := &statusRepo{blockers: map[int][]int{1: {2}}}
+	// Користувач 3 не приймає повідомлень ні від кого
+	settings := &settingsRepo{settings: map[int]models.UserSettings{
+		3: {UserId: 3, DirectMessages: repository.AudienceNobody},
+	}}
+	service := NewMessageService(messages, chats, statuses, settings)
+
+	privateId, err := chats.Create(ctx, models.Chat{Types: repository.ChatPrivate})
+	require.NoError(t, err)
+	chats.members[privateId] = []int{1, 2}
+	publicId, err := chats.Create(ctx, models.Chat{Types: repository.ChatPublic})
+	require.NoError(t, err)
+	chats.members[publicId] = []int{1, 2, 3}
+	silentId, err := chats.Create(ctx, models.Chat{Types: repository.ChatPrivate})
+	require.NoError(t, err)
+	chats.members[silentId] = []int{1, 3}
+
+	_, err = service.Create(ctx, models.Message{ChatId: privateId, Author: 1, Text: "hi"})
+	assert.ErrorIs(t, err, ErrBlocked)
+	assert.Empty(t, messages.messages)
+
+	// Той, хто заблокував, може писати, а в публічних чатах блокування не діє
+	_, err = service.Create(ctx, models.Message{ChatId: privateId, Author: 2, Text: "hi"})
+	require.NoError(t, err)
+	_, err = service.Create(ctx, models.Message{ChatId: publicId, Author: 1, Text: "hi"})
+	require.NoError(t, err)
+	assert.Len(t, messages.messages, 2)
+
+	_, err = service.Create(ctx, models.Message{ChatId: silentId, Author: 1, Text: "hi"})
+	assert.ErrorIs(t, err, ErrPrivacy)
+	_, err = service.Create(ctx, models.Message{ChatId: silentId, Author: 3, Text: "hi"})
+	require.NoError(t, err)
+
+	// Відповісти можна тому, хто сам почав чат, навіть якщо він не
+	// приймає повідомлень
+	starter := 3
+	startedId, err := chats.Create(ctx, models.Chat{Types: repository.ChatPrivate, CreatedBy: &starter})
+	require.NoError(t, err)
+	chats.members[startedId] = []int{1, 3}
+	_, err = service.Create(ctx, models.Message{ChatId: startedId, Author: 1, Text: "hi"})
+	require.NoError(t, err)
+
+	_, err = service.Create(ctx, models.Message{ChatId: startedId + 1, Author: 1, Text: "hi"})
+	assert.ErrorIs(t, err, ErrChatNotFound)
+}
@@ -6,6 +6,8 @@ package mock_service
 
 import (
 	models "cmd/pkg/repository/models"
+	context "context"
+	io "io"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
@@ -35,63 +37,63 @@ func (m *MockAuthorization) EXPECT() *MockAuthorizationMockRecorder {
 }
 
 // CreateUser mocks base method.
-func (m *MockAuthorization) CreateUser(user models.User) (int, error) {
+func (m *MockAuthorization) CreateUser(ctx context.Context, user models.User) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateUser", user)
+	ret := m.ctrl.Call(m, "CreateUser", ctx, user)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // CreateUser indicates an expected call of CreateUser.
-func (mr *MockAuthorizationMockRecorder) CreateUser(user interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) CreateUser(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthorization)(nil).CreateUser), ctx, user)
 }
 
 // GenerateToken mocks base method.
-func (m *MockAuthorization) GenerateToken(username, password string) (string, error) {
+func (m *MockAuthorization) GenerateToken(ctx context.Context, username, password string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", username, password)
+	ret := m.ctrl.Call(m, "GenerateToken", ctx, username, password)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockAuthorizationMockRecorder) GenerateToken(username, password interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GenerateToken(ctx, username, password interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), username, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockAuthorization)(nil).GenerateToken), ctx, username, password)
 }
 
 // GetByName mocks base method.
-func (m *MockAuthorization) GetByName(name string) (models.User, error) {
+func (m *MockAuthorization) GetByName(ctx context.Context, name string) (models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByName", name)
+	ret := m.ctrl.Call(m, "GetByName", ctx, name)
 	ret0, _ := ret[0].(models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetByName indicates an expected call of GetByName.
-func (mr *MockAuthorizationMockRecorder) GetByName(name interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GetByName(ctx, name interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockAuthorization)(nil).GetByName), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByName", reflect.TypeOf((*MockAuthorization)(nil).GetByName), ctx, name)
 }
 
 // GetUserById mocks base method.
-func (m *MockAuthorization) GetUserById(userId int) (models.User, error) {
+func (m *MockAuthorization) GetUserById(ctx context.Context, userId int) (models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", userId)
+	ret := m.ctrl.Call(m, "GetUserById", ctx, userId)
 	ret0, _ := ret[0].(models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockAuthorizationMockRecorder) GetUserById(userId interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) GetUserById(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockAuthorization)(nil).GetUserById), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockAuthorization)(nil).GetUserById), ctx, userId)
 }
 
 // ParseToken mocks base method.
@@ -110,31 +112,31 @@ func (mr *MockAuthorizationMockRecorder) ParseToken(token interface{}) *gomock.C
 }
 
 // UpdateData mocks base method.
-func (m *MockAuthorization) UpdateData(user models.User) error {
+func (m *MockAuthorization) UpdateData(ctx context.Context, user models.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateData", user)
+	ret := m.ctrl.Call(m, "UpdateData", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateData indicates an expected call of UpdateData.
-func (mr *MockAuthorizationMockRecorder) UpdateData(user interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) UpdateData(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockAuthorization)(nil).UpdateData), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateData", reflect.TypeOf((*MockAuthorization)(nil).UpdateData), ctx, user)
 }
 
 // UpdatePassword mocks base method.
-func (m *MockAuthorization) UpdatePassword(user models.User) error {
+func (m *MockAuthorization) UpdatePassword(ctx context.Context, user models.User) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdatePassword", user)
+	ret := m.ctrl.Call(m, "UpdatePassword", ctx, user)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdatePassword indicates an expected call of UpdatePassword.
-func (mr *MockAuthorizationMockRecorder) UpdatePassword(user interface{}) *gomock.Call {
+func (mr *MockAuthorizationMockRecorder) UpdatePassword(ctx, user interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockAuthorization)(nil).UpdatePassword), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*MockAuthorization)(nil).UpdatePassword), ctx, user)
 }
 
 // MockChat is a mock of Chat interface.
@@ -161,194 +163,289 @@ func (m *MockChat) EXPECT() *MockChatMockRecorder {
 }
 
 // AddUser mocks base method.
-func (m *MockChat) AddUser(users models.ChatUsers) (int, error) {
+func (m *MockChat) AddUser(ctx context.Context, userId int, users models.ChatUsers) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddUser", users)
+	ret := m.ctrl.Call(m, "AddUser", ctx, userId, users)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // AddUser indicates an expected call of AddUser.
-func (mr *MockChatMockRecorder) AddUser(users interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) AddUser(ctx, userId, users interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockChat)(nil).AddUser), users)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddUser", reflect.TypeOf((*MockChat)(nil).AddUser), ctx, userId, users)
+}
+
+// CheckMember mocks base method.
+func (m *MockChat) CheckMember(ctx context.Context, userId, chatId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckMember", ctx, userId, chatId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckMember indicates an expected call of CheckMember.
+func (mr *MockChatMockRecorder) CheckMember(ctx, userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckMember", reflect.TypeOf((*MockChat)(nil).CheckMember), ctx, userId, chatId)
 }
 
 // Create mocks base method.
-func (m *MockChat) Create(chat models.Chat) (int, error) {
+func (m *MockChat) Create(ctx context.Context, chat models.Chat, members ...int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", chat)
+	varargs := []interface{}{ctx, chat}
+	for _, a := range members {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockChatMockRecorder) Create(chat interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) Create(ctx, chat interface{}, members ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockChat)(nil).Create), chat)
+	varargs := append([]interface{}{ctx, chat}, members...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockChat)(nil).Create), varargs...)
 }
 
 // Delete mocks base method.
-func (m *MockChat) Delete(chatId int) error {
+func (m *MockChat) Delete(ctx context.Context, userId, chatId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", chatId)
+	ret := m.ctrl.Call(m, "Delete", ctx, userId, chatId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockChatMockRecorder) Delete(chatId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) Delete(ctx, userId, chatId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockChat)(nil).Delete), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockChat)(nil).Delete), ctx, userId, chatId)
 }
 
 // DeleteAllMessages mocks base method.
-func (m *MockChat) DeleteAllMessages(chatId int) error {
+func (m *MockChat) DeleteAllMessages(ctx context.Context, chatId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAllMessages", chatId)
+	ret := m.ctrl.Call(m, "DeleteAllMessages", ctx, chatId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteAllMessages indicates an expected call of DeleteAllMessages.
-func (mr *MockChatMockRecorder) DeleteAllMessages(chatId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) DeleteAllMessages(ctx, chatId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllMessages", reflect.TypeOf((*MockChat)(nil).DeleteAllMessages), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllMessages", reflect.TypeOf((*MockChat)(nil).DeleteAllMessages), ctx, chatId)
 }
 
 // DeleteUser mocks base method.
-func (m *MockChat) DeleteUser(userId, chatId int) error {
+func (m *MockChat) DeleteUser(ctx context.Context, actorId, userId, chatId int) (bool, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", userId, chatId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "DeleteUser", ctx, actorId, userId, chatId)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockChatMockRecorder) DeleteUser(userId, chatId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) DeleteUser(ctx, actorId, userId, chatId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockChat)(nil).DeleteUser), userId, chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockChat)(nil).DeleteUser), ctx, actorId, userId, chatId)
 }
 
 // Get mocks base method.
-func (m *MockChat) Get(chatId int) (models.Chat, error) {
+func (m *MockChat) Get(ctx context.Context, chatId int) (models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", chatId)
+	ret := m.ctrl.Call(m, "Get", ctx, chatId)
 	ret0, _ := ret[0].(models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockChatMockRecorder) Get(chatId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) Get(ctx, chatId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockChat)(nil).Get), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockChat)(nil).Get), ctx, chatId)
+}
+
+// GetForAdmin mocks base method.
+func (m *MockChat) GetForAdmin(ctx context.Context, userId, chatId int) (models.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForAdmin", ctx, userId, chatId)
+	ret0, _ := ret[0].(models.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetForAdmin indicates an expected call of GetForAdmin.
+func (mr *MockChatMockRecorder) GetForAdmin(ctx, userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForAdmin", reflect.TypeOf((*MockChat)(nil).GetForAdmin), ctx, userId, chatId)
+}
+
+// GetForUser mocks base method.
+func (m *MockChat) GetForUser(ctx context.Context, viewerId, chatId int) (models.Chat, models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetForUser", ctx, viewerId, chatId)
+	ret0, _ := ret[0].(models.Chat)
+	ret1, _ := ret[1].(models.User)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetForUser indicates an expected call of GetForUser.
+func (mr *MockChatMockRecorder) GetForUser(ctx, viewerId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetForUser", reflect.TypeOf((*MockChat)(nil).GetForUser), ctx, viewerId, chatId)
 }
 
 // GetPrivateChats mocks base method.
-func (m *MockChat) GetPrivateChats(userId int) ([]models.Chat, error) {
+func (m *MockChat) GetPrivateChats(ctx context.Context, userId int) ([]models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrivateChats", userId)
+	ret := m.ctrl.Call(m, "GetPrivateChats", ctx, userId)
 	ret0, _ := ret[0].([]models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrivateChats indicates an expected call of GetPrivateChats.
-func (mr *MockChatMockRecorder) GetPrivateChats(userId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) GetPrivateChats(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivateChats", reflect.TypeOf((*MockChat)(nil).GetPrivateChats), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivateChats", reflect.TypeOf((*MockChat)(nil).GetPrivateChats), ctx, userId)
 }
 
 // GetPrivates mocks base method.
-func (m *MockChat) GetPrivates(firstUser, secondUser int) (int, error) {
+func (m *MockChat) GetPrivates(ctx context.Context, firstUser, secondUser int) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPrivates", firstUser, secondUser)
+	ret := m.ctrl.Call(m, "GetPrivates", ctx, firstUser, secondUser)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPrivates indicates an expected call of GetPrivates.
-func (mr *MockChatMockRecorder) GetPrivates(firstUser, secondUser interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) GetPrivates(ctx, firstUser, secondUser interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivates", reflect.TypeOf((*MockChat)(nil).GetPrivates), firstUser, secondUser)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPrivates", reflect.TypeOf((*MockChat)(nil).GetPrivates), ctx, firstUser, secondUser)
 }
 
 // GetPublicChats mocks base method.
-func (m *MockChat) GetPublicChats(userId int) ([]models.Chat, error) {
+func (m *MockChat) GetPublicChats(ctx context.Context, userId int) ([]models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPublicChats", userId)
+	ret := m.ctrl.Call(m, "GetPublicChats", ctx, userId)
 	ret0, _ := ret[0].([]models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPublicChats indicates an expected call of GetPublicChats.
-func (mr *MockChatMockRecorder) GetPublicChats(userId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) GetPublicChats(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicChats", reflect.TypeOf((*MockChat)(nil).GetPublicChats), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPublicChats", reflect.TypeOf((*MockChat)(nil).GetPublicChats), ctx, userId)
 }
 
 // GetUserById mocks base method.
-func (m *MockChat) GetUserById(userId int) (models.User, error) {
+func (m *MockChat) GetUserById(ctx context.Context, userId int) (models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", userId)
+	ret := m.ctrl.Call(m, "GetUserById", ctx, userId)
 	ret0, _ := ret[0].(models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockChatMockRecorder) GetUserById(userId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) GetUserById(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockChat)(nil).GetUserById), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockChat)(nil).GetUserById), ctx, userId)
 }
 
 // GetUsers mocks base method.
-func (m *MockChat) GetUsers(chatId int) ([]models.User, error) {
+func (m *MockChat) GetUsers(ctx context.Context, chatId int) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers", chatId)
+	ret := m.ctrl.Call(m, "GetUsers", ctx, chatId)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUsers indicates an expected call of GetUsers.
-func (mr *MockChatMockRecorder) GetUsers(chatId interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) GetUsers(ctx, chatId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockChat)(nil).GetUsers), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*MockChat)(nil).GetUsers), ctx, chatId)
+}
+
+// PrivateChat mocks base method.
+func (m *MockChat) PrivateChat(ctx context.Context, creatorId, userId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PrivateChat", ctx, creatorId, userId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PrivateChat indicates an expected call of PrivateChat.
+func (mr *MockChatMockRecorder) PrivateChat(ctx, creatorId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrivateChat", reflect.TypeOf((*MockChat)(nil).PrivateChat), ctx, creatorId, userId)
 }
 
 // SearchChat mocks base method.
-func (m *MockChat) SearchChat(name string) ([]models.Chat, error) {
+func (m *MockChat) SearchChat(ctx context.Context, name string) ([]models.Chat, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchChat", name)
+	ret := m.ctrl.Call(m, "SearchChat", ctx, name)
 	ret0, _ := ret[0].([]models.Chat)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchChat indicates an expected call of SearchChat.
-func (mr *MockChatMockRecorder) SearchChat(name interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) SearchChat(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchChat", reflect.TypeOf((*MockChat)(nil).SearchChat), ctx, name)
+}
+
+// SetVisibility mocks base method.
+func (m *MockChat) SetVisibility(ctx context.Context, userId, chatId int, visibility string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVisibility", ctx, userId, chatId, visibility)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVisibility indicates an expected call of SetVisibility.
+func (mr *MockChatMockRecorder) SetVisibility(ctx, userId, chatId, visibility interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchChat", reflect.TypeOf((*MockChat)(nil).SearchChat), name)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVisibility", reflect.TypeOf((*MockChat)(nil).SetVisibility), ctx, userId, chatId, visibility)
 }
 
 // Update mocks base method.
-func (m *MockChat) Update(chat models.Chat) error {
+func (m *MockChat) Update(ctx context.Context, chat models.Chat) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", chat)
+	ret := m.ctrl.Call(m, "Update", ctx, chat)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockChatMockRecorder) Update(chat interface{}) *gomock.Call {
+func (mr *MockChatMockRecorder) Update(ctx, chat interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockChat)(nil).Update), ctx, chat)
+}
+
+// UpdateInfo mocks base method.
+func (m *MockChat) UpdateInfo(ctx context.Context, userId int, info models.Chat) (models.Chat, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateInfo", ctx, userId, info)
+	ret0, _ := ret[0].(models.Chat)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateInfo indicates an expected call of UpdateInfo.
+func (mr *MockChatMockRecorder) UpdateInfo(ctx, userId, info interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockChat)(nil).Update), chat)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateInfo", reflect.TypeOf((*MockChat)(nil).UpdateInfo), ctx, userId, info)
 }
 
 // MockStatus is a mock of Status interface.
@@ -374,167 +471,254 @@ func (m *MockStatus) EXPECT() *MockStatusMockRecorder {
 	return m.recorder
 }
 
-// AddStatus mocks base method.
-func (m *MockStatus) AddStatus(status models.Status) (int, error) {
+// Accept mocks base method.
+func (m *MockStatus) Accept(ctx context.Context, userId, senderId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddStatus", status)
+	ret := m.ctrl.Call(m, "Accept", ctx, userId, senderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Accept indicates an expected call of Accept.
+func (mr *MockStatusMockRecorder) Accept(ctx, userId, senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Accept", reflect.TypeOf((*MockStatus)(nil).Accept), ctx, userId, senderId)
+}
+
+// Block mocks base method.
+func (m *MockStatus) Block(ctx context.Context, userId, targetId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Block", ctx, userId, targetId)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// AddStatus indicates an expected call of AddStatus.
-func (mr *MockStatusMockRecorder) AddStatus(status interface{}) *gomock.Call {
+// Block indicates an expected call of Block.
+func (mr *MockStatusMockRecorder) Block(ctx, userId, targetId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStatus", reflect.TypeOf((*MockStatus)(nil).AddStatus), status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Block", reflect.TypeOf((*MockStatus)(nil).Block), ctx, userId, targetId)
 }
 
-// DeleteStatus mocks base method.
-func (m *MockStatus) DeleteStatus(status models.Status) error {
+// Cancel mocks base method.
+func (m *MockStatus) Cancel(ctx context.Context, userId, recipientId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteStatus", status)
+	ret := m.ctrl.Call(m, "Cancel", ctx, userId, recipientId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// DeleteStatus indicates an expected call of DeleteStatus.
-func (mr *MockStatusMockRecorder) DeleteStatus(status interface{}) *gomock.Call {
+// Cancel indicates an expected call of Cancel.
+func (mr *MockStatusMockRecorder) Cancel(ctx, userId, recipientId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteStatus", reflect.TypeOf((*MockStatus)(nil).DeleteStatus), status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Cancel", reflect.TypeOf((*MockStatus)(nil).Cancel), ctx, userId, recipientId)
 }
 
 // GetBlackList mocks base method.
-func (m *MockStatus) GetBlackList(userId int) ([]models.User, error) {
+func (m *MockStatus) GetBlackList(ctx context.Context, userId int) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBlackList", userId)
+	ret := m.ctrl.Call(m, "GetBlackList", ctx, userId)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBlackList indicates an expected call of GetBlackList.
-func (mr *MockStatusMockRecorder) GetBlackList(userId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetBlackList(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlackList", reflect.TypeOf((*MockStatus)(nil).GetBlackList), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlackList", reflect.TypeOf((*MockStatus)(nil).GetBlackList), ctx, userId)
 }
 
 // GetBlackListToUser mocks base method.
-func (m *MockStatus) GetBlackListToUser(userId int) ([]models.User, error) {
+func (m *MockStatus) GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetBlackListToUser", userId)
+	ret := m.ctrl.Call(m, "GetBlackListToUser", ctx, userId)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetBlackListToUser indicates an expected call of GetBlackListToUser.
-func (mr *MockStatusMockRecorder) GetBlackListToUser(userId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetBlackListToUser(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlackListToUser", reflect.TypeOf((*MockStatus)(nil).GetBlackListToUser), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlackListToUser", reflect.TypeOf((*MockStatus)(nil).GetBlackListToUser), ctx, userId)
 }
 
 // GetFriends mocks base method.
-func (m *MockStatus) GetFriends(userId int) ([]models.User, error) {
+func (m *MockStatus) GetFriends(ctx context.Context, userId int) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFriends", userId)
+	ret := m.ctrl.Call(m, "GetFriends", ctx, userId)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFriends indicates an expected call of GetFriends.
-func (mr *MockStatusMockRecorder) GetFriends(userId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetFriends(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFriends", reflect.TypeOf((*MockStatus)(nil).GetFriends), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFriends", reflect.TypeOf((*MockStatus)(nil).GetFriends), ctx, userId)
 }
 
 // GetInvites mocks base method.
-func (m *MockStatus) GetInvites(userId int) ([]models.User, error) {
+func (m *MockStatus) GetInvites(ctx context.Context, userId int) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetInvites", userId)
+	ret := m.ctrl.Call(m, "GetInvites", ctx, userId)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetInvites indicates an expected call of GetInvites.
-func (mr *MockStatusMockRecorder) GetInvites(userId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetInvites(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockStatus)(nil).GetInvites), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockStatus)(nil).GetInvites), ctx, userId)
 }
 
 // GetSentInvites mocks base method.
-func (m *MockStatus) GetSentInvites(userId int) ([]models.User, error) {
+func (m *MockStatus) GetSentInvites(ctx context.Context, userId int) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetSentInvites", userId)
+	ret := m.ctrl.Call(m, "GetSentInvites", ctx, userId)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetSentInvites indicates an expected call of GetSentInvites.
-func (mr *MockStatusMockRecorder) GetSentInvites(userId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetSentInvites(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentInvites", reflect.TypeOf((*MockStatus)(nil).GetSentInvites), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSentInvites", reflect.TypeOf((*MockStatus)(nil).GetSentInvites), ctx, userId)
 }
 
 // GetStatuses mocks base method.
-func (m *MockStatus) GetStatuses(senderId, recipientId int) ([]models.Status, error) {
+func (m *MockStatus) GetStatuses(ctx context.Context, userId, otherId int) ([]models.Status, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetStatuses", senderId, recipientId)
+	ret := m.ctrl.Call(m, "GetStatuses", ctx, userId, otherId)
 	ret0, _ := ret[0].([]models.Status)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetStatuses indicates an expected call of GetStatuses.
-func (mr *MockStatusMockRecorder) GetStatuses(senderId, recipientId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetStatuses(ctx, userId, otherId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatuses", reflect.TypeOf((*MockStatus)(nil).GetStatuses), senderId, recipientId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetStatuses", reflect.TypeOf((*MockStatus)(nil).GetStatuses), ctx, userId, otherId)
+}
+
+// GetSuggestions mocks base method.
+func (m *MockStatus) GetSuggestions(ctx context.Context, userId, limit int) ([]models.Suggestion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSuggestions", ctx, userId, limit)
+	ret0, _ := ret[0].([]models.Suggestion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSuggestions indicates an expected call of GetSuggestions.
+func (mr *MockStatusMockRecorder) GetSuggestions(ctx, userId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSuggestions", reflect.TypeOf((*MockStatus)(nil).GetSuggestions), ctx, userId, limit)
 }
 
 // GetUserById mocks base method.
-func (m *MockStatus) GetUserById(userId int) (models.User, error) {
+func (m *MockStatus) GetUserById(ctx context.Context, userId int) (models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserById", userId)
+	ret := m.ctrl.Call(m, "GetUserById", ctx, userId)
 	ret0, _ := ret[0].(models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserById indicates an expected call of GetUserById.
-func (mr *MockStatusMockRecorder) GetUserById(userId interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) GetUserById(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockStatus)(nil).GetUserById), ctx, userId)
+}
+
+// GetUserLists mocks base method.
+func (m *MockStatus) GetUserLists(ctx context.Context, userId, offset, limit int) (models.UserLists, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserLists", ctx, userId, offset, limit)
+	ret0, _ := ret[0].(models.UserLists)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserLists indicates an expected call of GetUserLists.
+func (mr *MockStatusMockRecorder) GetUserLists(ctx, userId, offset, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserById", reflect.TypeOf((*MockStatus)(nil).GetUserById), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserLists", reflect.TypeOf((*MockStatus)(nil).GetUserLists), ctx, userId, offset, limit)
+}
+
+// Invite mocks base method.
+func (m *MockStatus) Invite(ctx context.Context, userId, recipientId int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Invite", ctx, userId, recipientId)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Invite indicates an expected call of Invite.
+func (mr *MockStatusMockRecorder) Invite(ctx, userId, recipientId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Invite", reflect.TypeOf((*MockStatus)(nil).Invite), ctx, userId, recipientId)
+}
+
+// Refuse mocks base method.
+func (m *MockStatus) Refuse(ctx context.Context, userId, senderId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refuse", ctx, userId, senderId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Refuse indicates an expected call of Refuse.
+func (mr *MockStatusMockRecorder) Refuse(ctx, userId, senderId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refuse", reflect.TypeOf((*MockStatus)(nil).Refuse), ctx, userId, senderId)
 }
 
 // SearchUser mocks base method.
-func (m *MockStatus) SearchUser(username string) ([]models.User, error) {
+func (m *MockStatus) SearchUser(ctx context.Context, userId int, username string) ([]models.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SearchUser", username)
+	ret := m.ctrl.Call(m, "SearchUser", ctx, userId, username)
 	ret0, _ := ret[0].([]models.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // SearchUser indicates an expected call of SearchUser.
-func (mr *MockStatusMockRecorder) SearchUser(username interface{}) *gomock.Call {
+func (mr *MockStatusMockRecorder) SearchUser(ctx, userId, username interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUser", reflect.TypeOf((*MockStatus)(nil).SearchUser), username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUser", reflect.TypeOf((*MockStatus)(nil).SearchUser), ctx, userId, username)
 }
 
-// UpdateStatus mocks base method.
-func (m *MockStatus) UpdateStatus(status models.Status) error {
+// Unblock mocks base method.
+func (m *MockStatus) Unblock(ctx context.Context, userId, targetId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateStatus", status)
+	ret := m.ctrl.Call(m, "Unblock", ctx, userId, targetId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// UpdateStatus indicates an expected call of UpdateStatus.
-func (mr *MockStatusMockRecorder) UpdateStatus(status interface{}) *gomock.Call {
+// Unblock indicates an expected call of Unblock.
+func (mr *MockStatusMockRecorder) Unblock(ctx, userId, targetId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockStatus)(nil).UpdateStatus), status)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unblock", reflect.TypeOf((*MockStatus)(nil).Unblock), ctx, userId, targetId)
+}
+
+// Unfriend mocks base method.
+func (m *MockStatus) Unfriend(ctx context.Context, userId, friendId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfriend", ctx, userId, friendId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfriend indicates an expected call of Unfriend.
+func (mr *MockStatusMockRecorder) Unfriend(ctx, userId, friendId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfriend", reflect.TypeOf((*MockStatus)(nil).Unfriend), ctx, userId, friendId)
 }
 
 // MockMessage is a mock of Message interface.
@@ -561,60 +745,438 @@ func (m *MockMessage) EXPECT() *MockMessageMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockMessage) Create(msg models.Message) (int, error) {
+func (m *MockMessage) Create(ctx context.Context, msg models.Message) (int, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", msg)
+	ret := m.ctrl.Call(m, "Create", ctx, msg)
 	ret0, _ := ret[0].(int)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockMessageMockRecorder) Create(msg interface{}) *gomock.Call {
+func (mr *MockMessageMockRecorder) Create(ctx, msg interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessage)(nil).Create), msg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessage)(nil).Create), ctx, msg)
 }
 
 // DeleteAll mocks base method.
-func (m *MockMessage) DeleteAll(chatId int) error {
+func (m *MockMessage) DeleteAll(ctx context.Context, chatId int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteAll", chatId)
+	ret := m.ctrl.Call(m, "DeleteAll", ctx, chatId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteAll indicates an expected call of DeleteAll.
-func (mr *MockMessageMockRecorder) DeleteAll(chatId interface{}) *gomock.Call {
+func (mr *MockMessageMockRecorder) DeleteAll(ctx, chatId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockMessage)(nil).DeleteAll), chatId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockMessage)(nil).DeleteAll), ctx, chatId)
 }
 
 // Get mocks base method.
-func (m *MockMessage) Get(msgId int) (models.Message, error) {
+func (m *MockMessage) Get(ctx context.Context, msgId int) (models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", msgId)
+	ret := m.ctrl.Call(m, "Get", ctx, msgId)
 	ret0, _ := ret[0].(models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockMessageMockRecorder) Get(msgId interface{}) *gomock.Call {
+func (mr *MockMessageMockRecorder) Get(ctx, msgId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMessage)(nil).Get), msgId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMessage)(nil).Get), ctx, msgId)
 }
 
 // GetLimit mocks base method.
-func (m *MockMessage) GetLimit(chatId, limit int) ([]models.Message, error) {
+func (m *MockMessage) GetLimit(ctx context.Context, chatId, limit int) ([]models.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLimit", chatId, limit)
+	ret := m.ctrl.Call(m, "GetLimit", ctx, chatId, limit)
 	ret0, _ := ret[0].([]models.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLimit indicates an expected call of GetLimit.
-func (mr *MockMessageMockRecorder) GetLimit(chatId, limit interface{}) *gomock.Call {
+func (mr *MockMessageMockRecorder) GetLimit(ctx, chatId, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimit", reflect.TypeOf((*MockMessage)(nil).GetLimit), ctx, chatId, limit)
+}
+
+// MockUpload is a mock of Upload interface.
+type MockUpload struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadMockRecorder
+}
+
+// MockUploadMockRecorder is the mock recorder for MockUpload.
+type MockUploadMockRecorder struct {
+	mock *MockUpload
+}
+
+// NewMockUpload creates a new mock instance.
+func NewMockUpload(ctrl *gomock.Controller) *MockUpload {
+	mock := &MockUpload{ctrl: ctrl}
+	mock.recorder = &MockUploadMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUpload) EXPECT() *MockUploadMockRecorder {
+	return m.recorder
+}
+
+// DeleteImage mocks base method.
+func (m *MockUpload) DeleteImage(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteImage", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteImage indicates an expected call of DeleteImage.
+func (mr *MockUploadMockRecorder) DeleteImage(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteImage", reflect.TypeOf((*MockUpload)(nil).DeleteImage), ctx, name)
+}
+
+// Open mocks base method.
+func (m *MockUpload) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Open", ctx, name)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Open indicates an expected call of Open.
+func (mr *MockUploadMockRecorder) Open(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Open", reflect.TypeOf((*MockUpload)(nil).Open), ctx, name)
+}
+
+// Release mocks base method.
+func (m *MockUpload) Release(ctx context.Context, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockUploadMockRecorder) Release(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockUpload)(nil).Release), ctx, name)
+}
+
+// Replace mocks base method.
+func (m *MockUpload) Replace(ctx context.Context, oldName, newName string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Replace", ctx, oldName, newName)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Replace indicates an expected call of Replace.
+func (mr *MockUploadMockRecorder) Replace(ctx, oldName, newName interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Replace", reflect.TypeOf((*MockUpload)(nil).Replace), ctx, oldName, newName)
+}
+
+// SaveImage mocks base method.
+func (m *MockUpload) SaveImage(ctx context.Context, data []byte) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveImage", ctx, data)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SaveImage indicates an expected call of SaveImage.
+func (mr *MockUploadMockRecorder) SaveImage(ctx, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveImage", reflect.TypeOf((*MockUpload)(nil).SaveImage), ctx, data)
+}
+
+// SignedURL mocks base method.
+func (m *MockUpload) SignedURL(ctx context.Context, name string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SignedURL", ctx, name)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SignedURL indicates an expected call of SignedURL.
+func (mr *MockUploadMockRecorder) SignedURL(ctx, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SignedURL", reflect.TypeOf((*MockUpload)(nil).SignedURL), ctx, name)
+}
+
+// Sweep mocks base method.
+func (m *MockUpload) Sweep(ctx context.Context, dryRun bool) ([]models.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Sweep", ctx, dryRun)
+	ret0, _ := ret[0].([]models.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Sweep indicates an expected call of Sweep.
+func (mr *MockUploadMockRecorder) Sweep(ctx, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sweep", reflect.TypeOf((*MockUpload)(nil).Sweep), ctx, dryRun)
+}
+
+// Untracked mocks base method.
+func (m *MockUpload) Untracked(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Untracked", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Untracked indicates an expected call of Untracked.
+func (mr *MockUploadMockRecorder) Untracked(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Untracked", reflect.TypeOf((*MockUpload)(nil).Untracked), ctx)
+}
+
+// MockSettings is a mock of Settings interface.
+type MockSettings struct {
+	ctrl     *gomock.Controller
+	recorder *MockSettingsMockRecorder
+}
+
+// MockSettingsMockRecorder is the mock recorder for MockSettings.
+type MockSettingsMockRecorder struct {
+	mock *MockSettings
+}
+
+// NewMockSettings creates a new mock instance.
+func NewMockSettings(ctrl *gomock.Controller) *MockSettings {
+	mock := &MockSettings{ctrl: ctrl}
+	mock.recorder = &MockSettingsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockSettings) EXPECT() *MockSettingsMockRecorder {
+	return m.recorder
+}
+
+// GetPresence mocks base method.
+func (m *MockSettings) GetPresence(ctx context.Context, viewerId, userId int) (models.Presence, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPresence", ctx, viewerId, userId)
+	ret0, _ := ret[0].(models.Presence)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPresence indicates an expected call of GetPresence.
+func (mr *MockSettingsMockRecorder) GetPresence(ctx, viewerId, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPresence", reflect.TypeOf((*MockSettings)(nil).GetPresence), ctx, viewerId, userId)
+}
+
+// GetSettings mocks base method.
+func (m *MockSettings) GetSettings(ctx context.Context, userId int) (models.UserSettings, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSettings", ctx, userId)
+	ret0, _ := ret[0].(models.UserSettings)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSettings indicates an expected call of GetSettings.
+func (mr *MockSettingsMockRecorder) GetSettings(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSettings", reflect.TypeOf((*MockSettings)(nil).GetSettings), ctx, userId)
+}
+
+// Seen mocks base method.
+func (m *MockSettings) Seen(ctx context.Context, userId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seen", ctx, userId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Seen indicates an expected call of Seen.
+func (mr *MockSettingsMockRecorder) Seen(ctx, userId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seen", reflect.TypeOf((*MockSettings)(nil).Seen), ctx, userId)
+}
+
+// UpdateSettings mocks base method.
+func (m *MockSettings) UpdateSettings(ctx context.Context, settings models.UserSettings) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSettings", ctx, settings)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSettings indicates an expected call of UpdateSettings.
+func (mr *MockSettingsMockRecorder) UpdateSettings(ctx, settings interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSettings", reflect.TypeOf((*MockSettings)(nil).UpdateSettings), ctx, settings)
+}
+
+// MockInvite is a mock of Invite interface.
+type MockInvite struct {
+	ctrl     *gomock.Controller
+	recorder *MockInviteMockRecorder
+}
+
+// MockInviteMockRecorder is the mock recorder for MockInvite.
+type MockInviteMockRecorder struct {
+	mock *MockInvite
+}
+
+// NewMockInvite creates a new mock instance.
+func NewMockInvite(ctrl *gomock.Controller) *MockInvite {
+	mock := &MockInvite{ctrl: ctrl}
+	mock.recorder = &MockInviteMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvite) EXPECT() *MockInviteMockRecorder {
+	return m.recorder
+}
+
+// ApproveJoin mocks base method.
+func (m *MockInvite) ApproveJoin(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproveJoin", ctx, userId, chatId, joinId)
+	ret0, _ := ret[0].(models.ChatJoin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ApproveJoin indicates an expected call of ApproveJoin.
+func (mr *MockInviteMockRecorder) ApproveJoin(ctx, userId, chatId, joinId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproveJoin", reflect.TypeOf((*MockInvite)(nil).ApproveJoin), ctx, userId, chatId, joinId)
+}
+
+// CreateInvite mocks base method.
+func (m *MockInvite) CreateInvite(ctx context.Context, userId int, invite models.ChatInvite) (models.ChatInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvite", ctx, userId, invite)
+	ret0, _ := ret[0].(models.ChatInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvite indicates an expected call of CreateInvite.
+func (mr *MockInviteMockRecorder) CreateInvite(ctx, userId, invite interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvite", reflect.TypeOf((*MockInvite)(nil).CreateInvite), ctx, userId, invite)
+}
+
+// GetAdmins mocks base method.
+func (m *MockInvite) GetAdmins(ctx context.Context, chatId int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAdmins", ctx, chatId)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAdmins indicates an expected call of GetAdmins.
+func (mr *MockInviteMockRecorder) GetAdmins(ctx, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAdmins", reflect.TypeOf((*MockInvite)(nil).GetAdmins), ctx, chatId)
+}
+
+// GetInvites mocks base method.
+func (m *MockInvite) GetInvites(ctx context.Context, userId, chatId int) ([]models.ChatInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetInvites", ctx, userId, chatId)
+	ret0, _ := ret[0].([]models.ChatInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetInvites indicates an expected call of GetInvites.
+func (mr *MockInviteMockRecorder) GetInvites(ctx, userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvites", reflect.TypeOf((*MockInvite)(nil).GetInvites), ctx, userId, chatId)
+}
+
+// GetJoins mocks base method.
+func (m *MockInvite) GetJoins(ctx context.Context, userId, chatId int, status string) ([]models.ChatJoin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJoins", ctx, userId, chatId, status)
+	ret0, _ := ret[0].([]models.ChatJoin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJoins indicates an expected call of GetJoins.
+func (mr *MockInviteMockRecorder) GetJoins(ctx, userId, chatId, status interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJoins", reflect.TypeOf((*MockInvite)(nil).GetJoins), ctx, userId, chatId, status)
+}
+
+// Join mocks base method.
+func (m *MockInvite) Join(ctx context.Context, userId int, token string) (models.ChatJoin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Join", ctx, userId, token)
+	ret0, _ := ret[0].(models.ChatJoin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Join indicates an expected call of Join.
+func (mr *MockInviteMockRecorder) Join(ctx, userId, token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Join", reflect.TypeOf((*MockInvite)(nil).Join), ctx, userId, token)
+}
+
+// JoinChat mocks base method.
+func (m *MockInvite) JoinChat(ctx context.Context, userId, chatId int) (models.ChatJoin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JoinChat", ctx, userId, chatId)
+	ret0, _ := ret[0].(models.ChatJoin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JoinChat indicates an expected call of JoinChat.
+func (mr *MockInviteMockRecorder) JoinChat(ctx, userId, chatId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JoinChat", reflect.TypeOf((*MockInvite)(nil).JoinChat), ctx, userId, chatId)
+}
+
+// RejectJoin mocks base method.
+func (m *MockInvite) RejectJoin(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RejectJoin", ctx, userId, chatId, joinId)
+	ret0, _ := ret[0].(models.ChatJoin)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RejectJoin indicates an expected call of RejectJoin.
+func (mr *MockInviteMockRecorder) RejectJoin(ctx, userId, chatId, joinId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RejectJoin", reflect.TypeOf((*MockInvite)(nil).RejectJoin), ctx, userId, chatId, joinId)
+}
+
+// RevokeInvite mocks base method.
+func (m *MockInvite) RevokeInvite(ctx context.Context, userId, chatId, inviteId int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeInvite", ctx, userId, chatId, inviteId)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeInvite indicates an expected call of RevokeInvite.
+func (mr *MockInviteMockRecorder) RevokeInvite(ctx, userId, chatId, inviteId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimit", reflect.TypeOf((*MockMessage)(nil).GetLimit), chatId, limit)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeInvite", reflect.TypeOf((*MockInvite)(nil).RevokeInvite), ctx, userId, chatId, inviteId)
 }
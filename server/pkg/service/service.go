@@ -1,99 +1,216 @@
 package service
 
 import (
+	"cmd/pkg/config"
 	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
+	"cmd/pkg/storage"
+	"context"
+	"io"
 )
+
 //go:generate mockgen -source=service.go -destination=mocks/mock.go
 type Authorization interface {
 	// CreateUser кодує пароль викликає створення нового користувача
-	CreateUser(user models.User) (int, error)
+	CreateUser(ctx context.Context, user models.User) (int, error)
 	// GetByName викликає повернення даних користувача за ім'ям
-	GetByName(name string) (models.User, error)
+	GetByName(ctx context.Context, name string) (models.User, error)
 	// GetUserById викликає отримання даних користувача за його ID
-	GetUserById(userId int) (models.User, error)
+	GetUserById(ctx context.Context, userId int) (models.User, error)
 	// GenerateToken отримує за ім'ям та паролем користувача його ID,
 	// далі цей ID зашифровується у токен та повертається токен
-	GenerateToken(username, password string) (string, error)
+	GenerateToken(ctx context.Context, username, password string) (string, error)
 	// ParseToken отримує зашифрований токен, розшифровує його та
 	// повертає ID користувача
 	ParseToken(token string) (int, error)
 	// UpdateData оновлює ім'я або зображення
-	UpdateData(user models.User) error
+	UpdateData(ctx context.Context, user models.User) error
 	// UpdatePassword кодує пароль та оновлює його
-	UpdatePassword(user models.User) error
+	UpdatePassword(ctx context.Context, user models.User) error
 }
 
 type Chat interface {
-	// Create викликає створення нового чату
-	Create(chat models.Chat) (int, error)
+	// Create створює новий чат та додає до нього користувачів members
+	// в одній транзакції ТА повертає ID чату. Перший з members стає
+	// адміністратором та автором чату
+	Create(ctx context.Context, chat models.Chat, members ...int) (int, error)
 	// Get викликає отримання даних чату
-	Get(chatId int) (models.Chat, error)
+	Get(ctx context.Context, chatId int) (models.Chat, error)
+	// GetForUser повертає дані чату так, як їх бачить viewerId: приватний
+	// чат називається ім'ям співрозмовника та має його зображення.
+	// Співрозмовник повертається другим значенням
+	GetForUser(ctx context.Context, viewerId, chatId int) (models.Chat, models.User, error)
 	// Update викликає оновлення чату
-	Update(chat models.Chat) error
-	// Delete викликає видалення чату
-	Delete(chatId int) error
-	// AddUser викликає додання користувача до чату
-	AddUser(users models.ChatUsers) (int, error)
+	Update(ctx context.Context, chat models.Chat) error
+	// GetForAdmin повертає дані публічного чату для зміни, якщо userId -
+	// його адміністратор
+	GetForAdmin(ctx context.Context, userId, chatId int) (models.Chat, error)
+	// UpdateInfo змінює назву, опис та тему публічного чату, якщо userId -
+	// його адміністратор, ТА повертає оновлені дані чату
+	UpdateInfo(ctx context.Context, userId int, info models.Chat) (models.Chat, error)
+	// SetVisibility змінює видимість публічного чату, якщо userId - його
+	// адміністратор
+	SetVisibility(ctx context.Context, userId, chatId int, visibility string) error
+	// Delete видаляє чат разом з його учасниками, повідомленнями та
+	// посиланням на зображення в одній транзакції, якщо userId -
+	// адміністратор чату
+	Delete(ctx context.Context, userId, chatId int) error
+	// AddUser викликає додання іншого користувача до публічного чату його
+	// учасником userId; до чату з заявками чи прихованого - адміністратором.
+	// Користувача, що заблокував userId або не приймає від нього запрошень,
+	// додати не можна
+	AddUser(ctx context.Context, userId int, users models.ChatUsers) (int, error)
+	// CheckMember повертає ErrForbidden, якщо userId не є учасником чату
+	CheckMember(ctx context.Context, userId, chatId int) error
 	// GetUsers викликає отримання масиву користувачів чатом
-	GetUsers(chatId int) ([]models.User, error)
-	// DeleteUser викликає видалення користувача із чату
-	DeleteUser(userId, chatId int) error
+	GetUsers(ctx context.Context, chatId int) ([]models.User, error)
+	// DeleteUser видаляє користувача userId із чату за запитом actorId: сам
+	// користувач може вийти, а іншого учасника видаляє адміністратор. Якщо в
+	// чаті не залишилося користувачів, видаляє і чат, а якщо не залишилося
+	// адміністраторів - призначає найдавнішого учасника. Повертає, чи було
+	// видалено чат
+	DeleteUser(ctx context.Context, actorId, userId, chatId int) (bool, error)
 	// GetPrivates отримує два ID користувачів, повертає : при помилці - -1;
 	// якщо чат вже існує - його ID; якщо чату немає - 0
-	GetPrivates(firstUser, secondUser int) (int, error)
-	// GetPrivateChats викликає отримання масиву публічних чатів користувача
-	GetPrivateChats(userId int) ([]models.Chat, error)
-	// GetPublicChats викликає отримання масиву приватних чатів користувача
-	GetPublicChats(userId int) ([]models.Chat, error)
+	GetPrivates(ctx context.Context, firstUser, secondUser int) (int, error)
+	// PrivateChat отримує ID двох користувачів ТА повертає ID їх приватного
+	// чату, створюючи його за необхідністю. Якщо userId заблокував
+	// creatorId, повертає ErrBlocked, а якщо налаштування userId не
+	// дозволяють створити чат - ErrPrivacy
+	PrivateChat(ctx context.Context, creatorId, userId int) (int, error)
+	// GetPrivateChats викликає отримання масиву приватних чатів користувача
+	// з іншим учасником, названих ім'ям співрозмовника
+	GetPrivateChats(ctx context.Context, userId int) ([]models.Chat, error)
+	// GetPublicChats викликає отримання масиву публічних чатів користувача
+	// з останніми повідомленнями, впорядкованих від найновішої активності
+	GetPublicChats(ctx context.Context, userId int) ([]models.Chat, error)
 	// SearchChat викликає отримання масиву чатів, назви яких повністю чи
-	// частково збігаються з аргументом
-	SearchChat(name string) ([]models.Chat, error)
+	// частково збігаються з аргументом. Приховані чати не повертаються
+	SearchChat(ctx context.Context, name string) ([]models.Chat, error)
 	// DeleteAllMessages викликає видалення усіх повідомлень чата за його ID
-	DeleteAllMessages(chatId int) error
+	DeleteAllMessages(ctx context.Context, chatId int) error
 	// GetUserById викликає отримання даних користувача за його ID
-	GetUserById(userId int) (models.User, error)
+	GetUserById(ctx context.Context, userId int) (models.User, error)
 }
 
 type Status interface {
-	// AddStatus викликає створення нового статусу та повернення його ID
-	AddStatus(status models.Status) (int, error)
-	// GetStatuses викликає повернення даних щодо відносин між двома користувачами
-	GetStatuses(senderId, recipientId int) ([]models.Status, error)
-	// UpdateStatus викликає оновлення даних статусу
-	UpdateStatus(status models.Status) error
-	// DeleteStatus викликає видалення відносин між двома користувачами
-	DeleteStatus(status models.Status) error
+	// Invite надсилає запрошення у друзі від userId до recipientId ТА
+	// повертає ID запрошення
+	Invite(ctx context.Context, userId, recipientId int) (int, error)
+	// Accept приймає запрошення у друзі, яке senderId надіслав userId
+	Accept(ctx context.Context, userId, senderId int) error
+	// Refuse відхиляє запрошення у друзі, яке senderId надіслав userId
+	Refuse(ctx context.Context, userId, senderId int) error
+	// Cancel скасовує запрошення у друзі, яке userId надіслав recipientId
+	Cancel(ctx context.Context, userId, recipientId int) error
+	// Block блокує targetId для userId ТА повертає ID запису блокування
+	Block(ctx context.Context, userId, targetId int) (int, error)
+	// Unblock знімає блокування targetId користувачем userId
+	Unblock(ctx context.Context, userId, targetId int) error
+	// Unfriend видаляє дружбу userId та friendId
+	Unfriend(ctx context.Context, userId, friendId int) error
+	// GetStatuses викликає повернення усіх записів відносин між двома
+	// користувачами в обох напрямках
+	GetStatuses(ctx context.Context, userId, otherId int) ([]models.Status, error)
 	// GetFriends викликає отримання списку користувачів, що мають статус друзів
-	GetFriends(userId int) ([]models.User, error)
+	GetFriends(ctx context.Context, userId int) ([]models.User, error)
+	// GetUserLists повертає до limit пов'язаних з userId користувачів,
+	// починаючи з offset, розподілених за типом відносин
+	GetUserLists(ctx context.Context, userId, offset, limit int) (models.UserLists, error)
 	// GetBlackList викликає отримання списку користувачів,
 	// що для вас мають статус заблокованих
-	GetBlackList(userId int) ([]models.User, error)
+	GetBlackList(ctx context.Context, userId int) ([]models.User, error)
 	// GetBlackListToUser викликає отримання списку користувачів,
 	// для яких ви маєте статус заблокованого
-	GetBlackListToUser(userId int) ([]models.User, error)
+	GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error)
 	// GetSentInvites викликає отримання списку користувачів,
 	// що для вас мають статус запрошених у друзі
-	GetSentInvites(userId int) ([]models.User, error)
+	GetSentInvites(ctx context.Context, userId int) ([]models.User, error)
 	// GetInvites викликає отримання списку користувачів,
 	// для яких ви маєте статус запрошеного у друзі
-	GetInvites(userId int) ([]models.User, error)
-	// SearchUser викликає отримання списку чатів, що мають частково або
-	// повністю збіг з аргументом
-	SearchUser(username string) ([]models.User, error)
+	GetInvites(ctx context.Context, userId int) ([]models.User, error)
+	// SearchUser викликає отримання списку користувачів, що мають частково
+	// або повністю збіг з аргументом, крім тих, що заблокували userId
+	SearchUser(ctx context.Context, userId int, username string) ([]models.User, error)
+	// GetSuggestions викликає отримання до limit користувачів, яких userId
+	// може знати, за кількістю спільних друзів та публічних чатів
+	GetSuggestions(ctx context.Context, userId, limit int) ([]models.Suggestion, error)
 	// GetUserById викликає отримання даних користувача за його ID
-	GetUserById(userId int) (models.User, error)
+	GetUserById(ctx context.Context, userId int) (models.User, error)
 }
 
 type Message interface {
-	// Create викликає створення нового повідомлення та повертає його ID
-	Create(msg models.Message) (int, error)
+	// Create викликає створення нового повідомлення та повертає його ID.
+	// У приватний чат з користувачем, що заблокував автора, повертає
+	// ErrBlocked, а якщо він не приймає повідомлень від автора - ErrPrivacy
+	Create(ctx context.Context, msg models.Message) (int, error)
 	// Get викликає повернення повідомлення за його ID
-	Get(msgId int) (models.Message, error)
+	Get(ctx context.Context, msgId int) (models.Message, error)
 	// GetLimit викликає повернення певної кількості повідомлень чату за його ID
-	GetLimit(chatId, limit int) ([]models.Message, error)
+	GetLimit(ctx context.Context, chatId, limit int) ([]models.Message, error)
 	// DeleteAll викликає видалення усіх повідомлень чата за його ID
-	DeleteAll(chatId int) error
+	DeleteAll(ctx context.Context, chatId int) error
+}
+
+type Upload interface {
+	// SaveImage обробляє завантажене зображення, зберігає його оригінал та
+	// зменшені копії під SHA-256 вмісту ТА повертає ім'я файлу
+	SaveImage(ctx context.Context, data []byte) (string, error)
+	// Open повертає вміст збереженого файлу
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// SignedURL повертає тимчасове посилання на файл у сховищі або
+	// storage.ErrNoSignedURL, якщо файл віддає сам сервер
+	SignedURL(ctx context.Context, name string) (string, error)
+	// DeleteImage видаляє оригінал та зменшені копії зображення
+	DeleteImage(ctx context.Context, name string) error
+	// Replace додає посилання на нове зображення та прибирає посилання на
+	// старе. Старе зображення видалить прибиральник, коли на нього не
+	// залишиться посилань
+	Replace(ctx context.Context, oldName, newName string) error
+	// Release прибирає посилання на зображення
+	Release(ctx context.Context, name string) error
+	// Sweep повертає зображення, які ніхто не використовує довше за пільговий
+	// період, та видаляє їх, якщо dryRun = false
+	Sweep(ctx context.Context, dryRun bool) ([]models.Upload, error)
+	// Untracked повертає імена файлів сховища, про які не знає БД
+	Untracked(ctx context.Context) ([]string, error)
+}
+
+type Settings interface {
+	// GetSettings викликає отримання налаштувань приватності користувача
+	GetSettings(ctx context.Context, userId int) (models.UserSettings, error)
+	// UpdateSettings викликає збереження налаштувань приватності користувача
+	UpdateSettings(ctx context.Context, settings models.UserSettings) error
+	// Seen зберігає поточний час як час останньої активності користувача
+	Seen(ctx context.Context, userId int) error
+	// GetPresence повертає час останньої активності userId, якщо його
+	// налаштування дозволяють показувати його viewerId
+	GetPresence(ctx context.Context, viewerId, userId int) (models.Presence, error)
+}
+
+type Invite interface {
+	// CreateInvite створює посилання-запрошення до публічного чату від його
+	// учасника userId ТА повертає посилання з токеном
+	CreateInvite(ctx context.Context, userId int, invite models.ChatInvite) (models.ChatInvite, error)
+	// GetInvites повертає посилання-запрошення чату його адміністратору
+	GetInvites(ctx context.Context, userId, chatId int) ([]models.ChatInvite, error)
+	// RevokeInvite відкликає посилання-запрошення адміністратором чату або
+	// автором посилання
+	RevokeInvite(ctx context.Context, userId, chatId, inviteId int) error
+	// Join приєднує userId до чату за токеном посилання або створює заявку,
+	// що чекає схвалення, ТА повертає запис вступу
+	Join(ctx context.Context, userId int, token string) (models.ChatJoin, error)
+	// JoinChat приєднує userId до відкритого публічного чату або створює
+	// заявку на вступ до чату з заявками ТА повертає запис вступу
+	JoinChat(ctx context.Context, userId, chatId int) (models.ChatJoin, error)
+	// GetAdmins повертає ID адміністраторів чату
+	GetAdmins(ctx context.Context, chatId int) ([]int, error)
+	// GetJoins повертає вступи до чату зі статусом status його адміністратору
+	GetJoins(ctx context.Context, userId, chatId int, status string) ([]models.ChatJoin, error)
+	// ApproveJoin схвалює заявку на вступ до чату та додає її автора до чату
+	ApproveJoin(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error)
+	// RejectJoin відхиляє заявку на вступ до чату
+	RejectJoin(ctx context.Context, userId, chatId, joinId int) (models.ChatJoin, error)
 }
 
 type Service struct {
@@ -101,13 +218,22 @@ type Service struct {
 	Chat
 	Status
 	Message
+	Upload
+	Settings
+	Invite
 }
 
-func NewService(repos *repository.Repository) *Service {
+// NewService створює сервіси з налаштуваннями авторизації та завантажених
+// зображень
+func NewService(repos *repository.Repository, store storage.Storage, cnf config.Config) *Service {
+	icons := icons{sizes: cnf.Uploads.Sizes}
 	return &Service{
-		Authorization: NewAuthService(repos.Authorization),
-		Chat:          NewChatService(repos.Chat),
-		Status:        NewStatusService(repos.Status),
-		Message:       NewMessageService(repos.Message),
+		Authorization: NewAuthService(repos.Authorization, icons, cnf.Auth),
+		Chat:          NewChatService(repos.Chat, repos.Status, repos.Settings, repos.Transactor, icons),
+		Status:        NewStatusService(repos.Status, repos.Transactor, icons),
+		Message:       NewMessageService(repos.Message, repos.Chat, repos.Status, repos.Settings),
+		Upload:        NewUploadService(repos.Upload, store, cnf.Uploads),
+		Settings:      NewSettingsService(repos.Settings, repos.Status),
+		Invite:        NewInviteService(repos.Chat, repos.Invite, repos.Join, repos.Status, repos.Transactor),
 	}
 }
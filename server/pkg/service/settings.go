@@ -0,0 +1,109 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// seenInterval - як часто зберігається час останньої активності
+// користувача. Частіші запити не звертаються до БД
+const seenInterval = time.Minute
+
+type SettingsService struct {
+	repository repository.Settings
+	statuses   repository.Status
+	// seen - час останнього збереження активності за ID користувача
+	seen sync.Map
+}
+
+func NewSettingsService(repository repository.Settings, statuses repository.Status) *SettingsService {
+	return &SettingsService{repository: repository, statuses: statuses}
+}
+
+// GetSettings викликає отримання налаштувань приватності користувача
+func (s *SettingsService) GetSettings(ctx context.Context, userId int) (models.UserSettings, error) {
+	return s.repository.Get(ctx, userId)
+}
+
+// UpdateSettings викликає збереження налаштувань приватності користувача
+func (s *SettingsService) UpdateSettings(ctx context.Context, settings models.UserSettings) error {
+	return translate(s.repository.Save(ctx, settings), nil, nil, ErrUserNotFound)
+}
+
+// Seen зберігає поточний час як час останньої активності користувача, але
+// не частіше за seenInterval
+func (s *SettingsService) Seen(ctx context.Context, userId int) error {
+	now := time.Now()
+	if last, ok := s.seen.Load(userId); ok && now.Sub(last.(time.Time)) < seenInterval {
+		return nil
+	}
+	if err := s.repository.Seen(ctx, userId, now); err != nil {
+		return translate(err, nil, nil, ErrUserNotFound)
+	}
+	s.seen.Store(userId, now)
+	return nil
+}
+
+// GetPresence повертає час останньої активності userId. Якщо налаштування
+// userId не дозволяють показувати його viewerId або userId заблокував
+// viewerId, час не повертається
+func (s *SettingsService) GetPresence(ctx context.Context, viewerId, userId int) (models.Presence, error) {
+	presence := models.Presence{UserId: userId}
+	settings, err := s.repository.Get(ctx, userId)
+	if err != nil {
+		return presence, err
+	}
+	err = blocked(ctx, s.statuses, viewerId, userId)
+	if err == nil {
+		err = allowed(ctx, s.statuses, viewerId, userId, settings.LastSeen)
+	}
+	if errors.Is(err, ErrBlocked) || errors.Is(err, ErrPrivacy) {
+		return presence, nil
+	}
+	if err != nil {
+		return presence, err
+	}
+	presence.LastSeenAt, err = s.repository.GetLastSeen(ctx, userId)
+	return presence, err
+}
+
+// permitted повертає ErrPrivacy, якщо налаштування targetId, обрані
+// audience, не дозволяють дію userId
+func permitted(ctx context.Context, settings repository.Settings, statuses repository.Status, userId, targetId int, audience func(settings models.UserSettings) string) error {
+	if userId == targetId {
+		return nil
+	}
+	target, err := settings.Get(ctx, targetId)
+	if err != nil {
+		return err
+	}
+	return allowed(ctx, statuses, userId, targetId, audience(target))
+}
+
+func directMessages(settings models.UserSettings) string { return settings.DirectMessages }
+
+func chatInvites(settings models.UserSettings) string { return settings.ChatInvites }
+
+// allowed повертає ErrPrivacy, якщо audience користувача targetId не
+// включає userId. Дії щодо себе дозволені завжди
+func allowed(ctx context.Context, statuses repository.Status, userId, targetId int, audience string) error {
+	switch {
+	case userId == targetId || audience == repository.AudienceEveryone:
+		return nil
+	case audience == repository.AudienceFriends:
+		relation, err := statuses.GetStatuses(ctx, userId, targetId)
+		if err != nil {
+			return err
+		}
+		for _, status := range relation {
+			if status.Relationship == repository.StatusFriends {
+				return nil
+			}
+		}
+	}
+	return ErrPrivacy
+}
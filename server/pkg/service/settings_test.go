@@ -0,0 +1,64 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/memory"
+	"cmd/pkg/repository/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettingsService_GetPresence(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"owner", "friend", "stranger"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const owner, friend, stranger = 1, 2, 3
+	statuses := NewStatusService(repos.Status, repos.Transactor, icons{})
+	_, err := statuses.Invite(ctx, owner, friend)
+	require.NoError(t, err)
+	require.NoError(t, statuses.Accept(ctx, friend, owner))
+
+	s := NewSettingsService(repos.Settings, repos.Status)
+	presence, err := s.GetPresence(ctx, stranger, owner)
+	require.NoError(t, err)
+	assert.Nil(t, presence.LastSeenAt, "user has never been seen")
+
+	require.NoError(t, s.Seen(ctx, owner))
+	settings := repository.DefaultSettings(owner)
+	settings.LastSeen = repository.AudienceFriends
+	require.NoError(t, s.UpdateSettings(ctx, settings))
+
+	tests := []struct {
+		name     string
+		viewerId int
+		visible  bool
+	}{
+		{name: "Self", viewerId: owner, visible: true},
+		{name: "Friend", viewerId: friend, visible: true},
+		{name: "Stranger", viewerId: stranger, visible: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			presence, err := s.GetPresence(ctx, test.viewerId, owner)
+			require.NoError(t, err)
+			assert.Equal(t, owner, presence.UserId)
+			assert.Equal(t, test.visible, presence.LastSeenAt != nil)
+		})
+	}
+
+	// Заблокований не бачить часу активності навіть за налаштування everyone
+	require.NoError(t, s.UpdateSettings(ctx, repository.DefaultSettings(owner)))
+	_, err = statuses.Block(ctx, owner, stranger)
+	require.NoError(t, err)
+	presence, err = s.GetPresence(ctx, stranger, owner)
+	require.NoError(t, err)
+	assert.Nil(t, presence.LastSeenAt)
+
+	assert.ErrorIs(t, s.UpdateSettings(ctx, repository.DefaultSettings(100)), ErrUserNotFound)
+}
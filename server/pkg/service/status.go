@@ -3,72 +3,305 @@ package service
 import (
 	"cmd/pkg/repository"
 	"cmd/pkg/repository/models"
+	"context"
+	"fmt"
 )
 
 type StatusService struct {
 	repository repository.Status
+	transactor repository.Transactor
+	icons      icons
 }
 
-func NewStatusService(repository repository.Status) *StatusService {
-	return &StatusService{repository: repository}
+func NewStatusService(repository repository.Status, transactor repository.Transactor, icons icons) *StatusService {
+	return &StatusService{repository: repository, transactor: transactor, icons: icons}
 }
 
-// AddStatus викликає створення нового статусу та повернення його ID
-func (s *StatusService) AddStatus(status models.Status) (int, error) {
-	return s.repository.AddStatus(status)
+// relation - записи відносин активного користувача з іншим: out
+// надіслав активний користувач, in - інший. Дружба зберігається одним
+// записом у напрямку від того, хто запросив
+type relation struct {
+	out, in *models.Status
 }
 
-// GetStatuses викликає повернення даних щодо відносин між двома користувачами
-func (s *StatusService) GetStatuses(senderId, recipientId int) ([]models.Status, error) {
-	return s.repository.GetStatuses(senderId, recipientId)
+func newRelation(userId int, statuses []models.Status) (relation, error) {
+	var r relation
+	for i := range statuses {
+		switch statuses[i].Relationship {
+		case repository.StatusInvitation, repository.StatusFriends, repository.StatusBL:
+		default:
+			return r, fmt.Errorf("unknown relationship %q", statuses[i].Relationship)
+		}
+		if statuses[i].SenderId == userId {
+			r.out = &statuses[i]
+		} else {
+			r.in = &statuses[i]
+		}
+	}
+	return r, nil
 }
 
-// UpdateStatus викликає оновлення даних статусу
-func (s *StatusService) UpdateStatus(status models.Status) error {
-	return s.repository.UpdateStatus(status)
+// is перевіряє тип запису відносин
+func is(status *models.Status, relationship string) bool {
+	return status != nil && status.Relationship == relationship
 }
 
-// DeleteStatus викликає видалення відносин між двома користувачами
-func (s *StatusService) DeleteStatus(status models.Status) error {
-	return s.repository.DeleteStatus(status)
+// friends повертає запис дружби в будь-якому напрямку
+func (r relation) friends() *models.Status {
+	if is(r.out, repository.StatusFriends) {
+		return r.out
+	}
+	if is(r.in, repository.StatusFriends) {
+		return r.in
+	}
+	return nil
+}
+
+// transition отримує відносини userId з otherId та змінює їх функцією fn
+// в одній транзакції
+func (s *StatusService) transition(ctx context.Context, userId, otherId int, fn func(repos *repository.Repository, r relation) error) error {
+	if userId == otherId {
+		return ErrInvalidTransition.WithMessage("relationship with yourself is not allowed")
+	}
+	err := s.transactor.Transaction(ctx, func(repos *repository.Repository) error {
+		statuses, err := repos.Status.GetStatuses(ctx, userId, otherId)
+		if err != nil {
+			return err
+		}
+		r, err := newRelation(userId, statuses)
+		if err != nil {
+			return err
+		}
+		return fn(repos, r)
+	})
+	return translate(err, nil, ErrStatusExists, ErrUserNotFound)
+}
+
+// Invite надсилає запрошення у друзі від userId до recipientId ТА
+// повертає ID запрошення
+func (s *StatusService) Invite(ctx context.Context, userId, recipientId int) (int, error) {
+	var id int
+	err := s.transition(ctx, userId, recipientId, func(repos *repository.Repository, r relation) error {
+		switch {
+		case is(r.in, repository.StatusBL):
+			return ErrBlocked
+		case is(r.out, repository.StatusBL):
+			return ErrInvalidTransition.WithMessage("unblock the user first")
+		case r.friends() != nil:
+			return ErrInvalidTransition.WithMessage("users are already friends")
+		case is(r.out, repository.StatusInvitation):
+			return ErrInvalidTransition.WithMessage("invitation is already sent")
+		case is(r.in, repository.StatusInvitation):
+			return ErrInvalidTransition.WithMessage("user has already invited you")
+		}
+		var err error
+		id, err = repos.Status.AddStatus(ctx, models.Status{
+			SenderId:     userId,
+			RecipientId:  recipientId,
+			Relationship: repository.StatusInvitation,
+		})
+		return err
+	})
+	return id, err
+}
+
+// Accept приймає запрошення у друзі, яке senderId надіслав userId
+func (s *StatusService) Accept(ctx context.Context, userId, senderId int) error {
+	return s.transition(ctx, userId, senderId, func(repos *repository.Repository, r relation) error {
+		if !is(r.in, repository.StatusInvitation) {
+			return ErrInvalidTransition.WithMessage("no invitation from the user")
+		}
+		friends := *r.in
+		friends.Relationship = repository.StatusFriends
+		return repos.Status.UpdateStatus(ctx, friends)
+	})
+}
+
+// Refuse відхиляє запрошення у друзі, яке senderId надіслав userId
+func (s *StatusService) Refuse(ctx context.Context, userId, senderId int) error {
+	return s.transition(ctx, userId, senderId, func(repos *repository.Repository, r relation) error {
+		if !is(r.in, repository.StatusInvitation) {
+			return ErrInvalidTransition.WithMessage("no invitation from the user")
+		}
+		return repos.Status.DeleteStatus(ctx, *r.in)
+	})
+}
+
+// Cancel скасовує запрошення у друзі, яке userId надіслав recipientId
+func (s *StatusService) Cancel(ctx context.Context, userId, recipientId int) error {
+	return s.transition(ctx, userId, recipientId, func(repos *repository.Repository, r relation) error {
+		if !is(r.out, repository.StatusInvitation) {
+			return ErrInvalidTransition.WithMessage("no invitation to the user")
+		}
+		return repos.Status.DeleteStatus(ctx, *r.out)
+	})
+}
+
+// Block блокує targetId для userId ТА повертає ID запису блокування.
+// Запрошення та дружба між користувачами видаляються, а блокування
+// userId іншим користувачем залишається
+func (s *StatusService) Block(ctx context.Context, userId, targetId int) (int, error) {
+	var id int
+	err := s.transition(ctx, userId, targetId, func(repos *repository.Repository, r relation) error {
+		if is(r.out, repository.StatusBL) {
+			return ErrInvalidTransition.WithMessage("user is already blocked")
+		}
+		if r.in != nil && !is(r.in, repository.StatusBL) {
+			if err := repos.Status.DeleteStatus(ctx, *r.in); err != nil {
+				return err
+			}
+		}
+		if r.out != nil {
+			if err := repos.Status.DeleteStatus(ctx, *r.out); err != nil {
+				return err
+			}
+		}
+		var err error
+		id, err = repos.Status.AddStatus(ctx, models.Status{
+			SenderId:     userId,
+			RecipientId:  targetId,
+			Relationship: repository.StatusBL,
+		})
+		return err
+	})
+	return id, err
+}
+
+// Unblock знімає блокування targetId користувачем userId
+func (s *StatusService) Unblock(ctx context.Context, userId, targetId int) error {
+	return s.transition(ctx, userId, targetId, func(repos *repository.Repository, r relation) error {
+		if !is(r.out, repository.StatusBL) {
+			return ErrInvalidTransition.WithMessage("user is not blocked")
+		}
+		return repos.Status.DeleteStatus(ctx, *r.out)
+	})
+}
+
+// Unfriend видаляє дружбу userId та friendId незалежно від того, хто
+// надіслав запрошення
+func (s *StatusService) Unfriend(ctx context.Context, userId, friendId int) error {
+	return s.transition(ctx, userId, friendId, func(repos *repository.Repository, r relation) error {
+		friends := r.friends()
+		if friends == nil {
+			return ErrInvalidTransition.WithMessage("users are not friends")
+		}
+		return repos.Status.DeleteStatus(ctx, *friends)
+	})
+}
+
+// GetStatuses викликає повернення усіх записів відносин між двома
+// користувачами в обох напрямках
+func (s *StatusService) GetStatuses(ctx context.Context, userId, otherId int) ([]models.Status, error) {
+	return s.repository.GetStatuses(ctx, userId, otherId)
 }
 
 // GetFriends викликає отримання списку користувачів, що мають статус друзів
-func (s *StatusService) GetFriends(userId int) ([]models.User, error) {
-	return s.repository.GetFriends(userId)
+func (s *StatusService) GetFriends(ctx context.Context, userId int) ([]models.User, error) {
+	users, err := s.repository.GetFriends(ctx, userId)
+	return s.icons.users(users), err
+}
+
+// GetUserLists повертає до limit пов'язаних з userId користувачів,
+// починаючи з offset, розподілених за типом відносин. Користувачі
+// отримуються одним запитом, а NextOffset вказує на наступну сторінку
+func (s *StatusService) GetUserLists(ctx context.Context, userId, offset, limit int) (models.UserLists, error) {
+	var lists models.UserLists
+	// Зайвий запис показує, чи є наступна сторінка
+	relations, err := s.repository.GetRelations(ctx, userId, offset, limit+1)
+	if err != nil {
+		return lists, err
+	}
+	if len(relations) > limit {
+		relations = relations[:limit]
+		lists.NextOffset = offset + limit
+	}
+
+	for _, relation := range relations {
+		user := s.icons.user(relation.User)
+		switch {
+		case relation.Relationship == repository.StatusFriends:
+			lists.Friends = append(lists.Friends, user)
+		case relation.Relationship == repository.StatusBL && relation.Outgoing:
+			lists.Blacklist = append(lists.Blacklist, user)
+		case relation.Relationship == repository.StatusBL:
+			lists.OnBlacklist = append(lists.OnBlacklist, user)
+		case relation.Relationship == repository.StatusInvitation && relation.Outgoing:
+			lists.Invites = append(lists.Invites, user)
+		case relation.Relationship == repository.StatusInvitation:
+			lists.Requires = append(lists.Requires, user)
+		}
+	}
+	return lists, nil
 }
 
 // GetBlackList викликає отримання списку користувачів,
 // що для вас мають статус заблокованих
-func (s *StatusService) GetBlackList(userId int) ([]models.User, error) {
-	return s.repository.GetBlackList(userId)
+func (s *StatusService) GetBlackList(ctx context.Context, userId int) ([]models.User, error) {
+	users, err := s.repository.GetBlackList(ctx, userId)
+	return s.icons.users(users), err
 }
 
 // GetBlackListToUser викликає отримання списку користувачів,
 // для яких ви маєте статус заблокованого
-func (s *StatusService) GetBlackListToUser(userId int) ([]models.User, error) {
-	return s.repository.GetBlackListToUser(userId)
+func (s *StatusService) GetBlackListToUser(ctx context.Context, userId int) ([]models.User, error) {
+	users, err := s.repository.GetBlackListToUser(ctx, userId)
+	return s.icons.users(users), err
 }
 
 // GetSentInvites викликає отримання списку користувачів,
 // що для вас мають статус запрошених у друзі
-func (s *StatusService) GetSentInvites(userId int) ([]models.User, error) {
-	return s.repository.GetSentInvites(userId)
+func (s *StatusService) GetSentInvites(ctx context.Context, userId int) ([]models.User, error) {
+	users, err := s.repository.GetSentInvites(ctx, userId)
+	return s.icons.users(users), err
 }
 
 // GetInvites викликає отримання списку користувачів,
 // для яких ви маєте статус запрошеного у друзі
-func (s *StatusService) GetInvites(userId int) ([]models.User, error) {
-	return s.repository.GetInvites(userId)
+func (s *StatusService) GetInvites(ctx context.Context, userId int) ([]models.User, error) {
+	users, err := s.repository.GetInvites(ctx, userId)
+	return s.icons.users(users), err
+}
+
+// blocked повертає ErrBlocked, якщо хтось із others заблокував userId
+func blocked(ctx context.Context, statuses repository.Status, userId int, others ...int) error {
+	if len(others) == 0 {
+		return nil
+	}
+	blockers, err := statuses.GetBlackListToUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+	for _, blocker := range blockers {
+		for _, other := range others {
+			if blocker.Id == other {
+				return ErrBlocked
+			}
+		}
+	}
+	return nil
+}
+
+// SearchUser викликає отримання списку користувачів, що мають частково або
+// повністю збіг з аргументом. Користувачі, що заблокували userId, не
+// повертаються
+func (s *StatusService) SearchUser(ctx context.Context, userId int, username string) ([]models.User, error) {
+	users, err := s.repository.SearchUser(ctx, userId, username)
+	return s.icons.users(users), err
 }
 
-// SearchUser викликає отримання списку чатів, що мають частково або
-// повністю збіг з аргументом
-func (s *StatusService) SearchUser(username string) ([]models.User, error) {
-	return s.repository.SearchUser(username)
+// GetSuggestions викликає отримання до limit користувачів, яких userId
+// може знати, за кількістю спільних друзів та публічних чатів. Друзі,
+// запрошені та заблоковані в будь-якому напрямку не пропонуються
+func (s *StatusService) GetSuggestions(ctx context.Context, userId, limit int) ([]models.Suggestion, error) {
+	suggestions, err := s.repository.GetSuggestions(ctx, userId, limit)
+	for i := range suggestions {
+		suggestions[i].User = s.icons.user(suggestions[i].User)
+	}
+	return suggestions, err
 }
 
 // GetUserById викликає отримання даних користувача за його ID
-func (s *StatusService) GetUserById(userId int) (models.User, error) {
-	return s.repository.GetUserById(userId)
+func (s *StatusService) GetUserById(ctx context.Context, userId int) (models.User, error) {
+	user, err := s.repository.GetUserById(ctx, userId)
+	return s.icons.user(user), translate(err, ErrUserNotFound, nil, nil)
 }
@@ -0,0 +1,292 @@
+package service
+
+import (
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/memory"
+	"cmd/pkg/repository/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// move - дія користувача from щодо користувача to
+type move struct {
+	action   string
+	from, to int
+}
+
+// moves - переходи StatusService за назвами дій
+var moves = map[string]func(s *StatusService, ctx context.Context, userId, otherId int) error{
+	"invite": func(s *StatusService, ctx context.Context, userId, otherId int) error {
+		_, err := s.Invite(ctx, userId, otherId)
+		return err
+	},
+	"accept":   (*StatusService).Accept,
+	"refuse":   (*StatusService).Refuse,
+	"cancel":   (*StatusService).Cancel,
+	"unblock":  (*StatusService).Unblock,
+	"unfriend": (*StatusService).Unfriend,
+	"block": func(s *StatusService, ctx context.Context, userId, otherId int) error {
+		_, err := s.Block(ctx, userId, otherId)
+		return err
+	},
+}
+
+func TestStatusService_Transitions(t *testing.T) {
+	const (
+		first      = 1
+		second     = 2
+		invitation = repository.StatusInvitation
+		friends    = repository.StatusFriends
+		blocked    = repository.StatusBL
+	)
+
+	tests := []struct {
+		name    string
+		setup   []move
+		move    move
+		wantErr error
+		// want - записи відносин first та second після переходу
+		want []models.Status
+	}{
+		{
+			name: "Invite",
+			move: move{"invite", first, second},
+			want: []models.Status{{SenderId: first, RecipientId: second, Relationship: invitation}},
+		},
+		{
+			name:    "Invite twice",
+			setup:   []move{{"invite", first, second}},
+			move:    move{"invite", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: first, RecipientId: second, Relationship: invitation}},
+		},
+		{
+			name:    "Invite when invited",
+			setup:   []move{{"invite", second, first}},
+			move:    move{"invite", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: second, RecipientId: first, Relationship: invitation}},
+		},
+		{
+			name:    "Invite yourself",
+			move:    move{"invite", first, first},
+			wantErr: ErrInvalidTransition,
+		},
+		{
+			name:    "Invite friend",
+			setup:   []move{{"invite", second, first}, {"accept", first, second}},
+			move:    move{"invite", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: second, RecipientId: first, Relationship: friends}},
+		},
+		{
+			name:    "Invite when blocked",
+			setup:   []move{{"block", second, first}},
+			move:    move{"invite", first, second},
+			wantErr: ErrBlocked,
+			want:    []models.Status{{SenderId: second, RecipientId: first, Relationship: blocked}},
+		},
+		{
+			name:    "Invite blocked user",
+			setup:   []move{{"block", first, second}},
+			move:    move{"invite", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: first, RecipientId: second, Relationship: blocked}},
+		},
+		{
+			name:    "Invite unknown user",
+			move:    move{"invite", first, 3},
+			wantErr: ErrUserNotFound,
+		},
+		{
+			name:  "Accept",
+			setup: []move{{"invite", second, first}},
+			move:  move{"accept", first, second},
+			want:  []models.Status{{SenderId: second, RecipientId: first, Relationship: friends}},
+		},
+		{
+			name:    "Accept own invitation",
+			setup:   []move{{"invite", first, second}},
+			move:    move{"accept", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: first, RecipientId: second, Relationship: invitation}},
+		},
+		{
+			name:    "Accept without invitation",
+			move:    move{"accept", first, second},
+			wantErr: ErrInvalidTransition,
+		},
+		{
+			name:  "Refuse",
+			setup: []move{{"invite", second, first}},
+			move:  move{"refuse", first, second},
+		},
+		{
+			name:    "Refuse friend",
+			setup:   []move{{"invite", second, first}, {"accept", first, second}},
+			move:    move{"refuse", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: second, RecipientId: first, Relationship: friends}},
+		},
+		{
+			name:  "Cancel",
+			setup: []move{{"invite", first, second}},
+			move:  move{"cancel", first, second},
+		},
+		{
+			name:    "Cancel received invitation",
+			setup:   []move{{"invite", second, first}},
+			move:    move{"cancel", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: second, RecipientId: first, Relationship: invitation}},
+		},
+		{
+			name: "Block",
+			move: move{"block", first, second},
+			want: []models.Status{{SenderId: first, RecipientId: second, Relationship: blocked}},
+		},
+		{
+			name:  "Block friend",
+			setup: []move{{"invite", second, first}, {"accept", first, second}},
+			move:  move{"block", first, second},
+			want:  []models.Status{{SenderId: first, RecipientId: second, Relationship: blocked}},
+		},
+		{
+			name:  "Block inviting user",
+			setup: []move{{"invite", second, first}},
+			move:  move{"block", first, second},
+			want:  []models.Status{{SenderId: first, RecipientId: second, Relationship: blocked}},
+		},
+		{
+			name:  "Block invited user",
+			setup: []move{{"invite", first, second}},
+			move:  move{"block", first, second},
+			want:  []models.Status{{SenderId: first, RecipientId: second, Relationship: blocked}},
+		},
+		{
+			name:  "Block in return",
+			setup: []move{{"block", second, first}},
+			move:  move{"block", first, second},
+			want: []models.Status{
+				{SenderId: second, RecipientId: first, Relationship: blocked},
+				{SenderId: first, RecipientId: second, Relationship: blocked},
+			},
+		},
+		{
+			name:    "Block twice",
+			setup:   []move{{"block", first, second}},
+			move:    move{"block", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: first, RecipientId: second, Relationship: blocked}},
+		},
+		{
+			name:  "Unblock",
+			setup: []move{{"block", first, second}},
+			move:  move{"unblock", first, second},
+		},
+		{
+			name:    "Unblock when blocked",
+			setup:   []move{{"block", second, first}},
+			move:    move{"unblock", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: second, RecipientId: first, Relationship: blocked}},
+		},
+		{
+			name:  "Unfriend by invited user",
+			setup: []move{{"invite", second, first}, {"accept", first, second}},
+			move:  move{"unfriend", first, second},
+		},
+		{
+			name:  "Unfriend by inviting user",
+			setup: []move{{"invite", first, second}, {"accept", second, first}},
+			move:  move{"unfriend", first, second},
+		},
+		{
+			name:    "Unfriend not a friend",
+			setup:   []move{{"invite", first, second}},
+			move:    move{"unfriend", first, second},
+			wantErr: ErrInvalidTransition,
+			want:    []models.Status{{SenderId: first, RecipientId: second, Relationship: invitation}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			repos := memory.NewRepository()
+			for _, name := range []string{"first", "second"} {
+				_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+				require.NoError(t, err)
+			}
+			s := NewStatusService(repos.Status, repos.Transactor, icons{})
+
+			for _, m := range test.setup {
+				require.NoError(t, moves[m.action](s, ctx, m.from, m.to), "setup %s", m.action)
+			}
+
+			err := moves[test.move.action](s, ctx, test.move.from, test.move.to)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			statuses, err := s.GetStatuses(ctx, first, second)
+			require.NoError(t, err)
+			for i := range statuses {
+				statuses[i].Id = 0
+			}
+			assert.Equal(t, test.want, statuses)
+		})
+	}
+}
+
+func TestStatusService_GetUserLists(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepository()
+	for _, name := range []string{"user", "friend", "blocked", "blocker", "invited", "inviter"} {
+		_, err := repos.Authorization.CreateUser(ctx, models.User{Username: name, Password: "password"})
+		require.NoError(t, err)
+	}
+	const user, friend, blocked, blocker, invited, inviter = 1, 2, 3, 4, 5, 6
+	s := NewStatusService(repos.Status, repos.Transactor, icons{})
+	for _, m := range []move{
+		{"invite", user, friend}, {"accept", friend, user},
+		{"block", user, blocked}, {"block", blocker, user},
+		{"invite", user, invited}, {"invite", inviter, user},
+	} {
+		require.NoError(t, moves[m.action](s, ctx, m.from, m.to), m.action)
+	}
+
+	ids := func(users []models.User) []int {
+		var ids []int
+		for _, u := range users {
+			ids = append(ids, u.Id)
+		}
+		return ids
+	}
+	lists, err := s.GetUserLists(ctx, user, 0, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int{friend}, ids(lists.Friends))
+	assert.Equal(t, []int{blocked}, ids(lists.Blacklist))
+	assert.Equal(t, []int{blocker}, ids(lists.OnBlacklist))
+	assert.Equal(t, []int{invited}, ids(lists.Invites))
+	assert.Equal(t, []int{inviter}, ids(lists.Requires))
+	assert.Zero(t, lists.NextOffset)
+
+	// Сторінки разом містять усіх пов'язаних користувачів
+	var all []int
+	for offset := 0; ; {
+		lists, err = s.GetUserLists(ctx, user, offset, 2)
+		require.NoError(t, err)
+		all = append(all, ids(append(append(append(append(lists.Friends, lists.Blacklist...), lists.OnBlacklist...), lists.Invites...), lists.Requires...))...)
+		if lists.NextOffset == 0 {
+			break
+		}
+		offset = lists.NextOffset
+	}
+	assert.ElementsMatch(t, []int{friend, blocked, blocker, invited, inviter}, all)
+}
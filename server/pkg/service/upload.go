@@ -0,0 +1,220 @@
+package service
+
+import (
+	"bytes"
+	"cmd/pkg/config"
+	"cmd/pkg/imaging"
+	"cmd/pkg/repository"
+	"cmd/pkg/repository/models"
+	"cmd/pkg/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// ResizePrefix додається до імені зменшеної копії зображення
+	ResizePrefix = "resize-"
+	// ImagePath шлях, за яким сервер віддає зображення
+	ImagePath    = "/api/image/"
+	signedURLTTL = 15 * time.Minute
+)
+
+type UploadService struct {
+	repository repository.Upload
+	storage    storage.Storage
+	sizes      []int
+	// grace час, протягом якого файл без посилань не видаляється
+	grace time.Duration
+}
+
+func NewUploadService(repository repository.Upload, storage storage.Storage, cnf config.Uploads) *UploadService {
+	return &UploadService{repository: repository, storage: storage, sizes: cnf.Sizes, grace: cnf.Grace}
+}
+
+// RenditionName повертає ім'я квадратної копії зображення розміром size
+func RenditionName(name string, size int) string {
+	return fmt.Sprintf("%s%d-%s", ResizePrefix, size, name)
+}
+
+// OriginalName повертає ім'я оригіналу для імені будь-якої копії зображення
+func OriginalName(file string) string {
+	name := strings.TrimPrefix(file, ResizePrefix)
+	if name == file {
+		return file
+	}
+	// resize-<size>-<name>
+	if i := strings.IndexByte(name, '-'); i > 0 {
+		if _, err := strconv.Atoi(name[:i]); err == nil {
+			return name[i+1:]
+		}
+	}
+	return name
+}
+
+// hashName відповідає іменам файлів, що адресуються вмістом
+var hashName = regexp.MustCompile(`^[0-9a-f]{64}\.[a-z]+$`)
+
+// IsContentAddressed повідомляє, чи ім'я файлу (або його копії) є SHA-256
+// вмісту. Вміст таких файлів ніколи не змінюється
+func IsContentAddressed(file string) bool {
+	return hashName.MatchString(OriginalName(file))
+}
+
+// SaveImage обробляє завантажене зображення, зберігає його оригінал та
+// зменшені копії ТА повертає ім'я файлу. Помилки обробки повертаються
+// як ErrUnsupportedType, ErrImageTooLarge або ErrCorruptImage
+func (u *UploadService) SaveImage(ctx context.Context, data []byte) (string, error) {
+	img, err := imaging.Process(data, u.sizes)
+	if err != nil {
+		return "", translate(err, nil, nil, nil)
+	}
+
+	// Ім'я файлу - SHA-256 обробленого зображення, тож однакові зображення
+	// зберігаються один раз, а імена копій однозначно визначаються ним
+	sum := sha256.Sum256(img.Original)
+	name := hex.EncodeToString(sum[:]) + img.Ext()
+
+	// Запис створюється до збереження файлів, щоб прибиральник не видалив
+	// файл, який завантажили повторно
+	if err := u.repository.Create(ctx, name); err != nil {
+		return "", err
+	}
+
+	files := map[string][]byte{
+		name:                img.Original,
+		ResizePrefix + name: img.Thumbnail,
+	}
+	for size, data := range img.Renditions {
+		files[RenditionName(name, size)] = data
+	}
+
+	// Файли можуть використовуватися іншими об'єктами, тому у разі помилки
+	// вони не видаляються. Незавершене завантаження видалить прибиральник
+	for fileName, data := range files {
+		err := u.storage.Put(ctx, fileName, bytes.NewReader(data), int64(len(data)), storage.ContentType(fileName))
+		if err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+// Replace додає посилання на нове зображення та прибирає посилання на старе.
+// Старе зображення видалить прибиральник, коли на нього не залишиться посилань
+func (u *UploadService) Replace(ctx context.Context, oldName, newName string) error {
+	if err := u.repository.Acquire(ctx, newName); err != nil {
+		return err
+	}
+	return u.Release(ctx, oldName)
+}
+
+// Release прибирає посилання на зображення
+func (u *UploadService) Release(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	return u.repository.Release(ctx, name)
+}
+
+// Sweep повертає зображення, які ніхто не використовує довше за пільговий
+// період, та видаляє їх, якщо dryRun = false
+func (u *UploadService) Sweep(ctx context.Context, dryRun bool) ([]models.Upload, error) {
+	before := time.Now().Add(-u.grace)
+	orphans, err := u.repository.GetOrphans(ctx, before)
+	if err != nil || dryRun {
+		return orphans, err
+	}
+	var removed []models.Upload
+	for _, orphan := range orphans {
+		// Запис видаляється першим: якщо на файл встигли послатися, він лишається
+		ok, err := u.repository.Delete(ctx, orphan.Name, before)
+		if err != nil {
+			return removed, err
+		}
+		if !ok {
+			continue
+		}
+		if err := u.DeleteImage(ctx, orphan.Name); err != nil {
+			return removed, err
+		}
+		removed = append(removed, orphan)
+	}
+	return removed, nil
+}
+
+// Untracked повертає імена файлів сховища, про які не знає БД
+// (наприклад, завантажених до появи обліку файлів)
+func (u *UploadService) Untracked(ctx context.Context) ([]string, error) {
+	known, err := u.repository.GetNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool, len(known))
+	for _, name := range known {
+		names[name] = true
+	}
+
+	files, err := u.storage.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, file := range files {
+		if !names[OriginalName(file)] {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
+
+// Open повертає вміст збереженого файлу
+func (u *UploadService) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	r, err := u.storage.Get(ctx, name)
+	return r, translate(err, ErrImageNotFound, nil, nil)
+}
+
+// SignedURL повертає тимчасове посилання на файл у сховищі або
+// storage.ErrNoSignedURL, якщо файл віддає сам сервер
+func (u *UploadService) SignedURL(ctx context.Context, name string) (string, error) {
+	return u.storage.SignedURL(ctx, name, signedURLTTL)
+}
+
+// DeleteImage видаляє оригінал та зменшені копії зображення.
+// Відсутні файли пропускаються
+func (u *UploadService) DeleteImage(ctx context.Context, name string) error {
+	files := []string{name, ResizePrefix + name}
+	for _, size := range u.sizes {
+		files = append(files, RenditionName(name, size))
+	}
+	for _, file := range files {
+		if err := u.storage.Delete(ctx, file); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunSweeper періодично видаляє зображення, які ніхто не використовує
+func RunSweeper(upload Upload, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		removed, err := upload.Sweep(context.Background(), false)
+		if err != nil {
+			log.Printf("uploads sweep error: %s", err.Error())
+			continue
+		}
+		if len(removed) > 0 {
+			log.Printf("uploads sweep: removed %d files", len(removed))
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"bytes"
+	"cmd/pkg/config"
+	"cmd/pkg/repository/models"
+	"cmd/pkg/storage"
+	"context"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// uploadRepo - проста реалізація repository.Upload у пам'яті
+type uploadRepo struct {
+	uploads map[string]models.Upload
+	// used імена файлів, що використовуються користувачами чи чатами
+	used map[string]bool
+}
+
+func newUploadRepo() *uploadRepo {
+	return &uploadRepo{uploads: map[string]models.Upload{}, used: map[string]bool{}}
+}
+
+func (r *uploadRepo) Create(ctx context.Context, name string) error {
+	upload, ok := r.uploads[name]
+	if !ok {
+		upload = models.Upload{Id: len(r.uploads) + 1, Name: name, CreatedAt: time.Now()}
+	}
+	upload.UpdatedAt = time.Now()
+	r.uploads[name] = upload
+	return nil
+}
+
+func (r *uploadRepo) Acquire(ctx context.Context, name string) error {
+	_ = r.Create(ctx, name)
+	upload := r.uploads[name]
+	upload.Refs++
+	r.uploads[name] = upload
+	return nil
+}
+
+func (r *uploadRepo) Release(ctx context.Context, name string) error {
+	_ = r.Create(ctx, name)
+	upload := r.uploads[name]
+	if upload.Refs > 0 {
+		upload.Refs--
+	}
+	r.uploads[name] = upload
+	return nil
+}
+
+func (r *uploadRepo) GetOrphans(ctx context.Context, before time.Time) ([]models.Upload, error) {
+	var result []models.Upload
+	for name, upload := range r.uploads {
+		if upload.Refs == 0 && upload.UpdatedAt.Before(before) && !r.used[name] {
+			result = append(result, upload)
+		}
+	}
+	return result, nil
+}
+
+func (r *uploadRepo) GetNames(ctx context.Context) ([]string, error) {
+	var names []string
+	for name := range r.uploads {
+		names = append(names, name)
+	}
+	for name := range r.used {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (r *uploadRepo) Delete(ctx context.Context, name string, before time.Time) (bool, error) {
+	upload, ok := r.uploads[name]
+	if !ok || upload.Refs > 0 || !upload.UpdatedAt.Before(before) {
+		return false, nil
+	}
+	delete(r.uploads, name)
+	return true, nil
+}
+
+func TestUploadService_SaveImage(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	repo := newUploadRepo()
+	upload := NewUploadService(repo, store, config.Uploads{Sizes: []int{32, 64}, Grace: time.Hour})
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 80, 60))))
+
+	name, err := upload.SaveImage(ctx, buf.Bytes())
+	require.NoError(t, err)
+
+	names, err := store.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		name,
+		ResizePrefix + name,
+		RenditionName(name, 32),
+		RenditionName(name, 64),
+	}, names)
+	assert.Contains(t, repo.uploads, name)
+	assert.True(t, IsContentAddressed(name))
+
+	// Повторне завантаження того ж зображення не створює нових файлів
+	again, err := upload.SaveImage(ctx, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, name, again)
+	names, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Len(t, names, 4)
+
+	require.NoError(t, upload.DeleteImage(ctx, name))
+	names, err = store.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestUploadService_Sweep(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	repo := newUploadRepo()
+	upload := NewUploadService(repo, store, config.Uploads{Sizes: []int{32}})
+
+	for _, name := range []string{"upload-1.png", "resize-upload-1.png", "resize-32-upload-1.png", "upload-2.png", "upload-3.png"} {
+		require.NoError(t, store.Put(ctx, name, bytes.NewReader(nil), 0, ""))
+	}
+	_ = repo.Create(ctx, "upload-1.png")
+	_ = repo.Create(ctx, "upload-2.png")
+	repo.used["upload-2.png"] = true
+
+	// upload-3.png завантажено до появи обліку файлів
+	untracked, err := upload.Untracked(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"upload-3.png"}, untracked)
+
+	// Пробний запуск нічого не видаляє
+	orphans, err := upload.Sweep(ctx, true)
+	require.NoError(t, err)
+	require.Len(t, orphans, 1)
+	assert.Equal(t, "upload-1.png", orphans[0].Name)
+	names, _ := store.List(ctx)
+	assert.Len(t, names, 5)
+
+	_, err = upload.Sweep(ctx, false)
+	require.NoError(t, err)
+	names, _ = store.List(ctx)
+	assert.ElementsMatch(t, []string{"upload-2.png", "upload-3.png"}, names)
+	assert.NotContains(t, repo.uploads, "upload-1.png")
+}
+
+func TestUploadService_Replace(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	repo := newUploadRepo()
+	upload := NewUploadService(repo, store, config.Uploads{})
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 10, 10))))
+	name, err := upload.SaveImage(ctx, buf.Bytes())
+	require.NoError(t, err)
+
+	// Те саме зображення встановили двоє користувачів
+	require.NoError(t, upload.Replace(ctx, "", name))
+	require.NoError(t, upload.Replace(ctx, "", name))
+	assert.Equal(t, 2, repo.uploads[name].Refs)
+
+	// Файл лишається, доки на нього є посилання
+	require.NoError(t, upload.Release(ctx, name))
+	removed, err := upload.Sweep(ctx, false)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+
+	require.NoError(t, upload.Release(ctx, name))
+	removed, err = upload.Sweep(ctx, false)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	names, _ := store.List(ctx)
+	assert.Empty(t, names)
+}
+
+func TestIsContentAddressed(t *testing.T) {
+	hash := "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.png"
+	assert.True(t, IsContentAddressed(hash))
+	assert.True(t, IsContentAddressed(RenditionName(hash, 64)))
+	assert.True(t, IsContentAddressed(ResizePrefix+hash))
+	assert.False(t, IsContentAddressed("upload-1.png"))
+}
+
+func TestOriginalName(t *testing.T) {
+	assert.Equal(t, "upload-1.png", OriginalName("upload-1.png"))
+	assert.Equal(t, "upload-1.png", OriginalName("resize-upload-1.png"))
+	assert.Equal(t, "upload-1.png", OriginalName("resize-128-upload-1.png"))
+}
+
+func TestIcons(t *testing.T) {
+	i := icons{sizes: []int{32, 128}}
+
+	user := i.user(models.User{Id: 1, Icon: "upload-1.png"})
+	assert.Equal(t, map[int]string{
+		32:  "/api/image/resize-32-upload-1.png",
+		128: "/api/image/resize-128-upload-1.png",
+	}, user.Icons)
+
+	// Без зображення посилань немає
+	assert.Nil(t, i.chat(models.Chat{Id: 1}).Icons)
+}
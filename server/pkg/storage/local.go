@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LocalStorage зберігає файли у директорії на локальному диску
+type LocalStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) (*LocalStorage, error) {
+	if dir == "" {
+		dir = "uploads"
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{dir: dir}, nil
+}
+
+// Put отримує ім'я файлу та його вміст ТА зберігає файл
+func (l *LocalStorage) Put(_ context.Context, name string, r io.Reader, _ int64, _ string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	// Записуємо у тимчасовий файл, щоб не залишити напівзаписане зображення
+	tmp, err := os.CreateTemp(l.dir, ".put-*")
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get отримує ім'я файлу ТА повертає його вміст
+func (l *LocalStorage) Get(_ context.Context, name string) (io.ReadCloser, error) {
+	path, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Delete отримує ім'я файлу ТА видаляє його
+func (l *LocalStorage) Delete(_ context.Context, name string) error {
+	path, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// SignedURL не підтримується: локальні файли віддає сам сервер
+func (l *LocalStorage) SignedURL(context.Context, string, time.Duration) (string, error) {
+	return "", ErrNoSignedURL
+}
+
+// List повертає імена усіх збережених файлів
+func (l *LocalStorage) List(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	return names, nil
+}
+
+// path перевіряє ім'я файлу та повертає шлях до нього.
+// Ім'я не може містити шляхів, щоб не вийти за межі директорії
+func (l *LocalStorage) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return "", ErrNotFound
+	}
+	return filepath.Join(l.dir, name), nil
+}
+
+// ContentType повертає MIME-тип файлу за його розширенням
+func ContentType(name string) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
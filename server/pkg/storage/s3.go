@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"cmd/pkg/config"
+	"context"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Storage зберігає файли у S3-сумісному сховищі (AWS S3, MinIO)
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3Storage(cnf config.Storage) (*S3Storage, error) {
+	client, err := minio.New(cnf.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cnf.KeyId, cnf.Secret, ""),
+		Secure: cnf.UseSSL,
+		Region: cnf.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s := &S3Storage{client: client, bucket: cnf.Bucket}
+
+	// Створюємо bucket, якщо його ще немає
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, cnf.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cnf.Bucket, minio.MakeBucketOptions{Region: cnf.Region}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Put отримує ім'я файлу та його вміст ТА зберігає файл
+func (s *S3Storage) Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error {
+	_, err := s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+// Get отримує ім'я файлу ТА повертає його вміст
+func (s *S3Storage) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	// GetObject не звертається до сховища, тому перевіряємо наявність файлу окремо
+	if _, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{}); err != nil {
+		return nil, s.convert(err)
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, s.convert(err)
+	}
+	return obj, nil
+}
+
+// Delete отримує ім'я файлу ТА видаляє його
+func (s *S3Storage) Delete(ctx context.Context, name string) error {
+	if _, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{}); err != nil {
+		return s.convert(err)
+	}
+	return s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{})
+}
+
+// SignedURL отримує ім'я файлу ТА повертає тимчасове посилання на нього
+func (s *S3Storage) SignedURL(ctx context.Context, name string, ttl time.Duration) (string, error) {
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, name, ttl, url.Values{})
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// List повертає імена усіх збережених файлів
+func (s *S3Storage) List(ctx context.Context) ([]string, error) {
+	var names []string
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Recursive: true}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		names = append(names, obj.Key)
+	}
+	return names, nil
+}
+
+func (s *S3Storage) convert(err error) error {
+	if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"cmd/pkg/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNotFound повертається, якщо файлу з таким ім'ям не існує
+	ErrNotFound = errors.New("file not found")
+	// ErrNoSignedURL повертається сховищем, яке не вміє створювати підписані посилання
+	ErrNoSignedURL = errors.New("signed urls are not supported")
+)
+
+// Storage описує сховище завантажених файлів (зображень)
+type Storage interface {
+	// Put отримує ім'я файлу та його вміст ТА зберігає файл
+	Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error
+	// Get отримує ім'я файлу ТА повертає його вміст
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	// Delete отримує ім'я файлу ТА видаляє його
+	Delete(ctx context.Context, name string) error
+	// SignedURL отримує ім'я файлу ТА повертає тимчасове посилання на нього
+	SignedURL(ctx context.Context, name string, ttl time.Duration) (string, error)
+	// List повертає імена усіх збережених файлів
+	List(ctx context.Context) ([]string, error)
+}
+
+// New створює сховище за типом, вказаним у конфігурації
+func New(cnf config.Storage) (Storage, error) {
+	switch cnf.Driver {
+	case "", config.StorageLocal:
+		return NewLocalStorage(cnf.Dir)
+	case config.StorageS3:
+		return NewS3Storage(cnf)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cnf.Driver)
+	}
+}
+
+// Migrate копіює усі файли з одного сховища до іншого та видаляє їх
+// із початкового. Повертає кількість перенесених файлів
+func Migrate(ctx context.Context, from, to Storage) (int, error) {
+	names, err := from.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var moved int
+	for _, name := range names {
+		if err := move(ctx, from, to, name); err != nil {
+			return moved, fmt.Errorf("move %s: %w", name, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+func move(ctx context.Context, from, to Storage, name string) error {
+	r, err := from.Get(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := to.Put(ctx, name, r, -1, ContentType(name)); err != nil {
+		return err
+	}
+	return from.Delete(ctx, name)
+}
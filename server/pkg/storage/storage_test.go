@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"cmd/pkg/config"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testStorage перевіряє базову поведінку будь-якої реалізації сховища
+func testStorage(t *testing.T, s Storage) {
+	ctx := context.Background()
+
+	require.NoError(t, s.Put(ctx, "upload-1.jpeg", strings.NewReader("image"), 5, "image/jpeg"))
+
+	r, err := s.Get(ctx, "upload-1.jpeg")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	r.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "image", string(data))
+
+	names, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Contains(t, names, "upload-1.jpeg")
+
+	require.NoError(t, s.Delete(ctx, "upload-1.jpeg"))
+	_, err = s.Get(ctx, "upload-1.jpeg")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, s.Delete(ctx, "upload-1.jpeg"), ErrNotFound)
+}
+
+func TestLocalStorage(t *testing.T) {
+	s, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	testStorage(t, s)
+
+	_, err = s.SignedURL(context.Background(), "upload-1.jpeg", 0)
+	assert.ErrorIs(t, err, ErrNoSignedURL)
+
+	// Ім'я файлу не може вказувати за межі директорії
+	_, err = s.Get(context.Background(), "../go.mod")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+// TestS3Storage запускається на локальному MinIO, наприклад:
+// docker run -p 9000:9000 minio/minio server /data
+// S3_TEST_ENDPOINT=localhost:9000 go test ./pkg/storage/
+func TestS3Storage(t *testing.T) {
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	s, err := NewS3Storage(config.Storage{
+		Endpoint: endpoint,
+		Bucket:   "chat-test",
+		KeyId:    envOr("S3_TEST_ACCESS_KEY", "minioadmin"),
+		Secret:   envOr("S3_TEST_SECRET_KEY", "minioadmin"),
+	})
+	require.NoError(t, err)
+	testStorage(t, s)
+
+	url, err := s.SignedURL(context.Background(), "upload-1.jpeg", 60)
+	require.NoError(t, err)
+	assert.Contains(t, url, "upload-1.jpeg")
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	from, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+	to, err := NewLocalStorage(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, from.Put(ctx, "upload-1.jpeg", strings.NewReader("a"), 1, ""))
+	require.NoError(t, from.Put(ctx, "resize-upload-1.jpeg", strings.NewReader("b"), 1, ""))
+
+	moved, err := Migrate(ctx, from, to)
+	require.NoError(t, err)
+	assert.Equal(t, 2, moved)
+
+	left, err := from.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, left)
+	names, err := to.List(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"upload-1.jpeg", "resize-upload-1.jpeg"}, names)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,138 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// usernamePattern - дозволені символи імені користувача: літери, цифри,
+// "_", "." та "-"
+var usernamePattern = regexp.MustCompile(`^[\p{L}\p{N}_.-]+$`)
+
+// validate - спільний екземпляр перевірки. Він кешує опис структур та
+// безпечний для одночасного використання
+var validate = newValidate()
+
+func newValidate() *validator.Validate {
+	v := validator.New()
+
+	// У звітах поля називаються так само, як у JSON запиту
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	mustRegister(v, "username", func(fl validator.FieldLevel) bool {
+		return usernamePattern.MatchString(fl.Field().String())
+	})
+	return v
+}
+
+func mustRegister(v *validator.Validate, tag string, fn validator.Func) {
+	if err := v.RegisterValidation(tag, fn); err != nil {
+		panic(err)
+	}
+}
+
+// FieldError описує порушене правило одного поля
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors - перелік усіх полів, що не пройшли перевірку
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, field := range e {
+		parts[i] = field.Field + " " + field.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Validator перевіряє структури запитів за тегами validate.
+// Реалізує echo.Validator
+type Validator struct{}
+
+func (Validator) Validate(i interface{}) error {
+	return Struct(i)
+}
+
+// Struct перевіряє структуру за тегами validate та повертає Errors
+// з усіма полями, що не пройшли перевірку
+func Struct(i interface{}) error {
+	return convert(validate.Struct(i))
+}
+
+// Var перевіряє окреме значення field за правилами tag
+func Var(field string, value interface{}, tag string) error {
+	err := convert(validate.Var(value, tag))
+	var fields Errors
+	if errors.As(err, &fields) {
+		for i := range fields {
+			fields[i].Field = field
+		}
+	}
+	return err
+}
+
+func convert(err error) error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return err
+	}
+	fields := make(Errors, len(invalid))
+	for i, fe := range invalid {
+		fields[i] = FieldError{Field: fieldName(fe), Rule: fe.Tag(), Message: message(fe)}
+	}
+	return fields
+}
+
+// fieldName повертає шлях до поля без назви кореневої структури
+func fieldName(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.IndexByte(namespace, '.'); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+// message повертає опис порушеного правила для користувача
+func message(fe validator.FieldError) string {
+	isString := fe.Kind() == reflect.String
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "username":
+		return "may contain only letters, digits, '_', '.' and '-'"
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,40 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type input struct {
+	Username string `json:"username" validate:"required,min=3,max=32,username"`
+	Name     string `json:"name" validate:"required,max=5"`
+	UserId   int    `json:"user_id" validate:"required,gt=0"`
+	Nested   struct {
+		Status string `json:"status" validate:"oneof=pending joined"`
+	} `json:"nested"`
+}
+
+func TestStruct(t *testing.T) {
+	valid := input{Username: "Тарас_2.0", Name: "Чат", UserId: 1}
+	valid.Nested.Status = "joined"
+	assert.NoError(t, Struct(valid))
+
+	invalid := input{Username: "no spaces", Name: "too long", UserId: -1}
+	invalid.Nested.Status = "unknown"
+	assert.Equal(t, Errors{
+		{Field: "username", Rule: "username", Message: "may contain only letters, digits, '_', '.' and '-'"},
+		{Field: "name", Rule: "max", Message: "must be at most 5 characters"},
+		{Field: "user_id", Rule: "gt", Message: "must be greater than 0"},
+		{Field: "nested.status", Rule: "oneof", Message: "must be one of pending, joined"},
+	}, Struct(invalid))
+
+	assert.EqualError(t, Struct(input{Username: "ab", Name: "ok", UserId: 1, Nested: valid.Nested}),
+		"username must be at least 3 characters")
+}
+
+func TestVar(t *testing.T) {
+	assert.NoError(t, Var("name", "chat", "required,max=5"))
+	assert.Equal(t, Errors{{Field: "name", Rule: "required", Message: "is required"}},
+		Var("name", "", "required,max=5"))
+}
@@ -20,7 +20,7 @@ export const USER_DATA = (id: number) => `users/${id}`; // Отримати да
 export const SEARCH_USERS = (username: string) => `users/search/${username}`; // Пошук користувачів за ніком
 
 //get status users
-export const USERS_LIST = (id: number) => `users/${id}/all`; // Отримати список усіх
+export const USERS_LIST = (id: number, offset = 0) => `users/${id}/all?offset=${offset}`; // Отримати сторінку списків усіх
 
 //change status
 export const ADD_FRIEND = (id: number) => `users/${id}/invite`; // Запит на дружбу
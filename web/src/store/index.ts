@@ -33,7 +33,8 @@ export default new Vuex.Store<RootState>({
   },
   mutations: {
     openWebsocket(state, chatId: number) {
-      state.socket = new WebSocket(WEB_SOCKET + chatId);
+      const token = window.localStorage.getItem("token") || "";
+      state.socket = new WebSocket(WEB_SOCKET + chatId + "?token=" + encodeURIComponent(token));
     },
     closeSocket(state) {
       state.socket.close(1000);
@@ -219,19 +219,24 @@ const ChatModule: Module<ChatState, RootState> = ({
       });
     },
     /**
-     * Додає користувача до чату
+     * Додає користувача до чату. Для активного користувача це вступ до
+     * чату, що може чекати схвалення адміністратора
      * 
      * @param {number} userId - ID користувача 
      * @param {number} chatId - ID чату 
+     * @promise статус вступу (joined або pending) для активного користувача
      */
-    async addUserToChat({ }, { userId, chatId }) {
+    async addUserToChat({ }, { userId, chatId }): Promise<string | undefined> {
+      let status: string | undefined;
       await axiosInstanse
         .post(ADD_TO_CHAT(chatId), {
           "user_id": userId,
-        }).then(() => {
+        }).then((res) => {
+          status = res.data.join?.status;
           this.dispatch("getUserPublicChats", this.getters.USER_ID);
           this.dispatch("usersList", this.getters.USER_ID);
         })
+      return status;
     },
     /**
  * Видаляє користувача з чату
@@ -33,9 +33,12 @@ export interface UsersState {
 
     // Список користувачів, що запросили у друзі
     invitationsList: IUser[],
+
+    // Зсув наступної сторінки списків відносин або 0, якщо сторінка остання
+    usersListOffset: number,
 }
 
-// states 6; getters 11; mutations 6; actions 10;
+// states 7; getters 12; mutations 8; actions 11;
 const UsersModule: Module<UsersState, RootState> = ({
     state: () => ({
         searchUsersList: [],
@@ -44,6 +47,7 @@ const UsersModule: Module<UsersState, RootState> = ({
         onBlackList: [],
         sentInvitesList: [],
         invitationsList: [],
+        usersListOffset: 0,
     }),
     getters: {
         FRIEND_LIST: (state) => {
@@ -89,6 +93,9 @@ const UsersModule: Module<UsersState, RootState> = ({
         USERS_SEARCH_RESULT: (state) => {
             return state.searchUsersList;
         },
+        USERS_LIST_NEXT_OFFSET: (state) => {
+            return state.usersListOffset;
+        },
     },
     mutations: {
         setSearchUsersList(state, list: IUser[]) {
@@ -119,6 +126,17 @@ const UsersModule: Module<UsersState, RootState> = ({
                 a.username.localeCompare(b.username)
             );
         },
+        setUsersListOffset(state, offset: number | undefined) {
+            state.usersListOffset = offset || 0;
+        },
+        appendUsersLists(state, data: { [name: string]: IUser[] | undefined }) {
+            const byName = (a: IUser, b: IUser) => a.username.localeCompare(b.username);
+            state.friendsList = state.friendsList.concat(data.friends || []).sort(byName);
+            state.blackList = state.blackList.concat(data.blacklist || []).sort(byName);
+            state.onBlackList = state.onBlackList.concat(data.onBlacklist || []).sort(byName);
+            state.sentInvitesList = state.sentInvitesList.concat(data.invites || []).sort(byName);
+            state.invitationsList = state.invitationsList.concat(data.requires || []).sort(byName);
+        },
     },
     actions: {
         /**
@@ -153,7 +171,7 @@ const UsersModule: Module<UsersState, RootState> = ({
         /**
          * Оновлює списки усіх типів відносин користувача
          * (друзі, заблоковані, заблокували вас, запросили вас у друзі, 
-         * отримали від вас запрошення)
+         * отримали від вас запрошення) першою сторінкою
          * 
          * @param {number} userId - ID користувача
          */
@@ -161,11 +179,26 @@ const UsersModule: Module<UsersState, RootState> = ({
             await axiosInstanse
                 .get(USERS_LIST(userId))
                 .then((res) => {
-                    this.commit("setBlackList", res.data.blacklist);
-                    this.commit("setOnBlackLists", res.data.onBlacklist);
-                    this.commit("setSentInvitesList", res.data.invites);
-                    this.commit("setInvitationsList", res.data.requires);
-                    this.commit("setFriendsList", res.data.friends);
+                    this.commit("setBlackList", res.data.blacklist || []);
+                    this.commit("setOnBlackLists", res.data.onBlacklist || []);
+                    this.commit("setSentInvitesList", res.data.invites || []);
+                    this.commit("setInvitationsList", res.data.requires || []);
+                    this.commit("setFriendsList", res.data.friends || []);
+                    this.commit("setUsersListOffset", res.data.next_offset);
+                })
+        },
+        /**
+         * Дописує до списків відносин наступну сторінку, якщо вона є
+         * 
+         * @param {number} userId - ID користувача
+         */
+        async moreUsersList({ state }, userId: number) {
+            if (!state.usersListOffset) return;
+            await axiosInstanse
+                .get(USERS_LIST(userId, state.usersListOffset))
+                .then((res) => {
+                    this.commit("appendUsersLists", res.data);
+                    this.commit("setUsersListOffset", res.data.next_offset);
                 })
         },
         /**